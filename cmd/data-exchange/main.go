@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"simdokpol/internal/app"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/services"
+	"strings"
+)
+
+// data-exchange mengekspor/impor seluruh data dalam arsip portabel (tar.gz
+// berisi manifest.json dan satu file JSON lines per tabel), tidak bergantung
+// pada dialek database. Mode merge menggabungkan data kantor lain dengan ID
+// yang dipetakan ulang; mode replace mengganti seluruh isi tabel.
+//
+//	go run ./cmd/data-exchange export data.tar.gz
+//	go run ./cmd/data-exchange import data.tar.gz
+//	go run ./cmd/data-exchange -mode replace import data.tar.gz
+func main() {
+	mode := flag.String("mode", dto.DataImportMerge, "Mode impor: merge atau replace")
+	actorID := flag.Uint("actor", 1, "ID pengguna yang dicatat di log audit")
+	jsonOutput := flag.Bool("json", false, "Cetak laporan impor dalam format JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Pemakaian: data-exchange [-mode merge|replace] [-actor ID] [-json] export FILE | import FILE")
+	}
+	flag.Parse()
+
+	command, path := flag.Arg(0), flag.Arg(1)
+	if path == "" || (command != "export" && command != "import") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	db, err := app.OpenDatabase(cfg)
+	if err != nil {
+		log.Printf("❌ Gagal koneksi database: %v", err)
+		os.Exit(2)
+	}
+	auditService := services.NewAuditLogService(repositories.NewAuditLogRepository(db))
+	service := services.NewDataExchangeService(db, cfg.DBDialect, auditService)
+	ctx := context.Background()
+
+	if command == "export" {
+		file, err := os.Create(path)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			os.Exit(2)
+		}
+		manifest, err := service.Export(ctx, file, *actorID)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			log.Printf("❌ %v", err)
+			os.Exit(1)
+		}
+		var rows int64
+		for _, table := range manifest.Tables {
+			rows += table.Rows
+		}
+		fmt.Printf("✅ %d baris dari %d tabel diekspor ke %s\n", rows, len(manifest.Tables), path)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		os.Exit(2)
+	}
+	defer file.Close()
+	report, err := service.Import(ctx, file, *mode, *actorID)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+		return
+	}
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Println("   SIMDOKPOL - IMPOR DATA PORTABEL")
+	fmt.Printf("   🔌 Database: %s | Mode: %s | Sumber: %s\n", cfg.DBDialect, report.Mode, report.SourceDialect)
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("%-20s %8s %8s %8s %8s %8s\n", "Tabel", "Arsip", "Baru", "Ada", "Bentrok", "Lewati")
+	for _, table := range report.Tables {
+		fmt.Printf("%-20s %8d %8d %8d %8d %8d", table.Table, table.Rows, table.Inserted, table.Matched, table.Conflicts, table.Skipped)
+		if table.Note != "" {
+			fmt.Printf("  (%s)", table.Note)
+		}
+		fmt.Println()
+	}
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("✅ %d baris baru, %d sudah ada, %d bentrok (%d ms)\n", report.Inserted, report.Matched, report.Conflicts, report.DurationMs)
+	if len(report.ImportedUsers) > 0 {
+		fmt.Printf("⚠️  %d pengguna baru masuk nonaktif, aktifkan yang diperlukan lewat menu Pengguna:\n", len(report.ImportedUsers))
+		for _, user := range report.ImportedUsers {
+			fmt.Printf("   - %s %s (%s", user.NRP, user.NamaLengkap, user.Peran)
+			if user.PeranAsal != user.Peran {
+				fmt.Printf(", di arsip %s", user.PeranAsal)
+			}
+			fmt.Println(")")
+		}
+	}
+}
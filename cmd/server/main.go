@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"simdokpol/internal/app"
+)
+
+var (
+	version         = "dev"
+	changelogBase64 = ""
+)
+
+// Build server tanpa dependensi systray/GUI, untuk Linux server, container
+// atau service systemd. Flag sama dengan `simdokpol serve --headless`:
+//
+//	simdokpol-server [serve] [--port 8080] [--bind 0.0.0.0] [--data-dir DIR] [--tls --cert F --key F]
+//	simdokpol-server admin <perintah>   # lihat `simdokpol-server admin`
+func main() {
+	options := app.Options{
+		Version:   version,
+		Changelog: changelogBase64,
+		Edition:   "SERVER",
+		Mode:      "🖧 Mode: SERVER (HEADLESS)",
+	}
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "admin" {
+		os.Exit(app.AdminExitCode(app.RunAdmin(args[1:], options, os.Stdout, os.Stderr), os.Stderr))
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		args = args[1:]
+	}
+	serve, err := app.ParseServeFlags(args, options, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := app.RunHeadless(serve.Options); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
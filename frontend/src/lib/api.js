@@ -3,6 +3,9 @@ import axios from 'axios'
 const api = axios.create({
   baseURL: '/api',
   withCredentials: true,
+  // Double-submit CSRF: cookie csrf_token dikirim ulang lewat header
+  xsrfCookieName: 'csrf_token',
+  xsrfHeaderName: 'X-CSRF-Token',
 })
 
 export default api
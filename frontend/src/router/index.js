@@ -11,6 +11,10 @@ import SettingsView from '../views/SettingsView.vue'
 import ProfileView from '../views/ProfileView.vue'
 import AuditLogsView from '../views/AuditLogsView.vue'
 import JobPositionsView from '../views/JobPositionsView.vue'
+import RolesView from '../views/RolesView.vue'
+import HandoverView from '../views/HandoverView.vue'
+import ApiTokensView from '../views/ApiTokensView.vue'
+import BackupsView from '../views/BackupsView.vue'
 import TemplatesListView from '../views/TemplatesListView.vue'
 import TemplateFormView from '../views/TemplateFormView.vue'
 import ReportsView from '../views/ReportsView.vue'
@@ -40,16 +44,20 @@ const routes = [
       { path: 'documents/archived', name: 'documents-archived', component: DocumentsListView, props: { status: 'archived', title: 'Arsip Dokumen' } },
       { path: 'documents/new', name: 'documents-new', component: DocumentFormView },
       { path: 'documents/:id/edit', name: 'documents-edit', component: DocumentFormView },
+      { path: 'handover', name: 'handover', component: HandoverView },
       { path: 'users', name: 'users', component: UsersListView },
       { path: 'users/new', name: 'users-new', component: UserFormView },
       { path: 'users/:id/edit', name: 'users-edit', component: UserFormView },
       { path: 'jabatan', name: 'jabatan', component: JobPositionsView },
+      { path: 'roles', name: 'roles', component: RolesView },
+      { path: 'tokens', name: 'tokens', component: ApiTokensView },
       { path: 'templates', name: 'templates', component: TemplatesListView },
       { path: 'templates/new', name: 'templates-new', component: TemplateFormView },
       { path: 'templates/:id/edit', name: 'templates-edit', component: TemplateFormView },
       { path: 'reports', name: 'reports', component: ReportsView },
       { path: 'audit-logs', name: 'audit', component: AuditLogsView },
       { path: 'settings', name: 'settings', component: SettingsView },
+      { path: 'backups', name: 'backups', component: BackupsView },
       { path: 'panduan', name: 'panduan', component: PanduanView },
       { path: 'tentang', name: 'tentang', component: TentangView },
       { path: 'upgrade', name: 'upgrade', component: UpgradeView },
@@ -4,10 +4,13 @@ import api from '../lib/api'
 export const useAuthStore = defineStore('auth', {
   state: () => ({
     user: null,
+    permissions: [],
     loading: false,
   }),
   getters: {
     isAuthenticated: (state) => Boolean(state.user),
+    can: (state) => (permission) =>
+      state.user?.peran === 'SUPER_ADMIN' || state.permissions.includes(permission),
   },
   actions: {
     async fetchSession() {
@@ -15,8 +18,10 @@ export const useAuthStore = defineStore('auth', {
         this.loading = true
         const { data } = await api.get('/auth/me')
         this.user = data?.data?.user || null
+        this.permissions = data?.data?.permissions || []
       } catch (error) {
         this.user = null
+        this.permissions = []
       } finally {
         this.loading = false
       }
@@ -26,8 +31,17 @@ export const useAuthStore = defineStore('auth', {
       await this.fetchSession()
     },
     async logout() {
-      await api.post('/logout')
+      let redirectUrl = ''
+      if (this.user?.auth_source === 'oidc') {
+        // Ikut logout di IdP bila mendukung end_session_endpoint
+        const { data } = await api.post('/auth/sso/logout')
+        redirectUrl = data?.data?.redirect_url || ''
+      } else {
+        await api.post('/logout')
+      }
       this.user = null
+      this.permissions = []
+      return redirectUrl
     },
   },
 })
@@ -0,0 +1,738 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrAdminUsage dikembalikan RunAdmin bila perintah atau flag salah; entry
+// point memakainya untuk exit code 2.
+var ErrAdminUsage = errors.New("pemakaian perintah admin salah")
+
+// adminCommand satu subperintah `admin`. setup mendaftarkan flag lalu
+// mengembalikan fungsi yang dijalankan setelah database terbuka.
+type adminCommand struct {
+	name    string
+	usage   string
+	summary string
+	// rawDB: buka database tanpa menjalankan migrasi (khusus `migrate`)
+	rawDB bool
+	setup func(fs *flag.FlagSet) func(ctx context.Context, env *adminEnv) error
+}
+
+var adminCommands = []adminCommand{
+	{name: "reset-password", usage: "NRP [--password SANDI]", summary: "Ganti kata sandi akun lokal (acak bila --password kosong)", setup: adminResetPassword},
+	{name: "create-superadmin", usage: "--nrp NRP --nama NAMA [--pangkat P] [--jabatan J] [--password SANDI]", summary: "Buat akun Super Admin baru", setup: adminCreateSuperAdmin},
+	{name: "list-users", usage: "[--all]", summary: "Daftar pengguna (--all termasuk yang nonaktif)", setup: adminListUsers},
+	{name: "backup", usage: "[--method sqlite|pg_dump|mysqldump|portable]", summary: "Buat backup sekarang (ikut enkripsi dan off-site di pengaturan)", setup: adminBackup},
+	{name: "restore", usage: "FILE [--passphrase P | --key-file F] [--yes]", summary: "Pulihkan database dari file backup (hentikan server dulu)", setup: adminRestore},
+	{name: "migrate", usage: "status | up | down [N] | force VERSI", summary: "Kelola versi skema database", rawDB: true, setup: adminMigrate},
+	{name: "verify-audit", usage: "", summary: "Periksa rantai hash log audit", setup: adminVerifyAudit},
+	{name: "reindex-search", usage: "", summary: "Bangun ulang indeks tabel pencarian", setup: adminReindexSearch},
+	{name: "set-config", usage: "KEY=NILAI [KEY=NILAI ...]", summary: "Ubah pengaturan tersimpan di database", setup: adminSetConfig},
+	{name: "show-license", usage: "", summary: "Status lisensi dan Hardware ID", setup: adminShowLicense},
+}
+
+// adminEnv service yang sudah dirakit untuk satu perintah. Semua perubahan
+// dicatat di log audit atas nama akun aktor sistem (NRP SISTEM).
+type adminEnv struct {
+	out        io.Writer
+	errOut     io.Writer
+	jsonOutput bool
+	cfg        *config.Config
+	db         *gorm.DB
+	actor      *models.User
+	repos      repositorySet
+
+	auditService   services.AuditLogService
+	configService  services.ConfigService
+	userService    services.UserService
+	backupService  services.BackupService
+	licenseService services.LicenseService
+}
+
+// RunAdmin menjalankan perintah administrasi tanpa UI web:
+//
+//	simdokpol admin [--data-dir DIR] [--json] <perintah> [argumen]
+//
+// Log proses ditulis ke stderr, hasil ke stdout. Hentikan server sebelum
+// restore atau migrate down.
+func RunAdmin(args []string, opts Options, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("admin", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	dataDir := global.String("data-dir", opts.DataDir, "Folder data (.env, database SQLite, backup)")
+	jsonOutput := global.Bool("json", false, "Cetak hasil dalam format JSON")
+	global.Usage = func() { printAdminUsage(stderr, global) }
+	if err := global.Parse(args); err != nil {
+		return ErrAdminUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return ErrAdminUsage
+	}
+
+	var command *adminCommand
+	for i := range adminCommands {
+		if adminCommands[i].name == global.Arg(0) {
+			command = &adminCommands[i]
+		}
+	}
+	if command == nil {
+		fmt.Fprintf(stderr, "Perintah tidak dikenal: %s\n\n", global.Arg(0))
+		global.Usage()
+		return ErrAdminUsage
+	}
+
+	fs := flag.NewFlagSet("admin "+command.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Pemakaian: simdokpol admin %s %s\n", command.name, command.usage)
+		fs.PrintDefaults()
+	}
+	fs.BoolVar(jsonOutput, "json", *jsonOutput, "Cetak hasil dalam format JSON")
+	run := command.setup(fs)
+	if err := fs.Parse(interleaveFlags(global.Args()[1:])); err != nil {
+		return ErrAdminUsage
+	}
+
+	if err := Prepare(*dataDir); err != nil {
+		return fmt.Errorf("folder data: %w", err)
+	}
+	services.AppVersion = opts.Version
+	env, err := openAdminEnv(command.rawDB, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	env.jsonOutput = *jsonOutput
+	defer env.close()
+
+	ctx := context.Background()
+	err = run(ctx, env)
+	if errors.Is(err, ErrAdminUsage) {
+		fs.Usage()
+	}
+	return err
+}
+
+func printAdminUsage(output io.Writer, global *flag.FlagSet) {
+	fmt.Fprintln(output, "Pemakaian: simdokpol admin [--data-dir DIR] [--json] <perintah> [argumen]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Perintah:")
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	for _, command := range adminCommands {
+		fmt.Fprintf(w, "  %s\t%s\n", command.name, command.summary)
+	}
+	w.Flush()
+	fmt.Fprintln(output)
+	global.PrintDefaults()
+}
+
+// interleaveFlags memindahkan argumen posisi ke belakang agar
+// `reset-password 123 --password x` sama dengan `reset-password --password x 123`.
+func interleaveFlags(args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		// Flag bernilai dengan spasi (--password x); flag boolean tidak diberi nilai terpisah
+		if !strings.Contains(arg, "=") && i+1 < len(args) && !adminBoolFlags[strings.TrimLeft(arg, "-")] {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if len(positional) == 0 {
+		return flags
+	}
+	return append(append(flags, "--"), positional...)
+}
+
+var adminBoolFlags = map[string]bool{"all": true, "yes": true, "json": true}
+
+func openAdminEnv(rawDB bool, stdout, stderr io.Writer) (*adminEnv, error) {
+	env := &adminEnv{out: stdout, errOut: stderr, cfg: config.LoadConfig()}
+	var err error
+	if rawDB {
+		env.db, err = OpenDatabase(env.cfg)
+	} else {
+		env.db, err = SetupDatabase(env.cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	env.repos = newRepositorySet(env.db)
+	configureAuditPaths()
+	env.auditService = services.NewAuditLogService(env.repos.audit)
+	env.configService = services.NewConfigService(env.repos.config, env.db)
+	env.userService = services.NewUserService(env.repos.user, env.auditService, env.cfg)
+	env.backupService = services.NewBackupService(env.db, env.cfg, env.configService, env.auditService, env.repos.backupRun)
+	env.licenseService = services.NewLicenseService(env.repos.license, env.configService, env.auditService)
+	if !rawDB {
+		if env.actor, err = utils.EnsureSystemUser(env.db); err != nil {
+			env.close()
+			return nil, fmt.Errorf("akun aktor sistem: %w", err)
+		}
+	}
+	return env, nil
+}
+
+// close menunggu pengiriman backup off-site dan antrean log audit selesai
+// ditulis sebelum proses keluar.
+func (e *adminEnv) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := e.backupService.Close(ctx); err != nil {
+		fmt.Fprintf(e.errOut, "⚠️ %v\n", err)
+	}
+	if err := e.auditService.Close(ctx); err != nil {
+		fmt.Fprintf(e.errOut, "⚠️ Log audit: %v\n", err)
+	}
+	if sqlDB, err := e.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func (e *adminEnv) actorID() uint {
+	if e.actor == nil {
+		return 0
+	}
+	return e.actor.ID
+}
+
+func (e *adminEnv) printJSON(value interface{}) error {
+	encoder := json.NewEncoder(e.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func adminResetPassword(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	password := fs.String("password", "", "Kata sandi baru (minimal 8 karakter); kosong = dibuat acak")
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() != 1 {
+			return ErrAdminUsage
+		}
+		user, err := env.repos.user.FindByNRP(fs.Arg(0))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("pengguna dengan NRP %s tidak ditemukan", fs.Arg(0))
+		}
+		if err != nil {
+			return err
+		}
+		if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+			return fmt.Errorf("akun %s login lewat %s, kata sandinya tidak dikelola aplikasi", user.NRP, user.AuthSource)
+		}
+
+		newPassword, generated := *password, false
+		if newPassword == "" {
+			if newPassword, err = randomPassword(); err != nil {
+				return err
+			}
+			generated = true
+		}
+		if len(newPassword) < 8 {
+			return errors.New("kata sandi minimal 8 karakter")
+		}
+		if err := env.userService.Update(ctx, user, newPassword, env.actorID()); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "✅ Kata sandi %s (%s) diganti.\n", user.NamaLengkap, user.NRP)
+		if generated {
+			fmt.Fprintf(env.out, "   Kata sandi baru: %s\n", newPassword)
+		}
+		if user.DeletedAt.Valid {
+			fmt.Fprintln(env.out, "⚠️ Akun ini nonaktif; aktifkan lewat menu Pengguna agar bisa login.")
+		}
+		return nil
+	}
+}
+
+func adminCreateSuperAdmin(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	nrp := fs.String("nrp", "", "NRP (dipakai untuk login)")
+	nama := fs.String("nama", "", "Nama lengkap")
+	pangkat := fs.String("pangkat", "", "Pangkat")
+	jabatan := fs.String("jabatan", "", "Jabatan")
+	password := fs.String("password", "", "Kata sandi (minimal 8 karakter); kosong = dibuat acak")
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 || strings.TrimSpace(*nrp) == "" || strings.TrimSpace(*nama) == "" {
+			return ErrAdminUsage
+		}
+		if _, err := env.repos.user.FindByNRP(*nrp); err == nil {
+			return fmt.Errorf("NRP %s sudah terdaftar; pakai reset-password", *nrp)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		newPassword, generated := *password, false
+		if newPassword == "" {
+			var err error
+			if newPassword, err = randomPassword(); err != nil {
+				return err
+			}
+			generated = true
+		}
+		if len(newPassword) < 8 {
+			return errors.New("kata sandi minimal 8 karakter")
+		}
+		user := &models.User{
+			NamaLengkap: strings.TrimSpace(*nama),
+			NRP:         strings.TrimSpace(*nrp),
+			KataSandi:   newPassword,
+			Pangkat:     *pangkat,
+			Jabatan:     *jabatan,
+			Peran:       models.RoleSuperAdmin,
+			AuthSource:  models.AuthSourceLocal,
+		}
+		if err := env.userService.Create(ctx, user, env.actorID()); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "✅ Super Admin %s (%s) dibuat dengan ID %d.\n", user.NamaLengkap, user.NRP, user.ID)
+		if generated {
+			fmt.Fprintf(env.out, "   Kata sandi: %s\n", newPassword)
+		}
+		return nil
+	}
+}
+
+// adminUser baris keluaran list-users.
+type adminUser struct {
+	ID         uint   `json:"id"`
+	NRP        string `json:"nrp"`
+	Nama       string `json:"nama_lengkap"`
+	Pangkat    string `json:"pangkat"`
+	Peran      string `json:"peran"`
+	AuthSource string `json:"auth_source"`
+	Active     bool   `json:"active"`
+}
+
+func adminListUsers(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	all := fs.Bool("all", false, "Tampilkan juga pengguna nonaktif")
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 {
+			return ErrAdminUsage
+		}
+		query := env.db.WithContext(ctx).Unscoped().Where("auth_source <> ?", models.AuthSourceSystem)
+		if !*all {
+			query = query.Where("deleted_at IS NULL")
+		}
+		var users []models.User
+		if err := query.Order("nama_lengkap asc").Find(&users).Error; err != nil {
+			return err
+		}
+
+		rows := make([]adminUser, 0, len(users))
+		for _, user := range users {
+			source := user.AuthSource
+			if source == "" {
+				source = models.AuthSourceLocal
+			}
+			rows = append(rows, adminUser{
+				ID: user.ID, NRP: user.NRP, Nama: user.NamaLengkap, Pangkat: user.Pangkat,
+				Peran: user.Peran, AuthSource: source, Active: !user.DeletedAt.Valid,
+			})
+		}
+		if env.jsonOutput {
+			return env.printJSON(rows)
+		}
+
+		w := tabwriter.NewWriter(env.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNRP\tNAMA\tPERAN\tLOGIN\tSTATUS")
+		for _, row := range rows {
+			status := "aktif"
+			if !row.Active {
+				status = "nonaktif"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", row.ID, row.NRP, row.Nama, row.Peran, row.AuthSource, status)
+		}
+		w.Flush()
+		fmt.Fprintf(env.out, "%d pengguna\n", len(rows))
+		return nil
+	}
+}
+
+func adminBackup(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	method := fs.String("method", "", "Metode backup (bawaan: metode terbaik untuk dialek database)")
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 {
+			return ErrAdminUsage
+		}
+		path, err := env.backupService.CreateBackup(ctx, *method, env.actorID())
+		if err != nil {
+			return err
+		}
+		if env.jsonOutput {
+			return env.printJSON(map[string]string{"path": path})
+		}
+		fmt.Fprintf(env.out, "✅ Backup dibuat: %s\n", path)
+		return nil
+	}
+}
+
+func adminRestore(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	passphrase := fs.String("passphrase", "", "Passphrase backup terenkripsi (bawaan: dari pengaturan)")
+	keyFile := fs.String("key-file", "", "File kunci backup terenkripsi (bawaan: dari pengaturan)")
+	yes := fs.Bool("yes", false, "Langsung pulihkan; tanpa ini hanya validasi")
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() != 1 {
+			return ErrAdminUsage
+		}
+		var key *dto.BackupKey
+		if *passphrase != "" || *keyFile != "" {
+			key = &dto.BackupKey{Passphrase: *passphrase}
+			if *keyFile != "" {
+				content, err := os.ReadFile(*keyFile)
+				if err != nil {
+					return fmt.Errorf("file kunci: %w", err)
+				}
+				key.KeyFile = content
+			}
+		}
+
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		preview, err := env.backupService.ValidateRestore(ctx, file, key)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		if env.jsonOutput {
+			if err := env.printJSON(preview); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintf(env.out, "Format            : %s (%s -> %s)\n", preview.Format, preview.SourceDialect, preview.TargetDialect)
+			fmt.Fprintf(env.out, "Versi skema       : %d (database sekarang %d)\n", preview.SchemaVersion, preview.CurrentSchemaVersion)
+			fmt.Fprintf(env.out, "Integritas        : %s\n", preview.IntegrityCheck)
+			fmt.Fprintf(env.out, "Tabel             : %d\n", len(preview.Tables))
+			for _, warning := range preview.Warnings {
+				fmt.Fprintf(env.out, "⚠️ %s\n", warning)
+			}
+			for _, problem := range preview.Errors {
+				fmt.Fprintf(env.out, "❌ %s\n", problem)
+			}
+		}
+		if !preview.Compatible || !*yes {
+			_ = env.backupService.DiscardRestore(preview.Token)
+			if !preview.Compatible {
+				return services.ErrBackupValidationFailed
+			}
+			fmt.Fprintln(env.errOut, "File valid. Jalankan ulang dengan --yes untuk memulihkan.")
+			return nil
+		}
+
+		if err := refuseWhileServing(ctx, env); err != nil {
+			_ = env.backupService.DiscardRestore(preview.Token)
+			return err
+		}
+		if err := env.backupService.ConfirmRestore(ctx, preview.Token, env.actorID()); err != nil {
+			return err
+		}
+		fmt.Fprintln(env.errOut, "✅ Restore selesai. Jalankan ulang server.")
+		return nil
+	}
+}
+
+// refuseWhileServing menolak perintah yang mengganti isi database selama
+// masih ada server yang mencatat detak di database yang sama.
+func refuseWhileServing(ctx context.Context, env *adminEnv) error {
+	instances, err := services.ActiveServerInstances(ctx, env.db)
+	if err != nil {
+		return fmt.Errorf("gagal memeriksa server yang berjalan: %w", err)
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+	running := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		running = append(running, fmt.Sprintf("%s (PID %d, detak terakhir %s)", instance.Hostname, instance.PID, instance.HeartbeatAt.Local().Format("15:04:05")))
+	}
+	return fmt.Errorf("%w: %s", services.ErrServerRunning, strings.Join(running, ", "))
+}
+
+func adminMigrate(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	return func(ctx context.Context, env *adminEnv) error {
+		migrator := services.NewSchemaMigrationService(env.db, env.cfg.DBDialect)
+		command := fs.Arg(0)
+		if command == "" {
+			command = "status"
+		}
+
+		var detail string
+		switch command {
+		case "status":
+		case "up":
+			applied, err := migrator.Up(ctx)
+			if err != nil {
+				return err
+			}
+			detail = fmt.Sprintf("%d migrasi dijalankan", applied)
+		case "down":
+			steps := 1
+			if fs.NArg() > 1 {
+				var err error
+				if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps < 1 {
+					return ErrAdminUsage
+				}
+			}
+			rolledBack, err := migrator.Down(ctx, steps)
+			if err != nil {
+				return err
+			}
+			detail = fmt.Sprintf("%d migrasi dibatalkan", rolledBack)
+		case "force":
+			version, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || version < 0 {
+				return ErrAdminUsage
+			}
+			if err := migrator.Force(ctx, version); err != nil {
+				return err
+			}
+			detail = fmt.Sprintf("versi skema ditandai %d", version)
+		default:
+			return ErrAdminUsage
+		}
+
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		if detail != "" {
+			// Akun aktor dibuat setelah migrasi karena tabel users bisa saja baru ada
+			if env.actor, err = utils.EnsureSystemUser(env.db); err != nil {
+				fmt.Fprintf(env.errOut, "⚠️ Log audit tidak dicatat: %v\n", err)
+			} else {
+				env.auditService.LogEvent(ctx, dto.AuditEvent{
+					UserID:     env.actorID(),
+					Action:     models.AuditSchemaMigrated,
+					Detail:     fmt.Sprintf("CLI admin: %s (versi %d)", detail, status.CurrentVersion),
+					EntityType: models.AuditEntitySetting,
+					EntityID:   strconv.Itoa(status.CurrentVersion),
+				})
+			}
+			fmt.Fprintf(env.errOut, "✅ %s.\n", detail)
+		}
+
+		if env.jsonOutput {
+			return env.printJSON(status)
+		}
+		for _, migration := range status.Migrations {
+			mark := "  "
+			switch {
+			case migration.Dirty:
+				mark = "❗"
+			case migration.Applied:
+				mark = "✅"
+			}
+			appliedAt := "belum dijalankan"
+			if migration.AppliedAt != nil {
+				appliedAt = migration.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(env.out, "%s %06d %-36s %s\n", mark, migration.Version, migration.Name, appliedAt)
+		}
+		fmt.Fprintf(env.out, "Dialek            : %s\n", status.Dialect)
+		fmt.Fprintf(env.out, "Versi database    : %d\n", status.CurrentVersion)
+		fmt.Fprintf(env.out, "Versi aplikasi    : %d\n", status.LatestVersion)
+		fmt.Fprintf(env.out, "Tertunda          : %d\n", status.Pending)
+		if status.Dirty {
+			fmt.Fprintln(env.out, "❗ Ada migrasi gagal (dirty). Perbaiki database lalu jalankan `admin migrate force VERSI`.")
+		}
+		if status.CurrentVersion > status.LatestVersion {
+			fmt.Fprintln(env.out, "❗ Skema database lebih baru dari aplikasi ini.")
+		}
+		return nil
+	}
+}
+
+func adminVerifyAudit(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 {
+			return ErrAdminUsage
+		}
+		report, err := env.auditService.VerifyChain()
+		if err != nil {
+			return err
+		}
+		if env.jsonOutput {
+			if err := env.printJSON(report); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintf(env.out, "Entri diperiksa   : %d\n", report.CheckedEntries)
+			fmt.Fprintf(env.out, "Entri lama        : %d (sebelum rantai hash aktif)\n", report.LegacyEntries)
+			fmt.Fprintf(env.out, "Checkpoint        : %d\n", report.Checkpoints)
+			fmt.Fprintf(env.out, "Arsip             : %d file (%d entri)\n", report.Archives, report.ArchivedEntries)
+			fmt.Fprintf(env.out, "Hash terakhir     : %s\n", report.LastHash)
+			if report.Valid {
+				fmt.Fprintln(env.out, "✅ Rantai log audit utuh.")
+			}
+		}
+		if !report.Valid {
+			location := fmt.Sprintf("entri #%d", report.BrokenLogID)
+			if report.BrokenCheckpointID != 0 {
+				location += fmt.Sprintf(" (checkpoint #%d)", report.BrokenCheckpointID)
+			}
+			if report.BrokenArchiveID != 0 {
+				location += fmt.Sprintf(" (arsip #%d)", report.BrokenArchiveID)
+			}
+			return fmt.Errorf("rantai log audit RUSAK pada %s: %s", location, report.Reason)
+		}
+		return nil
+	}
+}
+
+func adminReindexSearch(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 {
+			return ErrAdminUsage
+		}
+		started := time.Now()
+		tables, err := services.ReindexSearch(ctx, env.db, env.cfg.DBDialect)
+		if err != nil {
+			return err
+		}
+		env.auditService.LogEvent(ctx, dto.AuditEvent{
+			UserID: env.actorID(),
+			Action: models.AuditSearchReindexed,
+			Detail: fmt.Sprintf("CLI admin: indeks %s dibangun ulang", strings.Join(tables, ", ")),
+		})
+		fmt.Fprintf(env.out, "✅ Indeks %s dibangun ulang (%s).\n", strings.Join(tables, ", "), time.Since(started).Round(time.Millisecond))
+		return nil
+	}
+}
+
+func adminSetConfig(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() == 0 {
+			return ErrAdminUsage
+		}
+		settings := make(map[string]string, fs.NArg())
+		for _, pair := range fs.Args() {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return fmt.Errorf("%w: %q bukan KEY=NILAI", ErrAdminUsage, pair)
+			}
+			settings[key] = value
+		}
+
+		// CLI dijalankan pemilik server, setara Super Admin
+		if err := env.configService.ValidateSettings(settings, true); err != nil {
+			return err
+		}
+		before := map[string]interface{}{}
+		if current, err := env.configService.GetConfig(); err == nil && current != nil {
+			before = services.SettingsSnapshot(current, settings)
+		}
+		if err := env.configService.SaveConfig(settings); err != nil {
+			return err
+		}
+		keys := services.SortedSettingKeys(settings)
+		env.auditService.LogEvent(ctx, dto.AuditEvent{
+			UserID:     env.actorID(),
+			Action:     models.AuditSettingsUpdated,
+			Detail:     "Pengaturan sistem diperbarui lewat CLI admin.",
+			EntityType: models.AuditEntitySetting,
+			EntityID:   strings.Join(keys, ","),
+			Before:     before,
+			After:      services.RedactSettings(settings),
+		})
+		fmt.Fprintf(env.out, "✅ %d pengaturan disimpan: %s\n", len(keys), strings.Join(keys, ", "))
+		fmt.Fprintln(env.errOut, "Restart server agar perubahan terbaca.")
+		return nil
+	}
+}
+
+func adminShowLicense(fs *flag.FlagSet) func(context.Context, *adminEnv) error {
+	return func(ctx context.Context, env *adminEnv) error {
+		if fs.NArg() > 0 {
+			return ErrAdminUsage
+		}
+		status, err := env.licenseService.GetLicenseStatus()
+		if err != nil {
+			return err
+		}
+		info := map[string]interface{}{
+			"status":      status,
+			"licensed":    env.licenseService.IsLicensed(),
+			"hardware_id": env.licenseService.GetHardwareID(),
+			"license_key": maskLicenseKey(os.Getenv(services.EnvLicenseKey)),
+		}
+		if env.jsonOutput {
+			return env.printJSON(info)
+		}
+		keys := make([]string, 0, len(info))
+		for key := range info {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(env.out, "%-18s: %v\n", key, info[key])
+		}
+		return nil
+	}
+}
+
+// maskLicenseKey hanya menampilkan 4 karakter terakhir kunci lisensi.
+func maskLicenseKey(key string) string {
+	if len(key) <= 4 {
+		return key
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AdminExitCode mencetak galat RunAdmin dan mengembalikan exit code:
+// 0 berhasil, 1 gagal, 2 pemakaian salah.
+func AdminExitCode(err error, stderr io.Writer) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrAdminUsage):
+		if err != ErrAdminUsage {
+			fmt.Fprintln(stderr, err)
+		}
+		return 2
+	default:
+		fmt.Fprintf(stderr, "❌ %v\n", err)
+		return 1
+	}
+}
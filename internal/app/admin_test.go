@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestInterleaveFlags(t *testing.T) {
+	assert.Equal(t, []string{"--password", "rahasia123", "--", "123"}, interleaveFlags([]string{"123", "--password", "rahasia123"}))
+	assert.Equal(t, []string{"--all", "--json"}, interleaveFlags([]string{"--all", "--json"}))
+	assert.Equal(t, []string{"--yes", "--", "backup.db"}, interleaveFlags([]string{"backup.db", "--yes"}))
+	assert.Equal(t, []string{"-method=portable", "--", "--x"}, interleaveFlags([]string{"-method=portable", "--", "--x"}))
+}
+
+func TestRunAdmin(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_DSN", "")
+	for _, key := range []string{"APP_SECRET_KEY", "JWT_SECRET_KEY", "AUDIT_SIGNING_KEY"} {
+		t.Setenv(key, "")
+	}
+
+	run := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		err := RunAdmin(append([]string{"--data-dir", dataDir}, args...), Options{Version: "uji"}, &stdout, io.Discard)
+		return stdout.String(), err
+	}
+
+	_, err := run()
+	assert.ErrorIs(t, err, ErrAdminUsage)
+	_, err = run("tidak-ada")
+	assert.ErrorIs(t, err, ErrAdminUsage)
+	_, err = run("create-superadmin", "--nrp", "123")
+	assert.ErrorIs(t, err, ErrAdminUsage, "nama wajib diisi")
+
+	out, err := run("create-superadmin", "--nrp", "123", "--nama", "Budi", "--password", "rahasia123")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Budi")
+	_, err = run("create-superadmin", "--nrp", "123", "--nama", "Budi")
+	assert.Error(t, err, "NRP yang sama ditolak")
+
+	out, err = run("reset-password", "123")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Kata sandi baru:")
+	_, err = run("reset-password", "999")
+	assert.Error(t, err)
+	_, err = run("reset-password", "123", "--password", "pendek")
+	assert.Error(t, err)
+
+	out, err = run("list-users", "--json")
+	require.NoError(t, err)
+	var users []adminUser
+	require.NoError(t, json.Unmarshal([]byte(out), &users))
+	require.Len(t, users, 1, "akun aktor sistem tidak ikut tampil")
+	assert.Equal(t, models.RoleSuperAdmin, users[0].Peran)
+
+	_, err = run("set-config", "nama_kantor=POLSEK UJI")
+	require.NoError(t, err)
+	_, err = run("set-config", "tanpa-nilai")
+	assert.ErrorIs(t, err, ErrAdminUsage)
+	_, err = run("set-config", "backup_schedule_time=25:00")
+	assert.ErrorIs(t, err, services.ErrInvalidSetting)
+	_, err = run("reindex-search")
+	require.NoError(t, err)
+	out, err = run("migrate", "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Tertunda          : 0")
+	assert.Contains(t, out, "✅ 000001")
+	out, err = run("verify-audit")
+	require.NoError(t, err)
+	assert.Contains(t, out, "✅ Rantai log audit utuh.")
+
+	db, err := gorm.Open(sqlite.Open(dataDir+"/simdokpol.db"), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	var system models.User
+	require.NoError(t, db.First(&system, "nrp = ?", models.SystemUserNRP).Error)
+	assert.Equal(t, models.AuthSourceSystem, system.AuthSource)
+
+	var budi models.User
+	require.NoError(t, db.First(&budi, "nrp = ?", "123").Error)
+	assert.NotEqual(t, "rahasia123", budi.KataSandi, "kata sandi disimpan sebagai hash")
+
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("user_id = ?", system.ID).Order("id").Pluck("aksi", &actions).Error)
+	assert.Equal(t, []string{
+		models.AuditCreateUser, models.AuditUpdateUser, models.AuditSettingsUpdated, models.AuditSearchReindexed,
+	}, actions)
+
+	var config models.Configuration
+	require.NoError(t, db.First(&config, "key = ?", "nama_kantor").Error)
+	assert.Equal(t, "POLSEK UJI", config.Value)
+
+	// Restore ditolak selama ada server yang masih berdetak
+	out, err = run("backup", "--json")
+	require.NoError(t, err)
+	var backup map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &backup))
+	server := models.ServerInstance{ID: "server-uji", Hostname: "polsek", PID: 42, StartedAt: time.Now(), HeartbeatAt: time.Now()}
+	require.NoError(t, db.Create(&server).Error)
+	_, err = run("restore", backup["path"])
+	require.NoError(t, err, "validasi saja tetap boleh")
+	_, err = run("restore", backup["path"], "--yes")
+	assert.ErrorIs(t, err, services.ErrServerRunning)
+
+	require.NoError(t, db.Model(&server).Update("heartbeat_at", time.Now().Add(-time.Hour)).Error)
+	_, err = run("restore", backup["path"], "--yes")
+	assert.NoError(t, err, "detak basi sisa server yang mati diabaikan")
+}
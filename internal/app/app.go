@@ -0,0 +1,264 @@
+// Package app merakit database, service, controller dan router SIMDOKPOL.
+// Entry point desktop (systray), Termux dan server headless hanya mengisi
+// Options lalu memanggil New dan Run.
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"simdokpol/internal/config"
+	"simdokpol/internal/middleware"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Options mengatur satu instance server. Nilai kosong mengikuti .env
+// (PORT, ENABLE_HTTPS) seperti sebelumnya.
+type Options struct {
+	Version   string
+	Changelog string // base64, diisi lewat ldflags
+	// Edition dan Mode hanya untuk banner log, mis. "DESKTOP" dan
+	// "💻 Mode: DESKTOP (GUI/SYSTRAY)"
+	Edition string
+	Mode    string
+	Port    string
+	// Bind alamat yang didengarkan; kosong = semua interface
+	Bind    string
+	DataDir string
+	// HTTPS nil = ikut ENABLE_HTTPS. CertFile/KeyFile kosong = sertifikat
+	// self-signed di folder data (dibuat otomatis)
+	HTTPS    *bool
+	CertFile string
+	KeyFile  string
+	// Notify menampilkan pemberitahuan ke pengguna (desktop); nil di server
+	Notify func(title, message string)
+}
+
+// App adalah server yang sudah dirakit tapi belum mendengarkan port.
+type App struct {
+	opts     Options
+	Config   *config.Config
+	DB       *gorm.DB
+	Router   *gin.Engine
+	Server   *http.Server
+	Port     string
+	HTTPS    bool
+	certFile string
+	keyFile  string
+
+	auditService  services.AuditLogService
+	configService services.ConfigService
+	backupService services.BackupService
+	// systemActorID akun sistem untuk log audit job latar belakang
+	systemActorID uint
+}
+
+// New membuka database dan merakit semua service serta rute. Database yang
+// gagal dibuka tidak menghentikan aplikasi (halaman setup tetap bisa
+// dipakai), kecuali skemanya lebih baru atau dirty.
+func New(opts Options) (*App, error) {
+	h := sha256.Sum256([]byte(services.AppSecretKeyString))
+	keyHash := fmt.Sprintf("%x", h[:4])
+
+	log.Println("==========================================")
+	log.Printf("🚀 SIMDOKPOL %s - v%s", opts.Edition, opts.Version)
+	services.AppVersion = opts.Version
+	log.Print(opts.Mode)
+	log.Printf("📂 Data Dir: %s", utils.GetAppDataDir())
+	log.Printf("🔑 Secret Hash: %s...", keyHash)
+	log.Println("==========================================")
+
+	a := &App{opts: opts, Config: config.LoadConfig()}
+
+	db, err := SetupDatabase(a.Config)
+	if errors.Is(err, services.ErrSchemaTooNew) || errors.Is(err, services.ErrSchemaDirty) {
+		// Jangan lanjut ke mode setup: database ini tidak boleh disentuh binary ini
+		return nil, fmt.Errorf("%w. Pakai versi aplikasi yang sesuai atau perbaiki dengan `simdokpol admin migrate`", err)
+	}
+	if err != nil {
+		log.Printf("❌ GAGAL KONEKSI DATABASE: %v. Cek config/restart.", err)
+		a.notify("SIMDOKPOL Error", "Gagal koneksi database. Cek log.")
+	} else {
+		SeedDefaultTemplates(db)
+		if system, errSystem := utils.EnsureSystemUser(db); errSystem != nil {
+			log.Printf("⚠️ Akun sistem: %v. Backup terjadwal dan retensi log audit tidak dijalankan.", errSystem)
+		} else {
+			a.systemActorID = system.ID
+		}
+	}
+	a.DB = db
+
+	a.Port = opts.Port
+	if a.Port == "" {
+		a.Port = os.Getenv("PORT")
+	}
+	if a.Port == "" {
+		a.Port = "8080"
+	}
+	a.HTTPS = os.Getenv("ENABLE_HTTPS") == "true"
+	if opts.HTTPS != nil {
+		a.HTTPS = *opts.HTTPS
+	}
+	if a.HTTPS {
+		a.certFile, a.keyFile = opts.CertFile, opts.KeyFile
+		if a.certFile == "" || a.keyFile == "" {
+			var errCert error
+			a.certFile, a.keyFile, errCert = utils.EnsureCertificates()
+			if errCert != nil {
+				log.Printf("⚠️ ERROR CERT: %v. Fallback ke HTTP.", errCert)
+				a.HTTPS = false
+			}
+		}
+	}
+
+	serverLimits := middleware.LoadServerLimits()
+	a.Router = a.buildRouter(serverLimits)
+	a.Server = &http.Server{Addr: net.JoinHostPort(opts.Bind, a.Port), Handler: a.Router}
+	serverLimits.Apply(a.Server)
+	return a, nil
+}
+
+func (a *App) notify(title, message string) {
+	if a.opts.Notify != nil {
+		a.opts.Notify(title, message)
+	}
+}
+
+// URL alamat untuk membuka aplikasi dari komputer yang sama.
+func (a *App) URL() string {
+	host := a.opts.Bind
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	scheme := "http"
+	if a.HTTPS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, a.Port))
+}
+
+// Run menjalankan job latar belakang dan server HTTP sampai ctx selesai atau
+// proses menerima SIGINT/SIGTERM, lalu mematikan semuanya dengan rapi
+// (antrean log audit ditulis dulu).
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stopAuditRetention := func() {}
+	stopBackupScheduler := func() {}
+	stopHeartbeat := func() {}
+	if a.DB != nil {
+		stopHeartbeat = services.StartServerHeartbeat(a.DB)
+		if a.systemActorID != 0 {
+			stopAuditRetention = services.StartAuditRetentionJob(a.auditService, a.configService, a.systemActorID)
+			stopBackupScheduler = services.StartBackupScheduler(a.backupService, a.systemActorID, a.opts.Notify)
+		}
+		if err := services.ReloadAuditSinks(a.auditService, a.configService); err != nil {
+			log.Printf("⚠️ Penerusan log audit: %v", err)
+		}
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		var err error
+		if a.HTTPS {
+			log.Printf("🔒 Server berjalan di %s (HTTPS)", a.Server.Addr)
+			err = a.Server.ListenAndServeTLS(a.certFile, a.keyFile)
+		} else {
+			log.Printf("🌐 Server berjalan di %s (HTTP)", a.Server.Addr)
+			err = a.Server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case err, ok := <-serverErr:
+		if ok {
+			runErr = fmt.Errorf("server error: %w", err)
+		}
+	}
+
+	log.Println("🛑 Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Shutdown error: %v", err)
+	}
+	log.Println("✅ Server stopped.")
+
+	stopAuditRetention()
+	stopBackupScheduler()
+	defer stopHeartbeat()
+	auditCtx, auditCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer auditCancel()
+	if err := a.backupService.Close(auditCtx); err != nil {
+		log.Printf("⚠️ Backup: %v", err)
+	}
+	if err := a.auditService.Close(auditCtx); err != nil {
+		log.Printf("⚠️ Log audit: %v", err)
+	}
+	return runErr
+}
+
+// repositories dibuat hanya bila database terbuka; tanpa database semua
+// bernilai nil dan aplikasi berjalan dalam mode setup.
+type repositorySet struct {
+	user              repositories.UserRepository
+	doc               repositories.LostDocumentRepository
+	resident          repositories.ResidentRepository
+	config            repositories.ConfigRepository
+	audit             repositories.AuditLogRepository
+	license           repositories.LicenseRepository
+	itemTemplate      repositories.ItemTemplateRepository
+	jobPosition       repositories.JobPositionRepository
+	role              repositories.RoleRepository
+	apiToken          repositories.APITokenRepository
+	backupRun         repositories.BackupRunRepository
+	sessionRevocation repositories.SessionRevocationRepository
+}
+
+func newRepositorySet(db *gorm.DB) repositorySet {
+	if db == nil {
+		return repositorySet{}
+	}
+	return repositorySet{
+		user:              repositories.NewUserRepository(db),
+		doc:               repositories.NewLostDocumentRepository(db),
+		resident:          repositories.NewResidentRepository(db),
+		config:            repositories.NewConfigRepository(db),
+		audit:             repositories.NewAuditLogRepository(db),
+		license:           repositories.NewLicenseRepository(db),
+		itemTemplate:      repositories.NewItemTemplateRepository(db),
+		jobPosition:       repositories.NewJobPositionRepository(db),
+		role:              repositories.NewRoleRepository(db),
+		apiToken:          repositories.NewAPITokenRepository(db),
+		backupRun:         repositories.NewBackupRunRepository(db),
+		sessionRevocation: repositories.NewSessionRevocationRepository(db),
+	}
+}
+
+// executableDir folder binary; template PDF dan aset pendukung dicari di sini.
+func executableDir() string {
+	exePath, _ := os.Executable()
+	return filepath.Dir(exePath)
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"simdokpol/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServeFlags(t *testing.T) {
+	defaults := Options{Edition: "SERVER", Port: "8080"}
+
+	t.Run("Bawaan", func(t *testing.T) {
+		serve, err := ParseServeFlags(nil, defaults, io.Discard)
+		require.NoError(t, err)
+		assert.False(t, serve.Headless)
+		assert.Equal(t, "8080", serve.Options.Port)
+		assert.Nil(t, serve.Options.HTTPS, "HTTPS ikut .env bila --tls tidak diisi")
+	})
+
+	t.Run("Lengkap", func(t *testing.T) {
+		serve, err := ParseServeFlags([]string{"--headless", "--port", "9443", "--bind", "127.0.0.1", "--data-dir", "/srv/simdokpol", "--cert", "a.crt", "--key", "a.key"}, defaults, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, serve.Headless)
+		assert.Equal(t, "SERVER", serve.Options.Edition)
+		assert.Equal(t, "9443", serve.Options.Port)
+		assert.Equal(t, "127.0.0.1", serve.Options.Bind)
+		assert.Equal(t, "/srv/simdokpol", serve.Options.DataDir)
+		require.NotNil(t, serve.Options.HTTPS)
+		assert.True(t, *serve.Options.HTTPS, "sertifikat sendiri mengaktifkan HTTPS")
+	})
+
+	t.Run("TLS Dimatikan", func(t *testing.T) {
+		serve, err := ParseServeFlags([]string{"--tls=false"}, defaults, io.Discard)
+		require.NoError(t, err)
+		require.NotNil(t, serve.Options.HTTPS)
+		assert.False(t, *serve.Options.HTTPS)
+	})
+
+	t.Run("Tidak Valid", func(t *testing.T) {
+		_, err := ParseServeFlags([]string{"--cert", "a.crt"}, defaults, io.Discard)
+		assert.Error(t, err)
+		_, err = ParseServeFlags([]string{"lain"}, defaults, io.Discard)
+		assert.Error(t, err)
+		_, err = ParseServeFlags([]string{"--tidak-ada"}, defaults, io.Discard)
+		assert.Error(t, err)
+	})
+}
+
+func TestNew_BuildsRouter(t *testing.T) {
+	t.Setenv("DB_DIALECT", "sqlite")
+	t.Setenv("DB_DSN", "")
+	t.Setenv("ENABLE_HTTPS", "false")
+	require.NoError(t, utils.SetAppDataDir(t.TempDir()))
+
+	server, err := New(Options{Version: "uji", Edition: "UJI", Bind: "127.0.0.1", Port: "18099"})
+	require.NoError(t, err)
+	require.NotNil(t, server.DB)
+	assert.Equal(t, "127.0.0.1:18099", server.Server.Addr)
+	assert.Equal(t, "http://127.0.0.1:18099", server.URL())
+	t.Cleanup(func() {
+		sqlDB, _ := server.DB.DB()
+		sqlDB.Close()
+	})
+
+	rec := httptest.NewRecorder()
+	server.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Belum setup: halaman terproteksi diarahkan ke /setup
+	rec = httptest.NewRecorder()
+	server.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/backups", nil))
+	assert.NotEqual(t, http.StatusNotFound, rec.Code)
+}
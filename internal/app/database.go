@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"simdokpol/internal/config"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// SetupDatabase membuka koneksi sesuai dialek di konfigurasi, menjalankan
+// migrasi skema dan mengisi data bawaan (jabatan, peran).
+func SetupDatabase(cfg *config.Config) (*gorm.DB, error) {
+	db, err := OpenDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// File SQL di migrations/ adalah sumber skema; database lama hasil
+	// AutoMigrate ditandai baseline sekali. Skema yang lebih baru dari
+	// binary ini ditolak agar data tidak rusak.
+	applied, err := services.NewSchemaMigrationService(db, cfg.DBDialect).Up(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("migrasi gagal: %w", err)
+	}
+	if applied > 0 {
+		log.Printf("✅ %d migrasi skema dijalankan", applied)
+	}
+	if err := utils.NormalizeLegacyJabatanRegu(db); err != nil {
+		log.Printf("WARN: gagal normalisasi jabatan regu: %v", err)
+	}
+	if err := utils.EnsureDefaultJobPositions(db); err != nil {
+		log.Printf("WARN: gagal seed jabatan default: %v", err)
+	}
+	if err := utils.EnsureDefaultRoles(db); err != nil {
+		log.Printf("WARN: gagal seed peran default: %v", err)
+	}
+	return db, nil
+}
+
+// OpenDatabase hanya membuka koneksi tanpa menyentuh skema. Dipakai
+// `admin migrate` (yang mengatur versi skema sendiri) dan cmd/data-exchange.
+func OpenDatabase(cfg *config.Config) (*gorm.DB, error) {
+	var db *gorm.DB
+	var err error
+	gormConfig := &gorm.Config{Logger: logger.Default.LogMode(logger.Warn)}
+
+	switch cfg.DBDialect {
+	case "mysql":
+		var tlsOption string
+		switch cfg.DBSSLMode {
+		case "require", "verify-full":
+			tlsOption = "true"
+		default:
+			tlsOption = "false"
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&tls=%s", cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName, tlsOption)
+		db, err = gorm.Open(mysql.Open(dsn), gormConfig)
+	case "postgres":
+		sslMode := cfg.DBSSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Jakarta", cfg.DBHost, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBPort, sslMode)
+		db, err = gorm.Open(postgres.Open(dsn), gormConfig)
+	default:
+		db, err = gorm.Open(sqlite.Open(cfg.DBDSN), gormConfig)
+		if err == nil {
+			db.Exec("PRAGMA journal_mode = WAL;")
+			db.Exec("PRAGMA synchronous = NORMAL;")
+			db.Exec("PRAGMA foreign_keys = ON;")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+	return db, nil
+}
+
+// SeedDefaultTemplates mengisi template barang bawaan bila tabelnya masih kosong.
+func SeedDefaultTemplates(db *gorm.DB) {
+	var count int64
+	db.Model(&models.ItemTemplate{}).Unscoped().Count(&count)
+	if count > 0 {
+		return
+	}
+
+	log.Println("🔹 Seeding templates...")
+
+	bankOptions := []string{
+		"BRI", "BCA", "Mandiri", "BNI", "BSI", "BTN", "CIMB Niaga",
+		"Danamon", "Permata", "Panin", "Maybank", "Mega",
+		"BTPN / Jenius", "Bank Daerah (BPD)", "Lainnya",
+	}
+
+	templates := []models.ItemTemplate{
+		{
+			NamaBarang: "KTP",
+			Urutan:     1,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:          "NIK",
+					Type:           "text",
+					DataLabel:      "NIK",
+					Regex:          "^[0-9]{16}$",
+					RequiredLength: 16,
+					IsNumeric:      true,
+					Placeholder:    "16 Digit NIK",
+				},
+			},
+		},
+		{
+			NamaBarang: "SIM",
+			Urutan:     2,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:     "Golongan SIM",
+					Type:      "select",
+					DataLabel: "Gol",
+					Options:   []string{"A", "B I", "B II", "C", "D"},
+				},
+				{
+					Label:     "Nomor SIM",
+					Type:      "text",
+					DataLabel: "No. SIM",
+					Regex:     "^[0-9]{12,14}$",
+					MinLength: 12,
+					MaxLength: 14,
+					IsNumeric: true,
+				},
+			},
+		},
+		{
+			NamaBarang: "STNK",
+			Urutan:     3,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:     "Nomor Polisi",
+					Type:      "text",
+					DataLabel: "No. Pol",
+				},
+				{
+					Label:     "Nomor Rangka",
+					Type:      "text",
+					DataLabel: "No. Rangka",
+				},
+				{
+					Label:     "Nomor Mesin",
+					Type:      "text",
+					DataLabel: "No. Mesin",
+				},
+			},
+		},
+		{
+			NamaBarang: "BPKB",
+			Urutan:     4,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:     "Nomor BPKB",
+					Type:      "text",
+					DataLabel: "No. BPKB",
+				},
+				{
+					Label:     "Atas Nama",
+					Type:      "text",
+					DataLabel: "a.n.",
+				},
+			},
+		},
+		{
+			NamaBarang: "IJAZAH",
+			Urutan:     5,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:     "Tingkat",
+					Type:      "select",
+					DataLabel: "Tingkat",
+					Options:   []string{"SD", "SMP", "SMA", "D3", "S1", "S2"},
+				},
+				{
+					Label:     "Nomor Ijazah",
+					Type:      "text",
+					DataLabel: "No. Ijazah",
+				},
+			},
+		},
+		{
+			NamaBarang: "ATM",
+			Urutan:     6,
+			IsActive:   true,
+			FieldsConfig: models.JSONFieldArray{
+				{
+					Label:     "Nama Bank",
+					Type:      "select",
+					DataLabel: "Bank",
+					Options:   bankOptions,
+				},
+				{
+					Label:     "Nomor Rekening",
+					Type:      "text",
+					DataLabel: "No. Rek",
+				},
+			},
+		},
+		{
+			NamaBarang:   "LAINNYA",
+			Urutan:       99,
+			IsActive:     true,
+			FieldsConfig: models.JSONFieldArray{},
+		},
+	}
+
+	db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nama_barang"}},
+		DoNothing: true,
+	}).Create(&templates)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Prepare memuat .env dari direktori data (dataDir kosong = lokasi bawaan
+// OS) lalu menyiapkan kunci rahasia. Dipanggil sekali di awal setiap entry point.
+func Prepare(dataDir string) error {
+	if dataDir != "" {
+		if err := utils.SetAppDataDir(dataDir); err != nil {
+			return err
+		}
+	}
+	SetupEnvironment()
+	InitializeSecrets()
+	return nil
+}
+
+func SetupEnvironment() {
+	envPath := filepath.Join(utils.GetAppDataDir(), ".env")
+	_ = godotenv.Overload(envPath)
+}
+
+func InitializeSecrets() {
+	if services.JWTSecretKeyString != "" {
+		services.JWTSecretKey = []byte(services.JWTSecretKeyString)
+	}
+
+	if services.AppSecretKeyString == "" {
+		services.AppSecretKeyString = os.Getenv("APP_SECRET_KEY")
+	}
+	if len(services.JWTSecretKey) == 0 {
+		if jwtStr := os.Getenv("JWT_SECRET_KEY"); jwtStr != "" {
+			services.JWTSecretKey = []byte(jwtStr)
+		}
+	}
+
+	updates := make(map[string]string)
+
+	if services.AppSecretKeyString == "" {
+		log.Println("🔑 Generating new APP_SECRET_KEY...")
+		b := make([]byte, 32)
+		rand.Read(b)
+		services.AppSecretKeyString = hex.EncodeToString(b)
+		updates["APP_SECRET_KEY"] = services.AppSecretKeyString
+		os.Setenv("APP_SECRET_KEY", services.AppSecretKeyString)
+	}
+
+	if len(services.JWTSecretKey) == 0 {
+		log.Println("🔑 Generating new JWT_SECRET_KEY...")
+		b := make([]byte, 32)
+		rand.Read(b)
+		jwtStr := hex.EncodeToString(b)
+		services.JWTSecretKey = []byte(jwtStr)
+		updates["JWT_SECRET_KEY"] = jwtStr
+		os.Setenv("JWT_SECRET_KEY", jwtStr)
+	}
+
+	// Kunci penanda tangan checkpoint log audit, unik per instalasi
+	if auditKey := os.Getenv("AUDIT_SIGNING_KEY"); auditKey != "" {
+		services.AuditSigningKey = []byte(auditKey)
+	} else {
+		log.Println("🔑 Generating new AUDIT_SIGNING_KEY...")
+		b := make([]byte, 32)
+		rand.Read(b)
+		auditKey = hex.EncodeToString(b)
+		services.AuditSigningKey = []byte(auditKey)
+		updates["AUDIT_SIGNING_KEY"] = auditKey
+		os.Setenv("AUDIT_SIGNING_KEY", auditKey)
+	}
+
+	if len(updates) > 0 {
+		if err := utils.UpdateEnvFile(updates); err != nil {
+			log.Printf("⚠️ Gagal menyimpan secrets ke .env: %v", err)
+		} else {
+			log.Println("✅ Secrets berhasil disimpan permanen ke .env")
+		}
+	}
+}
+
+// SetupLogging menulis log ke stdout sekaligus file bergilir di folder data.
+func SetupLogging() {
+	logPath := filepath.Join(utils.GetAppDataDir(), "logs", "simdokpol.log")
+	_ = os.MkdirAll(filepath.Dir(logPath), 0755)
+	fileLogger := &lumberjack.Logger{
+		Filename:   logPath,
+		MaxSize:    10,
+		MaxBackups: 3,
+		MaxAge:     28,
+		Compress:   true,
+	}
+	mw := io.MultiWriter(os.Stdout, fileLogger)
+	log.SetOutput(mw)
+}
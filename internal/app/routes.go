@@ -0,0 +1,370 @@
+package app
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"simdokpol/internal/controllers"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/middleware"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+	"simdokpol/web"
+
+	"github.com/gin-contrib/gzip"
+	"github.com/gin-gonic/gin"
+)
+
+// buildRouter merakit service dan controller lalu mendaftarkan semua rute
+// halaman dan API.
+func (a *App) buildRouter(serverLimits middleware.ServerLimits) *gin.Engine {
+	repos := newRepositorySet(a.DB)
+
+	configureAuditPaths()
+	auditService := services.NewAuditLogService(repos.audit)
+	configService := services.NewConfigService(repos.config, a.DB)
+	backupService := services.NewBackupService(a.DB, a.Config, configService, auditService, repos.backupRun)
+	licenseService := services.NewLicenseService(repos.license, configService, auditService)
+	userService := services.NewUserService(repos.user, auditService, a.Config)
+	authService := services.NewAuthService(repos.user, configService, auditService)
+	roleService := services.NewRoleService(repos.role, repos.user, auditService)
+	apiTokenService := services.NewAPITokenService(repos.apiToken, repos.user, roleService, auditService)
+	sessionRevocationService := services.NewSessionRevocationService(repos.sessionRevocation, configService)
+	oidcService := services.NewOIDCService(repos.user, configService, auditService, sessionRevocationService)
+	migrationService := services.NewDataMigrationService(a.DB, auditService, configService)
+	dataExchangeService := services.NewDataExchangeService(a.DB, a.Config.DBDialect, auditService)
+
+	exeDir := executableDir()
+
+	docService := services.NewLostDocumentService(a.DB, repos.doc, repos.resident, repos.user, auditService, configService, roleService, repos.config, exeDir)
+	dashboardService := services.NewDashboardService(repos.doc, repos.user, configService, roleService)
+	reportService := services.NewReportService(repos.doc, configService, exeDir)
+	itemTemplateService := services.NewItemTemplateService(repos.itemTemplate)
+	jobPositionService := services.NewJobPositionService(repos.jobPosition, auditService)
+	dbTestService := services.NewDBTestService()
+	updateService := services.NewUpdateService()
+
+	authController := controllers.NewAuthController(authService, configService, roleService, a.opts.Version)
+	userController := controllers.NewUserController(userService)
+	docController := controllers.NewLostDocumentController(docService)
+	dashboardController := controllers.NewDashboardController(dashboardService)
+	configController := controllers.NewConfigController(configService, userService, backupService, migrationService)
+	auditController := controllers.NewAuditLogController(auditService, configService)
+	backupController := controllers.NewBackupController(backupService)
+	dataExchangeController := controllers.NewDataExchangeController(dataExchangeService)
+	settingsController := controllers.NewSettingsController(configService, auditService, roleService)
+	licenseController := controllers.NewLicenseController(licenseService, auditService)
+	reportController := controllers.NewReportController(reportService, configService)
+	itemTemplateController := controllers.NewItemTemplateController(itemTemplateService)
+	jobPositionController := controllers.NewJobPositionController(jobPositionService)
+	dbTestController := controllers.NewDBTestController(dbTestService)
+	updateController := controllers.NewUpdateController(updateService, a.opts.Version)
+	systemController := controllers.NewSystemController(a.DB, auditService)
+	metricsController := controllers.NewMetricsController(services.NewMetricsService(a.DB, auditService, backupService, licenseService, configService))
+	roleController := controllers.NewRoleController(roleService)
+	apiTokenController := controllers.NewAPITokenController(apiTokenService)
+	ssoController := controllers.NewSSOController(oidcService, configService)
+
+	a.auditService = auditService
+	a.configService = configService
+	a.backupService = backupService
+
+	if os.Getenv("APP_ENV") == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.Default()
+	r.Use(middleware.SecurityHeadersMiddleware(a.HTTPS))
+	r.Use(middleware.BodyLimitMiddleware(serverLimits))
+	r.Use(middleware.RequestContextMiddleware())
+	r.Use(middleware.MaintenanceMiddleware(services.Maintenance))
+	r.Use(middleware.HTTPMetricsMiddleware())
+	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.CSRFMiddleware())
+	r.Use(gzip.Gzip(gzip.DefaultCompression))
+	r.MaxMultipartMemory = 8 << 20
+
+	funcMap := template.FuncMap{
+		"ToUpper":                strings.ToUpper,
+		"FormatTanggalIndonesia": utils.FormatTanggalIndonesia,
+	}
+	templ := template.Must(template.New("").Funcs(funcMap).ParseFS(web.Assets, "templates/*.html", "templates/partials/*.html"))
+	r.SetHTMLTemplate(templ)
+	r.StaticFS("/static", web.GetStaticFS())
+	spaDist := filepath.Join(".", "frontend", "dist")
+	serveSPA := func(c *gin.Context) {
+		requestedPath := strings.TrimPrefix(c.Param("filepath"), "/")
+		if requestedPath == "" {
+			requestedPath = "index.html"
+		}
+		distPath := filepath.Join(spaDist, requestedPath)
+		if _, err := os.Stat(distPath); err == nil {
+			c.File(distPath)
+			return
+		}
+		indexPath := filepath.Join(spaDist, "index.html")
+		if _, err := os.Stat(indexPath); err == nil {
+			c.File(indexPath)
+			return
+		}
+		c.String(http.StatusNotFound, "SPA build belum tersedia. Jalankan `cd frontend && npm run build`.")
+	}
+	r.GET("/app", serveSPA)
+	r.GET("/app/*filepath", serveSPA)
+
+	r.Use(func(c *gin.Context) {
+		c.Set("AppVersion", a.opts.Version)
+		decodedChangelog, _ := utils.DecodeBase64(a.opts.Changelog)
+		c.Set("AppChangelog", decodedChangelog)
+		c.Next()
+	})
+
+	r.GET("/login", authController.ShowLoginPage)
+	r.POST("/api/login", middleware.LoginRateLimiter.GetLimiterMiddleware(), authController.Login)
+	r.POST("/api/logout", authController.Logout)
+	r.GET("/api/auth/sso", ssoController.Status)
+	r.GET("/api/auth/sso/login", middleware.LoginRateLimiter.GetLimiterMiddleware(), ssoController.Login)
+	r.GET("/api/auth/sso/callback", ssoController.Callback)
+	r.POST("/api/auth/sso/logout", ssoController.Logout)
+	r.POST("/api/auth/sso/backchannel-logout", ssoController.BackchannelLogout)
+	r.GET("/setup", configController.ShowSetupPage)
+	r.POST("/api/setup", configController.SaveSetup)
+	r.POST("/api/setup/restore", configController.RestoreSetup)
+	r.POST("/api/db/test", dbTestController.TestConnection)
+	r.GET("/api/healthz", systemController.Healthz)
+	r.GET("/metrics", middleware.MetricsAccessMiddleware(middleware.LoadMetricsAccess()), metricsController.Prometheus)
+
+	authorized := r.Group("/")
+	authorized.Use(middleware.SetupMiddleware(configService))
+	if repos.user != nil {
+		authorized.Use(middleware.AuthMiddleware(repos.user, apiTokenService, sessionRevocationService))
+	}
+
+	authorized.GET("/", func(c *gin.Context) {
+		controllers.RenderHTML(c, "dashboard.html", gin.H{"Title": "Beranda", "Config": mustGetConfig(configService)})
+	})
+	authorized.GET("/api/auth/me", authController.Me)
+	authorized.GET("/api/config/limits", configController.GetLimits)
+	authorized.GET("/api/stats", dashboardController.GetStats)
+	authorized.GET("/api/stats/monthly-issuance", dashboardController.GetMonthlyChart)
+	authorized.GET("/api/stats/item-composition", dashboardController.GetItemCompositionChart)
+	authorized.GET("/api/notifications/expiring-documents", dashboardController.GetExpiringDocuments)
+	authorized.GET("/api/updates/check", updateController.CheckUpdate)
+
+	authorized.GET("/documents", func(c *gin.Context) {
+		controllers.RenderHTML(c, "document_list.html", gin.H{"Title": "Daftar Dokumen", "PageType": "active"})
+	})
+	authorized.GET("/documents/archived", func(c *gin.Context) {
+		controllers.RenderHTML(c, "document_list.html", gin.H{"Title": "Arsip Dokumen", "PageType": "archived"})
+	})
+	authorized.GET("/documents/new", middleware.PermissionMiddleware(roleService, models.PermDocumentCreate), func(c *gin.Context) {
+		controllers.RenderHTML(c, "document_form.html", gin.H{"Title": "Buat Surat Baru", "IsEdit": false, "DocID": 0})
+	})
+	authorized.GET("/documents/:id/edit", func(c *gin.Context) {
+		controllers.RenderHTML(c, "document_form.html", gin.H{"Title": "Edit Surat", "IsEdit": true, "DocID": c.Param("id")})
+	})
+
+	authorized.GET("/documents/:id/print", func(c *gin.Context) {
+		docID := c.Param("id")
+		var id uint
+		fmt.Sscanf(docID, "%d", &id)
+		userID := c.GetUint("userID")
+		doc, err := docService.FindForPrint(c.Request.Context(), id, userID)
+		if err != nil {
+			c.String(404, "Dokumen tidak ditemukan")
+			return
+		}
+		conf, _ := configService.GetConfig()
+		archiveDays := 15
+		if conf.ArchiveDurationDays > 0 {
+			archiveDays = conf.ArchiveDurationDays
+		}
+		controllers.RenderHTML(c, "print_preview.html", gin.H{
+			"Document":         doc,
+			"Config":           conf,
+			"ArchiveDays":      archiveDays,
+			"ArchiveDaysWords": utils.IntToIndonesianWords(archiveDays),
+		})
+	})
+
+	authorized.POST("/api/documents", middleware.PermissionMiddleware(roleService, models.PermDocumentCreate), docController.Create)
+	authorized.GET("/api/documents", docController.FindAll)
+	authorized.GET("/api/documents/handover", middleware.PermissionMiddleware(roleService, models.PermDocumentReadAll, models.PermDocumentRegu), docController.Handover)
+	authorized.GET("/api/documents/:id", docController.FindByID)
+	authorized.GET("/api/documents/:id/pdf", docController.GetPDF)
+	authorized.PUT("/api/documents/:id", docController.Update)
+	authorized.DELETE("/api/documents/:id", docController.Delete)
+	authorized.GET("/api/search", docController.SearchGlobal)
+	authorized.GET("/search", func(c *gin.Context) {
+		controllers.RenderHTML(c, "search_results.html", gin.H{"Title": "Hasil Pencarian"})
+	})
+
+	authorized.GET("/api/item-templates/active", itemTemplateController.FindAllActive)
+	authorized.GET("/profile", func(c *gin.Context) {
+		controllers.RenderHTML(c, "profile.html", gin.H{"Title": "Profil Saya"})
+	})
+	authorized.PUT("/api/profile", userController.UpdateProfile)
+	authorized.PUT("/api/profile/password", userController.ChangePassword)
+	authorized.GET("/api/profile/tokens", apiTokenController.ListMine)
+	authorized.GET("/api/profile/tokens/scopes", apiTokenController.Scopes)
+	authorized.POST("/api/profile/tokens", apiTokenController.Create)
+	authorized.DELETE("/api/profile/tokens/:id", apiTokenController.Revoke)
+	authorized.GET("/panduan", func(c *gin.Context) {
+		controllers.RenderHTML(c, "panduan.html", gin.H{"Title": "Panduan", "ActiveTab": "overview"})
+	})
+	authorized.GET("/panduan/setup", func(c *gin.Context) {
+		controllers.RenderHTML(c, "panduan_setup.html", gin.H{"Title": "Panduan", "ActiveTab": "setup"})
+	})
+	authorized.GET("/panduan/dokumen", func(c *gin.Context) {
+		controllers.RenderHTML(c, "panduan_dokumen.html", gin.H{"Title": "Panduan", "ActiveTab": "dokumen"})
+	})
+	authorized.GET("/panduan/admin", func(c *gin.Context) {
+		controllers.RenderHTML(c, "panduan_admin.html", gin.H{"Title": "Panduan", "ActiveTab": "admin"})
+	})
+	authorized.GET("/upgrade", func(c *gin.Context) {
+		conf, _ := configService.GetConfig()
+		controllers.RenderHTML(c, "upgrade.html", gin.H{"Title": "Upgrade ke Pro", "Config": conf})
+	})
+	authorized.GET("/tentang", func(c *gin.Context) {
+		conf, _ := configService.GetConfig()
+		controllers.RenderHTML(c, "tentang.html", gin.H{"Title": "Tentang", "Config": conf})
+	})
+
+	authorized.GET("/api/users/operators", userController.FindOperators)
+
+	userAdmin := authorized.Group("/")
+	userAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermUserManage))
+	userAdmin.GET("/users", func(c *gin.Context) {
+		controllers.RenderHTML(c, "user_list.html", gin.H{"Title": "Manajemen Pengguna"})
+	})
+	userAdmin.GET("/jabatan", func(c *gin.Context) {
+		controllers.RenderHTML(c, "jabatan_list.html", gin.H{"Title": "Master Jabatan"})
+	})
+	userAdmin.GET("/users/new", func(c *gin.Context) {
+		controllers.RenderHTML(c, "user_form.html", gin.H{"Title": "Tambah Pengguna", "IsEdit": false, "UserID": 0})
+	})
+	userAdmin.GET("/users/:id/edit", func(c *gin.Context) {
+		controllers.RenderHTML(c, "user_form.html", gin.H{"Title": "Edit Pengguna", "IsEdit": true, "UserID": c.Param("id")})
+	})
+	userAdmin.POST("/api/users", userController.Create)
+	userAdmin.GET("/api/users", userController.FindAll)
+	userAdmin.GET("/api/users/:id", userController.FindByID)
+	userAdmin.PUT("/api/users/:id", userController.Update)
+	userAdmin.DELETE("/api/users/:id", userController.Delete)
+	userAdmin.POST("/api/users/:id/activate", userController.Activate)
+	userAdmin.GET("/api/jabatans", jobPositionController.FindAll)
+	userAdmin.GET("/api/jabatans/active", jobPositionController.FindAllActive)
+	userAdmin.POST("/api/jabatans", jobPositionController.Create)
+	userAdmin.PUT("/api/jabatans/:id", jobPositionController.Update)
+	userAdmin.DELETE("/api/jabatans/:id", jobPositionController.Delete)
+	userAdmin.POST("/api/jabatans/:id/restore", jobPositionController.Restore)
+	userAdmin.GET("/api/tokens", apiTokenController.FindAll)
+	userAdmin.DELETE("/api/tokens/:id", apiTokenController.Revoke)
+
+	roleRead := authorized.Group("/")
+	roleRead.Use(middleware.PermissionMiddleware(roleService, models.PermUserManage, models.PermRoleManage))
+	roleRead.GET("/api/roles", roleController.FindAll)
+	roleRead.GET("/api/roles/permissions", roleController.Permissions)
+
+	roleAdmin := authorized.Group("/")
+	roleAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermRoleManage))
+	roleAdmin.POST("/api/roles", roleController.Save)
+	roleAdmin.PUT("/api/roles/:nama", roleController.Save)
+	roleAdmin.DELETE("/api/roles/:nama", roleController.Delete)
+
+	settingsAdmin := authorized.Group("/")
+	settingsAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermSettingsManage))
+	settingsAdmin.GET("/settings", func(c *gin.Context) {
+		controllers.RenderHTML(c, "settings.html", gin.H{"Title": "Pengaturan Sistem"})
+	})
+	settingsAdmin.GET("/api/settings", settingsController.GetSettings)
+	settingsAdmin.PUT("/api/settings", settingsController.UpdateSettings)
+	settingsAdmin.GET("/api/settings/download-cert", settingsController.DownloadCertificate)
+	settingsAdmin.POST("/api/settings/install-cert", settingsController.InstallCertificate)
+	settingsAdmin.GET("/api/metrics", systemController.Metrics)
+
+	dbAdmin := authorized.Group("/")
+	dbAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermDatabaseManage))
+	dbAdmin.GET("/api/backups/capabilities", backupController.Capabilities)
+	dbAdmin.GET("/api/backups/runs", backupController.ListRuns)
+	dbAdmin.GET("/api/backups/status", backupController.Status)
+	dbAdmin.GET("/api/backups", backupController.ListBackups)
+	dbAdmin.POST("/api/backups", backupController.CreateBackup)
+	dbAdmin.GET("/api/backups/files/:name", backupController.DownloadBackup)
+	dbAdmin.POST("/api/backups/files/:name/verify", backupController.VerifyBackup)
+	dbAdmin.POST("/api/backups/files/:name/restore", backupController.RestoreStoredBackup)
+	dbAdmin.POST("/api/backups/offsite/test", backupController.TestOffsite)
+	dbAdmin.DELETE("/api/backups/files/:name", backupController.DeleteBackup)
+	dbAdmin.POST("/api/restore", backupController.RestoreBackup)
+	dbAdmin.POST("/api/restore/validate", backupController.ValidateRestore)
+	dbAdmin.POST("/api/restore/:token/confirm", backupController.ConfirmRestore)
+	dbAdmin.DELETE("/api/restore/:token", backupController.DiscardRestore)
+	dbAdmin.POST("/api/settings/migrate", configController.MigrateDatabase)
+	dbAdmin.GET("/api/data/export", dataExchangeController.Export)
+	dbAdmin.POST("/api/data/import", dataExchangeController.Import)
+	dbAdmin.POST("/api/audit-archives/run", auditController.RunRetention)
+
+	auditAdmin := authorized.Group("/")
+	auditAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermAuditView))
+	auditAdmin.GET("/api/audit-logs", auditController.FindAll)
+	auditAdmin.GET("/api/audit-logs/export", auditController.Export)
+	auditAdmin.GET("/api/audit-logs/verify", auditController.Verify)
+	auditAdmin.GET("/api/residents/access-log", docController.ResidentAccessLog)
+	auditAdmin.GET("/api/audit-sinks", auditController.SinkStatus)
+	auditAdmin.GET("/api/audit-archives", auditController.ListArchives)
+	auditAdmin.GET("/api/audit-archives/:id/entries", auditController.SearchArchive)
+	auditAdmin.GET("/api/audit-archives/:id/download", auditController.DownloadArchive)
+	auditAdmin.GET("/audit-logs", func(c *gin.Context) {
+		controllers.RenderHTML(c, "audit_log_list.html", gin.H{"Title": "Log Audit"})
+	})
+
+	authorized.GET("/api/documents/export", middleware.PermissionMiddleware(roleService, models.PermDocumentExport), docController.Export)
+
+	licenseAdmin := authorized.Group("/")
+	licenseAdmin.Use(middleware.PermissionMiddleware(roleService, models.PermLicenseManage))
+	licenseAdmin.POST("/api/license/activate", licenseController.ActivateLicense)
+	licenseAdmin.GET("/api/license/hwid", licenseController.GetHardwareID)
+	licenseAdmin.GET("/api/license/hwid/qr", licenseController.GetHardwareIDQR)
+
+	pro := authorized.Group("/")
+	pro.Use(middleware.LicenseMiddleware(licenseService))
+	pro.GET("/reports/aggregate", middleware.PermissionMiddleware(roleService, models.PermReportView), reportController.ShowReportPage)
+	pro.GET("/api/reports/aggregate/pdf", middleware.PermissionMiddleware(roleService, models.PermReportView), reportController.GenerateReportPDF)
+
+	proTemplates := pro.Group("/")
+	proTemplates.Use(middleware.PermissionMiddleware(roleService, models.PermTemplateManage))
+	proTemplates.GET("/templates", func(c *gin.Context) {
+		controllers.RenderHTML(c, "item_template_list.html", gin.H{"Title": "Template Barang"})
+	})
+	proTemplates.GET("/templates/new", func(c *gin.Context) {
+		controllers.RenderHTML(c, "item_template_form.html", gin.H{"Title": "Tambah Template", "IsEdit": false, "TemplateID": 0})
+	})
+	proTemplates.GET("/templates/:id/edit", func(c *gin.Context) {
+		controllers.RenderHTML(c, "item_template_form.html", gin.H{"Title": "Edit Template", "IsEdit": true, "TemplateID": c.Param("id")})
+	})
+	proTemplates.GET("/api/item-templates", itemTemplateController.FindAll)
+	proTemplates.GET("/api/item-templates/:id", itemTemplateController.FindByID)
+	proTemplates.POST("/api/item-templates", itemTemplateController.Create)
+	proTemplates.PUT("/api/item-templates/:id", itemTemplateController.Update)
+	proTemplates.DELETE("/api/item-templates/:id", itemTemplateController.Delete)
+
+	return r
+}
+
+// configureAuditPaths menaruh jurnal log audit yang gagal ditulis (mis.
+// database putus) dan arsip retensi di folder data.
+func configureAuditPaths() {
+	appData := utils.GetAppDataDir()
+	services.AuditJournalPath = filepath.Join(appData, "audit-journal.jsonl")
+	services.AuditDeadLetterPath = filepath.Join(appData, "audit-deadletter.jsonl")
+	services.AuditArchiveDir = filepath.Join(appData, "audit-archives")
+}
+
+func mustGetConfig(s services.ConfigService) *dto.AppConfig {
+	c, _ := s.GetConfig()
+	return c
+}
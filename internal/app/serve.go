@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// ServeFlags hasil parsing perintah `serve`.
+type ServeFlags struct {
+	Headless bool
+	Options  Options
+}
+
+// ParseServeFlags membaca flag perintah `serve` di atas nilai bawaan opts:
+//
+//	serve --headless --port 8443 --bind 127.0.0.1 --data-dir /var/lib/simdokpol --tls --cert server.crt --key server.key
+//
+// Flag yang tidak diisi mengikuti .env di folder data.
+func ParseServeFlags(args []string, opts Options, output io.Writer) (*ServeFlags, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(output)
+	parsed := &ServeFlags{Options: opts}
+	fs.BoolVar(&parsed.Headless, "headless", false, "Jalankan tanpa systray/browser (server Linux, service)")
+	fs.StringVar(&parsed.Options.Port, "port", opts.Port, "Port HTTP/HTTPS (bawaan: PORT di .env atau 8080)")
+	fs.StringVar(&parsed.Options.Bind, "bind", opts.Bind, "Alamat yang didengarkan, mis. 127.0.0.1 (bawaan: semua interface)")
+	fs.StringVar(&parsed.Options.DataDir, "data-dir", opts.DataDir, "Folder data (.env, database SQLite, log, backup)")
+	tls := fs.Bool("tls", false, "Aktifkan HTTPS (bawaan: ENABLE_HTTPS di .env)")
+	fs.StringVar(&parsed.Options.CertFile, "cert", opts.CertFile, "File sertifikat TLS (bawaan: sertifikat self-signed otomatis)")
+	fs.StringVar(&parsed.Options.KeyFile, "key", opts.KeyFile, "File private key TLS")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("argumen tidak dikenal: %v", fs.Args())
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "tls" {
+			parsed.Options.HTTPS = tls
+		}
+	})
+	if (parsed.Options.CertFile == "") != (parsed.Options.KeyFile == "") {
+		return nil, fmt.Errorf("--cert dan --key harus diisi bersamaan")
+	}
+	if parsed.Options.CertFile != "" && parsed.Options.HTTPS == nil {
+		enabled := true
+		parsed.Options.HTTPS = &enabled
+	}
+	return parsed, nil
+}
+
+// RunHeadless menjalankan server tanpa antarmuka desktop sampai menerima
+// SIGINT/SIGTERM. Dipakai `serve --headless` dan build server/Termux.
+func RunHeadless(opts Options) error {
+	if err := Prepare(opts.DataDir); err != nil {
+		return fmt.Errorf("folder data: %w", err)
+	}
+	SetupLogging()
+	a, err := New(opts)
+	if err != nil {
+		return err
+	}
+	return a.Run(context.Background())
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenController struct {
+	service services.APITokenService
+}
+
+func NewAPITokenController(service services.APITokenService) *APITokenController {
+	return &APITokenController{service: service}
+}
+
+type CreateAPITokenRequest struct {
+	Nama          string   `json:"nama" binding:"required" example:"Skrip Laporan Harian"`
+	Scopes        []string `json:"scopes" example:"api:read,report:view"`
+	ExpiresInDays int      `json:"expires_in_days" example:"30"`
+}
+
+// currentUserFromSession mengambil pengguna login dan menolak request yang
+// datang lewat token API (token tidak boleh dipakai untuk membuat/mencabut token).
+func currentUserFromSession(ctx *gin.Context) (*models.User, bool) {
+	if _, viaToken := ctx.Get("apiToken"); viaToken {
+		APIError(ctx, http.StatusForbidden, "Token API tidak dapat dikelola menggunakan token API.")
+		return nil, false
+	}
+	u, exists := ctx.Get("currentUser")
+	user, ok := u.(*models.User)
+	if !exists || !ok {
+		APIError(ctx, http.StatusUnauthorized, "Diperlukan otorisasi")
+		return nil, false
+	}
+	return user, true
+}
+
+// @Summary Daftar Token API Milik Sendiri
+// @Tags Profile
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Router /profile/tokens [get]
+func (c *APITokenController) ListMine(ctx *gin.Context) {
+	user, ok := currentUserFromSession(ctx)
+	if !ok {
+		return
+	}
+	tokens, err := c.service.ListByUser(user.ID)
+	if err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar token.")
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Daftar Scope Token yang Bisa Dipilih
+// @Tags Profile
+// @Produce json
+// @Router /profile/tokens/scopes [get]
+func (c *APITokenController) Scopes(ctx *gin.Context) {
+	user, ok := currentUserFromSession(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, c.service.AvailableScopes(user))
+}
+
+// @Summary Membuat Token API Baru
+// @Description Nilai token hanya ditampilkan sekali pada respons ini.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param token body CreateAPITokenRequest true "Data Token"
+// @Router /profile/tokens [post]
+func (c *APITokenController) Create(ctx *gin.Context) {
+	user, ok := currentUserFromSession(ctx)
+	if !ok {
+		return
+	}
+	var req CreateAPITokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Input tidak valid.")
+		return
+	}
+
+	plain, token, err := c.service.Create(user, req.Nama, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	APIResponse(ctx, http.StatusCreated, "Token berhasil dibuat. Simpan sekarang, token tidak akan ditampilkan lagi.", gin.H{
+		"token":     plain,
+		"api_token": token,
+	})
+}
+
+// @Summary Daftar Semua Token API (Admin)
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Router /tokens [get]
+func (c *APITokenController) FindAll(ctx *gin.Context) {
+	tokens, err := c.service.ListAll()
+	if err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar token.")
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Mencabut Token API
+// @Router /profile/tokens/{id} [delete]
+// @Router /tokens/{id} [delete]
+func (c *APITokenController) Revoke(ctx *gin.Context) {
+	user, ok := currentUserFromSession(ctx)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID token tidak valid")
+		return
+	}
+
+	if err := c.service.Revoke(uint(id), user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			APIError(ctx, http.StatusNotFound, "Token tidak ditemukan.")
+		case errors.Is(err, services.ErrAccessDenied):
+			APIError(ctx, http.StatusForbidden, "Akses ditolak.")
+		default:
+			log.Printf("ERROR: Gagal mencabut token %d: %v", id, err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal mencabut token.")
+		}
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Token berhasil dicabut.", nil)
+}
@@ -1,21 +1,24 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"simdokpol/internal/dto" // <-- TAMBAH INI (FIX UNDEFINED DTO)
 	"simdokpol/internal/services"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuditLogController struct {
-	service services.AuditLogService
+	service       services.AuditLogService
+	configService services.ConfigService
 }
 
-func NewAuditLogController(service services.AuditLogService) *AuditLogController {
-	return &AuditLogController{service: service}
+func NewAuditLogController(service services.AuditLogService, configService services.ConfigService) *AuditLogController {
+	return &AuditLogController{service: service, configService: configService}
 }
 
 // @Summary Ekspor Log Audit ke Excel
@@ -34,7 +37,28 @@ func (c *AuditLogController) Export(ctx *gin.Context) {
 	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buffer.Bytes())
 }
 
+// @Summary Verifikasi rantai hash log audit
+// @Description Menelusuri rantai hash dan checkpoint bertanda tangan, lalu melaporkan kerusakan pertama.
+// @Tags Audit
+// @Produce json
+// @Router /audit-logs/verify [get]
+func (c *AuditLogController) Verify(ctx *gin.Context) {
+	report, err := c.service.VerifyChain()
+	if err != nil {
+		log.Printf("ERROR: Gagal memverifikasi log audit: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal memverifikasi log audit.")
+		return
+	}
+
+	message := "Rantai log audit utuh"
+	if !report.Valid {
+		message = "Rantai log audit rusak"
+	}
+	APIResponse(ctx, http.StatusOK, message, report)
+}
+
 // @Summary Mendapatkan Data Log Audit (Server-Side Paging)
+// @Description Mendukung filter entity_type, entity_id, actor_id, action, ip, request_id, date_from dan date_to (YYYY-MM-DD).
 // @Router /audit-logs [get]
 func (c *AuditLogController) FindAll(ctx *gin.Context) {
 	var req dto.DataTableRequest
@@ -46,8 +70,19 @@ func (c *AuditLogController) FindAll(ctx *gin.Context) {
 		req.Length = 10
 	}
 
+	// Filter terstruktur: entity_type, entity_id, actor_id, action, ip, request_id, date_from, date_to
+	var filter dto.AuditLogFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Parameter filter tidak valid.")
+		return
+	}
+
 	// Panggil service yang sudah support paging
-	response, err := c.service.GetAuditLogsPaged(req)
+	response, err := c.service.GetAuditLogsPaged(req, filter)
+	if errors.Is(err, services.ErrInvalidAuditFilter) {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR: Gagal mengambil data audit log: %v", err)
 		// Return JSON kosong valid untuk DataTables agar tidak alert error
@@ -62,4 +97,113 @@ func (c *AuditLogController) FindAll(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// @Summary Jalankan retensi log audit sekarang
+// @Description Entri yang lebih tua dari audit_retention_days dipindah ke arsip bulanan lalu dihapus dari database.
+// @Tags Audit
+// @Produce json
+// @Router /audit-archives/run [post]
+func (c *AuditLogController) RunRetention(ctx *gin.Context) {
+	appConfig, err := c.configService.GetConfig()
+	if err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal membaca konfigurasi.")
+		return
+	}
+
+	result, err := c.service.ApplyRetention(ctx.Request.Context(), appConfig.AuditRetentionDays, ctx.GetUint("userID"))
+	switch {
+	case errors.Is(err, services.ErrAuditRetentionDisabled):
+		APIError(ctx, http.StatusBadRequest, "Retensi log audit belum diatur di Pengaturan.")
+		return
+	case errors.Is(err, services.ErrAuditChainBroken):
+		APIError(ctx, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		log.Printf("ERROR: Retensi log audit gagal: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengarsipkan log audit.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, fmt.Sprintf("%d log audit diarsipkan.", result.ArchivedEntries), result)
+}
+
+// @Summary Status penerusan log audit (syslog / file)
+// @Description Jumlah terkirim, gagal dan dibuang per sink beserta galat terakhirnya.
+// @Tags Audit
+// @Produce json
+// @Router /audit-sinks [get]
+func (c *AuditLogController) SinkStatus(ctx *gin.Context) {
+	APIResponse(ctx, http.StatusOK, "Status penerusan log audit", c.service.SinkStatus())
+}
+
+// @Summary Daftar arsip log audit
+// @Tags Audit
+// @Produce json
+// @Router /audit-archives [get]
+func (c *AuditLogController) ListArchives(ctx *gin.Context) {
+	archives, err := c.service.ListArchives()
+	if err != nil {
+		log.Printf("ERROR: Gagal mengambil daftar arsip audit: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal memuat arsip log audit.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Daftar arsip log audit", archives)
+}
+
+// @Summary Cari isi arsip log audit (read-only, server-side paging)
+// @Description Isi arsip dicocokkan dulu dengan checksum, tanda tangan dan rantai hash-nya.
+// @Tags Audit
+// @Produce json
+// @Router /audit-archives/{id}/entries [get]
+func (c *AuditLogController) SearchArchive(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID arsip tidak valid")
+		return
+	}
+	var req dto.DataTableRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		req.Draw = 1
+		req.Start = 0
+		req.Length = 10
+	}
+
+	response, err := c.service.SearchArchive(uint(id), req)
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		APIError(ctx, http.StatusNotFound, "Arsip tidak ditemukan.")
+		return
+	case errors.Is(err, services.ErrAuditArchiveTampered):
+		APIError(ctx, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		log.Printf("ERROR: Gagal membaca arsip audit %d: %v", id, err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membaca arsip log audit.")
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Unduh file arsip log audit (gzip JSON lines)
+// @Tags Audit
+// @Router /audit-archives/{id}/download [get]
+func (c *AuditLogController) DownloadArchive(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID arsip tidak valid")
+		return
+	}
+
+	archive, path, err := c.service.GetArchiveFile(uint(id))
+	if errors.Is(err, services.ErrNotFound) {
+		APIError(ctx, http.StatusNotFound, "Arsip tidak ditemukan.")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Gagal mengambil arsip audit %d: %v", id, err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil arsip log audit.")
+		return
+	}
+	clearWriteDeadline(ctx)
+	ctx.FileAttachment(path, archive.FileName)
+}
@@ -3,21 +3,24 @@ package controllers
 import (
 	"net/http"
 	"os"
+	"simdokpol/internal/models"
 	"simdokpol/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthController struct {
-	service       services.AuthService
-	configService services.ConfigService
+	service        services.AuthService
+	configService  services.ConfigService
+	roleService    services.RoleService
 	currentVersion string
 }
 
-func NewAuthController(service services.AuthService, configService services.ConfigService, version string) *AuthController {
+func NewAuthController(service services.AuthService, configService services.ConfigService, roleService services.RoleService, version string) *AuthController {
 	return &AuthController{
-		service:       service,
-		configService: configService,
+		service:        service,
+		configService:  configService,
+		roleService:    roleService,
 		currentVersion: version,
 	}
 }
@@ -56,6 +59,8 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	}
 
 	isProduction := os.Getenv("APP_ENV") == "production"
+	// Strict: cookie sesi tidak ikut terkirim dari situs lain
+	ctx.SetSameSite(http.SameSiteStrictMode)
 	ctx.SetCookie("token", token, timeoutSeconds, "/", "", isProduction, true)
 
 	APIResponse(ctx, http.StatusOK, "Login berhasil", nil)
@@ -63,6 +68,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 
 func (c *AuthController) Logout(ctx *gin.Context) {
 	isProduction := os.Getenv("APP_ENV") == "production"
+	ctx.SetSameSite(http.SameSiteStrictMode)
 	ctx.SetCookie("token", "", -1, "/", "", isProduction, true)
 	APIResponse(ctx, http.StatusOK, "Logout berhasil", nil)
 }
@@ -73,5 +79,9 @@ func (c *AuthController) Me(ctx *gin.Context) {
 		APIError(ctx, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
-	APIResponse(ctx, http.StatusOK, "OK", gin.H{"user": user})
+	currentUser, _ := user.(*models.User)
+	APIResponse(ctx, http.StatusOK, "OK", gin.H{
+		"user":        user,
+		"permissions": c.roleService.GetPermissions(currentUser),
+	})
 }
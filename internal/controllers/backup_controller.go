@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/services"
+	"strconv"
 	"strings"
 	// "os" // Uncomment jika ingin auto-delete
 
@@ -21,9 +26,19 @@ func NewBackupController(service services.BackupService) *BackupController {
 
 func (c *BackupController) CreateBackup(ctx *gin.Context) {
 	actorID := ctx.GetUint("userID")
-	backupPath, err := c.service.CreateBackup(actorID)
+	// Form lama (settings.html) mengirim method lewat body, SPA lewat query
+	method := ctx.Query("method")
+	if method == "" {
+		method = ctx.PostForm("method")
+	}
+	clearWriteDeadline(ctx)
+	backupPath, err := c.service.CreateBackup(ctx.Request.Context(), method, actorID)
 	if err != nil {
 		log.Printf("ERROR Backup: %v", err)
+		if errors.Is(err, services.ErrBackupMethodUnavailable) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
 		APIError(ctx, http.StatusInternalServerError, "Gagal backup.")
 		return
 	}
@@ -39,30 +54,340 @@ func (c *BackupController) CreateBackup(ctx *gin.Context) {
 }
 
 func (c *BackupController) RestoreBackup(ctx *gin.Context) {
-	file, err := ctx.FormFile("restore-file")
+	src, key, ok := readRestoreUpload(ctx)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	actorID := ctx.GetUint("userID")
+	if err := c.service.RestoreBackup(ctx.Request.Context(), src, key, actorID); err != nil {
+		log.Printf("ERROR Restore: %v", err)
+		APIError(ctx, restoreErrorStatus(err), "Gagal restore: "+err.Error())
+		return
+	}
+
+	APIResponse(ctx, http.StatusOK, "Restore berhasil. Silakan restart aplikasi.", nil)
+}
+
+// @Summary Validasi File Restore
+// @Description Mengunggah file backup ke staging lalu memeriksa integritas, versi skema, dan jumlah baris tanpa mengubah database. Jalankan restore dengan token hasilnya.
+// @Tags Backup
+// @Accept multipart/form-data
+// @Produce json
+// @Param restore-file formData file true "File backup (.db, .dump, .sql, .tar.gz, .enc)"
+// @Param passphrase formData string false "Passphrase backup terenkripsi"
+// @Param key-file formData file false "File kunci backup terenkripsi"
+// @Success 200 {object} dto.RestorePreview
+// @Failure 400 {object} map[string]string
+// @Router /restore/validate [post]
+func (c *BackupController) ValidateRestore(ctx *gin.Context) {
+	src, key, ok := readRestoreUpload(ctx)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	preview, err := c.service.ValidateRestore(ctx.Request.Context(), src, key)
 	if err != nil {
-		APIError(ctx, http.StatusBadRequest, "File wajib diunggah.")
+		log.Printf("ERROR Validasi Restore: %v", err)
+		APIError(ctx, restoreErrorStatus(err), "Validasi gagal: "+err.Error())
 		return
 	}
+	if header, err := ctx.FormFile("restore-file"); err == nil {
+		preview.FileName = filepath.Base(header.Filename)
+	}
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// @Summary Jalankan Restore yang Sudah Divalidasi
+// @Description Database otomatis dikembalikan bila pemeriksaan setelah restore gagal.
+// @Tags Backup
+// @Produce json
+// @Param token path string true "Token dari /restore/validate"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /restore/{token}/confirm [post]
+func (c *BackupController) ConfirmRestore(ctx *gin.Context) {
+	actorID := ctx.GetUint("userID")
+	if err := c.service.ConfirmRestore(ctx.Request.Context(), ctx.Param("token"), actorID); err != nil {
+		log.Printf("ERROR Restore: %v", err)
+		APIError(ctx, restoreErrorStatus(err), "Gagal restore: "+err.Error())
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Restore berhasil. Silakan restart aplikasi.", nil)
+}
 
-	if !strings.HasSuffix(file.Filename, ".db") {
-		APIError(ctx, http.StatusBadRequest, "Format harus .db")
+// @Summary Batalkan Restore yang Sudah Divalidasi
+// @Tags Backup
+// @Produce json
+// @Param token path string true "Token dari /restore/validate"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /restore/{token} [delete]
+func (c *BackupController) DiscardRestore(ctx *gin.Context) {
+	if err := c.service.DiscardRestore(ctx.Param("token")); err != nil {
+		APIError(ctx, restoreErrorStatus(err), err.Error())
 		return
 	}
+	APIResponse(ctx, http.StatusOK, "File restore dibuang.", nil)
+}
+
+// readRestoreUpload membaca file restore beserta kunci opsional untuk backup
+// terenkripsi (kosong = kunci dari pengaturan). ok false berarti respons
+// galat sudah dikirim.
+func readRestoreUpload(ctx *gin.Context) (multipart.File, *dto.BackupKey, bool) {
+	file, err := ctx.FormFile("restore-file")
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "File wajib diunggah.")
+		return nil, nil, false
+	}
+
+	if !hasBackupExtension(file.Filename) {
+		APIError(ctx, http.StatusBadRequest, "Format harus .db, .dump, .sql, .tar.gz, atau .enc")
+		return nil, nil, false
+	}
+
+	key, err := readOptionalBackupKey(ctx)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
 
 	src, err := file.Open()
 	if err != nil {
 		APIError(ctx, http.StatusInternalServerError, "Gagal buka file.")
+		return nil, nil, false
+	}
+	return src, key, true
+}
+
+// readOptionalBackupKey: nil bila admin tidak mengisi passphrase maupun file kunci.
+func readOptionalBackupKey(ctx *gin.Context) (*dto.BackupKey, error) {
+	passphrase := ctx.PostForm("passphrase")
+	keyFile, err := readOptionalKeyFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" && len(keyFile) == 0 {
+		return nil, nil
+	}
+	return &dto.BackupKey{Passphrase: passphrase, KeyFile: keyFile}, nil
+}
+
+func restoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRestoreStagingNotFound), errors.Is(err, services.ErrBackupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrRestoreInProgress), errors.Is(err, services.ErrMaintenanceBusy):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrBackupFormatUnsupported), errors.Is(err, services.ErrBackupMethodUnavailable),
+		errors.Is(err, services.ErrBackupKeyRequired), errors.Is(err, services.ErrBackupWrongKey),
+		errors.Is(err, services.ErrBackupTampered), errors.Is(err, services.ErrInvalidBackup),
+		errors.Is(err, services.ErrBackupValidationFailed):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// @Summary Daftar File Backup
+// @Description File di folder backup server beserta ukuran, waktu dan SHA-256, terbaru dulu.
+// @Tags Backup
+// @Produce json
+// @Success 200 {array} dto.BackupFile
+// @Router /backups [get]
+func (c *BackupController) ListBackups(ctx *gin.Context) {
+	files, err := c.service.ListBackups()
+	if err != nil {
+		log.Printf("ERROR Daftar Backup: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membaca folder backup.")
 		return
 	}
-	defer src.Close()
+	ctx.JSON(http.StatusOK, files)
+}
 
-	actorID := ctx.GetUint("userID")
-	if err := c.service.RestoreBackup(src, actorID); err != nil {
-		log.Printf("ERROR Restore: %v", err)
-		APIError(ctx, http.StatusInternalServerError, "Gagal restore: "+err.Error())
+// @Summary Unduh File Backup
+// @Tags Backup
+// @Produce octet-stream
+// @Param name path string true "Nama file backup"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /backups/files/{name} [get]
+func (c *BackupController) DownloadBackup(ctx *gin.Context) {
+	path, err := c.service.DownloadBackup(ctx.Request.Context(), ctx.Param("name"), ctx.GetUint("userID"))
+	if err != nil {
+		APIError(ctx, restoreErrorStatus(err), err.Error())
 		return
 	}
+	clearWriteDeadline(ctx)
+	ctx.FileAttachment(path, filepath.Base(path))
+}
 
-	APIResponse(ctx, http.StatusOK, "Restore berhasil. Silakan restart aplikasi.", nil)
-}
\ No newline at end of file
+// @Summary Verifikasi File Backup
+// @Description Mencocokkan SHA-256 dengan riwayat lalu memeriksa isi file. Backup terenkripsi memakai kunci dari pengaturan bila passphrase/file kunci tidak diisi.
+// @Tags Backup
+// @Accept multipart/form-data
+// @Produce json
+// @Param name path string true "Nama file backup"
+// @Param passphrase formData string false "Passphrase backup terenkripsi"
+// @Param key-file formData file false "File kunci backup terenkripsi"
+// @Success 200 {object} dto.BackupVerification
+// @Failure 404 {object} map[string]string
+// @Router /backups/files/{name}/verify [post]
+func (c *BackupController) VerifyBackup(ctx *gin.Context) {
+	key, err := readOptionalBackupKey(ctx)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := c.service.VerifyBackup(ctx.Request.Context(), ctx.Param("name"), key)
+	if err != nil {
+		log.Printf("ERROR Verifikasi Backup: %v", err)
+		APIError(ctx, restoreErrorStatus(err), err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Hapus File Backup
+// @Tags Backup
+// @Produce json
+// @Param name path string true "Nama file backup"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /backups/files/{name} [delete]
+func (c *BackupController) DeleteBackup(ctx *gin.Context) {
+	if err := c.service.DeleteBackup(ctx.Request.Context(), ctx.Param("name"), ctx.GetUint("userID")); err != nil {
+		log.Printf("ERROR Hapus Backup: %v", err)
+		APIError(ctx, restoreErrorStatus(err), err.Error())
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "File backup dihapus.", nil)
+}
+
+// @Summary Validasi Restore dari File Backup di Server
+// @Description Sama seperti /restore/validate tanpa unggah ulang; jalankan dengan /restore/{token}/confirm.
+// @Tags Backup
+// @Accept multipart/form-data
+// @Produce json
+// @Param name path string true "Nama file backup"
+// @Param passphrase formData string false "Passphrase backup terenkripsi"
+// @Param key-file formData file false "File kunci backup terenkripsi"
+// @Success 200 {object} dto.RestorePreview
+// @Failure 404 {object} map[string]string
+// @Router /backups/files/{name}/restore [post]
+func (c *BackupController) RestoreStoredBackup(ctx *gin.Context) {
+	key, err := readOptionalBackupKey(ctx)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	preview, err := c.service.ValidateStoredRestore(ctx.Request.Context(), ctx.Param("name"), key)
+	if err != nil {
+		log.Printf("ERROR Validasi Restore: %v", err)
+		APIError(ctx, restoreErrorStatus(err), "Validasi gagal: "+err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// @Summary Tes Tujuan Backup Off-site
+// @Description Mencoba tujuan off-site dari form pengaturan (key backup_offsite_*): terhubung, menulis file uji lalu menghapusnya. Password/secret key kosong memakai nilai tersimpan.
+// @Tags Backup
+// @Accept json
+// @Produce json
+// @Param settings body map[string]string true "Pengaturan backup_offsite_*"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 400 {object} map[string]string "Error: Pengaturan belum lengkap"
+// @Failure 500 {object} map[string]string "Error: Koneksi Gagal"
+// @Router /backups/offsite/test [post]
+func (c *BackupController) TestOffsite(ctx *gin.Context) {
+	var settings map[string]string
+	if err := ctx.ShouldBindJSON(&settings); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Format data tidak valid")
+		return
+	}
+	target, err := c.service.TestOffsiteDestination(ctx.Request.Context(), settings)
+	if err != nil {
+		if errors.Is(err, services.ErrOffsiteNotConfigured) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		APIError(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Tujuan backup bisa ditulis: "+target, gin.H{"target": target})
+}
+
+// @Summary Metode Backup yang Tersedia di Server
+// @Tags Backup
+// @Produce json
+// @Success 200 {object} dto.BackupCapabilities
+// @Router /backups/capabilities [get]
+func (c *BackupController) Capabilities(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.service.Capabilities())
+}
+
+// @Summary Riwayat Backup
+// @Tags Backup
+// @Produce json
+// @Param limit query int false "Jumlah baris (maks 500)"
+// @Success 200 {array} models.BackupRun
+// @Router /backups/runs [get]
+func (c *BackupController) ListRuns(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	runs, err := c.service.ListRuns(limit)
+	if err != nil {
+		log.Printf("ERROR Riwayat Backup: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil riwayat backup.")
+		return
+	}
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// @Summary Status Backup Terjadwal
+// @Tags Backup
+// @Produce json
+// @Success 200 {object} dto.BackupStatus
+// @Router /backups/status [get]
+func (c *BackupController) Status(ctx *gin.Context) {
+	status, err := c.service.Status()
+	if err != nil {
+		log.Printf("ERROR Status Backup: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil status backup.")
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// readOptionalKeyFile membaca file kunci backup (field key-file) bila diunggah.
+func readOptionalKeyFile(ctx *gin.Context) ([]byte, error) {
+	header, err := ctx.FormFile("key-file")
+	if err != nil {
+		return nil, nil
+	}
+	if header.Size > 1<<20 {
+		return nil, errors.New("File kunci terlalu besar.")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.New("Gagal membaca file kunci.")
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// hasBackupExtension: isi file tetap diperiksa ulang di service
+func hasBackupExtension(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".db", ".dump", ".sql", ".tar.gz", ".enc"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,14 +2,18 @@ package controllers
 
 import (
 	"bytes"
+	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/middleware"
 	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -30,8 +34,20 @@ func setupBackupTestRouter(mockBackupSvc *mocks.BackupService, authInjector gin.
 	adminRoutes := router.Group("/api")
 	adminRoutes.Use(middleware.AdminAuthMiddleware())
 	{
+		adminRoutes.GET("/backups/capabilities", backupController.Capabilities)
+		adminRoutes.GET("/backups/runs", backupController.ListRuns)
+		adminRoutes.GET("/backups/status", backupController.Status)
+		adminRoutes.GET("/backups", backupController.ListBackups)
 		adminRoutes.POST("/backups", backupController.CreateBackup)
+		adminRoutes.GET("/backups/files/:name", backupController.DownloadBackup)
+		adminRoutes.POST("/backups/files/:name/verify", backupController.VerifyBackup)
+		adminRoutes.POST("/backups/files/:name/restore", backupController.RestoreStoredBackup)
+		adminRoutes.DELETE("/backups/files/:name", backupController.DeleteBackup)
+		adminRoutes.POST("/backups/offsite/test", backupController.TestOffsite)
 		adminRoutes.POST("/restore", backupController.RestoreBackup)
+		adminRoutes.POST("/restore/validate", backupController.ValidateRestore)
+		adminRoutes.POST("/restore/:token/confirm", backupController.ConfirmRestore)
+		adminRoutes.DELETE("/restore/:token", backupController.DiscardRestore)
 	}
 	return router
 }
@@ -53,7 +69,7 @@ func TestBackupController_CreateBackup(t *testing.T) {
 	err := os.WriteFile(dummyFilePath, []byte("dummy db data"), 0644)
 	assert.NoError(t, err)
 	
-	mockBackupSvc.On("CreateBackup", adminUserForBackup.ID).Return(dummyFilePath, nil).Once()
+	mockBackupSvc.On("CreateBackup", "", adminUserForBackup.ID).Return(dummyFilePath, nil).Once()
 
 	req, _ := http.NewRequest(http.MethodPost, "/api/backups", nil)
 	req.Header.Set("Accept", "application/json")
@@ -80,7 +96,7 @@ func TestBackupController_RestoreBackup(t *testing.T) {
 	}
 	router := setupBackupTestRouter(mockBackupSvc, authInjector)
 
-	mockBackupSvc.On("RestoreBackup", mock.Anything, adminUserForBackup.ID).Return(nil).Once()
+	mockBackupSvc.On("RestoreBackup", mock.Anything, (*dto.BackupKey)(nil), adminUserForBackup.ID).Return(nil).Once()
 
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
@@ -127,6 +143,311 @@ func TestBackupController_RestoreBackup_WrongExtension(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 	
 	// FIX: Update pesan error sesuai implementasi controller terbaru
-	assert.JSONEq(t, `{"error":"Format harus .db"}`, recorder.Body.String())
+	assert.JSONEq(t, `{"error":"Format harus .db, .dump, .sql, .tar.gz, atau .enc"}`, recorder.Body.String())
 	mockBackupSvc.AssertNotCalled(t, "RestoreBackup")
-}
\ No newline at end of file
+}
+
+func TestBackupController_RestoreBackup_EncryptedWithKey(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	expectedKey := &dto.BackupKey{Passphrase: "passphrase-uji-panjang", KeyFile: []byte("isi-file-kunci")}
+	mockBackupSvc.On("RestoreBackup", mock.Anything, expectedKey, adminUserForBackup.ID).Return(services.ErrBackupWrongKey).Once()
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("restore-file", "backup.db.enc")
+	part.Write([]byte("SDPENC"))
+	keyPart, _ := writer.CreateFormFile("key-file", "backup.key")
+	keyPart.Write([]byte("isi-file-kunci"))
+	writer.WriteField("passphrase", "passphrase-uji-panjang")
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/restore", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, req)
+
+	// Kunci salah adalah kesalahan input, bukan error server
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), services.ErrBackupWrongKey.Error())
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_StagedRestore(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+	const token = "0123456789abcdef0123456789abcdef"
+
+	t.Run("Validasi Mengembalikan Ringkasan", func(t *testing.T) {
+		preview := &dto.RestorePreview{Token: token, Format: "sqlite", Compatible: true, Tables: []dto.RestoreTablePreview{{Name: "users", BackupRows: 3, CurrentRows: 1}}}
+		mockBackupSvc.On("ValidateRestore", mock.Anything, (*dto.BackupKey)(nil)).Return(preview, nil).Once()
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("restore-file", "backup-2025.db")
+		part.Write([]byte("SQLite format 3\x00"))
+		writer.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/api/restore/validate", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"token":"`+token+`"`)
+		assert.Contains(t, recorder.Body.String(), `"file_name":"backup-2025.db"`)
+		assert.Contains(t, recorder.Body.String(), `"backup_rows":3`)
+	})
+
+	t.Run("Konfirmasi", func(t *testing.T) {
+		mockBackupSvc.On("ConfirmRestore", token, adminUserForBackup.ID).Return(nil).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/restore/"+token+"/confirm", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Token Kedaluwarsa", func(t *testing.T) {
+		mockBackupSvc.On("ConfirmRestore", "kedaluwarsa", adminUserForBackup.ID).Return(services.ErrRestoreStagingNotFound).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/restore/kedaluwarsa/confirm", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Rollback Dilaporkan", func(t *testing.T) {
+		mockBackupSvc.On("ConfirmRestore", token, adminUserForBackup.ID).Return(fmt.Errorf("%w: tabel users berisi 2 baris, seharusnya 3", services.ErrRestoreRolledBack)).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/restore/"+token+"/confirm", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "dikembalikan")
+	})
+
+	t.Run("Batal", func(t *testing.T) {
+		mockBackupSvc.On("DiscardRestore", token).Return(nil).Once()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/restore/"+token, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_Catalog(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	t.Run("Daftar", func(t *testing.T) {
+		files := []dto.BackupFile{{Name: "backup-2025-01-01_02-00-00.db", SizeBytes: 2048, SHA256: "abc", Kind: "scheduled"}}
+		mockBackupSvc.On("ListBackups").Return(files, nil).Once()
+		req, _ := http.NewRequest(http.MethodGet, "/api/backups", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"name":"backup-2025-01-01_02-00-00.db"`)
+	})
+
+	t.Run("Unduh", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup-uji.tar.gz")
+		assert.NoError(t, os.WriteFile(path, []byte("isi arsip"), 0600))
+		mockBackupSvc.On("DownloadBackup", "backup-uji.tar.gz", adminUserForBackup.ID).Return(path, nil).Once()
+		req, _ := http.NewRequest(http.MethodGet, "/api/backups/files/backup-uji.tar.gz", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Header().Get("Content-Disposition"), "backup-uji.tar.gz")
+		assert.Equal(t, "isi arsip", recorder.Body.String())
+	})
+
+	t.Run("Verifikasi", func(t *testing.T) {
+		result := &dto.BackupVerification{Name: "backup-uji.db", OK: false, Problems: []string{"Checksum SHA-256 berbeda"}}
+		mockBackupSvc.On("VerifyBackup", "backup-uji.db", (*dto.BackupKey)(nil)).Return(result, nil).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/backups/files/backup-uji.db/verify", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"ok":false`)
+	})
+
+	t.Run("Hapus File Tidak Ada", func(t *testing.T) {
+		mockBackupSvc.On("DeleteBackup", "hilang.db", adminUserForBackup.ID).Return(services.ErrBackupNotFound).Once()
+		req, _ := http.NewRequest(http.MethodDelete, "/api/backups/files/hilang.db", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("Restore Dari File Tersimpan", func(t *testing.T) {
+		preview := &dto.RestorePreview{Token: "0123456789abcdef0123456789abcdef", FileName: "backup-uji.db", Compatible: true}
+		mockBackupSvc.On("ValidateStoredRestore", "backup-uji.db", (*dto.BackupKey)(nil)).Return(preview, nil).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/backups/files/backup-uji.db/restore", nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"token":"0123456789abcdef0123456789abcdef"`)
+	})
+
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_TestOffsite(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	t.Run("Berhasil", func(t *testing.T) {
+		settings := map[string]string{"backup_offsite_type": "s3", "backup_offsite_s3_bucket": "arsip"}
+		mockBackupSvc.On("TestOffsiteDestination", settings).Return("s3://arsip", nil).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/backups/offsite/test", strings.NewReader(`{"backup_offsite_type":"s3","backup_offsite_s3_bucket":"arsip"}`))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "s3://arsip")
+	})
+
+	t.Run("Host Key Belum Dipercaya", func(t *testing.T) {
+		settings := map[string]string{"backup_offsite_type": "sftp"}
+		err := fmt.Errorf("%w; sidik jari server: SHA256:abc", services.ErrOffsiteHostKeyUnknown)
+		mockBackupSvc.On("TestOffsiteDestination", settings).Return("", err).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/backups/offsite/test", strings.NewReader(`{"backup_offsite_type":"sftp"}`))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "SHA256:abc")
+	})
+
+	t.Run("Belum Diatur", func(t *testing.T) {
+		settings := map[string]string{"backup_offsite_type": "none"}
+		mockBackupSvc.On("TestOffsiteDestination", settings).Return("", services.ErrOffsiteNotConfigured).Once()
+		req, _ := http.NewRequest(http.MethodPost, "/api/backups/offsite/test", strings.NewReader(`{"backup_offsite_type":"none"}`))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_CreateBackup_MethodUnavailable(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	mockBackupSvc.On("CreateBackup", "pg_dump", adminUserForBackup.ID).Return("", fmt.Errorf("%w: pg_dump", services.ErrBackupMethodUnavailable)).Once()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/backups?method=pg_dump", nil)
+	req.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "pg_dump")
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_Capabilities(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	mockBackupSvc.On("Capabilities").Return(dto.BackupCapabilities{
+		Dialect:       "postgres",
+		DefaultMethod: "portable",
+		Methods:       []dto.BackupMethod{{Name: "pg_dump", Reason: "pg_dump tidak ditemukan di PATH server"}, {Name: "portable", Available: true, CanRestore: true}},
+	}).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/backups/capabilities", nil)
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"default_method":"portable"`)
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_ListRuns(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	// limit di luar batas kembali ke default 100
+	mockBackupSvc.On("ListRuns", 100).Return([]models.BackupRun{
+		{ID: 2, Kind: models.BackupKindScheduled, Status: models.BackupStatusFailed, Error: "disk penuh"},
+		{ID: 1, Kind: models.BackupKindManual, Status: models.BackupStatusSuccess, FilePath: "/rahasia/backup.db", SHA256: "abc"},
+	}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/backups/runs?limit=9999", nil)
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"error":"disk penuh"`)
+	assert.NotContains(t, recorder.Body.String(), "/rahasia/", "path server tidak ikut dikirim")
+	mockBackupSvc.AssertExpectations(t)
+}
+
+func TestBackupController_Status(t *testing.T) {
+	mockBackupSvc := new(mocks.BackupService)
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForBackup)
+		c.Set("userID", adminUserForBackup.ID)
+		c.Next()
+	}
+	router := setupBackupTestRouter(mockBackupSvc, authInjector)
+
+	mockBackupSvc.On("Status").Return(&dto.BackupStatus{
+		Schedule:      dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "02:00"},
+		LastScheduled: &models.BackupRun{ID: 3, Status: models.BackupStatusFailed},
+		Failing:       true,
+	}, nil).Once()
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/backups/status", nil)
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"failing":true`)
+	mockBackupSvc.AssertExpectations(t)
+}
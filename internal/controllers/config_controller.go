@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -155,17 +156,19 @@ func (c *ConfigController) SaveSetup(ctx *gin.Context) {
 		return
 	}
 
-	if err := targetDB.AutoMigrate(
-		&models.User{}, &models.Resident{}, &models.LostDocument{}, &models.LostItem{},
-		&models.Configuration{}, &models.AuditLog{}, &models.ItemTemplate{}, &models.License{}, &models.JobPosition{},
-	); err != nil {
-		log.Printf("ERROR: AutoMigrate: %v", err)
+	// Skema lewat file migrasi bernomor agar versinya tercatat di
+	// schema_migrations, sama seperti saat aplikasi start
+	if _, err := services.NewSchemaMigrationService(targetDB, req.DBDialect).Up(ctx.Request.Context()); err != nil {
+		log.Printf("ERROR: Migrasi skema: %v", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal migrasi tabel.")
 		return
 	}
 	if err := utils.EnsureDefaultJobPositions(targetDB); err != nil {
 		log.Printf("WARN: gagal seed jabatan default: %v", err)
 	}
+	if err := utils.EnsureDefaultRoles(targetDB); err != nil {
+		log.Printf("WARN: gagal seed peran default: %v", err)
+	}
 
 	tx := targetDB.Begin()
 	if tx.Error != nil {
@@ -240,12 +243,13 @@ func (c *ConfigController) SaveSetup(ctx *gin.Context) {
 }
 
 func (c *ConfigController) MigrateDatabase(ctx *gin.Context) {
-	var req dto.DBTestRequest
+	var req dto.DataMigrationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		APIError(ctx, http.StatusBadRequest, "Input konfigurasi tidak valid.")
 		return
 	}
 
+	clearWriteDeadline(ctx)
 	ctx.Header("Content-Type", "text/event-stream")
 	ctx.Header("Cache-Control", "no-cache")
 	ctx.Header("Connection", "keep-alive")
@@ -253,10 +257,12 @@ func (c *ConfigController) MigrateDatabase(ctx *gin.Context) {
 
 	actorID := ctx.GetUint("userID")
 	progressChan := make(chan dto.MigrationProgress)
-	errorChan := make(chan error)
+	errorChan := make(chan error, 1)
 
+	// Bila koneksi putus migrasi berhenti; progres tersimpan di target dan
+	// dilanjutkan saat migrasi dijalankan ulang
 	go func() {
-		err := c.migrationService.MigrateDataTo(req, actorID, progressChan)
+		_, err := c.migrationService.MigrateDataTo(ctx.Request.Context(), req, actorID, progressChan)
 		if err != nil {
 			errorChan <- err
 		}
@@ -264,13 +270,25 @@ func (c *ConfigController) MigrateDatabase(ctx *gin.Context) {
 		close(errorChan)
 	}()
 
+	completeMessage := "Migrasi Selesai"
+	if req.DryRun {
+		completeMessage = "Estimasi Selesai"
+	}
 	ctx.Stream(func(w io.Writer) bool {
 		select {
 		case progress, ok := <-progressChan:
 			if !ok {
-				ctx.SSEvent("complete", map[string]string{"message": "Migrasi Selesai"})
+				if err := <-errorChan; err != nil {
+					ctx.SSEvent("error", map[string]string{"message": err.Error()})
+					return false
+				}
+				ctx.SSEvent("complete", map[string]string{"message": completeMessage})
 				return false
 			}
+			if progress.Report != nil {
+				ctx.SSEvent("report", progress.Report)
+				return true
+			}
 			ctx.SSEvent("progress", progress)
 			return true
 		case err := <-errorChan:
@@ -290,13 +308,28 @@ func (c *ConfigController) RestoreSetup(ctx *gin.Context) {
 		return
 	}
 	file, err := ctx.FormFile("restore-file")
-	if err != nil || !strings.HasSuffix(file.Filename, ".db") {
-		APIError(ctx, http.StatusBadRequest, "File harus .db")
+	if err != nil || !(strings.HasSuffix(file.Filename, ".db") || strings.HasSuffix(file.Filename, ".db.enc")) {
+		APIError(ctx, http.StatusBadRequest, "File harus .db atau .db.enc")
+		return
+	}
+	// Backup terenkripsi butuh passphrase/file kunci; instalasi baru belum punya pengaturannya
+	var key *dto.BackupKey
+	keyFile, err := readOptionalKeyFile(ctx)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
+	if passphrase := ctx.PostForm("passphrase"); passphrase != "" || len(keyFile) > 0 {
+		key = &dto.BackupKey{Passphrase: passphrase, KeyFile: keyFile}
+	}
 	src, _ := file.Open()
 	defer src.Close()
-	if err := c.backupService.RestoreBackup(src, 0); err != nil {
+	if err := c.backupService.RestoreBackup(ctx.Request.Context(), src, key, 0); err != nil {
+		if errors.Is(err, services.ErrBackupKeyRequired) || errors.Is(err, services.ErrBackupWrongKey) || errors.Is(err, services.ErrBackupTampered) ||
+			errors.Is(err, services.ErrInvalidBackup) || errors.Is(err, services.ErrBackupValidationFailed) || errors.Is(err, services.ErrBackupFormatUnsupported) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
 		APIError(ctx, http.StatusInternalServerError, "Gagal restore.")
 		return
 	}
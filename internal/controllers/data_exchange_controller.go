@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExchangeController struct {
+	service services.DataExchangeService
+}
+
+func NewDataExchangeController(service services.DataExchangeService) *DataExchangeController {
+	return &DataExchangeController{service: service}
+}
+
+// @Summary Ekspor Data Portabel
+// @Description Mengunduh seluruh data (tar.gz berisi manifest.json dan satu file JSON lines per tabel) yang bisa diimpor ke database dialek apa pun.
+// @Tags Data
+// @Produce application/gzip
+// @Success 200 {file} file
+// @Failure 500 {object} map[string]string
+// @Router /data/export [get]
+func (c *DataExchangeController) Export(ctx *gin.Context) {
+	clearWriteDeadline(ctx)
+	// Ditulis ke file sementara dulu agar galat di tengah ekspor masih bisa
+	// dilaporkan sebagai JSON, bukan arsip yang terpotong
+	tmp, err := os.CreateTemp("", "simdokpol-export-*.tar.gz")
+	if err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal menyiapkan file ekspor.")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := c.service.Export(ctx.Request.Context(), tmp, ctx.GetUint("userID")); err != nil {
+		log.Printf("ERROR Ekspor Data: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal ekspor data.")
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal menyimpan file ekspor.")
+		return
+	}
+	ctx.FileAttachment(tmp.Name(), "simdokpol-ekspor-"+time.Now().Format("2006-01-02_15-04-05")+".tar.gz")
+}
+
+// @Summary Impor Data Portabel
+// @Description Mode merge (default) menggabungkan data kantor lain: ID baru diberikan dan relasi dipetakan ulang, pengguna (NRP), penduduk (NIK) dan master data yang sama dipakai ulang, surat dengan nomor yang sudah ada dilewati. Mode replace mengganti seluruh isi tabel.
+// @Tags Data
+// @Accept multipart/form-data
+// @Produce json
+// @Param import-file formData file true "Arsip ekspor (.tar.gz)"
+// @Param mode formData string false "merge atau replace"
+// @Success 200 {object} dto.DataImportReport
+// @Failure 400 {object} map[string]string
+// @Router /data/import [post]
+func (c *DataExchangeController) Import(ctx *gin.Context) {
+	file, err := ctx.FormFile("import-file")
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "File wajib diunggah.")
+		return
+	}
+	if !strings.HasSuffix(strings.ToLower(file.Filename), ".tar.gz") {
+		APIError(ctx, http.StatusBadRequest, "Format harus .tar.gz hasil ekspor data.")
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		APIError(ctx, http.StatusInternalServerError, "Gagal buka file.")
+		return
+	}
+	defer src.Close()
+
+	mode := ctx.PostForm("mode")
+	if mode == "" {
+		mode = ctx.Query("mode")
+	}
+	report, err := c.service.Import(ctx.Request.Context(), src, mode, ctx.GetUint("userID"))
+	if err != nil {
+		log.Printf("ERROR Impor Data: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrDataImportModeInvalid) || errors.Is(err, services.ErrBackupFormatUnsupported) {
+			status = http.StatusBadRequest
+		}
+		APIError(ctx, status, "Gagal impor: "+err.Error())
+		return
+	}
+	message := "Impor data berhasil."
+	if report.Mode == dto.DataImportReplace {
+		message = "Impor data berhasil. Silakan restart aplikasi."
+	}
+	APIResponse(ctx, http.StatusOK, message, report)
+}
@@ -9,7 +9,11 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -62,4 +66,13 @@ func RenderHTML(ctx *gin.Context, templateName string, data gin.H) {
     }
 
 	ctx.HTML(http.StatusOK, templateName, data)
-}
\ No newline at end of file
+}
+// clearWriteDeadline mencabut batas HTTP_WRITE_TIMEOUT untuk request ini.
+// Dipakai stream SSE migrasi dan unduhan file besar (backup, ekspor data,
+// arsip audit) yang wajar berjalan lebih lama dari batas server.
+func clearWriteDeadline(ctx *gin.Context) {
+	err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARN: gagal mencabut batas waktu tulis %s: %v", ctx.Request.URL.Path, err)
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClearWriteDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newServer := func(clear bool) *httptest.Server {
+		router := gin.New()
+		router.GET("/stream", func(ctx *gin.Context) {
+			if clear {
+				clearWriteDeadline(ctx)
+			}
+			ctx.Writer.WriteString("mulai\n")
+			ctx.Writer.Flush()
+			time.Sleep(300 * time.Millisecond)
+			ctx.Writer.WriteString("selesai\n")
+		})
+		srv := httptest.NewUnstartedServer(router)
+		srv.Config.WriteTimeout = 100 * time.Millisecond
+		srv.Start()
+		return srv
+	}
+
+	t.Run("Success - Stream melewati WriteTimeout", func(t *testing.T) {
+		srv := newServer(true)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "mulai\nselesai\n", string(body))
+	})
+
+	t.Run("Baseline - Tanpa pencabutan stream terpotong", func(t *testing.T) {
+		srv := newServer(false)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/stream")
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.NotContains(t, string(body), "selesai")
+	})
+}
@@ -67,7 +67,7 @@ func (c *LicenseController) ActivateLicense(ctx *gin.Context) {
 
 	actorID := ctx.GetUint("userID")
 
-	license, err := c.service.ActivateLicense(req.Key, actorID)
+	license, err := c.service.ActivateLicense(ctx.Request.Context(), req.Key, actorID)
 	if err != nil {
 		log.Printf("ERROR: Gagal aktivasi lisensi oleh user %d: %v", actorID, err)
 		if errors.Is(err, services.ErrLicenseInvalid) || errors.Is(err, services.ErrLicenseBanned) {
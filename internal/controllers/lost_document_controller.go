@@ -9,6 +9,7 @@ import (
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -51,7 +52,7 @@ func (c *LostDocumentController) GetPDF(ctx *gin.Context) {
 
 	loggedInUserID := ctx.GetUint("userID")
 
-	buffer, filename, err := c.docService.GenerateDocumentPDF(uint(id), loggedInUserID)
+	buffer, filename, err := c.docService.GenerateDocumentPDF(ctx.Request.Context(), uint(id), loggedInUserID)
 	if err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, "Akses ditolak: Anda tidak memiliki izin untuk melihat dokumen ini.")
@@ -77,7 +78,7 @@ func (c *LostDocumentController) Export(ctx *gin.Context) {
 	query := ctx.Query("q")
 	status := ctx.DefaultQuery("status", "active")
 
-	buffer, filename, err := c.docService.ExportDocuments(query, status)
+	buffer, filename, err := c.docService.ExportDocuments(ctx.Request.Context(), query, status, ctx.GetUint("userID"))
 	if err != nil {
 		APIError(ctx, http.StatusInternalServerError, "Gagal membuat file ekspor.")
 		return
@@ -100,7 +101,7 @@ func (c *LostDocumentController) FindByID(ctx *gin.Context) {
 
 	loggedInUserID := ctx.GetUint("userID")
 
-	document, err := c.docService.FindByID(uint(id), loggedInUserID)
+	document, err := c.docService.FindByID(ctx.Request.Context(), uint(id), loggedInUserID)
 	if err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, "Akses ditolak: Anda tidak memiliki izin untuk melihat dokumen ini.")
@@ -113,6 +114,30 @@ func (c *LostDocumentController) FindByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, document)
 }
 
+// @Summary Laporan Akses Data Penduduk
+// @Description Siapa saja yang melihat, mencetak, mengunduh atau mengekspor data penduduk ber-NIK tertentu (UU PDP).
+// @Param nik query string true "NIK penduduk"
+// @Router /residents/access-log [get]
+func (c *LostDocumentController) ResidentAccessLog(ctx *gin.Context) {
+	nik := strings.TrimSpace(ctx.Query("nik"))
+	if nik == "" {
+		APIError(ctx, http.StatusBadRequest, "NIK wajib diisi")
+		return
+	}
+
+	report, err := c.docService.ResidentAccessReport(nik)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Penduduk dengan NIK tersebut tidak ditemukan")
+			return
+		}
+		APIError(ctx, http.StatusInternalServerError, "Gagal memuat laporan akses penduduk")
+		return
+	}
+
+	APIResponse(ctx, http.StatusOK, "Laporan akses penduduk", report)
+}
+
 // @Summary Pencarian Dokumen Global
 // @Router /search [get]
 func (c *LostDocumentController) SearchGlobal(ctx *gin.Context) {
@@ -151,18 +176,12 @@ func (c *LostDocumentController) FindAll(ctx *gin.Context) {
 
 	status := ctx.DefaultQuery("status", "active")
 
-	// --- PERBAIKAN LOGIC: Ambil Context User ---
-	userID := ctx.GetUint("userID")
-	var userRole string
+	var actor *models.User
 	if u, exists := ctx.Get("currentUser"); exists {
-		if user, ok := u.(*models.User); ok {
-			userRole = user.Peran
-		}
+		actor, _ = u.(*models.User)
 	}
-	// -------------------------------------------
 
-	// Kirim userID dan userRole ke Service
-	response, err := c.docService.GetDocumentsPaged(req, status, userID, userRole)
+	response, err := c.docService.GetDocumentsPaged(req, status, actor)
 	if err != nil {
 		log.Printf("ERROR: Gagal mengambil data dokumen: %v", err)
 		ctx.JSON(http.StatusInternalServerError, dto.DataTableResponse{
@@ -174,6 +193,37 @@ func (c *LostDocumentController) FindAll(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// @Summary Daftar Serah Terima Regu
+// @Description Surat aktif buatan anggota regu tertentu, untuk serah terima antar shift.
+// @Tags Documents
+// @Produce json
+// @Param regu query string true "Regu yang menyerahkan"
+// @Success 200 {array} models.LostDocument
+// @Security BearerAuth
+// @Router /documents/handover [get]
+func (c *LostDocumentController) Handover(ctx *gin.Context) {
+	var actor *models.User
+	if u, exists := ctx.Get("currentUser"); exists {
+		actor, _ = u.(*models.User)
+	}
+
+	regu := ctx.Query("regu")
+	if regu == "" && actor != nil {
+		regu = actor.Regu
+	}
+
+	docs, err := c.docService.GetShiftHandover(ctx.Request.Context(), regu, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			APIError(ctx, http.StatusForbidden, "Akses ditolak: Anda tidak memiliki izin serah terima regu.")
+			return
+		}
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, docs)
+}
+
 // @Summary Menghapus Dokumen
 // @Router /documents/{id} [delete]
 func (c *LostDocumentController) Delete(ctx *gin.Context) {
@@ -185,7 +235,7 @@ func (c *LostDocumentController) Delete(ctx *gin.Context) {
 
 	loggedInUserID := ctx.GetUint("userID")
 
-	if err := c.docService.DeleteLostDocument(uint(id), loggedInUserID); err != nil {
+	if err := c.docService.DeleteLostDocument(ctx.Request.Context(), uint(id), loggedInUserID); err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, err.Error())
 			return
@@ -235,7 +285,7 @@ func (c *LostDocumentController) Update(ctx *gin.Context) {
 		lostItems = append(lostItems, models.LostItem{NamaBarang: item.NamaBarang, Deskripsi: item.Deskripsi})
 	}
 
-	updatedDoc, err := c.docService.UpdateLostDocument(uint(id), residentData, lostItems, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID, loggedInUserID)
+	updatedDoc, err := c.docService.UpdateLostDocument(ctx.Request.Context(), uint(id), residentData, lostItems, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID, loggedInUserID)
 	if err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, err.Error())
@@ -280,7 +330,7 @@ func (c *LostDocumentController) Create(ctx *gin.Context) {
 		lostItems = append(lostItems, models.LostItem{NamaBarang: item.NamaBarang, Deskripsi: item.Deskripsi})
 	}
 
-	createdDoc, err := c.docService.CreateLostDocument(residentData, lostItems, operatorID, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID)
+	createdDoc, err := c.docService.CreateLostDocument(ctx.Request.Context(), residentData, lostItems, operatorID, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID)
 	if err != nil {
 		APIError(ctx, http.StatusInternalServerError, "Gagal membuat dokumen.")
 		return
@@ -260,7 +260,7 @@ func TestLostDocumentController_FindAll(t *testing.T) {
 				// MOCKING PAGING
 				mockSvc.On("GetDocumentsPaged", mock.MatchedBy(func(req dto.DataTableRequest) bool {
 					return req.Draw == 1
-				}), "active").Return(mockResponse, nil).Once()
+				}), "active", adminUser).Return(mockResponse, nil).Once()
 			},
 			expectedStatusCode: http.StatusOK,
 		},
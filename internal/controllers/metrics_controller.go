@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prometheusContentType format teks exposition 0.0.4.
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type MetricsController struct {
+	service services.MetricsService
+}
+
+func NewMetricsController(service services.MetricsService) *MetricsController {
+	return &MetricsController{service: service}
+}
+
+// @Summary Metrik Prometheus
+// @Description Latensi HTTP per rute, pool database, antrean audit, hasil backup dan arsip, surat terbit, dan status lisensi. Butuh METRICS_TOKEN atau IP di METRICS_ALLOWED_IPS.
+// @Tags System
+// @Produce plain
+// @Success 200 {string} string "Metrik format teks Prometheus"
+// @Failure 403 {object} map[string]string "Error: Akses metrik ditolak"
+// @Security BearerAuth
+// @Router /metrics [get]
+func (c *MetricsController) Prometheus(ctx *gin.Context) {
+	var buf bytes.Buffer
+	if err := c.service.WritePrometheus(ctx.Request.Context(), &buf); err != nil {
+		log.Printf("ERROR: Gagal menyusun metrik: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal menyusun metrik.")
+		return
+	}
+	ctx.Data(http.StatusOK, prometheusContentType, buf.Bytes())
+}
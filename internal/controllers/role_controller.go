@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleController struct {
+	service services.RoleService
+}
+
+func NewRoleController(service services.RoleService) *RoleController {
+	return &RoleController{service: service}
+}
+
+type RoleRequest struct {
+	Nama        string   `json:"nama" binding:"required" example:"KANIT"`
+	Deskripsi   string   `json:"deskripsi" example:"Kepala unit jaga"`
+	Permissions []string `json:"permissions"`
+}
+
+// @Summary Daftar Peran beserta Izinnya
+// @Router /roles [get]
+func (c *RoleController) FindAll(ctx *gin.Context) {
+	roles, err := c.service.FindAll()
+	if err != nil {
+		log.Printf("ERROR: Gagal mengambil data peran: %v", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data peran.")
+		return
+	}
+	ctx.JSON(http.StatusOK, roles)
+}
+
+// @Summary Katalog Izin yang Tersedia
+// @Router /roles/permissions [get]
+func (c *RoleController) Permissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.service.AvailablePermissions())
+}
+
+// @Summary Membuat atau Memperbarui Peran
+// @Router /roles [post]
+func (c *RoleController) Save(ctx *gin.Context) {
+	var req RoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Input tidak valid.")
+		return
+	}
+	if name := ctx.Param("nama"); name != "" {
+		req.Nama = name
+	}
+
+	role := &models.Role{
+		Nama:        req.Nama,
+		Deskripsi:   req.Deskripsi,
+		Permissions: models.JSONStringArray(req.Permissions),
+	}
+
+	actorID := ctx.GetUint("userID")
+	if err := c.service.Save(role, actorID); err != nil {
+		if errors.Is(err, services.ErrRoleProtected) {
+			APIError(ctx, http.StatusForbidden, err.Error())
+			return
+		}
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Peran berhasil disimpan.", role)
+}
+
+// @Summary Menghapus Peran
+// @Router /roles/{nama} [delete]
+func (c *RoleController) Delete(ctx *gin.Context) {
+	actorID := ctx.GetUint("userID")
+	if err := c.service.Delete(ctx.Param("nama"), actorID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			APIError(ctx, http.StatusNotFound, "Peran tidak ditemukan.")
+		case errors.Is(err, services.ErrRoleProtected), errors.Is(err, services.ErrRoleInUse):
+			APIError(ctx, http.StatusConflict, err.Error())
+		default:
+			log.Printf("ERROR: Gagal menghapus peran: %v", err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal menghapus peran.")
+		}
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Peran berhasil dihapus.", nil)
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/middleware"
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
 	"simdokpol/internal/utils"
@@ -17,12 +20,14 @@ import (
 type SettingsController struct {
 	configService services.ConfigService
 	auditService  services.AuditLogService
+	roleService   services.RoleService
 }
 
-func NewSettingsController(configService services.ConfigService, auditService services.AuditLogService) *SettingsController {
+func NewSettingsController(configService services.ConfigService, auditService services.AuditLogService, roleService services.RoleService) *SettingsController {
 	return &SettingsController{
 		configService: configService,
 		auditService:  auditService,
+		roleService:   roleService,
 	}
 }
 
@@ -43,24 +48,48 @@ func (c *SettingsController) UpdateSettings(ctx *gin.Context) {
 		return
 	}
 
-	// Validasi keamanan
-	if path, exists := settings["backup_path"]; exists {
-		if strings.Contains(path, "..") {
-			APIError(ctx, http.StatusBadRequest, "Path tidak valid.")
-			return
+	// Koneksi database dan backup hanya untuk pemegang database:manage.
+	// Rahasia kosong berarti "tidak diubah", jadi tetap boleh terkirim.
+	if !middleware.HasPermission(ctx, c.roleService, models.PermDatabaseManage) {
+		for key, value := range settings {
+			if services.IsDatabaseSetting(key) && !(value == "" && services.IsSecretSetting(key)) {
+				APIError(ctx, http.StatusForbidden, "Pengaturan database dan backup hanya bisa diubah pengguna dengan izin Backup, restore & migrasi database.")
+				return
+			}
 		}
 	}
-	if path, exists := settings["db_dsn"]; exists {
-		if strings.Contains(path, "..") {
-			APIError(ctx, http.StatusBadRequest, "Path DSN SQLite tidak valid.")
-			return
+
+	if err := c.configService.ValidateSettings(settings, isSuperAdmin(ctx)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPrivilegedGroupMapping):
+			APIError(ctx, http.StatusForbidden, err.Error())
+		case errors.Is(err, services.ErrInvalidSetting), errors.Is(err, services.ErrInvalidGroupMapping):
+			APIError(ctx, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("ERROR: Gagal memeriksa pengaturan: %v", err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal memeriksa pengaturan.")
 		}
+		return
 	}
 
 	// Bersihkan password kosong
 	if pass, exists := settings["db_pass"]; exists && pass == "" {
 		delete(settings, "db_pass")
 	}
+	if pass, exists := settings["ldap_bind_password"]; exists && pass == "" {
+		delete(settings, "ldap_bind_password")
+	}
+	if secret, exists := settings["oidc_client_secret"]; exists && secret == "" {
+		delete(settings, "oidc_client_secret")
+	}
+	if passphrase, exists := settings["backup_encryption_passphrase"]; exists && passphrase == "" {
+		delete(settings, "backup_encryption_passphrase")
+	}
+	for _, key := range []string{"backup_offsite_sftp_password", "backup_offsite_s3_secret_key"} {
+		if secret, exists := settings[key]; exists && secret == "" {
+			delete(settings, key)
+		}
+	}
 
 	// Default SSL Mode
 	if ssl, ok := settings["db_sslmode"]; ok && ssl == "" {
@@ -84,18 +113,41 @@ func (c *SettingsController) UpdateSettings(ctx *gin.Context) {
 		askForCert = true
 	}
 
+	before := map[string]interface{}{}
+	if current, err := c.configService.GetConfig(); err == nil && current != nil {
+		before = services.SettingsSnapshot(current, settings)
+	}
+
 	if err := c.configService.SaveConfig(settings); err != nil {
 		log.Printf("ERROR: Gagal menyimpan pengaturan: %v", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal menyimpan pengaturan.")
 		return
 	}
 
+	for key := range settings {
+		if strings.HasPrefix(key, "audit_syslog_") || strings.HasPrefix(key, "audit_file_") {
+			if err := services.ReloadAuditSinks(c.auditService, c.configService); err != nil {
+				log.Printf("WARN: Gagal memuat ulang penerusan log audit: %v", err)
+			}
+			break
+		}
+	}
+
 	actorID := ctx.GetUint("userID")
 	logDetail := "Pengaturan sistem telah diperbarui."
 	if restartRequired {
 		logDetail += " (Restarting System...)"
 	}
-	c.auditService.LogActivity(actorID, models.AuditSettingsUpdated, logDetail)
+	after := services.RedactSettings(settings)
+	c.auditService.LogEvent(ctx.Request.Context(), dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditSettingsUpdated,
+		Detail:     logDetail,
+		EntityType: models.AuditEntitySetting,
+		EntityID:   strings.Join(services.SortedSettingKeys(settings), ","),
+		Before:     before,
+		After:      after,
+	})
 
 	// --- AUTO RESTART SEQUENCE ---
 	// Restart otomatis hanya jika TIDAK perlu interaksi user (seperti download sertifikat)
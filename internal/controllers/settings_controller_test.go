@@ -3,12 +3,15 @@ package controllers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"simdokpol/internal/dto"
 	"simdokpol/internal/middleware"
 	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
+	"simdokpol/internal/services"
 	"sync"
 	"testing"
 
@@ -27,7 +30,9 @@ func setupSettingsTestRouter(mockConfigSvc *mocks.ConfigService, mockAuditSvc *m
 		mockAuditSvc = new(mocks.AuditLogService)
 	}
 
-	settingsController := NewSettingsController(mockConfigSvc, mockAuditSvc)
+	mockRoleSvc := new(mocks.RoleService)
+	mockRoleSvc.On("HasPermission", mock.Anything, models.PermDatabaseManage).Return(true).Maybe()
+	settingsController := NewSettingsController(mockConfigSvc, mockAuditSvc, mockRoleSvc)
 
 	router := gin.New()
 	if authInjector != nil {
@@ -96,8 +101,19 @@ func TestSettingsController_UpdateSettings(t *testing.T) {
 	var wg sync.WaitGroup
 	mockAuditSvc.On("SetWaitGroup", &wg).Once()
 
+	mockConfigSvc.On("ValidateSettings", mockSettingsUpdate, true).Return(nil).Once()
+	mockConfigSvc.On("GetConfig").Return(&dto.AppConfig{NamaKantor: "POLSEK LAMA", BackupPath: "./backups"}, nil).Once()
 	mockConfigSvc.On("SaveConfig", mockSettingsUpdate).Return(nil).Once()
-	mockAuditSvc.On("LogActivity", adminUserForSettings.ID, models.AuditSettingsUpdated, mock.AnythingOfType("string")).Once()
+	mockAuditSvc.On("LogEvent", mock.Anything, mock.MatchedBy(func(event dto.AuditEvent) bool {
+		before := event.Before.(map[string]interface{})
+		after := event.After.(map[string]interface{})
+		return event.UserID == adminUserForSettings.ID &&
+			event.Action == models.AuditSettingsUpdated &&
+			event.EntityType == models.AuditEntitySetting &&
+			event.EntityID == "backup_path,nama_kantor" &&
+			before["nama_kantor"] == "POLSEK LAMA" &&
+			after["nama_kantor"] == "POLSEK BARU"
+	})).Once()
 
 	jsonBody, _ := json.Marshal(mockSettingsUpdate)
 	req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
@@ -119,4 +135,157 @@ func TestSettingsController_UpdateSettings(t *testing.T) {
 
 	mockConfigSvc.AssertExpectations(t)
 	mockAuditSvc.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestSettingsController_UpdateSettings_ValidationFailed(t *testing.T) {
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminUserForSettings)
+		c.Set("userID", adminUserForSettings.ID)
+		c.Next()
+	}
+
+	for name, tc := range map[string]struct {
+		err      error
+		expected int
+	}{
+		"Nilai Tidak Valid":       {fmt.Errorf("%w: jam backup harus berformat HH:MM", services.ErrInvalidSetting), http.StatusBadRequest},
+		"Pemetaan Rusak":          {services.ErrInvalidGroupMapping, http.StatusBadRequest},
+		"Pengaturan Gagal Dibaca": {errors.New("database terkunci"), http.StatusInternalServerError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			payload := map[string]string{"backup_schedule_time": "25:00"}
+			mockConfigSvc := new(mocks.ConfigService)
+			mockConfigSvc.On("ValidateSettings", payload, true).Return(tc.err).Once()
+			mockAuditSvc := new(mocks.AuditLogService)
+			router := setupSettingsTestRouter(mockConfigSvc, mockAuditSvc, authInjector)
+
+			jsonBody, _ := json.Marshal(payload)
+			req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expected, recorder.Code)
+			mockConfigSvc.AssertNotCalled(t, "SaveConfig", mock.Anything)
+		})
+	}
+}
+
+func TestSettingsController_UpdateSettings_PrivilegedMappingForbidden(t *testing.T) {
+	adminTU := &models.User{ID: 2, NamaLengkap: "Admin TU", Peran: models.RoleAdminTU}
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminTU)
+		c.Set("userID", adminTU.ID)
+		c.Next()
+	}
+	payload := map[string]string{"oidc_role_mappings": `[{"group":"admin","peran":"SUPER_ADMIN"}]`}
+
+	mockConfigSvc := new(mocks.ConfigService)
+	mockConfigSvc.On("ValidateSettings", payload, false).Return(services.ErrPrivilegedGroupMapping).Once()
+	router := gin.New()
+	router.Use(authInjector)
+	mockRoleSvc := new(mocks.RoleService)
+	mockRoleSvc.On("HasPermission", adminTU, models.PermDatabaseManage).Return(false).Once()
+	router.PUT("/api/settings", NewSettingsController(mockConfigSvc, new(mocks.AuditLogService), mockRoleSvc).UpdateSettings)
+
+	jsonBody, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	mockConfigSvc.AssertNotCalled(t, "SaveConfig", mock.Anything)
+}
+
+func TestSettingsController_UpdateSettings_AuthSettingsNeedSuperAdmin(t *testing.T) {
+	adminTU := &models.User{ID: 2, NamaLengkap: "Admin TU", Peran: models.RoleAdminTU}
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminTU)
+		c.Set("userID", adminTU.ID)
+		c.Next()
+	}
+
+	for name, payload := range map[string]map[string]string{
+		"Server LDAP": {"nama_kantor": "POLSEK UJI", "ldap_url": "ldaps://ldap.penyerang.id"},
+		"Issuer SSO":  {"oidc_issuer": "https://idp.penyerang.id"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// ConfigService asli: penolakan harus datang dari ValidateSettings
+			configRepo := new(mocks.ConfigRepository)
+			mockRoleSvc := new(mocks.RoleService)
+			mockRoleSvc.On("HasPermission", adminTU, models.PermDatabaseManage).Return(true).Maybe()
+			router := gin.New()
+			router.Use(authInjector)
+			router.PUT("/api/settings", NewSettingsController(services.NewConfigService(configRepo, nil), new(mocks.AuditLogService), mockRoleSvc).UpdateSettings)
+
+			jsonBody, _ := json.Marshal(payload)
+			req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusForbidden, recorder.Code)
+			configRepo.AssertNotCalled(t, "SetMultiple", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSettingsController_UpdateSettings_DatabaseSettingsNeedDatabaseManage(t *testing.T) {
+	adminTU := &models.User{ID: 2, NamaLengkap: "Admin TU", Peran: models.RoleAdminTU}
+	authInjector := func(c *gin.Context) {
+		c.Set("currentUser", adminTU)
+		c.Set("userID", adminTU.ID)
+		c.Next()
+	}
+
+	for name, payload := range map[string]map[string]string{
+		"DSN":        {"nama_kantor": "POLSEK UJI", "db_dsn": "/tmp/lain.db"},
+		"Folder":     {"backup_path": "/mnt/flashdisk"},
+		"Enkripsi":   {"backup_encryption_mode": "none"},
+		"Off-site":   {"backup_offsite_folder_path": "/mnt/nas"},
+		"Rahasia DB": {"db_pass": "rahasia"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockConfigSvc := new(mocks.ConfigService)
+			mockRoleSvc := new(mocks.RoleService)
+			mockRoleSvc.On("HasPermission", adminTU, models.PermDatabaseManage).Return(false).Once()
+			router := gin.New()
+			router.Use(authInjector)
+			router.PUT("/api/settings", NewSettingsController(mockConfigSvc, new(mocks.AuditLogService), mockRoleSvc).UpdateSettings)
+
+			jsonBody, _ := json.Marshal(payload)
+			req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusForbidden, recorder.Code)
+			mockConfigSvc.AssertNotCalled(t, "SaveConfig", mock.Anything)
+		})
+	}
+
+	// Rahasia kosong (tidak diubah) tetap boleh ikut terkirim
+	payload := map[string]string{"nama_kantor": "POLSEK UJI", "db_pass": ""}
+	mockConfigSvc := new(mocks.ConfigService)
+	mockConfigSvc.On("ValidateSettings", payload, false).Return(nil).Once()
+	mockConfigSvc.On("GetConfig").Return(&dto.AppConfig{NamaKantor: "POLSEK LAMA"}, nil).Once()
+	mockConfigSvc.On("SaveConfig", map[string]string{"nama_kantor": "POLSEK UJI"}).Return(nil).Once()
+	mockAuditSvc := new(mocks.AuditLogService)
+	mockAuditSvc.On("LogEvent", mock.Anything, mock.Anything).Once()
+	mockRoleSvc := new(mocks.RoleService)
+	mockRoleSvc.On("HasPermission", adminTU, models.PermDatabaseManage).Return(false).Once()
+	router := gin.New()
+	router.Use(authInjector)
+	router.PUT("/api/settings", NewSettingsController(mockConfigSvc, mockAuditSvc, mockRoleSvc).UpdateSettings)
+
+	jsonBody, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPut, "/api/settings", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	mockConfigSvc.AssertExpectations(t)
+}
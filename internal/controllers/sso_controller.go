@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"simdokpol/internal/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ssoStateCookie   = "sso_state"
+	ssoIDTokenCookie = "sso_id_token"
+)
+
+type SSOController struct {
+	oidcService   services.OIDCService
+	configService services.ConfigService
+}
+
+func NewSSOController(oidcService services.OIDCService, configService services.ConfigService) *SSOController {
+	return &SSOController{
+		oidcService:   oidcService,
+		configService: configService,
+	}
+}
+
+// requestBaseURL menyusun "scheme://host" dari request, termasuk di balik reverse proxy.
+func requestBaseURL(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil || strings.EqualFold(ctx.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host
+}
+
+func (c *SSOController) setCookie(ctx *gin.Context, name, value string, maxAge int) {
+	isProduction := os.Getenv("APP_ENV") == "production"
+	sameSite := http.SameSiteStrictMode
+	if name == ssoStateCookie {
+		// Lax: cookie state harus ikut terkirim saat IdP me-redirect balik
+		sameSite = http.SameSiteLaxMode
+	}
+	ctx.SetSameSite(sameSite)
+	ctx.SetCookie(name, value, maxAge, "/", "", isProduction, true)
+}
+
+// @Summary Status SSO
+// @Description Menampilkan apakah tombol "Login dengan SSO" perlu ditampilkan.
+// @Tags Auth
+// @Produce json
+// @Router /auth/sso [get]
+func (c *SSOController) Status(ctx *gin.Context) {
+	APIResponse(ctx, http.StatusOK, "OK", gin.H{
+		"enabled": c.oidcService.IsEnabled(),
+		"label":   c.oidcService.ButtonLabel(),
+	})
+}
+
+// @Summary Mulai login SSO
+// @Description Redirect ke IdP dengan authorization code + PKCE.
+// @Tags Auth
+// @Router /auth/sso/login [get]
+func (c *SSOController) Login(ctx *gin.Context) {
+	authURL, state, err := c.oidcService.BeginLogin(ctx.Request.Context(), requestBaseURL(ctx)+"/api/auth/sso/callback")
+	if err != nil {
+		c.redirectWithError(ctx, err)
+		return
+	}
+	c.setCookie(ctx, ssoStateCookie, state, 600)
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// @Summary Callback SSO
+// @Description Menukar kode dari IdP, membuat sesi, lalu kembali ke aplikasi.
+// @Tags Auth
+// @Router /auth/sso/callback [get]
+func (c *SSOController) Callback(ctx *gin.Context) {
+	if idpErr := ctx.Query("error"); idpErr != "" {
+		log.Printf("WARN: IdP menolak login SSO: %s %s", idpErr, ctx.Query("error_description"))
+		c.redirectWithError(ctx, services.ErrSSOLoginFailed)
+		return
+	}
+
+	// State di query harus sama dengan cookie browser ini (cegah login CSRF)
+	state := ctx.Query("state")
+	cookieState, _ := ctx.Cookie(ssoStateCookie)
+	c.setCookie(ctx, ssoStateCookie, "", -1)
+	if state == "" || cookieState != state {
+		c.redirectWithError(ctx, services.ErrSSOInvalidState)
+		return
+	}
+
+	result, err := c.oidcService.CompleteLogin(ctx.Request.Context(), state, ctx.Query("code"))
+	if err != nil {
+		c.redirectWithError(ctx, err)
+		return
+	}
+
+	config, _ := c.configService.GetConfig()
+	timeoutSeconds := 28800 // Default 8 jam
+	if config != nil && config.SessionTimeout > 0 {
+		timeoutSeconds = config.SessionTimeout * 60
+	}
+	c.setCookie(ctx, "token", result.Token, timeoutSeconds)
+	c.setCookie(ctx, ssoIDTokenCookie, result.IDToken, timeoutSeconds)
+	ctx.Redirect(http.StatusFound, "/app/")
+}
+
+// @Summary Logout SSO
+// @Description Menghapus sesi lokal dan mengembalikan URL logout IdP bila didukung.
+// @Tags Auth
+// @Produce json
+// @Router /auth/sso/logout [post]
+func (c *SSOController) Logout(ctx *gin.Context) {
+	idToken, _ := ctx.Cookie(ssoIDTokenCookie)
+	c.setCookie(ctx, "token", "", -1)
+	c.setCookie(ctx, ssoIDTokenCookie, "", -1)
+
+	logoutURL, err := c.oidcService.LogoutURL(ctx.Request.Context(), idToken, requestBaseURL(ctx)+"/app/login")
+	if err != nil {
+		log.Printf("WARN: gagal menyusun URL logout SSO: %v", err)
+	}
+	APIResponse(ctx, http.StatusOK, "Logout berhasil", gin.H{"redirect_url": logoutURL})
+}
+
+// @Summary Back-channel logout SSO
+// @Description Dipanggil IdP (server ke server) untuk mencabut sesi pengguna.
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Router /auth/sso/backchannel-logout [post]
+func (c *SSOController) BackchannelLogout(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	if err := c.oidcService.HandleBackchannelLogout(ctx.Request.Context(), ctx.PostForm("logout_token")); err != nil {
+		log.Printf("WARN: back-channel logout ditolak: %v", err)
+		APIError(ctx, http.StatusBadRequest, "logout_token tidak valid")
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+func (c *SSOController) redirectWithError(ctx *gin.Context, err error) {
+	message := services.ErrSSOLoginFailed.Error()
+	for _, known := range []error{services.ErrSSODisabled, services.ErrSSOProviderUnavailable, services.ErrSSOInvalidState, services.ErrNoGroupMapping, services.ErrAccountInactive, services.ErrExternalAccountNotLinked} {
+		if errors.Is(err, known) {
+			message = known.Error()
+		}
+	}
+	ctx.Redirect(http.StatusFound, "/app/login?sso_error="+url.QueryEscape(message))
+}
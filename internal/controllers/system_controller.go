@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"simdokpol/internal/models"
+	"simdokpol/internal/services"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,14 +11,16 @@ import (
 )
 
 type SystemController struct {
-	db        *gorm.DB
-	startedAt time.Time
+	db           *gorm.DB
+	auditService services.AuditLogService
+	startedAt    time.Time
 }
 
-func NewSystemController(db *gorm.DB) *SystemController {
+func NewSystemController(db *gorm.DB, auditService services.AuditLogService) *SystemController {
 	return &SystemController{
-		db:        db,
-		startedAt: time.Now(),
+		db:           db,
+		auditService: auditService,
+		startedAt:    time.Now(),
 	}
 }
 
@@ -54,7 +57,7 @@ func (c *SystemController) Metrics(ctx *gin.Context) {
 	c.db.Model(&models.AuditLog{}).Count(&logs)
 	c.db.Model(&models.ItemTemplate{}).Count(&templates)
 
-	ctx.JSON(http.StatusOK, gin.H{
+	metrics := gin.H{
 		"uptime_s":   int64(time.Since(c.startedAt).Seconds()),
 		"users":      users,
 		"residents":  residents,
@@ -62,5 +65,10 @@ func (c *SystemController) Metrics(ctx *gin.Context) {
 		"lost_items": items,
 		"audit_logs": logs,
 		"templates":  templates,
-	})
+	}
+	if c.auditService != nil {
+		metrics["audit_writer"] = c.auditService.WriterStats()
+		metrics["audit_sinks"] = c.auditService.SinkStatus()
+	}
+	ctx.JSON(http.StatusOK, metrics)
 }
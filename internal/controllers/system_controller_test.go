@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -16,7 +18,7 @@ import (
 
 func TestSystemController_HealthzDegradedWithoutDB(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	controller := NewSystemController(nil)
+	controller := NewSystemController(nil, nil)
 
 	router := gin.New()
 	router.GET("/api/healthz", controller.Healthz)
@@ -39,7 +41,11 @@ func TestSystemController_MetricsWithDB(t *testing.T) {
 		t.Fatalf("gagal migrate: %v", err)
 	}
 
-	controller := NewSystemController(db)
+	auditService := new(mocks.AuditLogService)
+	auditService.On("WriterStats").Return(dto.AuditWriterStats{QueueCapacity: 1024, Written: 7}).Once()
+	auditService.On("SinkStatus").Return([]dto.AuditSinkStatus{{Name: "syslog", Target: "udp://127.0.0.1:514", Sent: 3}}).Once()
+
+	controller := NewSystemController(db, auditService)
 	router := gin.New()
 	router.GET("/api/metrics", controller.Metrics)
 
@@ -56,4 +62,14 @@ func TestSystemController_MetricsWithDB(t *testing.T) {
 	assert.Contains(t, payload, "documents")
 	assert.Contains(t, payload, "users")
 	assert.Contains(t, payload, "uptime_s")
+	auditWriter, ok := payload["audit_writer"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, float64(7), auditWriter["written"])
+		assert.Contains(t, auditWriter, "dropped")
+	}
+	auditSinks, ok := payload["audit_sinks"].([]interface{})
+	if assert.True(t, ok) && assert.Len(t, auditSinks, 1) {
+		assert.Equal(t, "syslog", auditSinks[0].(map[string]interface{})["name"])
+	}
+	auditService.AssertExpectations(t)
 }
@@ -20,12 +20,62 @@ func NewUserController(userService services.UserService) *UserController {
 	return &UserController{userService: userService}
 }
 
+// isSuperAdmin memeriksa apakah pengguna yang sedang login adalah Super Admin.
+func isSuperAdmin(ctx *gin.Context) bool {
+	actor, ok := ctx.Get("currentUser")
+	if !ok {
+		return false
+	}
+	user, ok := actor.(*models.User)
+	return ok && user.Peran == models.RoleSuperAdmin
+}
+
+// guardSuperAdminTarget mencegah pengguna non Super Admin (mis. ADMIN_TU)
+// mengubah atau menonaktifkan akun Super Admin.
+func (c *UserController) guardSuperAdminTarget(ctx *gin.Context, id uint) bool {
+	if isSuperAdmin(ctx) {
+		return true
+	}
+	target, err := c.userService.FindByID(id)
+	if err == nil && target.Peran == models.RoleSuperAdmin {
+		APIError(ctx, http.StatusForbidden, "Hanya Super Admin yang dapat mengelola akun Super Admin.")
+		return false
+	}
+	return true
+}
+
+// guardAuthSourceChange memastikan hanya Super Admin yang menautkan akun ke
+// LDAP / SSO: akun yang ditautkan bisa diambil alih pemilik NRP di direktori.
+// Kembali ke login lokal wajib disertai kata sandi baru.
+func (c *UserController) guardAuthSourceChange(ctx *gin.Context, id uint, source string, newPassword string) bool {
+	target, err := c.userService.FindByID(id)
+	if err != nil {
+		return true
+	}
+	current := target.AuthSource
+	if current == "" {
+		current = models.AuthSourceLocal
+	}
+	if current == source {
+		return true
+	}
+	if !isSuperAdmin(ctx) {
+		APIError(ctx, http.StatusForbidden, "Hanya Super Admin yang dapat mengubah sumber login pengguna.")
+		return false
+	}
+	if source == models.AuthSourceLocal && newPassword == "" {
+		APIError(ctx, http.StatusBadRequest, "Isi kata sandi baru saat mengembalikan akun ke login lokal.")
+		return false
+	}
+	return true
+}
+
 type CreateUserRequest struct {
 	NamaLengkap string `json:"nama_lengkap" binding:"required" example:"NAMA LENGKAP PETUGAS"`
 	NRP         string `json:"nrp" binding:"required" example:"98765"`
 	KataSandi   string `json:"kata_sandi" binding:"required,min=8" example:"password123"`
 	Pangkat     string `json:"pangkat" binding:"required" example:"BRIPDA"`
-	Peran       string `json:"peran" binding:"required" enums:"OPERATOR,SUPER_ADMIN"`
+	Peran       string `json:"peran" binding:"required" enums:"OPERATOR,SUPER_ADMIN,PIMPINAN,KANIT,ADMIN_TU"`
 	Jabatan     string `json:"jabatan" binding:"required" example:"ANGGOTA JAGA"`
 	Regu        string `json:"regu" example:"I"`
 }
@@ -38,6 +88,9 @@ type UpdateUserRequest struct {
 	Peran       string `json:"peran" binding:"required"`
 	Jabatan     string `json:"jabatan" binding:"required"`
 	Regu        string `json:"regu"`
+	// AuthSource menautkan akun ke login direktori/SSO (atau kembali ke
+	// lokal). Kosong = tidak diubah.
+	AuthSource string `json:"auth_source" binding:"omitempty,oneof=local ldap oidc" enums:"local,ldap,oidc"`
 }
 
 type ChangePasswordRequest struct {
@@ -78,7 +131,7 @@ func (c *UserController) UpdateProfile(ctx *gin.Context) {
 		Pangkat:     req.Pangkat,
 	}
 
-	updatedUser, err := c.userService.UpdateProfile(userID, dataToUpdate)
+	updatedUser, err := c.userService.UpdateProfile(ctx.Request.Context(), userID, dataToUpdate)
 	if err != nil {
 		log.Printf("ERROR: Gagal memperbarui profil untuk user ID %d: %v", userID, err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memperbarui profil.")
@@ -112,7 +165,7 @@ func (c *UserController) ChangePassword(ctx *gin.Context) {
 
 	userID := ctx.GetUint("userID")
 
-	err := c.userService.ChangePassword(userID, req.OldPassword, req.NewPassword)
+	err := c.userService.ChangePassword(ctx.Request.Context(), userID, req.OldPassword, req.NewPassword)
 	if err != nil {
 		log.Printf("Gagal mengubah password untuk user ID %d: %v", userID, err)
 		if errors.Is(err, services.ErrOldPasswordMismatch) {
@@ -143,6 +196,10 @@ func (c *UserController) Create(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
+	if req.Peran == models.RoleSuperAdmin && !isSuperAdmin(ctx) {
+		APIError(ctx, http.StatusForbidden, "Hanya Super Admin yang dapat menetapkan peran Super Admin.")
+		return
+	}
 	actorID := ctx.GetUint("userID")
 
 	user := models.User{
@@ -155,7 +212,7 @@ func (c *UserController) Create(ctx *gin.Context) {
 		Regu:        req.Regu,
 	}
 
-	if err := c.userService.Create(&user, actorID); err != nil {
+	if err := c.userService.Create(ctx.Request.Context(), &user, actorID); err != nil {
 		log.Printf("ERROR: Gagal membuat pengguna: %v", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal membuat pengguna.")
 		return
@@ -178,6 +235,16 @@ func (c *UserController) Update(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "Kata sandi baru minimal 8 karakter")
 		return
 	}
+	if req.Peran == models.RoleSuperAdmin && !isSuperAdmin(ctx) {
+		APIError(ctx, http.StatusForbidden, "Hanya Super Admin yang dapat menetapkan peran Super Admin.")
+		return
+	}
+	if !c.guardSuperAdminTarget(ctx, uint(id)) {
+		return
+	}
+	if req.AuthSource != "" && !c.guardAuthSourceChange(ctx, uint(id), req.AuthSource, req.KataSandi) {
+		return
+	}
 	actorID := ctx.GetUint("userID")
 
 	user := models.User{
@@ -188,9 +255,10 @@ func (c *UserController) Update(ctx *gin.Context) {
 		Peran:       req.Peran,
 		Jabatan:     req.Jabatan,
 		Regu:        req.Regu,
+		AuthSource:  req.AuthSource,
 	}
 
-	if err := c.userService.Update(&user, req.KataSandi, actorID); err != nil {
+	if err := c.userService.Update(ctx.Request.Context(), &user, req.KataSandi, actorID); err != nil {
 		log.Printf("ERROR: Gagal memperbarui pengguna id %d: %v", id, err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memperbarui pengguna.")
 		return
@@ -204,9 +272,12 @@ func (c *UserController) Delete(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
 		return
 	}
+	if !c.guardSuperAdminTarget(ctx, uint(id)) {
+		return
+	}
 	actorID := ctx.GetUint("userID")
 
-	if err := c.userService.Deactivate(uint(id), actorID); err != nil {
+	if err := c.userService.Deactivate(ctx.Request.Context(), uint(id), actorID); err != nil {
 		log.Printf("ERROR: Gagal menonaktifkan pengguna id %d: %v", id, err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal menonaktifkan pengguna.")
 		return
@@ -220,9 +291,12 @@ func (c *UserController) Activate(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
 		return
 	}
+	if !c.guardSuperAdminTarget(ctx, uint(id)) {
+		return
+	}
 	actorID := ctx.GetUint("userID")
 
-	if err := c.userService.Activate(uint(id), actorID); err != nil {
+	if err := c.userService.Activate(ctx.Request.Context(), uint(id), actorID); err != nil {
 		log.Printf("ERROR: Gagal mengaktifkan pengguna id %d: %v", id, err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengaktifkan pengguna.")
 		return
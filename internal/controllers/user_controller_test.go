@@ -282,3 +282,64 @@ func TestUserController_UpdateProfile(t *testing.T) {
 		})
 	}
 }
+
+// TestUserController_Update_AuthSource menguji penautan akun ke login LDAP / SSO
+func TestUserController_Update_AuthSource(t *testing.T) {
+	adminUser := &models.User{ID: 1, Peran: models.RoleSuperAdmin}
+	adminTU := &models.User{ID: 2, Peran: models.RoleAdminTU}
+	target := &models.User{ID: 7, NRP: "88010001", Peran: models.RoleOperator, AuthSource: models.AuthSourceLocal}
+	body := func(source, password string) gin.H {
+		return gin.H{"nama_lengkap": "BUDI", "nrp": "88010001", "pangkat": "BRIPDA", "peran": models.RoleOperator, "jabatan": "ANGGOTA", "auth_source": source, "kata_sandi": password}
+	}
+
+	testCases := []struct {
+		name         string
+		actor        *models.User
+		body         gin.H
+		expectUpdate bool
+		expectedCode int
+	}{
+		{"Super Admin Menautkan Ke LDAP", adminUser, body(models.AuthSourceLDAP, ""), true, http.StatusOK},
+		{"Admin TU Tidak Boleh Menautkan", adminTU, body(models.AuthSourceLDAP, ""), false, http.StatusForbidden},
+		{"Admin TU Mengirim Sumber Yang Sama", adminTU, body(models.AuthSourceLocal, ""), true, http.StatusOK},
+		{"Kembali Ke Lokal Tanpa Sandi", adminUser, body(models.AuthSourceLocal, ""), false, http.StatusBadRequest},
+		{"Sumber Tidak Dikenal", adminUser, body("kerberos", ""), false, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockSvc := new(mocks.UserService)
+			current := *target
+			if tc.name == "Kembali Ke Lokal Tanpa Sandi" {
+				current.AuthSource = models.AuthSourceLDAP
+			}
+			mockSvc.On("FindByID", uint(7)).Return(&current, nil).Maybe()
+			if tc.expectUpdate {
+				mockSvc.On("Update", mock.MatchedBy(func(u *models.User) bool {
+					return u.ID == 7 && u.AuthSource == tc.body["auth_source"]
+				}), "", tc.actor.ID).Return(nil).Once()
+			}
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("currentUser", tc.actor)
+				c.Set("userID", tc.actor.ID)
+				c.Next()
+			})
+			router.PUT("/api/users/:id", NewUserController(mockSvc).Update)
+
+			jsonBody, _ := json.Marshal(tc.body)
+			req, _ := http.NewRequest(http.MethodPut, "/api/users/7", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code, recorder.Body.String())
+			if !tc.expectUpdate {
+				mockSvc.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package dto
+
+import (
+	"simdokpol/internal/models"
+	"time"
+)
+
+// AuditChainReport adalah hasil verifikasi rantai hash log audit.
+// Bila Valid false, BrokenLogID/BrokenCheckpointID menunjuk kerusakan pertama.
+type AuditChainReport struct {
+	Valid              bool      `json:"valid"`
+	CheckedEntries     int64     `json:"checked_entries"`
+	LegacyEntries      int64     `json:"legacy_entries"` // entri lama sebelum rantai hash aktif
+	Checkpoints        int       `json:"checkpoints"`
+	LastHash           string    `json:"last_hash"`
+	BrokenLogID        uint      `json:"broken_log_id,omitempty"`
+	BrokenCheckpointID uint      `json:"broken_checkpoint_id,omitempty"`
+	BrokenArchiveID    uint      `json:"broken_archive_id,omitempty"`
+	Archives           int       `json:"archives"`
+	ArchivedEntries    int64     `json:"archived_entries"` // sudah dipindah ke file arsip
+	Reason             string    `json:"reason,omitempty"`
+	VerifiedAt         time.Time `json:"verified_at"`
+}
+
+// AuditEvent adalah entri log audit terstruktur. Before/After diisi objek
+// apa saja yang bisa di-JSON-kan (mis. model sebelum & sesudah diubah).
+type AuditEvent struct {
+	UserID     uint
+	Action     string
+	Detail     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditLogFilter adalah filter tambahan untuk daftar log audit.
+type AuditLogFilter struct {
+	EntityType string `form:"entity_type"`
+	EntityID   string `form:"entity_id"`
+	ActorID    uint   `form:"actor_id"`
+	Action     string `form:"action"`
+	IPAddress  string `form:"ip"`
+	RequestID  string `form:"request_id"`
+	DateFrom   string `form:"date_from"` // YYYY-MM-DD
+	DateTo     string `form:"date_to"`   // YYYY-MM-DD, inklusif
+
+	// Diisi service dari DateFrom/DateTo
+	From *time.Time `form:"-"`
+	To   *time.Time `form:"-"`
+}
+
+// AuditWriterStats adalah kondisi antrean writer log audit untuk endpoint metrics.
+type AuditWriterStats struct {
+	QueueLength    int   `json:"queue_length"`
+	QueueCapacity  int   `json:"queue_capacity"`
+	Written        int64 `json:"written"`
+	Failed         int64 `json:"failed"`        // gagal ditulis setelah semua percobaan
+	Spilled        int64 `json:"spilled"`       // dialihkan ke journal di disk
+	Replayed       int64 `json:"replayed"`      // dari journal, akhirnya masuk database
+	Dropped        int64 `json:"dropped"`       // hilang sama sekali
+	DeadLettered   int64 `json:"dead_lettered"` // ditolak database, disimpan di file dead-letter
+	JournalPending int64 `json:"journal_pending"`
+}
+
+// AuditRetentionResult adalah hasil satu kali jalan retensi log audit.
+type AuditRetentionResult struct {
+	RetentionDays   int                   `json:"retention_days"`
+	Cutoff          time.Time             `json:"cutoff"`
+	ArchivedEntries int                   `json:"archived_entries"`
+	Archives        []models.AuditArchive `json:"archives"`
+}
+
+// AuditArchiveEntry adalah satu baris di file arsip log audit. Nama & NRP
+// pengguna ikut disimpan agar arsip tetap terbaca walau penggunanya dihapus.
+type AuditArchiveEntry struct {
+	ID         uint      `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	UserID     uint      `json:"user_id"`
+	UserName   string    `json:"user_name,omitempty"`
+	UserNRP    string    `json:"user_nrp,omitempty"`
+	Aksi       string    `json:"aksi"`
+	Detail     string    `json:"detail"`
+	EntityType string    `json:"entity_type,omitempty"`
+	EntityID   string    `json:"entity_id,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Before     string    `json:"before,omitempty"`
+	After      string    `json:"after,omitempty"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// ResidentAccessReport menjawab "siapa saja yang melihat data penduduk ini"
+// (UU PDP): ringkasan per pengguna plus daftar akses terbaru.
+type ResidentAccessReport struct {
+	ResidentID          uint                  `json:"resident_id"`
+	NIK                 string                `json:"nik"`
+	NamaLengkap         string                `json:"nama_lengkap"`
+	TotalAccess         int                   `json:"total_access"`
+	Truncated           bool                  `json:"truncated"`                      // entri lebih lama tidak ikut ditampilkan
+	ArchivedEntries     int                   `json:"archived_entries"`               // entri yang diambil dari arsip retensi
+	ArchivesUnavailable []string              `json:"archives_unavailable,omitempty"` // periode arsip yang gagal dibaca
+	Accessors           []ResidentAccessor    `json:"accessors"`
+	Entries             []ResidentAccessEntry `json:"entries"`
+	GeneratedAt         time.Time             `json:"generated_at"`
+}
+
+// ResidentAccessor adalah ringkasan akses satu pengguna ke data seorang penduduk.
+type ResidentAccessor struct {
+	UserID      uint           `json:"user_id"`
+	NamaLengkap string         `json:"nama_lengkap"`
+	NRP         string         `json:"nrp"`
+	Count       int            `json:"count"`
+	Actions     map[string]int `json:"actions"`
+	FirstAccess time.Time      `json:"first_access"`
+	LastAccess  time.Time      `json:"last_access"`
+}
+
+// ResidentAccessEntry adalah satu kejadian akses (lihat/cetak/unduh/ekspor).
+type ResidentAccessEntry struct {
+	LogID     uint      `json:"log_id"`
+	Timestamp time.Time `json:"timestamp"`
+	UserID    uint      `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	UserNRP   string    `json:"user_nrp"`
+	Aksi      string    `json:"aksi"`
+	Detail    string    `json:"detail"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Archived  bool      `json:"archived,omitempty"` // berasal dari arsip retensi
+}
+
+// AuditSinkStatus adalah status pengiriman satu tujuan penerusan log audit.
+type AuditSinkStatus struct {
+	Name        string     `json:"name"`   // syslog / file
+	Target      string     `json:"target"` // alamat syslog atau path file
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`
+	Dropped     int64      `json:"dropped"` // antrean penerusan penuh
+	Pending     int        `json:"pending"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
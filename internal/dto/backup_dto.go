@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"simdokpol/internal/models"
+	"time"
+)
+
+// BackupMethod menjelaskan satu cara backup dan apakah bisa dipakai di host ini.
+type BackupMethod struct {
+	Name      string `json:"name"` // sqlite, pg_dump, mysqldump, portable
+	Label     string `json:"label"`
+	Extension string `json:"extension"`
+	Available bool   `json:"available"`
+	// CanRestore false bila alat restore (pg_restore/mysql) tidak ditemukan
+	CanRestore bool   `json:"can_restore"`
+	Tool       string `json:"tool,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Reason     string `json:"reason,omitempty"` // alasan bila tidak tersedia
+}
+
+// BackupCapabilities dipakai UI untuk menampilkan pilihan metode backup
+// dan jenis file yang bisa dipulihkan pada database aktif.
+type BackupCapabilities struct {
+	Dialect           string         `json:"dialect"`
+	DefaultMethod     string         `json:"default_method"`
+	Methods           []BackupMethod `json:"methods"`
+	RestoreExtensions []string       `json:"restore_extensions"`
+	// Encryption: none, passphrase atau keyfile (file backup berakhiran .enc)
+	Encryption string `json:"encryption"`
+}
+
+// PortableBackupManifest adalah manifest.json di dalam arsip backup portabel
+// (tar.gz berisi satu file JSON lines per tabel). Arsip yang sama dipakai
+// untuk ekspor/impor data antar kantor.
+type PortableBackupManifest struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SourceDialect string    `json:"source_dialect"`
+	// SchemaVersion kosong pada arsip dari versi aplikasi lama
+	SchemaVersion int                   `json:"schema_version,omitempty"`
+	Tables        []PortableBackupTable `json:"tables"`
+}
+
+type PortableBackupTable struct {
+	Name    string                 `json:"name"`
+	File    string                 `json:"file"`
+	Rows    int64                  `json:"rows"`
+	SHA256  string                 `json:"sha256"`
+	Columns []PortableBackupColumn `json:"columns,omitempty"`
+}
+
+// PortableBackupColumn menjelaskan satu kolom di file JSON lines. Type memakai
+// tipe data GORM (uint, string, bool, time, ...), bukan tipe SQL dialek tertentu.
+type PortableBackupColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable,omitempty"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+}
+
+// EncryptedBackupHeader adalah metadata di awal file backup terenkripsi (.enc).
+// Header tidak dienkripsi tapi ikut diautentikasi, jadi tidak bisa diubah
+// tanpa membuat restore gagal.
+type EncryptedBackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	AppVersion    string    `json:"app_version"`
+	SchemaVersion int       `json:"schema_version"`
+	Dialect       string    `json:"dialect"`
+	Method        string    `json:"method"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	KDF        string `json:"kdf"` // argon2id (passphrase) atau hkdf-sha256 (file kunci)
+	Salt       []byte `json:"salt"`
+	KDFTime    uint32 `json:"kdf_time,omitempty"`
+	KDFMemory  uint32 `json:"kdf_memory_kib,omitempty"`
+	KDFThreads uint8  `json:"kdf_threads,omitempty"`
+	// KeyCheck membedakan "kunci salah" dari "file diubah"
+	KeyCheck    []byte `json:"key_check"`
+	ChunkSize   int    `json:"chunk_size"`
+	NoncePrefix []byte `json:"nonce_prefix"`
+}
+
+// BackupKey adalah kunci yang diberikan admin saat restore. Kosong berarti
+// memakai kunci dari pengaturan enkripsi backup.
+type BackupKey struct {
+	Passphrase string
+	KeyFile    []byte
+}
+
+// BackupFile adalah satu file di folder backup. Kind/Method/RunID kosong
+// berarti file tidak tercatat di riwayat (misalnya disalin manual).
+type BackupFile struct {
+	Name       string    `json:"name"`
+	SizeBytes  int64     `json:"size_bytes"`
+	ModifiedAt time.Time `json:"modified_at"`
+	SHA256     string    `json:"sha256"`
+	Encrypted  bool      `json:"encrypted"`
+	Kind       string    `json:"kind,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	RunID      uint      `json:"run_id,omitempty"`
+}
+
+// BackupVerification adalah hasil pemeriksaan ulang file backup yang
+// tersimpan: checksum dibandingkan dengan riwayat, lalu isinya diperiksa
+// sesuai format. OK false berarti Problems tidak kosong.
+type BackupVerification struct {
+	Name           string    `json:"name"`
+	SizeBytes      int64     `json:"size_bytes"`
+	SHA256         string    `json:"sha256"`
+	RecordedSHA256 string    `json:"recorded_sha256,omitempty"`
+	Format         string    `json:"format"`
+	Encrypted      bool      `json:"encrypted"`
+	IntegrityCheck string    `json:"integrity_check"`
+	Problems       []string  `json:"problems"`
+	Warnings       []string  `json:"warnings"`
+	OK             bool      `json:"ok"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// RestorePreview adalah ringkasan hasil validasi file restore. Admin
+// melihatnya dulu, lalu restore dijalankan dengan Token sebelum ExpiresAt.
+// Compatible false berarti Errors tidak kosong dan restore akan ditolak.
+type RestorePreview struct {
+	Token                string                `json:"token"`
+	FileName             string                `json:"file_name,omitempty"`
+	Format               string                `json:"format"`
+	SizeBytes            int64                 `json:"size_bytes"`
+	SHA256               string                `json:"sha256"`
+	Encrypted            bool                  `json:"encrypted"`
+	AppVersion           string                `json:"app_version,omitempty"`
+	SourceDialect        string                `json:"source_dialect,omitempty"`
+	TargetDialect        string                `json:"target_dialect"`
+	CreatedAt            *time.Time            `json:"created_at,omitempty"`
+	SchemaVersion        int                   `json:"schema_version"` // 0 = tidak tercatat di backup
+	CurrentSchemaVersion int                   `json:"current_schema_version"`
+	IntegrityCheck       string                `json:"integrity_check"`
+	Tables               []RestoreTablePreview `json:"tables"`
+	Warnings             []string              `json:"warnings"`
+	Errors               []string              `json:"errors"`
+	Compatible           bool                  `json:"compatible"`
+	ExpiresAt            time.Time             `json:"expires_at"`
+}
+
+type RestoreTablePreview struct {
+	Name        string `json:"name"`
+	BackupRows  int64  `json:"backup_rows"`  // -1 = baru diketahui setelah restore
+	CurrentRows int64  `json:"current_rows"` // -1 = tabel belum ada di database aktif
+	Missing     bool   `json:"missing"`
+}
+
+// BackupStatus merangkum jadwal dan hasil backup terakhir untuk dashboard.
+// Failing true bila backup terjadwal terakhir gagal.
+type BackupStatus struct {
+	Schedule      BackupScheduleConfig `json:"schedule"`
+	NextRunAt     *time.Time           `json:"next_run_at,omitempty"`
+	LastRun       *models.BackupRun    `json:"last_run,omitempty"`
+	LastScheduled *models.BackupRun    `json:"last_scheduled,omitempty"`
+	LastSuccess   *models.BackupRun    `json:"last_success,omitempty"`
+	Failing       bool                 `json:"failing"`
+	// OffsiteFailing: backup terakhir berhasil dibuat tapi gagal dikirim off-site
+	OffsiteFailing bool `json:"offsite_failing"`
+}
@@ -14,6 +14,21 @@ type AppConfig struct {
 	ZonaWaktu           string `json:"zona_waktu"`
 	BackupPath          string `json:"backup_path"`
 	ArchiveDurationDays int    `json:"archive_duration_days"`
+	AuditRetentionDays  int    `json:"audit_retention_days"` // 0 = log audit disimpan selamanya
+
+	// --- AUDIT AKSES DATA PRIBADI (UU PDP) ---
+	AuditReadEnabled       bool `json:"audit_read_enabled"`        // catat siapa melihat/mencetak/mengekspor data penduduk
+	AuditReadSamplePercent int  `json:"audit_read_sample_percent"` // 1-100, hanya untuk aksi "lihat"
+	AuditReadDedupMinutes  int  `json:"audit_read_dedup_minutes"`  // 0 = setiap kali dilihat dicatat
+
+	// --- PENERUSAN LOG AUDIT (selain database) ---
+	AuditSyslog AuditSyslogConfig `json:"audit_syslog"`
+	AuditFile   AuditFileConfig   `json:"audit_file"`
+
+	// --- BACKUP TERJADWAL ---
+	BackupSchedule   BackupScheduleConfig   `json:"backup_schedule"`
+	BackupEncryption BackupEncryptionConfig `json:"backup_encryption"`
+	BackupOffsite    BackupOffsiteConfig    `json:"backup_offsite"`
 
 	// --- FITUR BARU: TIMEOUTS ---
 	SessionTimeout int `json:"session_timeout"` // Max durasi login (Jam)
@@ -29,4 +44,122 @@ type AppConfig struct {
 	DBDSN         string `json:"db_dsn"`
 	DBSSLMode     string `json:"db_sslmode"`
 	LicenseStatus string `json:"license_status"`
+
+	// --- AUTENTIKASI ---
+	AuthProvider string     `json:"auth_provider"` // "local" (bcrypt) atau "ldap"
+	LDAP         LDAPConfig `json:"ldap"`
+	OIDC         OIDCConfig `json:"oidc"`
+}
+
+// BackupScheduleConfig mengatur backup otomatis dan retensi file backup
+// (pola kakek-ayah-anak: simpan N harian, mingguan dan bulanan terakhir).
+type BackupScheduleConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Frequency   string `json:"frequency"` // daily atau weekly
+	Time        string `json:"time"`      // HH:MM, zona waktu server
+	Weekday     int    `json:"weekday"`   // 0 = Minggu, hanya untuk weekly
+	Method      string `json:"method"`    // kosong = metode bawaan dialek
+	KeepDaily   int    `json:"keep_daily"`
+	KeepWeekly  int    `json:"keep_weekly"`
+	KeepMonthly int    `json:"keep_monthly"`
+}
+
+// BackupEncryptionConfig mengatur enkripsi file backup. Kunci diturunkan dari
+// passphrase admin atau dari file kunci di server. Passphrase tidak dikirim ke UI.
+type BackupEncryptionConfig struct {
+	Mode          string `json:"mode"` // none, passphrase, keyfile
+	Passphrase    string `json:"-"`
+	HasPassphrase bool   `json:"has_passphrase"`
+	KeyFile       string `json:"key_file"`
+}
+
+// BackupOffsiteConfig mengatur salinan backup di luar PC kantor: server
+// SFTP, bucket S3-compatible (AWS, MinIO, dll) atau folder jaringan yang
+// di-mount. Password/secret key tidak dikirim ke UI.
+type BackupOffsiteConfig struct {
+	Type    string `json:"type"`    // none, sftp, s3, folder
+	Retries int    `json:"retries"` // jumlah percobaan ulang bila unggah gagal
+
+	SFTPHost        string `json:"sftp_host"`
+	SFTPPort        int    `json:"sftp_port"`
+	SFTPUser        string `json:"sftp_user"`
+	SFTPPassword    string `json:"-"`
+	HasSFTPPassword bool   `json:"has_sftp_password"`
+	SFTPKeyFile     string `json:"sftp_key_file"` // private key OpenSSH di server aplikasi
+	SFTPHostKey     string `json:"sftp_host_key"` // sidik jari SHA256:... host key server
+	SFTPPath        string `json:"sftp_path"`
+
+	S3Endpoint     string `json:"s3_endpoint"` // host[:port], tanpa skema
+	S3Bucket       string `json:"s3_bucket"`
+	S3Region       string `json:"s3_region"`
+	S3AccessKey    string `json:"s3_access_key"`
+	S3SecretKey    string `json:"-"`
+	HasS3SecretKey bool   `json:"has_s3_secret_key"`
+	S3Prefix       string `json:"s3_prefix"`
+	S3UseSSL       bool   `json:"s3_use_ssl"`
+
+	FolderPath string `json:"folder_path"`
+}
+
+// AuditSyslogConfig meneruskan log audit ke syslog pusat (RFC 5424).
+type AuditSyslogConfig struct {
+	Enabled            bool   `json:"enabled"`
+	Network            string `json:"network"` // udp, tcp atau tls
+	Address            string `json:"address"` // host:port
+	AppName            string `json:"app_name"`
+	Facility           int    `json:"facility"` // 0-23, bawaan 13 (log audit)
+	CACertPath         string `json:"ca_cert_path"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// AuditFileConfig menulis salinan log audit ke file JSON lines yang dirotasi.
+type AuditFileConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Path       string `json:"path"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+	MaxAgeDays int    `json:"max_age_days"` // 0 = file lama tidak dihapus berdasarkan umur
+	Compress   bool   `json:"compress"`
+}
+
+// LDAPConfig berisi pengaturan bind ke LDAP/Active Directory.
+// BindPassword sengaja tidak ikut dikirim ke UI.
+type LDAPConfig struct {
+	URL                string             `json:"url"` // ldap://host:389 atau ldaps://host:636
+	StartTLS           bool               `json:"start_tls"`
+	InsecureSkipVerify bool               `json:"insecure_skip_verify"`
+	CACertPath         string             `json:"ca_cert_path"`
+	BindDN             string             `json:"bind_dn"`
+	BindPassword       string             `json:"-"`
+	BaseDN             string             `json:"base_dn"`
+	UserFilter         string             `json:"user_filter"` // %s diganti NRP, mis. (sAMAccountName=%s)
+	NameAttribute      string             `json:"name_attribute"`
+	GroupAttribute     string             `json:"group_attribute"`
+	GroupMappings      []GroupMapping `json:"group_mappings"`
+	AllowLocalFallback bool               `json:"allow_local_fallback"`
+}
+
+// OIDCConfig berisi pengaturan "Login dengan SSO" (authorization code + PKCE).
+// ClientSecret sengaja tidak ikut dikirim ke UI.
+type OIDCConfig struct {
+	Enabled      bool           `json:"enabled"`
+	Issuer       string         `json:"issuer"` // mis. https://sso.polri.go.id/realms/polres
+	ClientID     string         `json:"client_id"`
+	ClientSecret string         `json:"-"`
+	RedirectURL  string         `json:"redirect_url"` // kosong = otomatis dari host request
+	Scopes       []string       `json:"scopes"`
+	NRPClaim     string         `json:"nrp_claim"`
+	NameClaim    string         `json:"name_claim"`
+	RoleClaim    string         `json:"role_claim"`
+	RoleMappings []GroupMapping `json:"role_mappings"`
+	ButtonLabel  string         `json:"button_label"`
+}
+
+// GroupMapping memetakan grup direktori / klaim peran IdP ke peran, pangkat
+// dan jabatan. Pemetaan dicek berurutan, yang pertama cocok dipakai.
+type GroupMapping struct {
+	Group   string `json:"group"` // DN lengkap, CN grup, atau nilai klaim
+	Peran   string `json:"peran"`
+	Pangkat string `json:"pangkat"`
+	Jabatan string `json:"jabatan"`
 }
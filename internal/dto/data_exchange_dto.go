@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// Mode impor arsip data portabel
+const (
+	DataImportMerge   = "merge"   // gabungkan ke data yang ada, ID dipetakan ulang
+	DataImportReplace = "replace" // kosongkan tabel lalu isi persis seperti arsip
+)
+
+// DataImportTableReport hasil impor per tabel. Matched = baris yang sudah ada
+// di database (mis. NIK atau NRP sama) sehingga dipakai ulang, Conflicts =
+// baris yang bentrok dan tidak disisipkan, Skipped = baris yang sengaja tidak
+// digabung (lihat Note).
+type DataImportTableReport struct {
+	Table     string `json:"table"`
+	Rows      int64  `json:"rows"`
+	Inserted  int64  `json:"inserted"`
+	Matched   int64  `json:"matched"`
+	Conflicts int64  `json:"conflicts"`
+	Skipped   int64  `json:"skipped"`
+	Note      string `json:"note,omitempty"`
+}
+
+// DataImportUser pengguna baru hasil impor merge. Masuk dalam keadaan
+// nonaktif; Super Admin diturunkan ke Operator (PeranAsal = peran di arsip).
+type DataImportUser struct {
+	NRP         string `json:"nrp"`
+	NamaLengkap string `json:"nama_lengkap"`
+	Peran       string `json:"peran"`
+	PeranAsal   string `json:"peran_asal"`
+}
+
+type DataImportReport struct {
+	Mode          string                  `json:"mode"`
+	SourceDialect string                  `json:"source_dialect"`
+	ExportedAt    time.Time               `json:"exported_at"`
+	SchemaVersion int                     `json:"schema_version,omitempty"`
+	Tables        []DataImportTableReport `json:"tables"`
+	Inserted      int64                   `json:"inserted"`
+	Matched       int64                   `json:"matched"`
+	Conflicts     int64                   `json:"conflicts"`
+	ImportedUsers []DataImportUser        `json:"imported_users,omitempty"`
+	DurationMs    int64                   `json:"duration_ms"`
+}
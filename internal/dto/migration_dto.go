@@ -1,7 +1,63 @@
 package dto
 
+import "time"
+
 type MigrationProgress struct {
 	Step    string `json:"step"`    // Sedang memproses tabel apa
 	Percent int    `json:"percent"` // 0-100
 	Message string `json:"message"` // Pesan detail
-}
\ No newline at end of file
+	// Report hanya terisi di pesan terakhir (Step "report")
+	Report *DataMigrationReport `json:"report,omitempty"`
+}
+
+// DataMigrationRequest target migrasi data. DryRun hanya menghitung baris,
+// checksum sumber dan estimasi durasi tanpa mengubah database target.
+type DataMigrationRequest struct {
+	DBTestRequest
+	DryRun bool `json:"dry_run"`
+}
+
+// DataMigrationTableReport hasil per tabel: jumlah baris dan checksum isi di
+// sumber dan target.
+type DataMigrationTableReport struct {
+	Table          string `json:"table"`
+	Label          string `json:"label"`
+	SourceRows     int64  `json:"source_rows"`
+	TargetRows     int64  `json:"target_rows"`
+	SourceChecksum string `json:"source_checksum"`
+	TargetChecksum string `json:"target_checksum,omitempty"`
+	CopiedRows     int64  `json:"copied_rows"` // disalin pada percobaan ini
+	Resumed        bool   `json:"resumed"`     // sebagian sudah tersalin di percobaan sebelumnya
+	Match          bool   `json:"match"`
+}
+
+// DataMigrationReport laporan akhir migrasi data (atau estimasi dry-run).
+type DataMigrationReport struct {
+	DryRun           bool                       `json:"dry_run"`
+	TargetDialect    string                     `json:"target_dialect"`
+	Tables           []DataMigrationTableReport `json:"tables"`
+	TotalRows        int64                      `json:"total_rows"`
+	Verified         bool                       `json:"verified"`
+	EstimatedSeconds int64                      `json:"estimated_seconds,omitempty"`
+	DurationMs       int64                      `json:"duration_ms"`
+	Warnings         []string                   `json:"warnings,omitempty"`
+}
+
+// SchemaMigrationInfo status satu file migrasi SQL.
+type SchemaMigrationInfo struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Dirty     bool       `json:"dirty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// SchemaStatus ringkasan versi skema database dibanding migrasi bawaan aplikasi.
+type SchemaStatus struct {
+	Dialect        string                `json:"dialect"`
+	CurrentVersion int                   `json:"current_version"` // 0 = belum ada migrasi tercatat
+	LatestVersion  int                   `json:"latest_version"`
+	Pending        int                   `json:"pending"`
+	Dirty          bool                  `json:"dirty"`
+	Migrations     []SchemaMigrationInfo `json:"migrations"`
+}
@@ -3,17 +3,26 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"simdokpol/internal/models"
 	"simdokpol/internal/repositories" // <-- IMPORT BARU
 	"simdokpol/internal/services"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Middleware sekarang menerima UserRepository untuk mengambil data pengguna
-func AuthMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
+// Middleware sekarang menerima UserRepository untuk mengambil data pengguna,
+// APITokenService untuk token pribadi via header "Authorization: Bearer" dan
+// SessionRevocationService untuk sesi SSO yang dicabut IdP.
+func AuthMiddleware(userRepo repositories.UserRepository, tokenService services.APITokenService, revocations services.SessionRevocationService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if header := c.GetHeader("Authorization"); tokenService != nil && strings.HasPrefix(header, "Bearer ") {
+			authenticateAPIToken(c, tokenService, strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+			return
+		}
+
 		tokenString, err := c.Cookie("token")
 
 		if err != nil {
@@ -50,6 +59,28 @@ func AuthMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 			userID := uint(claims["userID"].(float64))
 
+			// Sesi SSO yang sudah di-logout oleh IdP (back-channel logout)
+			sid, _ := claims["sid"].(string)
+			sub, _ := claims["sub"].(string)
+			if revocations != nil && (sid != "" || sub != "") {
+				issuedAt, _ := claims.GetIssuedAt()
+				var iat time.Time
+				if issuedAt != nil {
+					iat = issuedAt.Time
+				}
+				revoked, err := revocations.IsRevoked(iat, services.SessionKeys(sid, sub)...)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memeriksa sesi"})
+					c.Abort()
+					return
+				}
+				if revoked {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "Sesi SSO Anda sudah berakhir, silakan login ulang"})
+					c.Abort()
+					return
+				}
+			}
+
 			// Ambil data lengkap pengguna dan simpan di context
 			user, err := userRepo.FindByID(userID)
 			if err != nil {
@@ -66,4 +97,36 @@ func AuthMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
 			c.Abort()
 		}
 	}
-}
\ No newline at end of file
+}
+
+// authenticateAPIToken memvalidasi token pribadi dan menerapkan scope baca/tulis.
+func authenticateAPIToken(c *gin.Context, tokenService services.APITokenService, plainToken string) {
+	token, user, err := tokenService.Authenticate(plainToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if !token.HasScope(models.ScopeAPIRead) && !token.HasScope(models.ScopeAPIWrite) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token tidak memiliki scope api:read"})
+			c.Abort()
+			return
+		}
+	default:
+		if !token.HasScope(models.ScopeAPIWrite) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token tidak memiliki scope api:write"})
+			c.Abort()
+			return
+		}
+	}
+
+	tokenService.RecordUse(token, c.ClientIP(), c.Request.Method, c.Request.URL.Path)
+
+	c.Set("userID", user.ID)
+	c.Set("currentUser", user)
+	c.Set("apiToken", token)
+	c.Next()
+}
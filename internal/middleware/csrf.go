@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"os"
+	"simdokpol/internal/utils"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFCookieName dibaca JavaScript halaman lalu dikirim ulang lewat header
+	// (double-submit cookie). Situs lain tidak bisa membaca cookie ini.
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+	csrfFormField  = "csrf_token"
+)
+
+// csrfExemptPaths dipanggil server ke server (tanpa cookie browser).
+var csrfExemptPaths = map[string]bool{
+	"/api/auth/sso/backchannel-logout": true,
+}
+
+// AllowedOrigins mengembalikan origin tambahan dari env ALLOWED_ORIGINS
+// (dipisah koma), mis. https://simdokpol.polres.go.id untuk mode server.
+func AllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// IsAllowedOrigin mengizinkan localhost, 127.0.0.1, vhost lokal aplikasi
+// (port berapa pun) dan origin yang terdaftar di ALLOWED_ORIGINS.
+func IsAllowedOrigin(origin string, extra []string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	switch strings.ToLower(parsed.Hostname()) {
+	case "localhost", "127.0.0.1", "::1", utils.LocalDomain:
+		return true
+	}
+	for _, allowed := range extra {
+		if strings.EqualFold(strings.TrimRight(origin, "/"), allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSameOrigin mengecek apakah origin sama dengan host yang sedang diakses.
+func isSameOrigin(origin string, c *gin.Context) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, c.Request.Host)
+}
+
+// CORSMiddleware menggantikan cors.Default() yang mengizinkan semua origin.
+func CORSMiddleware() gin.HandlerFunc {
+	extra := AllowedOrigins()
+	return cors.New(cors.Config{
+		AllowOriginFunc:  func(origin string) bool { return IsAllowedOrigin(origin, extra) },
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", CSRFHeaderName, RequestIDHeader},
+		ExposeHeaders:    []string{RequestIDHeader},
+		AllowCredentials: true,
+	})
+}
+
+func newCSRFToken() string {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// CSRFMiddleware melindungi semua POST/PUT/PATCH/DELETE yang memakai cookie sesi:
+//  1. Origin (atau Referer) wajib sama dengan host atau origin yang diizinkan.
+//  2. Header X-CSRF-Token wajib sama dengan cookie csrf_token (double-submit).
+//
+// Request dengan token API (Authorization: Bearer) dilewati karena
+// kredensialnya tidak dikirim otomatis oleh browser.
+func CSRFMiddleware() gin.HandlerFunc {
+	extra := AllowedOrigins()
+	return func(c *gin.Context) {
+		cookieToken, err := c.Cookie(CSRFCookieName)
+		if err != nil || cookieToken == "" {
+			cookieToken = newCSRFToken()
+			secure := c.Request.TLS != nil || os.Getenv("APP_ENV") == "production"
+			c.SetSameSite(http.SameSiteStrictMode)
+			// Tidak HttpOnly: JavaScript halaman perlu membacanya
+			c.SetCookie(CSRFCookieName, cookieToken, 0, "/", "", secure, false)
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+		if csrfExemptPaths[c.Request.URL.Path] || strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+			c.Next()
+			return
+		}
+
+		origin := c.GetHeader("Origin")
+		if origin == "" || origin == "null" {
+			if referer, err := url.Parse(c.GetHeader("Referer")); err == nil && referer.Host != "" {
+				origin = referer.Scheme + "://" + referer.Host
+			}
+		}
+		if origin != "" && !isSameOrigin(origin, c) && !IsAllowedOrigin(origin, extra) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permintaan lintas situs ditolak"})
+			c.Abort()
+			return
+		}
+
+		sent := c.GetHeader(CSRFHeaderName)
+		if sent == "" && strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			sent = c.PostForm(csrfFormField)
+		}
+		if sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(cookieToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token CSRF tidak valid, muat ulang halaman lalu coba lagi"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCSRFToken = "token-csrf-uji"
+
+// setupCSRFRouter menyusun middleware seperti di main.go: CORS, CSRF, lalu auth cookie.
+func setupCSRFRouter(t *testing.T) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ALLOWED_ORIGINS", "https://simdokpol.polres.go.id")
+	services.JWTSecretKey = []byte("test-secret")
+
+	userRepo := new(mocks.UserRepository)
+	userRepo.On("FindByID", uint(1)).Return(&models.User{ID: 1, Peran: models.RoleSuperAdmin}, nil)
+
+	router := gin.New()
+	router.Use(CORSMiddleware())
+	router.Use(CSRFMiddleware())
+	router.POST("/api/auth/sso/backchannel-logout", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	authorized := router.Group("/")
+	authorized.Use(AuthMiddleware(userRepo, nil, nil))
+	authorized.GET("/api/documents", func(c *gin.Context) { c.Status(http.StatusOK) })
+	authorized.DELETE("/api/documents/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	authorized.POST("/api/restore", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userID": 1,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}).SignedString(services.JWTSecretKey)
+	assert.NoError(t, err)
+	return router, session
+}
+
+func newWriteRequest(method, path, session string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	req.Host = "localhost:8080"
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "token", Value: session})
+	req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: testCSRFToken})
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
+func TestCSRF_CrossOriginWritesRejected(t *testing.T) {
+	router, session := setupCSRFRouter(t)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+	}{
+		{"DELETE Dokumen Dari Situs Lain", http.MethodDelete, "/api/documents/1", map[string]string{"Origin": "https://evil.example"}},
+		{"POST Restore Dari Situs Lain Walau Token Cocok", http.MethodPost, "/api/restore", map[string]string{"Origin": "https://evil.example", CSRFHeaderName: testCSRFToken}},
+		{"Referer Situs Lain Tanpa Origin", http.MethodPost, "/api/restore", map[string]string{"Referer": "https://evil.example/halaman", CSRFHeaderName: testCSRFToken}},
+		{"Origin null", http.MethodDelete, "/api/documents/1", map[string]string{"Origin": "null", CSRFHeaderName: testCSRFToken}},
+		{"Same-Origin Tanpa Token CSRF", http.MethodDelete, "/api/documents/1", map[string]string{"Origin": "http://localhost:8080"}},
+		{"Same-Origin Token Salah", http.MethodDelete, "/api/documents/1", map[string]string{"Origin": "http://localhost:8080", CSRFHeaderName: "tebakan"}},
+		{"Tanpa Origin dan Tanpa Token", http.MethodPost, "/api/restore", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newWriteRequest(tc.method, tc.path, session, tc.headers))
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+}
+
+func TestCSRF_LegitimateWritesAllowed(t *testing.T) {
+	router, session := setupCSRFRouter(t)
+
+	testCases := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{"Same-Origin Dengan Token", map[string]string{"Origin": "http://localhost:8080", CSRFHeaderName: testCSRFToken}},
+		{"Vhost Lokal", map[string]string{"Origin": "https://simdokpol.local:8080", CSRFHeaderName: testCSRFToken}},
+		{"Origin Dari ALLOWED_ORIGINS", map[string]string{"Origin": "https://simdokpol.polres.go.id", CSRFHeaderName: testCSRFToken}},
+		{"Tanpa Origin Dengan Token", map[string]string{CSRFHeaderName: testCSRFToken}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newWriteRequest(http.MethodDelete, "/api/documents/1", session, tc.headers))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestCSRF_CookieAndExemptions(t *testing.T) {
+	router, session := setupCSRFRouter(t)
+
+	t.Run("GET Menerbitkan Cookie CSRF SameSite Strict", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/documents", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: session})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		cookie := rec.Header().Get("Set-Cookie")
+		assert.Contains(t, cookie, CSRFCookieName+"=")
+		assert.Contains(t, cookie, "SameSite=Strict")
+		assert.NotContains(t, cookie, "HttpOnly")
+	})
+
+	t.Run("Back-channel Logout Tidak Butuh Token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/sso/backchannel-logout", strings.NewReader("logout_token=x"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Preflight Dari Situs Lain Ditolak", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/documents/1", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "https://evil.example")
+		req.Header.Set("Access-Control-Request-Method", "DELETE")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Preflight Dari Localhost Diizinkan", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/documents/1", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:5173")
+		req.Header.Set("Access-Control-Request-Method", "DELETE")
+		req.Header.Set("Access-Control-Request-Headers", CSRFHeaderName)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "http://localhost:5173", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+func TestCSRF_BearerTokenSkipsCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CSRFMiddleware())
+	router.POST("/api/documents", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/documents", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer sdp_contoh")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
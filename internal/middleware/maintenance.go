@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceMiddleware menolak request (503) selama database diganti
+// lewat restore, dan mencatat request yang sedang berjalan agar restore
+// menunggu semuanya selesai dulu.
+func MaintenanceMiddleware(gate *services.MaintenanceGate) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, leave, err := gate.Enter(c.Request.Context())
+		if err != nil {
+			c.Header("Retry-After", "10")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		defer leave()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAccess menentukan siapa yang boleh membaca /metrics: pembawa
+// token (Authorization: Bearer) atau alamat di allowlist.
+type MetricsAccess struct {
+	Token      string
+	AllowedIPs []*net.IPNet
+}
+
+// LoadMetricsAccess membaca akses /metrics dari env:
+//
+//	METRICS_TOKEN       -> token untuk scraper (kosong = tanpa token)
+//	METRICS_ALLOWED_IPS -> IP/CIDR dipisah koma, mis. "10.0.0.0/24,192.168.1.5"
+//
+// Tanpa METRICS_ALLOWED_IPS hanya localhost yang diizinkan. Entri yang tidak
+// valid diabaikan.
+func LoadMetricsAccess() MetricsAccess {
+	access := MetricsAccess{Token: strings.TrimSpace(os.Getenv("METRICS_TOKEN"))}
+	allowed := os.Getenv("METRICS_ALLOWED_IPS")
+	if strings.TrimSpace(allowed) == "" {
+		allowed = "127.0.0.1,::1"
+	}
+	for _, entry := range strings.Split(allowed, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("WARN: METRICS_ALLOWED_IPS %q diabaikan: %v", entry, err)
+			continue
+		}
+		access.AllowedIPs = append(access.AllowedIPs, network)
+	}
+	return access
+}
+
+func (a MetricsAccess) allows(c *gin.Context) bool {
+	if a.Token != "" {
+		header := c.GetHeader("Authorization")
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1 {
+			return true
+		}
+	}
+	// RemoteIP, bukan ClientIP: X-Forwarded-For bisa dipalsukan. Di balik
+	// reverse proxy pakai token.
+	ip := net.ParseIP(c.RemoteIP())
+	for _, network := range a.AllowedIPs {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricsAccessMiddleware menolak scrape yang tidak membawa token yang benar
+// dan tidak berasal dari allowlist.
+func MetricsAccessMiddleware(access MetricsAccess) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !access.allows(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akses metrik ditolak."})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HTTPMetricsMiddleware mencatat durasi setiap request per pola rute untuk
+// histogram di /metrics. Request ke rute yang tidak terdaftar digabung
+// sebagai "unmatched" agar path acak tidak menambah deret.
+func HTTPMetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		services.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(started))
+	}
+}
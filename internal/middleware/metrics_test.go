@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMetricsAccess(t *testing.T) {
+	t.Setenv("METRICS_TOKEN", "")
+	t.Setenv("METRICS_ALLOWED_IPS", "")
+	access := LoadMetricsAccess()
+	require.Len(t, access.AllowedIPs, 2, "bawaan hanya loopback")
+
+	t.Setenv("METRICS_TOKEN", " rahasia ")
+	t.Setenv("METRICS_ALLOWED_IPS", "10.0.0.0/24, 192.168.1.5, bukan-ip, fd00::1")
+	access = LoadMetricsAccess()
+	assert.Equal(t, "rahasia", access.Token)
+	require.Len(t, access.AllowedIPs, 3, "entri tidak valid dilewati")
+	assert.Equal(t, "192.168.1.5/32", access.AllowedIPs[1].String())
+	assert.Equal(t, "fd00::1/128", access.AllowedIPs[2].String())
+}
+
+func TestMetricsAccessMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("METRICS_TOKEN", "rahasia")
+	t.Setenv("METRICS_ALLOWED_IPS", "10.0.0.0/24")
+	router := gin.New()
+	router.GET("/metrics", MetricsAccessMiddleware(LoadMetricsAccess()), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   int
+	}{
+		{"IP Di Allowlist", "10.0.0.7:4000", nil, http.StatusOK},
+		{"Token Benar", "203.0.113.9:4000", map[string]string{"Authorization": "Bearer rahasia"}, http.StatusOK},
+		{"Token Salah", "203.0.113.9:4000", map[string]string{"Authorization": "Bearer salah"}, http.StatusForbidden},
+		{"Tanpa Token Dari Luar", "203.0.113.9:4000", nil, http.StatusForbidden},
+		{"X-Forwarded-For Tidak Dipercaya", "203.0.113.9:4000", map[string]string{"X-Forwarded-For": "10.0.0.7"}, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
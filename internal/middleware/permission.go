@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionMiddleware hanya meneruskan request jika peran pengguna
+// memiliki salah satu izin yang diminta.
+func PermissionMiddleware(roleService services.RoleService, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userInterface, exists := c.Get("currentUser")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak. Pengguna tidak terautentikasi."})
+			c.Abort()
+			return
+		}
+
+		currentUser, ok := userInterface.(*models.User)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak. Tipe data pengguna tidak valid."})
+			c.Abort()
+			return
+		}
+
+		if hasAnyPermission(c, roleService, currentUser, permissions) {
+			c.Next()
+			return
+		}
+
+		if c.Request.Header.Get("Accept") == "application/json" || strings.HasPrefix(c.Request.URL.Path, "/api") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak. Anda tidak memiliki hak akses yang cukup."})
+		} else {
+			c.Redirect(http.StatusFound, "/")
+		}
+		c.Abort()
+	}
+}
+
+// HasPermission dipakai controller yang membatasi sebagian isi request
+// dengan izin tambahan, dengan aturan yang sama seperti PermissionMiddleware.
+func HasPermission(c *gin.Context, roleService services.RoleService, permission string) bool {
+	value, _ := c.Get("currentUser")
+	currentUser, ok := value.(*models.User)
+	return ok && hasAnyPermission(c, roleService, currentUser, []string{permission})
+}
+
+func hasAnyPermission(c *gin.Context, roleService services.RoleService, currentUser *models.User, permissions []string) bool {
+	// Request via token API juga dibatasi oleh scope token-nya
+	var apiToken *models.APIToken
+	if t, ok := c.Get("apiToken"); ok {
+		apiToken, _ = t.(*models.APIToken)
+	}
+
+	for _, perm := range permissions {
+		if apiToken != nil && !apiToken.HasScope(perm) {
+			continue
+		}
+		if roleService.HasPermission(currentUser, perm) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"simdokpol/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern membatasi X-Request-ID dari reverse proxy agar aman disimpan di log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+func newRequestID() string {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(raw)
+}
+
+// RequestContextMiddleware memberi setiap request ID unik (atau memakai
+// X-Request-ID dari reverse proxy) lalu menitipkan IP, user agent dan
+// request ID ke context request untuk log audit.
+func RequestContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		userAgent := c.Request.UserAgent()
+		if len(userAgent) > 255 {
+			userAgent = strings.ToValidUTF8(userAgent[:255], "")
+		}
+		c.Request = c.Request.WithContext(utils.WithRequestMeta(c.Request.Context(), utils.RequestMeta{
+			IPAddress: c.ClientIP(),
+			UserAgent: userAgent,
+			RequestID: requestID,
+		}))
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simdokpol/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestContextMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestContextMiddleware())
+	var captured utils.RequestMeta
+	router.GET("/api/ping", func(c *gin.Context) {
+		captured = utils.RequestMetaFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	testCases := []struct {
+		name      string
+		requestID string
+		keepID    bool
+	}{
+		{"ID Dari Proxy Dipakai", "proxy-req-0001", true},
+		{"Tanpa ID Dibuatkan", "", false},
+		{"ID Tidak Valid Diganti", "bad id\r\nX-Evil: 1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+			req.RemoteAddr = "192.168.1.20:5555"
+			req.Header.Set("User-Agent", strings.Repeat("a", 300))
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, "192.168.1.20", captured.IPAddress)
+			assert.Len(t, captured.UserAgent, 255)
+			assert.Equal(t, captured.RequestID, recorder.Header().Get(RequestIDHeader))
+			if tc.keepID {
+				assert.Equal(t, tc.requestID, captured.RequestID)
+			} else {
+				assert.NotEqual(t, tc.requestID, captured.RequestID)
+				assert.Len(t, captured.RequestID, 24)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentSecurityPolicy berlaku untuk template server dan SPA /app.
+// Semua aset (JS, CSS, font) disajikan lokal dari /static dan /app, jadi
+// sumber eksternal tidak diizinkan. 'unsafe-inline' masih dibutuhkan karena
+// template lama memakai <script> dan atribut style inline. Blob dipakai saat
+// mengunduh laporan/backup lewat URL.createObjectURL.
+var ContentSecurityPolicy = strings.Join([]string{
+	"default-src 'self'",
+	"script-src 'self' 'unsafe-inline'",
+	"style-src 'self' 'unsafe-inline'",
+	"img-src 'self' data: blob:",
+	"font-src 'self' data:",
+	"connect-src 'self'",
+	"object-src 'none'",
+	"base-uri 'self'",
+	"form-action 'self'",
+	"frame-ancestors 'none'",
+}, "; ")
+
+// hstsValue: 1 tahun. Tanpa includeSubDomains agar domain induk instansi
+// tidak ikut terpaksa HTTPS.
+const hstsValue = "max-age=31536000"
+
+// SecurityHeadersMiddleware memasang header keamanan di setiap respons.
+// HSTS hanya dikirim bila server berjalan dengan HTTPS (ENABLE_HTTPS) atau
+// request datang lewat TLS/reverse proxy HTTPS; di HTTP polos header itu
+// diabaikan browser dan bisa mengunci akses lokal.
+func SecurityHeadersMiddleware(httpsEnabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Content-Security-Policy", ContentSecurityPolicy)
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+
+		if httpsEnabled || c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+			header.Set("Strict-Transport-Security", hstsValue)
+		}
+		c.Next()
+	}
+}
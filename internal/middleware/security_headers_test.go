@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSecurityRouter(httpsEnabled bool, limits ServerLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecurityHeadersMiddleware(httpsEnabled))
+	router.Use(BodyLimitMiddleware(limits))
+	router.GET("/login", func(c *gin.Context) { c.String(http.StatusOK, "<html></html>") })
+	router.GET("/app/*filepath", func(c *gin.Context) { c.String(http.StatusOK, "<div id=\"app\"></div>") })
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	router.POST("/api/documents", echo)
+	router.POST("/api/restore", echo)
+	router.POST("/api/restore/validate", echo)
+	router.POST("/api/data/import", echo)
+	return router
+}
+
+func TestSecurityHeaders_EachHeader(t *testing.T) {
+	router := setupSecurityRouter(false, DefaultServerLimits())
+
+	for _, path := range []string{"/login", "/app/", "/app/documents/1"} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			csp := rec.Header().Get("Content-Security-Policy")
+			assert.Contains(t, csp, "default-src 'self'")
+			assert.Contains(t, csp, "frame-ancestors 'none'")
+			assert.Contains(t, csp, "object-src 'none'")
+			assert.NotContains(t, csp, "unsafe-eval")
+			assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get("Referrer-Policy"))
+			assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+			assert.Empty(t, rec.Header().Get("Strict-Transport-Security"), "HSTS tidak boleh dikirim di HTTP")
+		})
+	}
+}
+
+func TestSecurityHeaders_HSTS(t *testing.T) {
+	t.Run("HTTPS Aktif", func(t *testing.T) {
+		router := setupSecurityRouter(true, DefaultServerLimits())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+		assert.Equal(t, hstsValue, rec.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("Request TLS", func(t *testing.T) {
+		router := setupSecurityRouter(false, DefaultServerLimits())
+		req := httptest.NewRequest(http.MethodGet, "/app/", nil)
+		req.TLS = &tls.ConnectionState{}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, hstsValue, rec.Header().Get("Strict-Transport-Security"))
+	})
+
+	t.Run("Di Balik Reverse Proxy HTTPS", func(t *testing.T) {
+		router := setupSecurityRouter(false, DefaultServerLimits())
+		req := httptest.NewRequest(http.MethodGet, "/app/", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, hstsValue, rec.Header().Get("Strict-Transport-Security"))
+	})
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	limits := DefaultServerLimits()
+	limits.MaxBodyBytes = 16
+	limits.MaxUploadBytes = 64
+	router := setupSecurityRouter(false, limits)
+
+	testCases := []struct {
+		name         string
+		path         string
+		size         int
+		chunked      bool
+		expectedCode int
+	}{
+		{"Body Kecil Diterima", "/api/documents", 16, false, http.StatusOK},
+		{"Content-Length Terlalu Besar", "/api/documents", 17, false, http.StatusRequestEntityTooLarge},
+		{"Chunked Terlalu Besar", "/api/documents", 100, true, http.StatusRequestEntityTooLarge},
+		{"Restore Memakai Batas Upload", "/api/restore", 64, false, http.StatusOK},
+		{"Restore Melebihi Batas Upload", "/api/restore", 65, false, http.StatusRequestEntityTooLarge},
+		{"Validasi Restore Memakai Batas Upload", "/api/restore/validate", 64, false, http.StatusOK},
+		{"Impor Data Memakai Batas Upload", "/api/data/import", 64, false, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(strings.Repeat("a", tc.size)))
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestLoadServerLimits(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		assert.Equal(t, DefaultServerLimits(), LoadServerLimits())
+	})
+
+	t.Run("Dari Env", func(t *testing.T) {
+		t.Setenv("HTTP_READ_HEADER_TIMEOUT", "5s")
+		t.Setenv("HTTP_READ_TIMEOUT", "30")
+		t.Setenv("HTTP_WRITE_TIMEOUT", "10m")
+		t.Setenv("HTTP_IDLE_TIMEOUT", "bukan-durasi")
+		t.Setenv("HTTP_MAX_HEADER_KB", "32")
+		t.Setenv("HTTP_MAX_BODY_MB", "2")
+		t.Setenv("HTTP_MAX_UPLOAD_MB", "-1")
+
+		limits := LoadServerLimits()
+		assert.Equal(t, 5*time.Second, limits.ReadHeaderTimeout)
+		assert.Equal(t, 30*time.Second, limits.ReadTimeout)
+		assert.Equal(t, 10*time.Minute, limits.WriteTimeout)
+		assert.Equal(t, DefaultServerLimits().IdleTimeout, limits.IdleTimeout)
+		assert.Equal(t, 32<<10, limits.MaxHeaderBytes)
+		assert.Equal(t, int64(2<<20), limits.MaxBodyBytes)
+		assert.Equal(t, DefaultServerLimits().MaxUploadBytes, limits.MaxUploadBytes)
+
+		srv := &http.Server{}
+		limits.Apply(srv)
+		assert.Equal(t, 5*time.Second, srv.ReadHeaderTimeout)
+		assert.Equal(t, 30*time.Second, srv.ReadTimeout)
+		assert.Equal(t, 10*time.Minute, srv.WriteTimeout)
+		assert.Equal(t, 32<<10, srv.MaxHeaderBytes)
+	})
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServerLimits mengatur timeout dan batas ukuran request HTTP.
+// Nilai default cukup untuk pemakaian LAN; semuanya bisa diubah lewat .env.
+type ServerLimits struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// MaxBodyBytes berlaku untuk semua route kecuali uploadPaths.
+	MaxBodyBytes int64
+	// MaxUploadBytes untuk unggah file restore yang bisa jauh lebih besar.
+	MaxUploadBytes int64
+}
+
+// uploadPaths menerima file backup database atau arsip ekspor data, jadi
+// memakai MaxUploadBytes.
+var uploadPaths = map[string]bool{
+	"/api/restore":          true,
+	"/api/restore/validate": true,
+	"/api/setup/restore":    true,
+	"/api/data/import":      true,
+}
+
+// DefaultServerLimits dipakai bila env tidak diisi.
+func DefaultServerLimits() ServerLimits {
+	return ServerLimits{
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       2 * time.Minute,
+		// Generate PDF bisa lama di perangkat lambat; stream migrasi dan unduhan
+		// file besar mencabut batas ini sendiri (lihat clearWriteDeadline)
+		WriteTimeout:   5 * time.Minute,
+		IdleTimeout:    2 * time.Minute,
+		MaxHeaderBytes: 64 << 10,
+		MaxBodyBytes:   10 << 20,
+		MaxUploadBytes: 512 << 20,
+	}
+}
+
+// LoadServerLimits membaca batas dari env:
+//
+//	HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT,
+//	HTTP_IDLE_TIMEOUT  -> durasi Go (mis. "30s", "5m") atau angka detik
+//	HTTP_MAX_HEADER_KB -> ukuran header maksimum (KB)
+//	HTTP_MAX_BODY_MB, HTTP_MAX_UPLOAD_MB -> ukuran body maksimum (MB)
+//
+// Nilai yang tidak valid diabaikan dan default tetap dipakai.
+func LoadServerLimits() ServerLimits {
+	limits := DefaultServerLimits()
+	envDuration("HTTP_READ_HEADER_TIMEOUT", &limits.ReadHeaderTimeout)
+	envDuration("HTTP_READ_TIMEOUT", &limits.ReadTimeout)
+	envDuration("HTTP_WRITE_TIMEOUT", &limits.WriteTimeout)
+	envDuration("HTTP_IDLE_TIMEOUT", &limits.IdleTimeout)
+	if kb, ok := envPositiveInt("HTTP_MAX_HEADER_KB"); ok {
+		limits.MaxHeaderBytes = int(kb << 10)
+	}
+	if mb, ok := envPositiveInt("HTTP_MAX_BODY_MB"); ok {
+		limits.MaxBodyBytes = mb << 20
+	}
+	if mb, ok := envPositiveInt("HTTP_MAX_UPLOAD_MB"); ok {
+		limits.MaxUploadBytes = mb << 20
+	}
+	return limits
+}
+
+// Apply memasang timeout dan batas header ke http.Server.
+func (l ServerLimits) Apply(srv *http.Server) {
+	srv.ReadHeaderTimeout = l.ReadHeaderTimeout
+	srv.ReadTimeout = l.ReadTimeout
+	srv.WriteTimeout = l.WriteTimeout
+	srv.IdleTimeout = l.IdleTimeout
+	srv.MaxHeaderBytes = l.MaxHeaderBytes
+}
+
+func envDuration(key string, target *time.Duration) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+		*target = time.Duration(seconds) * time.Second
+		return
+	}
+	if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+		*target = parsed
+		return
+	}
+	log.Printf("WARN: nilai %s=%q tidak valid, memakai default %s", key, raw, *target)
+}
+
+func envPositiveInt(key string) (int64, bool) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value <= 0 {
+		log.Printf("WARN: nilai %s=%q tidak valid, memakai default", key, raw)
+		return 0, false
+	}
+	return value, true
+}
+
+// BodyLimitMiddleware membatasi ukuran body di semua route. Request dengan
+// Content-Length melebihi batas langsung ditolak 413; body tanpa
+// Content-Length (chunked) dipotong oleh http.MaxBytesReader.
+func BodyLimitMiddleware(limits ServerLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := limits.MaxBodyBytes
+		if uploadPaths[c.Request.URL.Path] {
+			limit = limits.MaxUploadBytes
+		}
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Ukuran data melebihi batas yang diizinkan"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APITokenRepository struct {
+	mock.Mock
+}
+
+func (_m *APITokenRepository) Create(token *models.APIToken) error {
+	ret := _m.Called(token)
+	return ret.Error(0)
+}
+
+func (_m *APITokenRepository) FindByID(id uint) (*models.APIToken, error) {
+	ret := _m.Called(id)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.APIToken), ret.Error(1)
+}
+
+func (_m *APITokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+	ret := _m.Called(hash)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.APIToken), ret.Error(1)
+}
+
+func (_m *APITokenRepository) FindByUser(userID uint) ([]models.APIToken, error) {
+	ret := _m.Called(userID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.APIToken), ret.Error(1)
+}
+
+func (_m *APITokenRepository) FindAll() ([]models.APIToken, error) {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.APIToken), ret.Error(1)
+}
+
+func (_m *APITokenRepository) Revoke(id uint, at time.Time) error {
+	ret := _m.Called(id, at)
+	return ret.Error(0)
+}
+
+func (_m *APITokenRepository) TouchLastUsed(id uint, at time.Time, ip string, notSince time.Time) (bool, error) {
+	ret := _m.Called(id, at, ip, notSince)
+	return ret.Bool(0), ret.Error(1)
+}
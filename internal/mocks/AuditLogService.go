@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"bytes"
+	"context"
 	"simdokpol/internal/dto" // <-- Pastikan import DTO ada
 	"simdokpol/internal/models"
 	"sync"
@@ -21,6 +22,10 @@ func (_m *AuditLogService) LogActivity(userID uint, action string, details strin
 	_m.Called(userID, action, details)
 }
 
+func (_m *AuditLogService) LogEvent(ctx context.Context, event dto.AuditEvent) {
+	_m.Called(ctx, event)
+}
+
 func (_m *AuditLogService) FindAll() ([]models.AuditLog, error) {
 	ret := _m.Called()
 	if ret.Get(0) == nil {
@@ -29,9 +34,17 @@ func (_m *AuditLogService) FindAll() ([]models.AuditLog, error) {
 	return ret.Get(0).([]models.AuditLog), ret.Error(1)
 }
 
+func (_m *AuditLogService) FindEntityHistory(entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	ret := _m.Called(entityType, entityID, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditLog), ret.Error(1)
+}
+
 // --- TAMBAHAN BARU (FIX ERROR INTERFACE) ---
-func (_m *AuditLogService) GetAuditLogsPaged(req dto.DataTableRequest) (*dto.DataTableResponse, error) {
-	ret := _m.Called(req)
+func (_m *AuditLogService) GetAuditLogsPaged(req dto.DataTableRequest, filter dto.AuditLogFilter) (*dto.DataTableResponse, error) {
+	ret := _m.Called(req, filter)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
@@ -45,4 +58,79 @@ func (_m *AuditLogService) ExportAuditLogs() (*bytes.Buffer, string, error) {
 		return nil, ret.String(1), ret.Error(2)
 	}
 	return ret.Get(0).(*bytes.Buffer), ret.String(1), ret.Error(2)
-}
\ No newline at end of file
+}
+
+func (_m *AuditLogService) VerifyChain() (*dto.AuditChainReport, error) {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.AuditChainReport), ret.Error(1)
+}
+
+func (_m *AuditLogService) Close(ctx context.Context) error {
+	ret := _m.Called(ctx)
+	return ret.Error(0)
+}
+
+func (_m *AuditLogService) ConfigureSinks(config *dto.AppConfig) {
+	_m.Called(config)
+}
+
+func (_m *AuditLogService) SinkStatus() []dto.AuditSinkStatus {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil
+	}
+	return ret.Get(0).([]dto.AuditSinkStatus)
+}
+
+func (_m *AuditLogService) WriterStats() dto.AuditWriterStats {
+	ret := _m.Called()
+	return ret.Get(0).(dto.AuditWriterStats)
+}
+
+func (_m *AuditLogService) ApplyRetention(ctx context.Context, retentionDays int, actorID uint) (*dto.AuditRetentionResult, error) {
+	ret := _m.Called(retentionDays, actorID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.AuditRetentionResult), ret.Error(1)
+}
+
+func (_m *AuditLogService) ListArchives() ([]models.AuditArchive, error) {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditArchive), ret.Error(1)
+}
+
+func (_m *AuditLogService) SearchArchive(id uint, req dto.DataTableRequest) (*dto.DataTableResponse, error) {
+	ret := _m.Called(id, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.DataTableResponse), ret.Error(1)
+}
+
+func (_m *AuditLogService) FindArchivedEntityHistory(entityType, entityID string, limit int) ([]dto.AuditArchiveEntry, []models.AuditArchive, error) {
+	ret := _m.Called(entityType, entityID, limit)
+	var entries []dto.AuditArchiveEntry
+	if ret.Get(0) != nil {
+		entries = ret.Get(0).([]dto.AuditArchiveEntry)
+	}
+	var unreadable []models.AuditArchive
+	if ret.Get(1) != nil {
+		unreadable = ret.Get(1).([]models.AuditArchive)
+	}
+	return entries, unreadable, ret.Error(2)
+}
+
+func (_m *AuditLogService) GetArchiveFile(id uint) (*models.AuditArchive, string, error) {
+	ret := _m.Called(id)
+	if ret.Get(0) == nil {
+		return nil, ret.String(1), ret.Error(2)
+	}
+	return ret.Get(0).(*models.AuditArchive), ret.String(1), ret.Error(2)
+}
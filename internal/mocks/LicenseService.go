@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/models"
 
 	"github.com/stretchr/testify/mock"
@@ -10,7 +11,7 @@ type LicenseService struct {
 	mock.Mock
 }
 
-func (_m *LicenseService) ActivateLicense(key string, actorID uint) (*models.License, error) {
+func (_m *LicenseService) ActivateLicense(ctx context.Context, key string, actorID uint) (*models.License, error) {
 	ret := _m.Called(key, actorID)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
@@ -36,8 +36,8 @@ func (m *LostDocumentRepository) FindAll(query string, statusFilter string, arch
 }
 
 // FIX: Update Signature (Tambah userID & userRole)
-func (m *LostDocumentRepository) FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, userID uint, userRole string) ([]models.LostDocument, int64, int64, error) {
-	args := m.Called(req, statusFilter, archiveDurationDays, userID, userRole)
+func (m *LostDocumentRepository) FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, scope repositories.DocumentScope) ([]models.LostDocument, int64, int64, error) {
+	args := m.Called(req, statusFilter, archiveDurationDays, scope)
 	return args.Get(0).([]models.LostDocument), args.Get(1).(int64), args.Get(2).(int64), args.Error(3)
 }
 
@@ -92,6 +92,16 @@ func (m *LostDocumentRepository) FindAllExpiringDocuments(start time.Time, end t
 	args := m.Called(start, end)
 	return args.Get(0).([]models.LostDocument), args.Error(1)
 }
+
+func (m *LostDocumentRepository) FindExpiringDocumentsForRegu(regu string, start time.Time, end time.Time) ([]models.LostDocument, error) {
+	args := m.Called(regu, start, end)
+	return args.Get(0).([]models.LostDocument), args.Error(1)
+}
+
+func (m *LostDocumentRepository) FindActiveByRegu(regu string, since time.Time) ([]models.LostDocument, error) {
+	args := m.Called(regu, since)
+	return args.Get(0).([]models.LostDocument), args.Error(1)
+}
 // ------------------------------------------------
 
 func (m *LostDocumentRepository) GetItemCompositionStatsInRange(start time.Time, end time.Time) ([]dto.ItemCompositionStat, error) {
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RoleRepository struct {
+	mock.Mock
+}
+
+func (_m *RoleRepository) FindAll() ([]models.Role, error) {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.Role), ret.Error(1)
+}
+
+func (_m *RoleRepository) FindByName(nama string) (*models.Role, error) {
+	ret := _m.Called(nama)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.Role), ret.Error(1)
+}
+
+func (_m *RoleRepository) Save(role *models.Role) error {
+	ret := _m.Called(role)
+	return ret.Error(0)
+}
+
+func (_m *RoleRepository) Delete(nama string) error {
+	ret := _m.Called(nama)
+	return ret.Error(0)
+}
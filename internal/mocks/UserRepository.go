@@ -139,4 +139,8 @@ func (_m *UserRepository) CountAll() (int64, error) {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
-}
\ No newline at end of file
+}
+func (_m *UserRepository) CountByRole(peran string) (int64, error) {
+	ret := _m.Called(peran)
+	return ret.Get(0).(int64), ret.Error(1)
+}
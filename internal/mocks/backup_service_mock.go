@@ -2,7 +2,11 @@
 package mocks
 
 import (
+	"context"
 	"io"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,12 +16,103 @@ type BackupService struct {
 	mock.Mock
 }
 
-func (m *BackupService) CreateBackup(actorID uint) (string, error) {
-	args := m.Called(actorID)
+func (m *BackupService) CreateBackup(ctx context.Context, method string, actorID uint) (string, error) {
+	args := m.Called(method, actorID)
 	return args.String(0), args.Error(1)
 }
 
-func (m *BackupService) RestoreBackup(uploadedFile io.Reader, actorID uint) error {
-	args := m.Called(uploadedFile, actorID)
+func (m *BackupService) RestoreBackup(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey, actorID uint) error {
+	args := m.Called(uploadedFile, key, actorID)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+
+func (m *BackupService) ValidateRestore(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey) (*dto.RestorePreview, error) {
+	args := m.Called(uploadedFile, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RestorePreview), args.Error(1)
+}
+
+func (m *BackupService) ConfirmRestore(ctx context.Context, token string, actorID uint) error {
+	args := m.Called(token, actorID)
+	return args.Error(0)
+}
+
+func (m *BackupService) DiscardRestore(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *BackupService) Capabilities() dto.BackupCapabilities {
+	args := m.Called()
+	return args.Get(0).(dto.BackupCapabilities)
+}
+
+func (m *BackupService) ListBackups() ([]dto.BackupFile, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.BackupFile), args.Error(1)
+}
+
+func (m *BackupService) DownloadBackup(ctx context.Context, name string, actorID uint) (string, error) {
+	args := m.Called(name, actorID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *BackupService) VerifyBackup(ctx context.Context, name string, key *dto.BackupKey) (*dto.BackupVerification, error) {
+	args := m.Called(name, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BackupVerification), args.Error(1)
+}
+
+func (m *BackupService) DeleteBackup(ctx context.Context, name string, actorID uint) error {
+	args := m.Called(name, actorID)
+	return args.Error(0)
+}
+
+func (m *BackupService) ValidateStoredRestore(ctx context.Context, name string, key *dto.BackupKey) (*dto.RestorePreview, error) {
+	args := m.Called(name, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RestorePreview), args.Error(1)
+}
+
+func (m *BackupService) TestOffsiteDestination(ctx context.Context, settings map[string]string) (string, error) {
+	args := m.Called(settings)
+	return args.String(0), args.Error(1)
+}
+
+func (m *BackupService) RunDueBackup(ctx context.Context, now time.Time, actorID uint) (*models.BackupRun, error) {
+	args := m.Called(now, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BackupRun), args.Error(1)
+}
+
+func (m *BackupService) ListRuns(limit int) ([]models.BackupRun, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BackupRun), args.Error(1)
+}
+
+func (m *BackupService) Status() (*dto.BackupStatus, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BackupStatus), args.Error(1)
+}
+
+func (m *BackupService) Close(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"bytes"
+	"context"
 	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
 
@@ -12,7 +13,15 @@ type LostDocumentService struct {
 	mock.Mock
 }
 
-func (m *LostDocumentService) CreateLostDocument(residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
+func (m *LostDocumentService) GetShiftHandover(ctx context.Context, regu string, actor *models.User) ([]models.LostDocument, error) {
+	args := m.Called(regu, actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LostDocument), args.Error(1)
+}
+
+func (m *LostDocumentService) CreateLostDocument(ctx context.Context, residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
 	args := m.Called(residentData, items, operatorID, lokasiHilang, petugasPelaporID, pejabatPersetujuID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -20,7 +29,7 @@ func (m *LostDocumentService) CreateLostDocument(residentData models.Resident, i
 	return args.Get(0).(*models.LostDocument), args.Error(1)
 }
 
-func (m *LostDocumentService) UpdateLostDocument(docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error) {
+func (m *LostDocumentService) UpdateLostDocument(ctx context.Context, docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error) {
 	args := m.Called(docID, residentData, items, lokasiHilang, petugasPelaporID, pejabatPersetujuID, loggedInUserID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -38,7 +47,7 @@ func (m *LostDocumentService) SearchGlobal(query string, limit int) ([]models.Lo
 	return args.Get(0).([]models.LostDocument), args.Error(1)
 }
 
-func (m *LostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocument, error) {
+func (m *LostDocumentService) FindByID(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error) {
 	args := m.Called(id, actorID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -46,20 +55,36 @@ func (m *LostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocum
 	return args.Get(0).(*models.LostDocument), args.Error(1)
 }
 
-func (m *LostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) error {
+func (m *LostDocumentService) FindForPrint(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error) {
+	args := m.Called(id, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LostDocument), args.Error(1)
+}
+
+func (m *LostDocumentService) ResidentAccessReport(nik string) (*dto.ResidentAccessReport, error) {
+	args := m.Called(nik)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.ResidentAccessReport), args.Error(1)
+}
+
+func (m *LostDocumentService) DeleteLostDocument(ctx context.Context, id uint, loggedInUserID uint) error {
 	args := m.Called(id, loggedInUserID)
 	return args.Error(0)
 }
 
-func (m *LostDocumentService) ExportDocuments(query string, statusFilter string) (*bytes.Buffer, string, error) {
-	args := m.Called(query, statusFilter)
+func (m *LostDocumentService) ExportDocuments(ctx context.Context, query string, statusFilter string, actorID uint) (*bytes.Buffer, string, error) {
+	args := m.Called(query, statusFilter, actorID)
 	if args.Get(0) == nil {
 		return nil, "", args.Error(2)
 	}
 	return args.Get(0).(*bytes.Buffer), args.String(1), args.Error(2)
 }
 
-func (m *LostDocumentService) GenerateDocumentPDF(docID uint, actorID uint) (*bytes.Buffer, string, error) {
+func (m *LostDocumentService) GenerateDocumentPDF(ctx context.Context, docID uint, actorID uint) (*bytes.Buffer, string, error) {
 	args := m.Called(docID, actorID)
 	if args.Get(0) == nil {
 		return nil, "", args.Error(2)
@@ -68,8 +93,8 @@ func (m *LostDocumentService) GenerateDocumentPDF(docID uint, actorID uint) (*by
 }
 
 // --- FIX DISINI: Update Signature menjadi 4 Parameter ---
-func (m *LostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, userID uint, userRole string) (*dto.DataTableResponse, error) {
-	args := m.Called(req, statusFilter, userID, userRole)
+func (m *LostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, actor *models.User) (*dto.DataTableResponse, error) {
+	args := m.Called(req, statusFilter, actor)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
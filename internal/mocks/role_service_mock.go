@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RoleService struct {
+	mock.Mock
+}
+
+func (m *RoleService) FindAll() ([]models.Role, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *RoleService) FindByName(nama string) (*models.Role, error) {
+	args := m.Called(nama)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *RoleService) Save(role *models.Role, actorID uint) error {
+	args := m.Called(role, actorID)
+	return args.Error(0)
+}
+
+func (m *RoleService) Delete(nama string, actorID uint) error {
+	args := m.Called(nama, actorID)
+	return args.Error(0)
+}
+
+func (m *RoleService) HasPermission(user *models.User, permission string) bool {
+	args := m.Called(user, permission)
+	return args.Bool(0)
+}
+
+func (m *RoleService) GetPermissions(user *models.User) []string {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
+func (m *RoleService) AvailablePermissions() []models.PermissionInfo {
+	args := m.Called()
+	return args.Get(0).([]models.PermissionInfo)
+}
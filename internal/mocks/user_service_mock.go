@@ -2,6 +2,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/dto" // <-- Pastikan import DTO
 	"simdokpol/internal/models"
 
@@ -13,12 +14,12 @@ type UserService struct {
 	mock.Mock
 }
 
-func (m *UserService) Create(user *models.User, actorID uint) error {
+func (m *UserService) Create(ctx context.Context, user *models.User, actorID uint) error {
 	args := m.Called(user, actorID)
 	return args.Error(0)
 }
 
-func (m *UserService) UpdateProfile(userID uint, user *models.User) (*models.User, error) {
+func (m *UserService) UpdateProfile(ctx context.Context, userID uint, user *models.User) (*models.User, error) {
 	args := m.Called(userID, user)
 	if u := args.Get(0); u != nil {
 		if usr, ok := u.(*models.User); ok {
@@ -68,22 +69,22 @@ func (m *UserService) FindOperators() ([]models.User, error) {
 	return nil, args.Error(1)
 }
 
-func (m *UserService) Update(user *models.User, newPassword string, actorID uint) error {
+func (m *UserService) Update(ctx context.Context, user *models.User, newPassword string, actorID uint) error {
 	args := m.Called(user, newPassword, actorID)
 	return args.Error(0)
 }
 
-func (m *UserService) Deactivate(id uint, actorID uint) error {
+func (m *UserService) Deactivate(ctx context.Context, id uint, actorID uint) error {
 	args := m.Called(id, actorID)
 	return args.Error(0)
 }
 
-func (m *UserService) Activate(id uint, actorID uint) error {
+func (m *UserService) Activate(ctx context.Context, id uint, actorID uint) error {
 	args := m.Called(id, actorID)
 	return args.Error(0)
 }
 
-func (m *UserService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+func (m *UserService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
 	args := m.Called(userID, oldPassword, newPassword)
 	return args.Error(0)
 }
\ No newline at end of file
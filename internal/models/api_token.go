@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Scope dasar token API. Selain dua scope ini, token juga bisa membawa
+// key izin (mis. "report:view") yang dimiliki pemiliknya.
+const (
+	ScopeAPIRead  = "api:read"
+	ScopeAPIWrite = "api:write"
+
+	// APITokenPrefix menandai token pribadi agar mudah dikenali (dan dipindai bila bocor)
+	APITokenPrefix = "sdp_"
+)
+
+// APIToken adalah token pribadi untuk akses skrip/integrasi ke /api.
+// Nilai token asli hanya ditampilkan sekali saat dibuat; yang disimpan hanya hash-nya.
+type APIToken struct {
+	ID         uint            `gorm:"primarykey" json:"id"`
+	UserID     uint            `gorm:"not null;index" json:"user_id"`
+	User       User            `gorm:"foreignKey:UserID" json:"user"`
+	Nama       string          `gorm:"size:100;not null" json:"nama"`
+	Prefix     string          `gorm:"size:16;not null" json:"prefix"`
+	TokenHash  string          `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     JSONStringArray `gorm:"type:text" json:"scopes"`
+	ExpiresAt  *time.Time      `json:"expires_at"`
+	LastUsedAt *time.Time      `json:"last_used_at"`
+	LastUsedIP string          `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt  *time.Time      `json:"revoked_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// HasScope mengecek apakah token membawa scope tertentu
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable: token belum dicabut dan belum kedaluwarsa
+func (t *APIToken) IsUsable(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
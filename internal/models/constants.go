@@ -4,6 +4,66 @@ package models
 const (
 	RoleSuperAdmin = "SUPER_ADMIN"
 	RoleOperator   = "OPERATOR"
+	RolePimpinan   = "PIMPINAN"
+	RoleKanit      = "KANIT"
+	RoleAdminTU    = "ADMIN_TU"
+)
+
+// Konstanta untuk Izin (Permission) Peran
+const (
+	PermDocumentCreate    = "document:create"
+	PermDocumentReadAll   = "document:read_all"
+	PermDocumentRegu      = "document:regu"
+	PermDocumentEditAll   = "document:edit_all"
+	PermDocumentDeleteAll = "document:delete_all"
+	PermDocumentExport    = "document:export"
+	PermReportView        = "report:view"
+	PermTemplateManage    = "template:manage"
+	PermUserManage        = "user:manage"
+	PermRoleManage        = "role:manage"
+	PermSettingsManage    = "settings:manage"
+	PermDatabaseManage    = "database:manage"
+	PermAuditView         = "audit:view"
+	PermLicenseManage     = "license:manage"
+)
+
+// AllPermissions adalah katalog izin yang dikenal sistem (urutan tampil di UI)
+var AllPermissions = []PermissionInfo{
+	{Key: PermDocumentCreate, Label: "Membuat surat baru", Kelompok: "Dokumen"},
+	{Key: PermDocumentReadAll, Label: "Melihat & mencetak semua surat", Kelompok: "Dokumen"},
+	{Key: PermDocumentRegu, Label: "Melihat, mengubah & mencetak surat satu regu", Kelompok: "Dokumen"},
+	{Key: PermDocumentEditAll, Label: "Mengubah surat milik petugas lain", Kelompok: "Dokumen"},
+	{Key: PermDocumentDeleteAll, Label: "Menghapus surat milik petugas lain", Kelompok: "Dokumen"},
+	{Key: PermDocumentExport, Label: "Ekspor data surat ke Excel", Kelompok: "Dokumen"},
+	{Key: PermReportView, Label: "Melihat laporan agregat", Kelompok: "Laporan"},
+	{Key: PermTemplateManage, Label: "Mengelola template barang", Kelompok: "Master Data"},
+	{Key: PermUserManage, Label: "Mengelola pengguna & jabatan", Kelompok: "Master Data"},
+	{Key: PermRoleManage, Label: "Mengelola peran & hak akses", Kelompok: "Sistem"},
+	{Key: PermSettingsManage, Label: "Mengubah pengaturan sistem", Kelompok: "Sistem"},
+	{Key: PermDatabaseManage, Label: "Backup, restore & migrasi database", Kelompok: "Sistem"},
+	{Key: PermAuditView, Label: "Melihat & ekspor log audit", Kelompok: "Sistem"},
+	{Key: PermLicenseManage, Label: "Aktivasi lisensi", Kelompok: "Sistem"},
+}
+
+// IsKnownPermission mengecek apakah key izin terdaftar di katalog
+func IsKnownPermission(key string) bool {
+	for _, p := range AllPermissions {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Sumber autentikasi akun pengguna
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap"
+	AuthSourceOIDC  = "oidc"
+	// Akun aktor sistem untuk perintah CLI admin; tidak bisa login dan
+	// tidak tampil di daftar pengguna
+	AuthSourceSystem = "system"
+	SystemUserNRP    = "SISTEM"
 )
 
 // Konstanta untuk Status Dokumen
@@ -24,5 +84,58 @@ const (
 	AuditSystemSetup     = "SETUP SISTEM"
 	AuditBackupCreated   = "BUAT BACKUP"
 	AuditRestoreFromFile = "PULIHKAN DARI FILE"
+	AuditRestoreRolledBack = "RESTORE DIBATALKAN"
+	AuditBackupPruned    = "HAPUS BACKUP LAMA"
+	AuditBackupDeleted   = "HAPUS BACKUP"
+	AuditBackupDownloaded = "UNDUH BACKUP"
+	AuditBackupReplicated = "KIRIM BACKUP OFF-SITE"
 	AuditSettingsUpdated = "PERBARUI PENGATURAN"
-)
\ No newline at end of file
+	AuditDataMigrated    = "MIGRASI DATABASE"
+	AuditSchemaMigrated  = "MIGRASI SKEMA"
+	AuditSearchReindexed = "REINDEX PENCARIAN"
+	AuditDataExported    = "EKSPOR DATA"
+	AuditDataImported    = "IMPOR DATA"
+	AuditRoleUpdated     = "PERBARUI PERAN"
+	AuditRoleDeleted     = "HAPUS PERAN"
+	AuditShiftHandover   = "SERAH TERIMA REGU"
+	AuditTokenCreated    = "BUAT TOKEN API"
+	AuditTokenRevoked    = "CABUT TOKEN API"
+	AuditTokenUsed       = "PAKAI TOKEN API"
+	AuditSSOLogin        = "LOGIN SSO"
+	AuditLicenseActivated = "AKTIVASI LISENSI"
+	AuditLicenseFailed    = "GAGAL AKTIVASI"
+	AuditLogArchived      = "ARSIP LOG AUDIT"
+	// Akses baca data pribadi penduduk (UU PDP)
+	AuditViewResident     = "LIHAT DATA PENDUDUK"
+	AuditPrintDocument    = "CETAK DOKUMEN"
+	AuditDownloadPDF      = "UNDUH PDF DOKUMEN"
+	AuditExportResidents  = "EKSPOR DATA PENDUDUK"
+)
+
+// Jenis pemicu dan status riwayat backup (BackupRun)
+const (
+	BackupKindManual    = "manual"
+	BackupKindScheduled = "scheduled"
+	BackupStatusSuccess = "BERHASIL"
+	BackupStatusFailed  = "GAGAL"
+
+	// Status salinan off-site (BackupRun.OffsiteStatus)
+	BackupOffsiteUploaded = "TERKIRIM"
+	BackupOffsiteFailed   = "GAGAL"
+	BackupOffsiteRemoved  = "DIHAPUS"
+)
+
+// SchemaVersion adalah nomor migrasi skema terbaru (folder migrations/).
+// Naikkan bersama file migrasi baru; dicatat di header backup terenkripsi.
+const SchemaVersion = 15
+
+// Jenis entitas yang dirujuk log audit (kolom entity_type)
+const (
+	AuditEntityDocument = "document"
+	AuditEntityUser     = "user"
+	AuditEntitySetting  = "setting"
+	AuditEntityLicense  = "license"
+	AuditEntityBackup   = "backup"
+	AuditEntityAuditArchive = "audit_archive"
+	AuditEntityResident     = "resident"
+)
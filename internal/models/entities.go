@@ -29,6 +29,7 @@ type User struct {
 	Peran       string         `gorm:"size:50;not null;default:'OPERATOR'" json:"peran"`
 	Jabatan     string         `gorm:"size:100" json:"jabatan"`
 	Regu        string         `gorm:"size:10" json:"regu"`
+	AuthSource  string         `gorm:"size:20;not null;default:'local'" json:"auth_source,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -97,6 +98,127 @@ type AuditLog struct {
 	Aksi      string    `gorm:"size:255;not null"`
 	Detail    string    `gorm:"type:text"`
 	Timestamp time.Time `gorm:"not null"`
+	// Referensi objek yang terdampak, mis. EntityType "document" + EntityID "12"
+	EntityType string `gorm:"size:50;index:idx_audit_logs_entity"`
+	EntityID   string `gorm:"size:64;index:idx_audit_logs_entity"`
+	IPAddress  string `gorm:"size:64"`
+	UserAgent  string `gorm:"size:255"`
+	RequestID  string `gorm:"size:64;index"`
+	// Snapshot JSON sebelum/sesudah perubahan (kosong bila tidak relevan)
+	Before string `gorm:"type:text"`
+	After  string `gorm:"type:text"`
+	// PrevHash dan Hash membentuk rantai: mengubah/menghapus satu entri
+	// memutus hash entri sesudahnya. Kosong untuk entri sebelum fitur ini.
+	PrevHash string `gorm:"size:64"`
+	Hash     string `gorm:"size:64;index"`
+}
+
+// AuditCheckpoint menyegel kepala rantai log audit secara berkala dengan
+// tanda tangan HMAC (kunci di .env, bukan di database), sehingga rantai
+// yang disusun ulang oleh orang yang hanya punya akses DB tetap ketahuan.
+type AuditCheckpoint struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	LastLogID uint      `gorm:"not null;index" json:"last_log_id"`
+	LastHash  string    `gorm:"size:64;not null" json:"last_hash"`
+	Signature string    `gorm:"size:64;not null" json:"signature"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+// AuditArchive mencatat satu file arsip log audit (gzip JSON lines) yang
+// entrinya sudah dihapus dari database. Baris terakhir menjadi jangkar rantai
+// hash bagi entri yang tersisa; tanda tangannya berantai seperti checkpoint.
+type AuditArchive struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Period     string    `gorm:"size:7;not null;index" json:"period"` // YYYY-MM
+	FileName   string    `gorm:"size:255;not null" json:"file_name"`
+	FirstLogID uint      `gorm:"not null" json:"first_log_id"`
+	LastLogID  uint      `gorm:"not null;uniqueIndex" json:"last_log_id"`
+	EntryCount int       `gorm:"not null" json:"entry_count"`
+	PrevHash   string    `gorm:"size:64" json:"prev_hash"` // hash entri sebelum FirstLogID
+	LastHash   string    `gorm:"size:64" json:"last_hash"`
+	FileSHA256 string    `gorm:"column:file_sha256;size:64;not null" json:"file_sha256"`
+	Signature  string    `gorm:"size:64;not null" json:"signature"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+// BackupRun mencatat satu kali proses backup (manual atau terjadwal) beserta
+// hasilnya. PrunedAt terisi saat file-nya dihapus oleh kebijakan retensi.
+// Kolom Offsite* mencatat salinan di tujuan luar (SFTP/S3/folder jaringan).
+type BackupRun struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	Kind       string     `gorm:"size:20;not null;index" json:"kind"` // manual / scheduled
+	Method     string     `gorm:"size:20;not null" json:"method"`
+	Status     string     `gorm:"size:20;not null;index" json:"status"`
+	FileName   string     `gorm:"size:255" json:"file_name"`
+	FilePath   string     `gorm:"size:1024" json:"-"`
+	SizeBytes  int64      `json:"size_bytes"`
+	DurationMs int64      `json:"duration_ms"`
+	SHA256     string     `gorm:"column:sha256;size:64" json:"sha256"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	ActorID    uint       `json:"actor_id"` // backup terjadwal: akun sistem
+	StartedAt  time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt time.Time  `gorm:"not null" json:"finished_at"`
+	PrunedAt   *time.Time `json:"pruned_at"`
+
+	OffsiteStatus   string     `gorm:"size:20;index" json:"offsite_status,omitempty"` // kosong = replikasi nonaktif
+	OffsiteTarget   string     `gorm:"size:255" json:"offsite_target,omitempty"`
+	OffsiteAttempts int        `json:"offsite_attempts,omitempty"`
+	OffsiteError    string     `gorm:"type:text" json:"offsite_error,omitempty"`
+	OffsiteAt       *time.Time `json:"offsite_at,omitempty"`
+}
+
+// SchemaMigration mencatat file migrasi SQL (folder migrations/) yang sudah
+// dijalankan. Dirty tertinggal true bila migrasi MySQL gagal di tengah jalan
+// (DDL MySQL tidak bisa di-rollback) dan harus diperbaiki manual.
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	Dirty     bool      `gorm:"not null;default:false" json:"dirty"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// SchemaMigrationLock adalah kunci migrasi untuk SQLite (MySQL/PostgreSQL
+// memakai advisory lock). Baris yang terlalu lama dianggap sisa proses mati.
+type SchemaMigrationLock struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:64;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+// ServerInstance detak server yang sedang berjalan, diperbarui berkala dan
+// dihapus saat server berhenti. `admin restore` menolak jalan selama masih
+// ada detak yang segar di database yang sama.
+type ServerInstance struct {
+	ID          string    `gorm:"primaryKey;size:32" json:"id"`
+	Hostname    string    `gorm:"size:255" json:"hostname"`
+	PID         int       `gorm:"column:pid" json:"pid"`
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+	HeartbeatAt time.Time `gorm:"not null;index" json:"heartbeat_at"`
+}
+
+// SessionRevocation sesi SSO yang sudah di-logout oleh IdP (back-channel
+// logout). SessionKey berisi "sid:<sid>" atau "sub:<sub>"; semua JWT dengan
+// kunci itu yang terbit sebelum RevokedAt ditolak. Baris dihapus setelah
+// ExpiresAt, saat JWT tersebut sudah kedaluwarsa dengan sendirinya.
+type SessionRevocation struct {
+	SessionKey string    `gorm:"primaryKey;size:191" json:"session_key"`
+	RevokedAt  time.Time `gorm:"not null" json:"revoked_at"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// DataMigrationProgress mencatat sejauh mana migrasi data sudah tersalin ke
+// database target. Tabel ini hanya ada di target selama migrasi belum
+// terverifikasi, sehingga migrasi yang terputus bisa dilanjutkan.
+type DataMigrationProgress struct {
+	Name      string `gorm:"primaryKey;size:100"` // nama tabel
+	LastID    uint   // ID terakhir yang tersalin (tabel ber-ID angka)
+	Rows      int64
+	Done      bool `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (DataMigrationProgress) TableName() string {
+	return "data_migration_progress"
 }
 
 // JobPosition master data jabatan
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// JSONStringArray menyimpan daftar string sebagai JSON di kolom teks
+type JSONStringArray []string
+
+// Value mengonversi slice string menjadi JSON untuk disimpan di DB
+func (j JSONStringArray) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan mengonversi JSON dari DB kembali menjadi slice string
+func (j *JSONStringArray) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*j = JSONStringArray{}
+		return nil
+	default:
+		return errors.New("tipe data tidak didukung untuk JSONStringArray")
+	}
+
+	if len(b) == 0 {
+		*j = JSONStringArray{}
+		return nil
+	}
+	return json.Unmarshal(b, j)
+}
+
+// Role merepresentasikan peran pengguna beserta izin yang dimilikinya.
+// Nama peran disimpan di kolom User.Peran.
+type Role struct {
+	Nama        string          `gorm:"primaryKey;size:50" json:"nama"`
+	Deskripsi   string          `gorm:"size:255" json:"deskripsi"`
+	Permissions JSONStringArray `gorm:"type:text" json:"permissions"`
+	IsSystem    bool            `gorm:"not null;default:false" json:"is_system"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// HasPermission mengecek apakah peran memiliki izin tertentu
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionInfo dipakai UI untuk menampilkan daftar izin yang bisa dipilih
+type PermissionInfo struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Kelompok string `json:"kelompok"`
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APITokenRepository interface {
+	Create(token *models.APIToken) error
+	FindByID(id uint) (*models.APIToken, error)
+	FindByHash(hash string) (*models.APIToken, error)
+	FindByUser(userID uint) ([]models.APIToken, error)
+	FindAll() ([]models.APIToken, error)
+	Revoke(id uint, at time.Time) error
+	TouchLastUsed(id uint, at time.Time, ip string, notSince time.Time) (bool, error)
+}
+
+type apiTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+func (r *apiTokenRepository) Create(token *models.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *apiTokenRepository) FindByID(id uint) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) FindByHash(hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) FindByUser(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *apiTokenRepository) FindAll() ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := r.db.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at desc").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *apiTokenRepository) Revoke(id uint, at time.Time) error {
+	return r.db.Model(&models.APIToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at).Error
+}
+
+// TouchLastUsed hanya memperbarui bila last_used_at kosong atau lebih tua dari
+// notSince, dan mengembalikan true bila baris benar-benar diperbarui. Karena
+// syaratnya dicek di UPDATE itu sendiri, request serentak (juga dari server
+// lain) hanya satu yang menang.
+func (r *apiTokenRepository) TouchLastUsed(id uint, at time.Time, ip string, notSince time.Time) (bool, error) {
+	result := r.db.Model(&models.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, notSince).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip})
+	return result.RowsAffected > 0, result.Error
+}
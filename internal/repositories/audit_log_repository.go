@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"simdokpol/internal/dto" // Import DTO
 	"simdokpol/internal/models"
@@ -10,15 +12,47 @@ import (
 
 type AuditLogRepository interface {
 	Create(log *models.AuditLog) error
+	// CreateBatch menyimpan beberapa entri dalam satu INSERT (semua atau tidak sama sekali)
+	CreateBatch(logs []*models.AuditLog) error
 	// Ubah FindAll jadi FindAllPaged
-	FindAllPaged(req dto.DataTableRequest) ([]models.AuditLog, int64, int64, error)
+	FindAllPaged(req dto.DataTableRequest, filter dto.AuditLogFilter) ([]models.AuditLog, int64, int64, error)
 	FindAll() ([]models.AuditLog, error) // Tetap ada untuk Export Excel
+	// FindByEntity: riwayat satu entitas, terbaru dulu (limit <= 0 = semua)
+	FindByEntity(entityType, entityID string, limit int) ([]models.AuditLog, error)
+
+	// Rantai hash & checkpoint
+	// WithChainLock menjalankan fn dalam transaksi yang memegang kunci rantai
+	// di database, sehingga instance lain yang memakai database yang sama
+	// menunggu dan tidak menyambung ke kepala rantai yang sama.
+	WithChainLock(fn func(repo AuditLogRepository) error) error
+	FindLast() (*models.AuditLog, error)
+	FindByID(id uint) (*models.AuditLog, error)
+	FindBatchAfter(afterID uint, limit int) ([]models.AuditLog, error)
+	CreateCheckpoint(checkpoint *models.AuditCheckpoint) error
+	FindLastCheckpoint() (*models.AuditCheckpoint, error)
+	FindCheckpoints() ([]models.AuditCheckpoint, error)
+
+	// Retensi & arsip
+	FindBatchForArchive(afterID uint, limit int) ([]models.AuditLog, error)
+	ArchiveRange(archive *models.AuditArchive) error
+	FindArchives() ([]models.AuditArchive, error)
+	FindArchiveByID(id uint) (*models.AuditArchive, error)
+	FindLastArchive() (*models.AuditArchive, error)
 }
 
 type auditLogRepository struct {
 	db *gorm.DB
 }
 
+const (
+	auditChainAdvisoryLockID = 7361368 // pg_advisory_xact_lock, beda dari kunci migrasi skema
+	auditChainMySQLLockName  = "simdokpol_audit_chain"
+	auditChainLockTimeoutSec = 30
+)
+
+// errAuditChainLockTimeout: GET_LOCK MySQL habis waktu; ditulis ulang oleh writer.
+var errAuditChainLockTimeout = errors.New("kunci rantai log audit sedang dipegang proses lain")
+
 func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
 	return &auditLogRepository{db: db}
 }
@@ -27,14 +61,157 @@ func (r *auditLogRepository) Create(log *models.AuditLog) error {
 	return r.db.Create(log).Error
 }
 
+func (r *auditLogRepository) CreateBatch(logs []*models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.Create(logs).Error
+}
+
+// WithChainLock: PostgreSQL memakai advisory lock transaksi, MySQL GET_LOCK
+// pada koneksi yang sama dengan transaksinya (dilepas setelah commit).
+// Transaksi SQLite sudah serializable: penulis yang kalah mendapat "database
+// is locked" dan ditulis ulang oleh writer dengan kepala rantai terbaru.
+func (r *auditLogRepository) WithChainLock(fn func(repo AuditLogRepository) error) error {
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%ds'", auditChainLockTimeoutSec)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainAdvisoryLockID).Error; err != nil {
+				return err
+			}
+			return fn(&auditLogRepository{db: tx})
+		})
+	case "mysql":
+		return r.db.Connection(func(conn *gorm.DB) error {
+			var acquired sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", auditChainMySQLLockName, auditChainLockTimeoutSec).Scan(&acquired).Error; err != nil {
+				return err
+			}
+			if acquired.Int64 != 1 {
+				return errAuditChainLockTimeout
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", auditChainMySQLLockName)
+			return conn.Transaction(func(tx *gorm.DB) error {
+				return fn(&auditLogRepository{db: tx})
+			})
+		})
+	default:
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			return fn(&auditLogRepository{db: tx})
+		})
+	}
+}
+
+// FindLast mengembalikan entri terakhir (kepala rantai), nil bila tabel kosong.
+func (r *auditLogRepository) FindLast() (*models.AuditLog, error) {
+	var logEntry models.AuditLog
+	err := r.db.Order("id desc").Limit(1).Find(&logEntry).Error
+	if err != nil || logEntry.ID == 0 {
+		return nil, err
+	}
+	return &logEntry, nil
+}
+
+func (r *auditLogRepository) FindByID(id uint) (*models.AuditLog, error) {
+	var logEntry models.AuditLog
+	if err := r.db.First(&logEntry, id).Error; err != nil {
+		return nil, err
+	}
+	return &logEntry, nil
+}
+
+// FindBatchAfter dipakai verifikasi rantai: urut ID naik, tanpa preload user.
+func (r *auditLogRepository) FindBatchAfter(afterID uint, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.Where("id > ?", afterID).Order("id asc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+func (r *auditLogRepository) CreateCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	return r.db.Create(checkpoint).Error
+}
+
+// FindLastCheckpoint mengembalikan checkpoint terakhir, nil bila belum ada.
+func (r *auditLogRepository) FindLastCheckpoint() (*models.AuditCheckpoint, error) {
+	var checkpoint models.AuditCheckpoint
+	err := r.db.Order("id desc").Limit(1).Find(&checkpoint).Error
+	if err != nil || checkpoint.ID == 0 {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (r *auditLogRepository) FindCheckpoints() ([]models.AuditCheckpoint, error) {
+	var checkpoints []models.AuditCheckpoint
+	err := r.db.Order("id asc").Find(&checkpoints).Error
+	return checkpoints, err
+}
+
+// FindBatchForArchive sama seperti FindBatchAfter tetapi ikut memuat nama pengguna
+// agar arsip tetap terbaca walau penggunanya kelak dihapus.
+func (r *auditLogRepository) FindBatchForArchive(afterID uint, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.Preload("User").Where("id > ?", afterID).Order("id asc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// ArchiveRange mencatat arsip lalu menghapus entri yang sudah diarsipkan dalam satu transaksi.
+func (r *auditLogRepository) ArchiveRange(archive *models.AuditArchive) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+		return tx.Where("id >= ? AND id <= ?", archive.FirstLogID, archive.LastLogID).Delete(&models.AuditLog{}).Error
+	})
+}
+
+func (r *auditLogRepository) FindArchives() ([]models.AuditArchive, error) {
+	var archives []models.AuditArchive
+	err := r.db.Order("last_log_id asc").Find(&archives).Error
+	return archives, err
+}
+
+func (r *auditLogRepository) FindArchiveByID(id uint) (*models.AuditArchive, error) {
+	var archive models.AuditArchive
+	if err := r.db.First(&archive, id).Error; err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// FindLastArchive mengembalikan arsip terbaru (jangkar rantai), nil bila belum ada.
+func (r *auditLogRepository) FindLastArchive() (*models.AuditArchive, error) {
+	var archive models.AuditArchive
+	err := r.db.Order("last_log_id desc").Limit(1).Find(&archive).Error
+	if err != nil || archive.ID == 0 {
+		return nil, err
+	}
+	return &archive, nil
+}
+
 func (r *auditLogRepository) FindAll() ([]models.AuditLog, error) {
 	var logs []models.AuditLog
 	err := r.db.Preload("User").Order("timestamp desc").Find(&logs).Error
 	return logs, err
 }
 
+func (r *auditLogRepository) FindByEntity(entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	db := r.db.Preload("User").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("timestamp desc, id desc")
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	err := db.Find(&logs).Error
+	return logs, err
+}
+
 // Implementasi Paging Server-Side
-func (r *auditLogRepository) FindAllPaged(req dto.DataTableRequest) ([]models.AuditLog, int64, int64, error) {
+func (r *auditLogRepository) FindAllPaged(req dto.DataTableRequest, filter dto.AuditLogFilter) ([]models.AuditLog, int64, int64, error) {
 	var logs []models.AuditLog
 	var total int64
 	var filtered int64
@@ -52,7 +229,33 @@ func (r *auditLogRepository) FindAllPaged(req dto.DataTableRequest) ([]models.Au
 	// 1. Hitung Total
 	db.Count(&total)
 
-	// 2. Filter Search
+	// 2. Filter terstruktur
+	if filter.EntityType != "" {
+		db = db.Where("audit_logs.entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		db = db.Where("audit_logs.entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != 0 {
+		db = db.Where("audit_logs.user_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		db = db.Where("audit_logs.aksi = ?", filter.Action)
+	}
+	if filter.IPAddress != "" {
+		db = db.Where("audit_logs.ip_address = ?", filter.IPAddress)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("audit_logs.request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("audit_logs.timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("audit_logs.timestamp < ?", *filter.To)
+	}
+
+	// 3. Filter Search
 	if req.Search != "" {
 		search := fmt.Sprintf("%%%s%%", req.Search)
 		db = db.Joins("LEFT JOIN users ON users.id = audit_logs.user_id").
@@ -60,9 +263,9 @@ func (r *auditLogRepository) FindAllPaged(req dto.DataTableRequest) ([]models.Au
 	}
 	db.Count(&filtered)
 
-	// 3. Paging & Ordering
+	// 4. Paging & Ordering
 	err := db.Preload("User").
-		Order("timestamp desc").
+		Order("audit_logs.timestamp desc").
 		Limit(limit).
 		Offset(req.Start).
 		Find(&logs).Error
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"errors"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BackupRunRepository interface {
+	Create(run *models.BackupRun) error
+	FindRecent(limit int) ([]models.BackupRun, error)
+	// FindLatest mengembalikan nil (tanpa error) bila belum ada riwayat
+	FindLatest(kind string) (*models.BackupRun, error)
+	FindLatestByStatus(status string) (*models.BackupRun, error)
+	// FindRetained: backup berhasil yang file-nya belum dihapus retensi, terbaru dulu
+	FindRetained() ([]models.BackupRun, error)
+	MarkPruned(ids []uint, prunedAt time.Time) error
+	// FindReplicated: backup yang salinan off-site-nya masih ada, terbaru dulu
+	FindReplicated() ([]models.BackupRun, error)
+	UpdateOffsite(run *models.BackupRun) error
+}
+
+type backupRunRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRunRepository(db *gorm.DB) BackupRunRepository {
+	return &backupRunRepository{db: db}
+}
+
+func (r *backupRunRepository) Create(run *models.BackupRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *backupRunRepository) FindRecent(limit int) ([]models.BackupRun, error) {
+	var runs []models.BackupRun
+	err := r.db.Order("started_at desc").Order("id desc").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+func (r *backupRunRepository) FindLatest(kind string) (*models.BackupRun, error) {
+	query := r.db
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	return firstBackupRun(query)
+}
+
+func (r *backupRunRepository) FindLatestByStatus(status string) (*models.BackupRun, error) {
+	return firstBackupRun(r.db.Where("status = ?", status))
+}
+
+func firstBackupRun(query *gorm.DB) (*models.BackupRun, error) {
+	var run models.BackupRun
+	err := query.Order("started_at desc").Order("id desc").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *backupRunRepository) FindRetained() ([]models.BackupRun, error) {
+	var runs []models.BackupRun
+	err := r.db.Where("status = ? AND pruned_at IS NULL", models.BackupStatusSuccess).
+		Order("started_at desc").Order("id desc").Find(&runs).Error
+	return runs, err
+}
+
+func (r *backupRunRepository) MarkPruned(ids []uint, prunedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.BackupRun{}).Where("id IN ?", ids).Update("pruned_at", prunedAt).Error
+}
+
+func (r *backupRunRepository) FindReplicated() ([]models.BackupRun, error) {
+	var runs []models.BackupRun
+	err := r.db.Where("status = ? AND offsite_status = ?", models.BackupStatusSuccess, models.BackupOffsiteUploaded).
+		Order("started_at desc").Order("id desc").Find(&runs).Error
+	return runs, err
+}
+
+func (r *backupRunRepository) UpdateOffsite(run *models.BackupRun) error {
+	return r.db.Model(&models.BackupRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"offsite_status":   run.OffsiteStatus,
+		"offsite_target":   run.OffsiteTarget,
+		"offsite_attempts": run.OffsiteAttempts,
+		"offsite_error":    run.OffsiteError,
+		"offsite_at":       run.OffsiteAt,
+	}).Error
+}
@@ -15,6 +15,15 @@ type MonthlyCount struct {
 	Count int `gorm:"column:count"`
 }
 
+// DocumentScope membatasi dokumen yang boleh dilihat seorang pengguna.
+// All = true berarti tanpa batasan kepemilikan. Regu terisi berarti pengguna
+// (mis. KANIT JAGA) boleh melihat semua surat buatan anggota regu tersebut.
+type DocumentScope struct {
+	UserID uint
+	Regu   string
+	All    bool
+}
+
 type LostDocumentRepository interface {
 	Create(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
 	FindByID(id uint) (*models.LostDocument, error)
@@ -23,8 +32,7 @@ type LostDocumentRepository interface {
 	FindAll(query string, statusFilter string, archiveDurationDays int) ([]models.LostDocument, error)
 	
 	// FindAllPaged (Untuk DataTables - Dengan Paging & Filter User)
-	// Update Signature: Tambah userID dan userRole
-	FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, userID uint, userRole string) ([]models.LostDocument, int64, int64, error)
+	FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, scope DocumentScope) ([]models.LostDocument, int64, int64, error)
 	
 	SearchGlobal(query string, limit int) ([]models.LostDocument, error)
 	Update(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
@@ -37,6 +45,10 @@ type LostDocumentRepository interface {
 	// Method Notifikasi Khusus
 	FindExpiringDocumentsForUser(userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error)
 	FindAllExpiringDocuments(expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error)
+	FindExpiringDocumentsForRegu(regu string, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error)
+
+	// Serah terima regu: surat aktif (belum diarsipkan) buatan anggota regu
+	FindActiveByRegu(regu string, since time.Time) ([]models.LostDocument, error)
 	
 	GetItemCompositionStatsInRange(start time.Time, end time.Time) ([]dto.ItemCompositionStat, error)
 	CountByOperatorInRange(start time.Time, end time.Time) ([]dto.OperatorStat, error)
@@ -78,9 +90,25 @@ func (r *lostDocumentRepository) selectMonth(field string) string {
 	}
 }
 
+// reguOperatorsSubquery memilih ID seluruh anggota regu (termasuk yang sudah nonaktif)
+func (r *lostDocumentRepository) reguOperatorsSubquery(regu string) *gorm.DB {
+	return r.db.Unscoped().Model(&models.User{}).Select("id").Where("regu = ?", regu)
+}
+
+// applyScope menerapkan batasan kepemilikan sesuai DocumentScope
+func (r *lostDocumentRepository) applyScope(db *gorm.DB, scope DocumentScope) *gorm.DB {
+	if scope.All {
+		return db
+	}
+	if scope.Regu != "" {
+		return db.Where("lost_documents.operator_id = ? OR lost_documents.operator_id IN (?)", scope.UserID, r.reguOperatorsSubquery(scope.Regu))
+	}
+	return db.Where("lost_documents.operator_id = ?", scope.UserID)
+}
+
 // --- IMPLEMENTASI UTAMA ---
 
-func (r *lostDocumentRepository) FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, userID uint, userRole string) ([]models.LostDocument, int64, int64, error) {
+func (r *lostDocumentRepository) FindAllPaged(req dto.DataTableRequest, statusFilter string, archiveDurationDays int, scope DocumentScope) ([]models.LostDocument, int64, int64, error) {
 	var docs []models.LostDocument
 	var total, filtered int64
 
@@ -113,10 +141,13 @@ func (r *lostDocumentRepository) FindAllPaged(req dto.DataTableRequest, statusFi
 		// Filter rentang waktu expiring
 		db = db.Where("tanggal_laporan BETWEEN ? AND ?", archiveDate, warningDate)
 		
-		// Filter Kepemilikan (Jika bukan Super Admin)
-		if userRole != models.RoleSuperAdmin {
-			db = db.Where("operator_id = ?", userID)
-		}
+		// Filter Kepemilikan (Jika tidak punya izin lihat semua)
+		db = r.applyScope(db, scope)
+	}
+
+	// Filter "Surat Regu Saya" untuk kepala regu
+	if req.FilterType == "regu" && scope.Regu != "" {
+		db = db.Where("lost_documents.operator_id IN (?)", r.reguOperatorsSubquery(scope.Regu))
 	}
 
 	// 3. Logic Search (Global Search)
@@ -275,6 +306,20 @@ func (r *lostDocumentRepository) FindAllExpiringDocuments(start time.Time, end t
 	return docs, err
 }
 
+func (r *lostDocumentRepository) FindExpiringDocumentsForRegu(regu string, start time.Time, end time.Time) ([]models.LostDocument, error) {
+	var docs []models.LostDocument
+	err := r.db.Where("operator_id IN (?) AND tanggal_laporan BETWEEN ? AND ?", r.reguOperatorsSubquery(regu), start, end).Find(&docs).Error
+	return docs, err
+}
+
+func (r *lostDocumentRepository) FindActiveByRegu(regu string, since time.Time) ([]models.LostDocument, error) {
+	var docs []models.LostDocument
+	err := r.db.Preload("Resident").Preload("Operator").Preload("LostItems").
+		Where("operator_id IN (?) AND tanggal_laporan > ?", r.reguOperatorsSubquery(regu), since).
+		Order("tanggal_laporan asc").Find(&docs).Error
+	return docs, err
+}
+
 func (r *lostDocumentRepository) GetItemCompositionStatsInRange(start time.Time, end time.Time) ([]dto.ItemCompositionStat, error) {
 	var results []dto.ItemCompositionStat
 	err := r.db.Model(&models.LostItem{}).
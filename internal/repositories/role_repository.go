@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	FindAll() ([]models.Role, error)
+	FindByName(nama string) (*models.Role, error)
+	Save(role *models.Role) error
+	Delete(nama string) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) FindAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Order("is_system desc, nama asc").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByName(nama string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("nama = ?", nama).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) Save(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+func (r *roleRepository) Delete(nama string) error {
+	return r.db.Where("nama = ?", nama).Delete(&models.Role{}).Error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRevocationRepository interface {
+	// Revoke mencatat (atau memperbarui) pencabutan untuk setiap kunci
+	Revoke(keys []string, revokedAt, expiresAt time.Time) error
+	// IsRevoked true bila salah satu kunci dicabut pada atau setelah issuedAt
+	IsRevoked(keys []string, issuedAt time.Time) (bool, error)
+	DeleteExpired(now time.Time) error
+}
+
+type sessionRevocationRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRevocationRepository(db *gorm.DB) SessionRevocationRepository {
+	return &sessionRevocationRepository{db: db}
+}
+
+func (r *sessionRevocationRepository) Revoke(keys []string, revokedAt, expiresAt time.Time) error {
+	revocations := make([]models.SessionRevocation, 0, len(keys))
+	for _, key := range keys {
+		revocations = append(revocations, models.SessionRevocation{SessionKey: key, RevokedAt: revokedAt, ExpiresAt: expiresAt})
+	}
+	if len(revocations) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_at", "expires_at"}),
+	}).Create(&revocations).Error
+}
+
+func (r *sessionRevocationRepository) IsRevoked(keys []string, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.SessionRevocation{}).
+		Where("session_key IN ? AND revoked_at >= ?", keys, issuedAt).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRevocationRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.SessionRevocation{}).Error
+}
@@ -17,6 +17,7 @@ type UserRepository interface {
 	Delete(id uint) error
 	Restore(id uint) error
 	CountAll() (int64, error)
+	CountByRole(peran string) (int64, error)
 }
 
 type userRepository struct {
@@ -39,7 +40,8 @@ func (r *userRepository) FindAllPaged(req dto.DataTableRequest, statusFilter str
 	if limit <= 0 { limit = 10 }
 	if limit > 100 { limit = 100 }
 
-	db := r.db.Model(&models.User{})
+	// Akun aktor sistem (CLI admin) tidak dikelola lewat UI
+	db := r.db.Model(&models.User{}).Where("auth_source <> ?", models.AuthSourceSystem)
 	if statusFilter == "inactive" {
 		db = db.Unscoped().Where("deleted_at IS NOT NULL")
 	} else {
@@ -80,7 +82,7 @@ func (r *userRepository) FindOperators() ([]models.User, error) {
 	// 1. Hapus Unscoped() agar user yang sudah dihapus tidak muncul.
 	// 2. Tetap ambil SEMUA role (Operator & Super Admin) karena Kanit butuh tanda tangan.
 	// 3. Filter deleted_at secara eksplisit (optional di GORM, tapi bagus untuk kepastian).
-	err := r.db.Where("deleted_at IS NULL").Order("nama_lengkap asc").Find(&users).Error
+	err := r.db.Where("deleted_at IS NULL AND auth_source <> ?", models.AuthSourceSystem).Order("nama_lengkap asc").Find(&users).Error
 	return users, err
 }
 
@@ -98,6 +100,12 @@ func (r *userRepository) Restore(id uint) error {
 
 func (r *userRepository) CountAll() (int64, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Count(&count).Error
+	err := r.db.Model(&models.User{}).Where("auth_source <> ?", models.AuthSourceSystem).Count(&count).Error
 	return count, err
-}
\ No newline at end of file
+}
+
+func (r *userRepository) CountByRole(peran string) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.User{}).Where("peran = ?", peran).Count(&count).Error
+	return count, err
+}
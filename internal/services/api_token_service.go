@@ -0,0 +1,176 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	apiTokenDefaultDays = 30
+	apiTokenMaxDays     = 365
+	// apiTokenUseInterval last_used dan log audit pemakaian token dicatat
+	// paling sering sekali per interval ini, supaya skrip yang memanggil API
+	// terus-menerus tidak menulis ke database di setiap request
+	apiTokenUseInterval = 5 * time.Minute
+)
+
+type APITokenService interface {
+	Create(owner *models.User, nama string, scopes []string, expiresInDays int) (string, *models.APIToken, error)
+	ListByUser(userID uint) ([]models.APIToken, error)
+	ListAll() ([]models.APIToken, error)
+	Revoke(id uint, actor *models.User) error
+	Authenticate(plainToken string) (*models.APIToken, *models.User, error)
+	RecordUse(token *models.APIToken, ip, method, path string)
+	AvailableScopes(owner *models.User) []string
+}
+
+type apiTokenService struct {
+	tokenRepo    repositories.APITokenRepository
+	userRepo     repositories.UserRepository
+	roleService  RoleService
+	auditService AuditLogService
+}
+
+func NewAPITokenService(tokenRepo repositories.APITokenRepository, userRepo repositories.UserRepository, roleService RoleService, auditService AuditLogService) APITokenService {
+	return &apiTokenService{
+		tokenRepo:    tokenRepo,
+		userRepo:     userRepo,
+		roleService:  roleService,
+		auditService: auditService,
+	}
+}
+
+func hashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// AvailableScopes: scope dasar baca/tulis ditambah izin yang dimiliki pemilik token.
+func (s *apiTokenService) AvailableScopes(owner *models.User) []string {
+	scopes := []string{models.ScopeAPIRead, models.ScopeAPIWrite}
+	return append(scopes, s.roleService.GetPermissions(owner)...)
+}
+
+func (s *apiTokenService) Create(owner *models.User, nama string, scopes []string, expiresInDays int) (string, *models.APIToken, error) {
+	if owner == nil {
+		return "", nil, errors.New("pengguna tidak valid")
+	}
+	nama = strings.TrimSpace(nama)
+	if nama == "" {
+		return "", nil, errors.New("nama token wajib diisi")
+	}
+	if expiresInDays <= 0 {
+		expiresInDays = apiTokenDefaultDays
+	}
+	if expiresInDays > apiTokenMaxDays {
+		return "", nil, fmt.Errorf("masa berlaku token maksimal %d hari", apiTokenMaxDays)
+	}
+
+	allowed := make(map[string]bool)
+	for _, sc := range s.AvailableScopes(owner) {
+		allowed[sc] = true
+	}
+	seen := make(map[string]bool)
+	tokenScopes := models.JSONStringArray{}
+	for _, sc := range scopes {
+		if !allowed[sc] {
+			return "", nil, fmt.Errorf("scope tidak diizinkan: %s", sc)
+		}
+		if !seen[sc] {
+			seen[sc] = true
+			tokenScopes = append(tokenScopes, sc)
+		}
+	}
+	if !seen[models.ScopeAPIRead] && !seen[models.ScopeAPIWrite] {
+		tokenScopes = append(tokenScopes, models.ScopeAPIRead)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("gagal membuat token: %w", err)
+	}
+	plain := models.APITokenPrefix + hex.EncodeToString(raw)
+	expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+
+	token := &models.APIToken{
+		UserID:    owner.ID,
+		Nama:      nama,
+		Prefix:    plain[:len(models.APITokenPrefix)+8],
+		TokenHash: hashAPIToken(plain),
+		Scopes:    tokenScopes,
+		ExpiresAt: &expiresAt,
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return "", nil, err
+	}
+
+	s.auditService.LogActivity(owner.ID, models.AuditTokenCreated, fmt.Sprintf("Membuat token API '%s' (%s…) dengan scope: %s, berlaku hingga %s", token.Nama, token.Prefix, strings.Join(token.Scopes, ", "), expiresAt.Format("2006-01-02")))
+	return plain, token, nil
+}
+
+func (s *apiTokenService) ListByUser(userID uint) ([]models.APIToken, error) {
+	return s.tokenRepo.FindByUser(userID)
+}
+
+func (s *apiTokenService) ListAll() ([]models.APIToken, error) {
+	return s.tokenRepo.FindAll()
+}
+
+// Revoke: pemilik boleh mencabut tokennya sendiri, admin pengguna boleh mencabut token siapa saja.
+func (s *apiTokenService) Revoke(id uint, actor *models.User) error {
+	token, err := s.tokenRepo.FindByID(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	if token.UserID != actor.ID && !s.roleService.HasPermission(actor, models.PermUserManage) {
+		return ErrAccessDenied
+	}
+	if err := s.tokenRepo.Revoke(id, time.Now()); err != nil {
+		return err
+	}
+
+	s.auditService.LogActivity(actor.ID, models.AuditTokenRevoked, fmt.Sprintf("Mencabut token API '%s' (%s…) milik pengguna ID %d", token.Nama, token.Prefix, token.UserID))
+	return nil
+}
+
+func (s *apiTokenService) Authenticate(plainToken string) (*models.APIToken, *models.User, error) {
+	if !strings.HasPrefix(plainToken, models.APITokenPrefix) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	token, err := s.tokenRepo.FindByHash(hashAPIToken(plainToken))
+	if err != nil || !token.IsUsable(time.Now()) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	// FindByID bersifat unscoped, jadi pemilik yang sudah dinonaktifkan harus ditolak di sini
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil || user.DeletedAt.Valid {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	return token, user, nil
+}
+
+// RecordUse mencatat pemakaian token ke kolom last_used dan log audit,
+// hanya untuk pemakaian pertama dalam setiap apiTokenUseInterval.
+func (s *apiTokenService) RecordUse(token *models.APIToken, ip, method, path string) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < apiTokenUseInterval {
+		return
+	}
+	touched, err := s.tokenRepo.TouchLastUsed(token.ID, now, ip, now.Add(-apiTokenUseInterval))
+	if err != nil {
+		log.Printf("WARN: gagal memperbarui pemakaian token %d: %v", token.ID, err)
+		return
+	}
+	if !touched {
+		return
+	}
+	s.auditService.LogActivity(token.UserID, models.AuditTokenUsed, fmt.Sprintf("Token API '%s' (%s…) dipakai dari %s: %s %s", token.Nama, token.Prefix, ip, method, path))
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestAPITokenService_Create(t *testing.T) {
+	owner := &models.User{ID: 5, Peran: models.RoleOperator}
+
+	t.Run("Success - Token disimpan dalam bentuk hash", func(t *testing.T) {
+		mockTokenRepo := new(mocks.APITokenRepository)
+		mockRoleService := new(mocks.RoleService)
+		mockAuditService := new(mocks.AuditLogService)
+		service := NewAPITokenService(mockTokenRepo, new(mocks.UserRepository), mockRoleService, mockAuditService)
+
+		mockRoleService.On("GetPermissions", owner).Return([]string{models.PermDocumentCreate})
+		var stored *models.APIToken
+		mockTokenRepo.On("Create", mock.AnythingOfType("*models.APIToken")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.APIToken)
+		}).Return(nil).Once()
+		mockAuditService.On("LogActivity", owner.ID, models.AuditTokenCreated, mock.Anything).Return().Once()
+
+		plain, token, err := service.Create(owner, "Skrip Laporan", []string{models.PermDocumentCreate}, 7)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(plain, models.APITokenPrefix))
+		assert.Equal(t, hashAPIToken(plain), stored.TokenHash)
+		assert.NotContains(t, stored.TokenHash, plain)
+		assert.True(t, token.HasScope(models.ScopeAPIRead), "scope baca ditambahkan bila tidak ada scope dasar")
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), *token.ExpiresAt, time.Minute)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("Failure - Scope melebihi izin pemilik", func(t *testing.T) {
+		mockRoleService := new(mocks.RoleService)
+		service := NewAPITokenService(new(mocks.APITokenRepository), new(mocks.UserRepository), mockRoleService, new(mocks.AuditLogService))
+		mockRoleService.On("GetPermissions", owner).Return([]string{models.PermDocumentCreate})
+
+		_, _, err := service.Create(owner, "Skrip", []string{models.PermDatabaseManage}, 7)
+		assert.Error(t, err)
+	})
+
+	t.Run("Failure - Masa berlaku terlalu panjang", func(t *testing.T) {
+		service := NewAPITokenService(new(mocks.APITokenRepository), new(mocks.UserRepository), new(mocks.RoleService), new(mocks.AuditLogService))
+		_, _, err := service.Create(owner, "Skrip", nil, 1000)
+		assert.Error(t, err)
+	})
+}
+
+func TestAPITokenService_Authenticate(t *testing.T) {
+	plain := models.APITokenPrefix + "abc123"
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	user := &models.User{ID: 5}
+	inactiveUser := &models.User{ID: 6, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+
+	testCases := []struct {
+		name        string
+		token       *models.APIToken
+		repoErr     error
+		expectError bool
+	}{
+		{name: "Success - Token aktif", token: &models.APIToken{ID: 1, UserID: 5, ExpiresAt: &future}},
+		{name: "Failure - Token kedaluwarsa", token: &models.APIToken{ID: 1, UserID: 5, ExpiresAt: &past}, expectError: true},
+		{name: "Failure - Token dicabut", token: &models.APIToken{ID: 1, UserID: 5, ExpiresAt: &future, RevokedAt: &past}, expectError: true},
+		{name: "Failure - Token tidak dikenal", repoErr: errors.New("record not found"), expectError: true},
+		{name: "Failure - Pemilik nonaktif", token: &models.APIToken{ID: 2, UserID: 6, ExpiresAt: &future}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockTokenRepo := new(mocks.APITokenRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			if tc.repoErr != nil {
+				mockTokenRepo.On("FindByHash", hashAPIToken(plain)).Return(nil, tc.repoErr).Once()
+			} else {
+				mockTokenRepo.On("FindByHash", hashAPIToken(plain)).Return(tc.token, nil).Once()
+			}
+			mockUserRepo.On("FindByID", uint(5)).Return(user, nil).Maybe()
+			mockUserRepo.On("FindByID", uint(6)).Return(inactiveUser, nil).Maybe()
+
+			service := NewAPITokenService(mockTokenRepo, mockUserRepo, new(mocks.RoleService), new(mocks.AuditLogService))
+			token, owner, err := service.Authenticate(plain)
+
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidAPIToken)
+				assert.Nil(t, token)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, user, owner)
+			}
+		})
+	}
+}
+
+func TestAPITokenService_RecordUse(t *testing.T) {
+	recent := time.Now().Add(-time.Minute)
+	old := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name        string
+		lastUsedAt  *time.Time
+		touched     bool
+		expectTouch bool
+		expectAudit bool
+	}{
+		{name: "Success - Pemakaian pertama dicatat", lastUsedAt: nil, touched: true, expectTouch: true, expectAudit: true},
+		{name: "Success - Pemakaian setelah interval dicatat", lastUsedAt: &old, touched: true, expectTouch: true, expectAudit: true},
+		{name: "Skip - Masih dalam interval", lastUsedAt: &recent, expectTouch: false, expectAudit: false},
+		{name: "Skip - Request lain sudah mencatat lebih dulu", lastUsedAt: &old, touched: false, expectTouch: true, expectAudit: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockTokenRepo := new(mocks.APITokenRepository)
+			mockAuditService := new(mocks.AuditLogService)
+			service := NewAPITokenService(mockTokenRepo, new(mocks.UserRepository), new(mocks.RoleService), mockAuditService)
+			token := &models.APIToken{ID: 3, UserID: 5, Nama: "Skrip", Prefix: "sdp_abc", LastUsedAt: tc.lastUsedAt}
+
+			if tc.expectTouch {
+				mockTokenRepo.On("TouchLastUsed", uint(3), mock.AnythingOfType("time.Time"), "10.0.0.1", mock.MatchedBy(func(notSince time.Time) bool {
+					return time.Since(notSince) >= apiTokenUseInterval-time.Second
+				})).Return(tc.touched, nil).Once()
+			}
+			if tc.expectAudit {
+				mockAuditService.On("LogActivity", uint(5), models.AuditTokenUsed, mock.Anything).Return().Once()
+			}
+
+			service.RecordUse(token, "10.0.0.1", "GET", "/api/v1/documents")
+
+			mockTokenRepo.AssertExpectations(t)
+			mockAuditService.AssertExpectations(t)
+			if !tc.expectTouch {
+				mockTokenRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if !tc.expectAudit {
+				mockAuditService.AssertNotCalled(t, "LogActivity", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Checkpoint dibuat setiap N entri baru atau bila checkpoint terakhir sudah terlalu lama.
+	auditCheckpointEvery  = 100
+	auditCheckpointMaxAge = time.Hour
+
+	auditVerifyBatchSize = 500
+)
+
+// auditHashPayload adalah isi entri yang di-hash. Urutan field tetap
+// (json.Marshal struct) agar hash bisa dihitung ulang di database mana pun.
+// Field tambahan memakai omitempty supaya hash entri lama tidak berubah.
+type auditHashPayload struct {
+	PrevHash   string `json:"prev_hash"`
+	UserID     uint   `json:"user_id"`
+	Aksi       string `json:"aksi"`
+	Detail     string `json:"detail"`
+	Timestamp  int64  `json:"timestamp"`
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   string `json:"entity_id,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	Before     string `json:"before,omitempty"`
+	After      string `json:"after,omitempty"`
+}
+
+// computeAuditHash menghitung SHA-256 dari isi entri + hash entri sebelumnya.
+// Timestamp dipakai dalam milidetik karena MySQL menyimpan datetime(3).
+func computeAuditHash(entry *models.AuditLog) string {
+	payload, _ := json.Marshal(auditHashPayload{
+		PrevHash:   entry.PrevHash,
+		UserID:     entry.UserID,
+		Aksi:       entry.Aksi,
+		Detail:     entry.Detail,
+		Timestamp:  entry.Timestamp.UnixMilli(),
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Before:     entry.Before,
+		After:      entry.After,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// signCheckpoint menandatangani checkpoint beserta tanda tangan checkpoint
+// sebelumnya, sehingga menghapus checkpoint di tengah juga ketahuan.
+func signCheckpoint(checkpoint *models.AuditCheckpoint, prevSignature string) string {
+	mac := hmac.New(sha256.New, AuditSigningKey)
+	fmt.Fprintf(mac, "%d|%s|%d|%s", checkpoint.LastLogID, checkpoint.LastHash, checkpoint.CreatedAt.UnixMilli(), prevSignature)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appendToChain menyambungkan satu batch entri ke rantai lalu menyimpannya
+// sekaligus. chainMu mengantrekan goroutine di proses ini; WithChainLock
+// mengantrekan instance lain yang memakai database yang sama, sehingga dua
+// batch tidak pernah merujuk PrevHash yang sama.
+func (s *auditLogService) appendToChain(entries []*models.AuditLog) error {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	if s.repo == nil {
+		return ErrAuditStoreUnavailable
+	}
+	err := s.repo.WithChainLock(func(repo repositories.AuditLogRepository) error {
+		last, err := repo.FindLast()
+		if err != nil {
+			return err
+		}
+		prevHash := ""
+		if last != nil {
+			prevHash = last.Hash
+		} else if anchor, err := repo.FindLastArchive(); err != nil {
+			return err
+		} else if anchor != nil {
+			// Semua entri sudah diarsipkan: sambung ke entri terakhir di arsip
+			prevHash = anchor.LastHash
+		}
+		for _, entry := range entries {
+			// Percobaan sebelumnya yang gagal bisa saja sudah mengisi ID
+			entry.ID = 0
+			entry.PrevHash = prevHash
+			entry.Timestamp = entry.Timestamp.Truncate(time.Millisecond)
+			entry.Hash = computeAuditHash(entry)
+			prevHash = entry.Hash
+		}
+		return repo.CreateBatch(entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Entri sudah tersimpan; gagal checkpoint tidak boleh membuat batch ditulis ulang
+	head := entries[len(entries)-1]
+	if err := s.repo.WithChainLock(func(repo repositories.AuditLogRepository) error {
+		return s.checkpointIfDue(repo, head)
+	}); err != nil {
+		log.Printf("WARN: gagal membuat checkpoint log audit: %v", err)
+	}
+	s.forwardToSinks(entries)
+	return nil
+}
+
+func (s *auditLogService) checkpointIfDue(repo repositories.AuditLogRepository, head *models.AuditLog) error {
+	if len(AuditSigningKey) == 0 {
+		return nil
+	}
+	lastCheckpoint, err := repo.FindLastCheckpoint()
+	if err != nil {
+		return err
+	}
+	prevSignature := ""
+	if lastCheckpoint != nil {
+		if head.ID-lastCheckpoint.LastLogID < auditCheckpointEvery && time.Since(lastCheckpoint.CreatedAt) < auditCheckpointMaxAge {
+			return nil
+		}
+		prevSignature = lastCheckpoint.Signature
+	}
+
+	checkpoint := &models.AuditCheckpoint{
+		LastLogID: head.ID,
+		LastHash:  head.Hash,
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}
+	checkpoint.Signature = signCheckpoint(checkpoint, prevSignature)
+	return repo.CreateCheckpoint(checkpoint)
+}
+
+// VerifyChain menelusuri seluruh rantai dari entri tertua lalu mencocokkan
+// setiap checkpoint. Yang dilaporkan hanya kerusakan pertama yang ditemukan.
+func (s *auditLogService) VerifyChain() (*dto.AuditChainReport, error) {
+	report := &dto.AuditChainReport{Valid: true, VerifiedAt: time.Now()}
+	fail := func(logID, checkpointID uint, reason string) (*dto.AuditChainReport, error) {
+		report.Valid = false
+		report.BrokenLogID = logID
+		report.BrokenCheckpointID = checkpointID
+		report.Reason = reason
+		return report, nil
+	}
+
+	// Entri yang sudah diarsipkan tidak ada lagi di database; rantai dilanjutkan
+	// dari hash terakhir di arsip terbaru.
+	archives, err := s.repo.FindArchives()
+	if err != nil {
+		return nil, err
+	}
+	report.Archives = len(archives)
+	if len(archives) > 0 && len(AuditSigningKey) == 0 {
+		return fail(0, 0, "AUDIT_SIGNING_KEY tidak tersedia, arsip tidak bisa diverifikasi")
+	}
+	var archivedUpTo uint
+	prevHash := ""
+	prevSignature := ""
+	for i := range archives {
+		archive := &archives[i]
+		if !hmac.Equal([]byte(signArchive(archive, prevSignature)), []byte(archive.Signature)) {
+			report.BrokenArchiveID = archive.ID
+			return fail(archive.FirstLogID, 0, "tanda tangan arsip tidak valid (arsip diubah atau dihapus)")
+		}
+		if archive.PrevHash != prevHash || (i > 0 && archive.FirstLogID <= archivedUpTo) {
+			report.BrokenArchiveID = archive.ID
+			return fail(archive.FirstLogID, 0, "arsip tidak tersambung ke arsip sebelumnya")
+		}
+		prevSignature = archive.Signature
+		prevHash = archive.LastHash
+		archivedUpTo = archive.LastLogID
+		report.ArchivedEntries += int64(archive.EntryCount)
+	}
+
+	chainStarted := prevHash != ""
+	var afterID uint
+	for {
+		batch, err := s.repo.FindBatchAfter(afterID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			entry := &batch[i]
+			afterID = entry.ID
+			if entry.Hash == "" {
+				if chainStarted {
+					return fail(entry.ID, 0, "hash entri dikosongkan")
+				}
+				// Entri lama sebelum fitur rantai hash aktif
+				report.LegacyEntries++
+				continue
+			}
+			chainStarted = true
+			if entry.PrevHash != prevHash {
+				return fail(entry.ID, 0, "tidak tersambung ke entri sebelumnya (ada entri yang dihapus atau disisipkan)")
+			}
+			if computeAuditHash(entry) != entry.Hash {
+				return fail(entry.ID, 0, "isi entri tidak cocok dengan hash-nya (entri diubah)")
+			}
+			prevHash = entry.Hash
+			report.CheckedEntries++
+		}
+		if len(batch) < auditVerifyBatchSize {
+			break
+		}
+	}
+	report.LastHash = prevHash
+
+	checkpoints, err := s.repo.FindCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+	report.Checkpoints = len(checkpoints)
+	if len(checkpoints) > 0 && len(AuditSigningKey) == 0 {
+		return fail(0, 0, "AUDIT_SIGNING_KEY tidak tersedia, checkpoint tidak bisa diverifikasi")
+	}
+	prevSignature = ""
+	for i := range checkpoints {
+		checkpoint := &checkpoints[i]
+		expected := signCheckpoint(checkpoint, prevSignature)
+		if !hmac.Equal([]byte(expected), []byte(checkpoint.Signature)) {
+			return fail(checkpoint.LastLogID, checkpoint.ID, "tanda tangan checkpoint tidak valid (checkpoint diubah atau dihapus)")
+		}
+		prevSignature = checkpoint.Signature
+		if checkpoint.LastLogID <= archivedUpTo {
+			// Entri yang disegel sudah dipindah ke arsip
+			continue
+		}
+
+		sealed, err := s.repo.FindByID(checkpoint.LastLogID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fail(checkpoint.LastLogID, checkpoint.ID, "entri yang disegel checkpoint sudah tidak ada")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sealed.Hash != checkpoint.LastHash {
+			return fail(checkpoint.LastLogID, checkpoint.ID, "hash entri berbeda dari yang disegel checkpoint (rantai disusun ulang)")
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"path/filepath"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupAuditChain(t *testing.T, entries int) (AuditLogService, *gorm.DB) {
+	t.Helper()
+	AuditSigningKey = []byte("kunci-uji-audit")
+	t.Cleanup(func() { AuditSigningKey = nil })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+
+	service := NewAuditLogService(repositories.NewAuditLogRepository(db))
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+	for i := 0; i < entries; i++ {
+		service.LogActivity(1, models.AuditUpdateDocument, "Memperbarui dokumen")
+		wg.Wait()
+	}
+	return service, db
+}
+
+// rehashFrom meniru penyerang yang punya akses DB: menyusun ulang rantai setelah mengubah data.
+func rehashFrom(t *testing.T, db *gorm.DB) {
+	var logs []models.AuditLog
+	require.NoError(t, db.Order("id asc").Find(&logs).Error)
+	prev := ""
+	for i := range logs {
+		logs[i].PrevHash = prev
+		logs[i].Hash = computeAuditHash(&logs[i])
+		require.NoError(t, db.Save(&logs[i]).Error)
+		prev = logs[i].Hash
+	}
+}
+
+func TestAuditChain_ValidChain(t *testing.T) {
+	service, db := setupAuditChain(t, 5)
+
+	var logs []models.AuditLog
+	require.NoError(t, db.Order("id asc").Find(&logs).Error)
+	assert.Empty(t, logs[0].PrevHash)
+	for i := 1; i < len(logs); i++ {
+		assert.Equal(t, logs[i-1].Hash, logs[i].PrevHash)
+	}
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, int64(5), report.CheckedEntries)
+	assert.Equal(t, 1, report.Checkpoints)
+	assert.Equal(t, logs[4].Hash, report.LastHash)
+}
+
+func TestAuditChain_DetectsTampering(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tamper         func(t *testing.T, db *gorm.DB)
+		expectedLogID  uint
+		expectedReason string
+	}{
+		{
+			name: "Detail Diubah",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Model(&models.AuditLog{}).Where("id = ?", 3).Update("detail", "Tidak terjadi apa-apa").Error)
+			},
+			expectedLogID:  3,
+			expectedReason: "entri diubah",
+		},
+		{
+			name: "Entri Dihapus",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Delete(&models.AuditLog{}, 3).Error)
+			},
+			expectedLogID:  4,
+			expectedReason: "dihapus",
+		},
+		{
+			name: "Hash Dikosongkan",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Model(&models.AuditLog{}).Where("id = ?", 2).Updates(map[string]interface{}{"hash": "", "prev_hash": ""}).Error)
+			},
+			expectedLogID:  2,
+			expectedReason: "dikosongkan",
+		},
+		{
+			name: "Rantai Disusun Ulang",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Model(&models.AuditLog{}).Where("id = ?", 1).Update("aksi", models.AuditSystemSetup).Error)
+				rehashFrom(t, db)
+			},
+			expectedLogID:  1,
+			expectedReason: "disusun ulang",
+		},
+		{
+			name: "Checkpoint Dipalsukan",
+			tamper: func(t *testing.T, db *gorm.DB) {
+				require.NoError(t, db.Model(&models.AuditCheckpoint{}).Where("id = ?", 1).Update("last_log_id", 5).Error)
+			},
+			expectedLogID:  5,
+			expectedReason: "tanda tangan checkpoint",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, db := setupAuditChain(t, 5)
+			tc.tamper(t, db)
+
+			report, err := service.VerifyChain()
+			require.NoError(t, err)
+			assert.False(t, report.Valid)
+			assert.Equal(t, tc.expectedLogID, report.BrokenLogID)
+			assert.Contains(t, report.Reason, tc.expectedReason)
+		})
+	}
+}
+
+func TestAuditChain_LegacyEntries(t *testing.T) {
+	AuditSigningKey = []byte("kunci-uji-audit")
+	t.Cleanup(func() { AuditSigningKey = nil })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+
+	// Entri dari versi lama belum punya hash
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.Create(&models.AuditLog{UserID: 1, Aksi: models.AuditCreateDocument, Timestamp: time.Now()}).Error)
+	}
+
+	service := NewAuditLogService(repositories.NewAuditLogRepository(db))
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+	service.LogActivity(1, models.AuditCreateDocument, "Dokumen baru")
+	wg.Wait()
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, int64(3), report.LegacyEntries)
+	assert.Equal(t, int64(1), report.CheckedEntries)
+}
+
+func TestAuditChain_CheckpointInterval(t *testing.T) {
+	service, db := setupAuditChain(t, auditCheckpointEvery+1)
+
+	var checkpoints []models.AuditCheckpoint
+	require.NoError(t, db.Order("id asc").Find(&checkpoints).Error)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, uint(1), checkpoints[0].LastLogID)
+	assert.Equal(t, uint(auditCheckpointEvery+1), checkpoints[1].LastLogID)
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+}
+
+// TestAuditChain_TwoInstancesShareDatabase meniru dua server yang memakai
+// database yang sama: chainMu tidak saling mengenal, jadi rantai hanya utuh
+// bila penyambungan dikunci di database.
+func TestAuditChain_TwoInstancesShareDatabase(t *testing.T) {
+	AuditSigningKey = []byte("kunci-uji-audit")
+	t.Cleanup(func() { AuditSigningKey = nil })
+	path := filepath.Join(t.TempDir(), "bersama.db")
+
+	var instances []*auditLogService
+	for i := 0; i < 2; i++ {
+		db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+		if i == 0 {
+			require.NoError(t, db.AutoMigrate(&models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+		}
+		instances = append(instances, NewAuditLogService(repositories.NewAuditLogRepository(db)).(*auditLogService))
+	}
+
+	const perInstance = 40
+	var wg sync.WaitGroup
+	for i, service := range instances {
+		wg.Add(1)
+		go func(actor uint, service *auditLogService) {
+			defer wg.Done()
+			for n := 0; n < perInstance; n++ {
+				entry := &models.AuditLog{UserID: actor, Aksi: models.AuditUpdateDocument, Detail: "paralel", Timestamp: time.Now()}
+				// "database is locked" ditulis ulang, sama seperti writer
+				for attempt := 0; attempt < 200; attempt++ {
+					if err := service.appendToChain([]*models.AuditLog{entry}); err == nil {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(uint(i+1), service)
+	}
+	wg.Wait()
+
+	report, err := instances[0].VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.EqualValues(t, 2*perInstance, report.CheckedEntries)
+}
@@ -2,10 +2,15 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"simdokpol/internal/dto" // Pastikan import DTO ada
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
+	"simdokpol/internal/utils"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,20 +19,64 @@ import (
 
 type AuditLogService interface {
 	LogActivity(userID uint, action string, details string)
+	// LogEvent mencatat entri terstruktur; IP, user agent dan request ID
+	// diambil dari ctx bila berasal dari request HTTP.
+	LogEvent(ctx context.Context, event dto.AuditEvent)
 	FindAll() ([]models.AuditLog, error)
+	FindEntityHistory(entityType, entityID string, limit int) ([]models.AuditLog, error)
 	ExportAuditLogs() (*bytes.Buffer, string, error)
 	SetWaitGroup(wg *sync.WaitGroup)
 	// Method baru untuk paging (Fix Performance)
-	GetAuditLogsPaged(req dto.DataTableRequest) (*dto.DataTableResponse, error)
+	GetAuditLogsPaged(req dto.DataTableRequest, filter dto.AuditLogFilter) (*dto.DataTableResponse, error)
+	// VerifyChain mengecek rantai hash dan checkpoint, melaporkan kerusakan pertama
+	VerifyChain() (*dto.AuditChainReport, error)
+	// Close menunggu antrean log audit selesai ditulis, dipanggil saat shutdown
+	Close(ctx context.Context) error
+	WriterStats() dto.AuditWriterStats
+	// Penerusan ke syslog/file di samping database
+	ConfigureSinks(config *dto.AppConfig)
+	SinkStatus() []dto.AuditSinkStatus
+
+	// Retensi: entri lama dipindah ke arsip bulanan bertanda tangan
+	ApplyRetention(ctx context.Context, retentionDays int, actorID uint) (*dto.AuditRetentionResult, error)
+	ListArchives() ([]models.AuditArchive, error)
+	SearchArchive(id uint, req dto.DataTableRequest) (*dto.DataTableResponse, error)
+	GetArchiveFile(id uint) (*models.AuditArchive, string, error)
+	// FindArchivedEntityHistory mencari riwayat satu entitas di arsip,
+	// terbaru dulu; arsip yang gagal dibaca dikembalikan di unreadable
+	FindArchivedEntityHistory(entityType, entityID string, limit int) (entries []dto.AuditArchiveEntry, unreadable []models.AuditArchive, err error)
 }
 
 type auditLogService struct {
-	repo repositories.AuditLogRepository
-	wg   *sync.WaitGroup
+	repo    repositories.AuditLogRepository
+	wg      *sync.WaitGroup
+	chainMu sync.Mutex
+
+	// Writer tunggal: antrean terbatas + journal di disk saat database tidak bisa ditulis
+	queue     chan *models.AuditLog
+	writerMu  sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	stopCh    chan struct{}
+	done      chan struct{}
+	journalMu sync.Mutex
+	stats     auditWriterStats
+
+	retentionMu sync.Mutex
+
+	sinkMu sync.RWMutex
+	sinks  []*auditSinkWorker
 }
 
 func NewAuditLogService(repo repositories.AuditLogRepository) AuditLogService {
-	return &auditLogService{repo: repo}
+	s := &auditLogService{
+		repo:   repo,
+		queue:  make(chan *models.AuditLog, auditQueueSize),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.runWriter()
+	return s
 }
 
 // SetWaitGroup digunakan oleh unit test
@@ -35,34 +84,80 @@ func (s *auditLogService) SetWaitGroup(wg *sync.WaitGroup) {
 	s.wg = wg
 }
 
-// LogActivity berjalan async
+// LogActivity berjalan async lewat antrean writer
 func (s *auditLogService) LogActivity(userID uint, action string, details string) {
-	if s.wg != nil {
-		s.wg.Add(1)
+	s.LogEvent(context.Background(), dto.AuditEvent{UserID: userID, Action: action, Detail: details})
+}
+
+func (s *auditLogService) LogEvent(ctx context.Context, event dto.AuditEvent) {
+	meta := utils.RequestMetaFromContext(ctx)
+	logEntry := &models.AuditLog{
+		UserID:     event.UserID,
+		Aksi:       event.Action,
+		Detail:     event.Detail,
+		Timestamp:  time.Now(),
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		RequestID:  meta.RequestID,
+		// Snapshot di-serialize sekarang, sebelum objeknya sempat diubah pemanggil
+		Before: auditSnapshot(event.Before),
+		After:  auditSnapshot(event.After),
 	}
 
-	go func() {
-		if s.wg != nil {
-			defer s.wg.Done()
-		}
-		
-		logEntry := &models.AuditLog{
-			UserID:    userID,
-			Aksi:      action,
-			Detail:    details,
-			Timestamp: time.Now(),
-		}
-		_ = s.repo.Create(logEntry)
-	}()
+	s.enqueue(logEntry)
+}
+
+func auditSnapshot(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("WARN: snapshot audit gagal di-serialize: %v", err)
+		return ""
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
 }
 
 func (s *auditLogService) FindAll() ([]models.AuditLog, error) {
 	return s.repo.FindAll()
 }
 
+func (s *auditLogService) FindEntityHistory(entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	if s.repo == nil {
+		return nil, ErrAuditStoreUnavailable
+	}
+	return s.repo.FindByEntity(entityType, entityID, limit)
+}
+
 // GetAuditLogsPaged: Logic baru untuk server-side paging
-func (s *auditLogService) GetAuditLogsPaged(req dto.DataTableRequest) (*dto.DataTableResponse, error) {
-	logs, total, filtered, err := s.repo.FindAllPaged(req)
+func (s *auditLogService) GetAuditLogsPaged(req dto.DataTableRequest, filter dto.AuditLogFilter) (*dto.DataTableResponse, error) {
+	if from := strings.TrimSpace(filter.DateFrom); from != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, ErrInvalidAuditFilter
+		}
+		filter.From = &parsed
+	}
+	if to := strings.TrimSpace(filter.DateTo); to != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, ErrInvalidAuditFilter
+		}
+		// Inklusif: sampai akhir hari tersebut
+		end := parsed.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidAuditFilter
+	}
+
+	logs, total, filtered, err := s.repo.FindAllPaged(req, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +183,7 @@ func (s *auditLogService) ExportAuditLogs() (*bytes.Buffer, string, error) {
 	}
 	f.DeleteSheet("Sheet1")
 
-	headers := []string{"Waktu", "Pengguna (Aktor)", "NRP", "Aksi", "Detail Aktivitas"}
+	headers := []string{"ID", "Waktu", "Pengguna (Aktor)", "NRP", "Aksi", "Detail Aktivitas", "Entitas", "ID Entitas", "Alamat IP", "Request ID", "Hash Sebelumnya", "Hash"}
 	for i, header := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, header)
@@ -104,11 +199,18 @@ func (s *auditLogService) ExportAuditLogs() (*bytes.Buffer, string, error) {
 			userNRP = logEntry.User.NRP
 		}
 
-		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), logEntry.Timestamp.Format("02-01-2006 15:04:05"))
-		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), userName)
-		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), userNRP)
-		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), logEntry.Aksi)
-		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), logEntry.Detail)
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), logEntry.ID)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), logEntry.Timestamp.Format("02-01-2006 15:04:05"))
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), userName)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), userNRP)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), logEntry.Aksi)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), logEntry.Detail)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), logEntry.EntityType)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), logEntry.EntityID)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), logEntry.IPAddress)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), logEntry.RequestID)
+		f.SetCellValue(sheet, fmt.Sprintf("K%d", row), logEntry.PrevHash)
+		f.SetCellValue(sheet, fmt.Sprintf("L%d", row), logEntry.Hash)
 	}
 
 	buffer, err := f.WriteToBuffer()
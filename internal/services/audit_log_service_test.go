@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAuditLogService_LogEventAndFilters(t *testing.T) {
+	AuditSigningKey = []byte("kunci-uji-audit")
+	t.Cleanup(func() { AuditSigningKey = nil })
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+
+	service := NewAuditLogService(repositories.NewAuditLogRepository(db))
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+
+	ctx := utils.WithRequestMeta(context.Background(), utils.RequestMeta{
+		IPAddress: "10.0.0.7",
+		UserAgent: "Mozilla/5.0",
+		RequestID: "req-12345678",
+	})
+	service.LogEvent(ctx, dto.AuditEvent{
+		UserID:     2,
+		Action:     models.AuditUpdateUser,
+		Detail:     "Mengubah data pengguna",
+		EntityType: models.AuditEntityUser,
+		EntityID:   "9",
+		Before:     map[string]string{"jabatan": "BRIPDA"},
+		After:      map[string]string{"jabatan": "BRIPTU"},
+	})
+	wg.Wait()
+	service.LogActivity(1, models.AuditSSOLogin, "Login SSO")
+	wg.Wait()
+
+	var stored models.AuditLog
+	require.NoError(t, db.Where("entity_type = ?", models.AuditEntityUser).First(&stored).Error)
+	assert.Equal(t, "9", stored.EntityID)
+	assert.Equal(t, "10.0.0.7", stored.IPAddress)
+	assert.Equal(t, "Mozilla/5.0", stored.UserAgent)
+	assert.Equal(t, "req-12345678", stored.RequestID)
+	var before map[string]string
+	require.NoError(t, json.Unmarshal([]byte(stored.Before), &before))
+	assert.Equal(t, "BRIPDA", before["jabatan"])
+	assert.JSONEq(t, `{"jabatan":"BRIPTU"}`, stored.After)
+
+	// Field baru ikut di-hash, jadi mengubahnya memutus rantai
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+
+	today := time.Now().Format("2006-01-02")
+	testCases := []struct {
+		name     string
+		filter   dto.AuditLogFilter
+		expected int
+	}{
+		{"Tanpa Filter", dto.AuditLogFilter{}, 2},
+		{"Entitas", dto.AuditLogFilter{EntityType: models.AuditEntityUser, EntityID: "9"}, 1},
+		{"Aktor", dto.AuditLogFilter{ActorID: 1}, 1},
+		{"Alamat IP", dto.AuditLogFilter{IPAddress: "10.0.0.7"}, 1},
+		{"Request ID", dto.AuditLogFilter{RequestID: "req-12345678"}, 1},
+		{"Rentang Tanggal Hari Ini", dto.AuditLogFilter{DateFrom: today, DateTo: today}, 2},
+		{"Rentang Tanggal Lampau", dto.AuditLogFilter{DateFrom: "2020-01-01", DateTo: "2020-01-31"}, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := service.GetAuditLogsPaged(dto.DataTableRequest{Draw: 1, Length: 10}, tc.filter)
+			require.NoError(t, err)
+			assert.Len(t, resp.Data.([]models.AuditLog), tc.expected)
+		})
+	}
+
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("id = ?", stored.ID).Update("ip_address", "10.0.0.8").Error)
+	report, err = service.VerifyChain()
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, stored.ID, report.BrokenLogID)
+
+	_, err = service.GetAuditLogsPaged(dto.DataTableRequest{}, dto.AuditLogFilter{DateFrom: "2025-02-10", DateTo: "2025-02-01"})
+	assert.ErrorIs(t, err, ErrInvalidAuditFilter)
+	_, err = service.GetAuditLogsPaged(dto.DataTableRequest{}, dto.AuditLogFilter{DateFrom: "10/02/2025"})
+	assert.ErrorIs(t, err, ErrInvalidAuditFilter)
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditRetentionFirstRun = 5 * time.Minute
+	auditRetentionInterval = 24 * time.Hour
+)
+
+// signArchive menandatangani arsip beserta tanda tangan arsip sebelumnya,
+// sama seperti checkpoint, sehingga arsip yang dihapus di tengah ketahuan.
+func signArchive(archive *models.AuditArchive, prevSignature string) string {
+	mac := hmac.New(sha256.New, AuditSigningKey)
+	fmt.Fprintf(mac, "%s|%s|%d|%d|%d|%s|%s|%s|%d|%s",
+		archive.Period, archive.FileName, archive.FirstLogID, archive.LastLogID, archive.EntryCount,
+		archive.PrevHash, archive.LastHash, archive.FileSHA256, archive.CreatedAt.UnixMilli(), prevSignature)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newAuditArchiveEntry(entry *models.AuditLog) dto.AuditArchiveEntry {
+	return dto.AuditArchiveEntry{
+		ID:         entry.ID,
+		Timestamp:  entry.Timestamp,
+		UserID:     entry.UserID,
+		UserName:   entry.User.NamaLengkap,
+		UserNRP:    entry.User.NRP,
+		Aksi:       entry.Aksi,
+		Detail:     entry.Detail,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Before:     entry.Before,
+		After:      entry.After,
+		PrevHash:   entry.PrevHash,
+		Hash:       entry.Hash,
+	}
+}
+
+func archiveEntryHash(entry dto.AuditArchiveEntry) string {
+	return computeAuditHash(&models.AuditLog{
+		UserID:     entry.UserID,
+		Aksi:       entry.Aksi,
+		Detail:     entry.Detail,
+		Timestamp:  entry.Timestamp,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Before:     entry.Before,
+		After:      entry.After,
+		PrevHash:   entry.PrevHash,
+	})
+}
+
+// auditArchiveFile menulis satu file arsip: gzip di atas file, SHA-256 dihitung dari byte terkompresi.
+type auditArchiveFile struct {
+	archive *models.AuditArchive
+	tmpPath string
+	file    *os.File
+	hasher  hash.Hash
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+func newAuditArchiveFile(period string, first *models.AuditLog) (*auditArchiveFile, error) {
+	if err := os.MkdirAll(AuditArchiveDir, 0700); err != nil {
+		return nil, err
+	}
+	fileName := fmt.Sprintf("audit-%s-%06d.jsonl.gz", period, first.ID)
+	tmpPath := filepath.Join(AuditArchiveDir, fileName+".tmp")
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, hasher))
+	return &auditArchiveFile{
+		archive: &models.AuditArchive{
+			Period:     period,
+			FileName:   fileName,
+			FirstLogID: first.ID,
+			PrevHash:   first.PrevHash,
+		},
+		tmpPath: tmpPath,
+		file:    file,
+		hasher:  hasher,
+		gz:      gz,
+		encoder: json.NewEncoder(gz),
+	}, nil
+}
+
+func (f *auditArchiveFile) write(entry *models.AuditLog) error {
+	if err := f.encoder.Encode(newAuditArchiveEntry(entry)); err != nil {
+		return err
+	}
+	f.archive.LastLogID = entry.ID
+	f.archive.LastHash = entry.Hash
+	f.archive.EntryCount++
+	return nil
+}
+
+func (f *auditArchiveFile) close() error {
+	if err := f.gz.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.archive.FileSHA256 = hex.EncodeToString(f.hasher.Sum(nil))
+	return os.Rename(f.tmpPath, filepath.Join(AuditArchiveDir, f.archive.FileName))
+}
+
+func (f *auditArchiveFile) discard() {
+	f.file.Close()
+	os.Remove(f.tmpPath)
+	os.Remove(filepath.Join(AuditArchiveDir, f.archive.FileName))
+}
+
+// ApplyRetention memindahkan entri yang lebih tua dari retentionDays ke file
+// arsip bulanan lalu menghapusnya dari database. Rantai diperiksa dulu; bila
+// rusak, retensi dibatalkan agar bukti tidak ikut terhapus. Hasil setiap
+// jalan dicatat di metrik /metrics.
+func (s *auditLogService) ApplyRetention(ctx context.Context, retentionDays int, actorID uint) (*dto.AuditRetentionResult, error) {
+	result, err := s.applyRetention(ctx, retentionDays, actorID)
+	if errors.Is(err, ErrAuditRetentionDisabled) {
+		return result, err
+	}
+	auditArchiveRunsTotal.inc(metricResult(err))
+	if err == nil {
+		auditArchivedEntriesTotal.add(float64(result.ArchivedEntries))
+		lastAuditArchiveSuccess.Store(time.Now().Unix())
+	}
+	return result, err
+}
+
+func (s *auditLogService) applyRetention(ctx context.Context, retentionDays int, actorID uint) (*dto.AuditRetentionResult, error) {
+	if retentionDays <= 0 {
+		return nil, ErrAuditRetentionDisabled
+	}
+	if len(AuditSigningKey) == 0 || AuditArchiveDir == "" {
+		return nil, ErrAuditArchiveUnavailable
+	}
+	if s.repo == nil {
+		return nil, ErrAuditStoreUnavailable
+	}
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+
+	result := &dto.AuditRetentionResult{
+		RetentionDays: retentionDays,
+		Cutoff:        time.Now().AddDate(0, 0, -retentionDays),
+		Archives:      []models.AuditArchive{},
+	}
+
+	prevHash, prevSignature := "", ""
+	anchor, err := s.repo.FindLastArchive()
+	if err != nil {
+		return nil, err
+	}
+	if anchor != nil {
+		prevHash, prevSignature = anchor.LastHash, anchor.Signature
+	}
+
+	var current *auditArchiveFile
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		archiveFile := current
+		current = nil
+		if err := archiveFile.close(); err != nil {
+			archiveFile.discard()
+			return err
+		}
+		archive := archiveFile.archive
+		archive.CreatedAt = time.Now().Truncate(time.Millisecond)
+		archive.Signature = signArchive(archive, prevSignature)
+		if err := s.repo.ArchiveRange(archive); err != nil {
+			archiveFile.discard()
+			return err
+		}
+		prevSignature = archive.Signature
+		result.Archives = append(result.Archives, *archive)
+		result.ArchivedEntries += archive.EntryCount
+
+		s.LogEvent(ctx, dto.AuditEvent{
+			UserID:     actorID,
+			Action:     models.AuditLogArchived,
+			Detail:     fmt.Sprintf("Mengarsipkan %d log audit periode %s ke %s.", archive.EntryCount, archive.Period, archive.FileName),
+			EntityType: models.AuditEntityAuditArchive,
+			EntityID:   strconv.FormatUint(uint64(archive.ID), 10),
+			After:      archive,
+		})
+		return nil
+	}
+
+	var afterID uint
+	for done := false; !done; {
+		batch, err := s.repo.FindBatchForArchive(afterID, auditVerifyBatchSize)
+		if err != nil {
+			if current != nil {
+				current.discard()
+			}
+			return nil, err
+		}
+		for i := range batch {
+			entry := &batch[i]
+			if !entry.Timestamp.Before(result.Cutoff) {
+				done = true
+				break
+			}
+			if entry.Hash != "" || prevHash != "" {
+				if entry.PrevHash != prevHash || computeAuditHash(entry) != entry.Hash {
+					if current != nil {
+						current.discard()
+					}
+					return nil, fmt.Errorf("%w (entri #%d)", ErrAuditChainBroken, entry.ID)
+				}
+			}
+
+			period := entry.Timestamp.Format("2006-01")
+			if current != nil && current.archive.Period != period {
+				if err := finish(); err != nil {
+					return nil, err
+				}
+			}
+			if current == nil {
+				if current, err = newAuditArchiveFile(period, entry); err != nil {
+					return nil, err
+				}
+			}
+			if err := current.write(entry); err != nil {
+				current.discard()
+				return nil, err
+			}
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+		if len(batch) < auditVerifyBatchSize {
+			done = true
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *auditLogService) ListArchives() ([]models.AuditArchive, error) {
+	return s.repo.FindArchives()
+}
+
+// GetArchiveFile mengembalikan data arsip beserta lokasi filenya untuk diunduh.
+func (s *auditLogService) GetArchiveFile(id uint) (*models.AuditArchive, string, error) {
+	archive, err := s.repo.FindArchiveByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return archive, filepath.Join(AuditArchiveDir, filepath.Base(archive.FileName)), nil
+}
+
+// SearchArchive membaca arsip (read-only) setelah tanda tangan, checksum dan
+// rantai hash di dalamnya dicocokkan, lalu memfilter & memotong per halaman.
+func (s *auditLogService) SearchArchive(id uint, req dto.DataTableRequest) (*dto.DataTableResponse, error) {
+	archive, path, err := s.GetArchiveFile(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.readArchive(archive, path)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := entries
+	if search := strings.ToLower(strings.TrimSpace(req.Search)); search != "" {
+		filtered = make([]dto.AuditArchiveEntry, 0)
+		for _, entry := range entries {
+			haystack := strings.ToLower(strings.Join([]string{
+				entry.Aksi, entry.Detail, entry.UserName, entry.UserNRP,
+				entry.EntityType, entry.EntityID, entry.IPAddress, entry.RequestID,
+			}, " "))
+			if strings.Contains(haystack, search) {
+				filtered = append(filtered, entry)
+			}
+		}
+	}
+
+	limit := req.Length
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	start := req.Start
+	if start < 0 || start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	return &dto.DataTableResponse{
+		Draw:            req.Draw,
+		RecordsTotal:    int64(len(entries)),
+		RecordsFiltered: int64(len(filtered)),
+		Data:            filtered[start:end],
+	}, nil
+}
+
+// FindArchivedEntityHistory menelusuri semua arsip dari yang terbaru. Arsip
+// yang tanda tangan/isinya tidak cocok atau tidak bisa dibaca (mis. kunci
+// tanda tangan tidak ada) dilewati dan dilaporkan, bukan menggagalkan semua.
+func (s *auditLogService) FindArchivedEntityHistory(entityType, entityID string, limit int) ([]dto.AuditArchiveEntry, []models.AuditArchive, error) {
+	archives, err := s.repo.FindArchives()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var found []dto.AuditArchiveEntry
+	var unreadable []models.AuditArchive
+	for i := len(archives) - 1; i >= 0 && len(found) < limit; i-- {
+		archive := archives[i]
+		entries, err := s.readArchive(&archive, filepath.Join(AuditArchiveDir, filepath.Base(archive.FileName)))
+		if err != nil {
+			log.Printf("WARN: arsip log audit %s tidak ikut ditelusuri: %v", archive.FileName, err)
+			unreadable = append(unreadable, archive)
+			continue
+		}
+		for j := len(entries) - 1; j >= 0 && len(found) < limit; j-- {
+			if entries[j].EntityType == entityType && entries[j].EntityID == entityID {
+				found = append(found, entries[j])
+			}
+		}
+	}
+	return found, unreadable, nil
+}
+
+func (s *auditLogService) readArchive(archive *models.AuditArchive, path string) ([]dto.AuditArchiveEntry, error) {
+	tampered := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrAuditArchiveTampered, reason)
+	}
+
+	if len(AuditSigningKey) == 0 {
+		return nil, ErrAuditArchiveUnavailable
+	}
+	archives, err := s.repo.FindArchives()
+	if err != nil {
+		return nil, err
+	}
+	prevSignature := ""
+	for i := range archives {
+		if archives[i].ID == archive.ID {
+			break
+		}
+		prevSignature = archives[i].Signature
+	}
+	if !hmac.Equal([]byte(signArchive(archive, prevSignature)), []byte(archive.Signature)) {
+		return nil, tampered("tanda tangan arsip tidak valid")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) != archive.FileSHA256 {
+		return nil, tampered("checksum file berbeda")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, tampered("file bukan gzip")
+	}
+	defer gz.Close()
+
+	entries := make([]dto.AuditArchiveEntry, 0, archive.EntryCount)
+	prevHash := archive.PrevHash
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry dto.AuditArchiveEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, tampered("baris arsip rusak")
+		}
+		if entry.Hash != "" || prevHash != "" {
+			if entry.PrevHash != prevHash || archiveEntryHash(entry) != entry.Hash {
+				return nil, tampered(fmt.Sprintf("entri #%d tidak cocok dengan rantai hash", entry.ID))
+			}
+		}
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, tampered("arsip tidak terbaca utuh")
+	}
+	if len(entries) != archive.EntryCount || prevHash != archive.LastHash {
+		return nil, tampered("jumlah entri atau hash terakhir berbeda")
+	}
+	return entries, nil
+}
+
+// StartAuditRetentionJob menjalankan retensi log audit sehari sekali sesuai
+// audit_retention_days, dicatat atas nama akun sistem actorID. Fungsi yang
+// dikembalikan menghentikan job.
+func StartAuditRetentionJob(auditService AuditLogService, configService ConfigService, actorID uint) (stop func()) {
+	stopCh := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(auditRetentionFirstRun)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-timer.C:
+			}
+
+			leave, _ := Maintenance.Hold(context.Background())
+			appConfig, err := configService.GetConfig()
+			if err == nil && appConfig.AuditRetentionDays > 0 {
+				result, err := auditService.ApplyRetention(context.Background(), appConfig.AuditRetentionDays, actorID)
+				if err != nil {
+					log.Printf("ERROR: retensi log audit gagal: %v", err)
+				} else if result.ArchivedEntries > 0 {
+					log.Printf("INFO: %d log audit diarsipkan ke %d file", result.ArchivedEntries, len(result.Archives))
+				}
+			}
+			leave()
+			timer.Reset(auditRetentionInterval)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stopCh) }) }
+}
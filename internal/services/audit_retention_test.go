@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupAuditRetention mengisi 3 entri Januari 2025, 2 entri Februari 2025 dan 2 entri baru.
+func setupAuditRetention(t *testing.T) (*auditLogService, *gorm.DB, *sync.WaitGroup) {
+	t.Helper()
+	AuditSigningKey = []byte("kunci-uji-audit")
+	AuditArchiveDir = t.TempDir()
+	t.Cleanup(func() {
+		AuditSigningKey = nil
+		AuditArchiveDir = ""
+	})
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+	require.NoError(t, db.Create(&models.User{ID: 1, NamaLengkap: "Budi Santoso", NRP: "85010001", KataSandi: "x", Peran: models.RoleSuperAdmin}).Error)
+
+	service := NewAuditLogService(repositories.NewAuditLogRepository(db)).(*auditLogService)
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+
+	var entries []*models.AuditLog
+	for i := 1; i <= 3; i++ {
+		entries = append(entries, &models.AuditLog{UserID: 1, Aksi: models.AuditCreateDocument, Detail: fmt.Sprintf("Januari ke-%d", i), Timestamp: time.Date(2025, 1, 10+i, 9, 0, 0, 0, time.Local)})
+	}
+	for i := 1; i <= 2; i++ {
+		entries = append(entries, &models.AuditLog{UserID: 1, Aksi: models.AuditUpdateDocument, Detail: fmt.Sprintf("Februari ke-%d", i), Timestamp: time.Date(2025, 2, 3+i, 9, 0, 0, 0, time.Local)})
+	}
+	for i := 1; i <= 2; i++ {
+		entries = append(entries, &models.AuditLog{UserID: 1, Aksi: models.AuditUpdateDocument, Detail: fmt.Sprintf("Baru ke-%d", i), Timestamp: time.Now()})
+	}
+	// Satu entri Januari dan satu Februari menyangkut penduduk ID 7
+	for _, entry := range []*models.AuditLog{entries[1], entries[3]} {
+		entry.EntityType, entry.EntityID = models.AuditEntityResident, "7"
+	}
+	for _, entry := range entries {
+		require.NoError(t, service.appendToChain([]*models.AuditLog{entry}))
+	}
+	return service, db, &wg
+}
+
+func TestAuditRetention_ArchivesAndKeepsChainValid(t *testing.T) {
+	service, db, wg := setupAuditRetention(t)
+
+	result, err := service.ApplyRetention(context.Background(), 30, 1)
+	require.NoError(t, err)
+	wg.Wait()
+
+	require.Len(t, result.Archives, 2)
+	assert.Equal(t, 5, result.ArchivedEntries)
+	assert.Equal(t, "2025-01", result.Archives[0].Period)
+	assert.Equal(t, 3, result.Archives[0].EntryCount)
+	assert.Equal(t, "2025-02", result.Archives[1].Period)
+	assert.FileExists(t, filepath.Join(AuditArchiveDir, result.Archives[0].FileName))
+
+	// Tersisa 2 entri baru + 2 catatan retensi itu sendiri
+	var remaining []models.AuditLog
+	require.NoError(t, db.Order("id asc").Find(&remaining).Error)
+	require.Len(t, remaining, 4)
+	assert.Equal(t, models.AuditLogArchived, remaining[2].Aksi)
+	assert.Equal(t, models.AuditEntityAuditArchive, remaining[2].EntityType)
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, 2, report.Archives)
+	assert.Equal(t, int64(5), report.ArchivedEntries)
+	assert.Equal(t, int64(4), report.CheckedEntries)
+
+	// Arsip bisa dicari tanpa dikembalikan ke database
+	resp, err := service.SearchArchive(result.Archives[0].ID, dto.DataTableRequest{Draw: 1, Length: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.RecordsTotal)
+	entries := resp.Data.([]dto.AuditArchiveEntry)
+	assert.Equal(t, "Januari ke-1", entries[0].Detail)
+	assert.Equal(t, "Budi Santoso", entries[0].UserName)
+
+	resp, err = service.SearchArchive(result.Archives[1].ID, dto.DataTableRequest{Draw: 1, Length: 10, Search: "ke-2"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.RecordsFiltered)
+
+	// Jalan kedua tidak menemukan entri kedaluwarsa
+	again, err := service.ApplyRetention(context.Background(), 30, 1)
+	require.NoError(t, err)
+	assert.Empty(t, again.Archives)
+}
+
+func TestAuditRetention_FindArchivedEntityHistory(t *testing.T) {
+	service, _, wg := setupAuditRetention(t)
+
+	result, err := service.ApplyRetention(context.Background(), 30, 1)
+	require.NoError(t, err)
+	wg.Wait()
+	require.Len(t, result.Archives, 2)
+
+	entries, unreadable, err := service.FindArchivedEntityHistory(models.AuditEntityResident, "7", 10)
+	require.NoError(t, err)
+	assert.Empty(t, unreadable)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Februari ke-1", entries[0].Detail, "terbaru dulu")
+	assert.Equal(t, "Januari ke-2", entries[1].Detail)
+
+	entries, _, err = service.FindArchivedEntityHistory(models.AuditEntityResident, "7", 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Februari ke-1", entries[0].Detail)
+
+	// Arsip Februari rusak: dilewati dan dilaporkan, Januari tetap terbaca
+	path := filepath.Join(AuditArchiveDir, result.Archives[1].FileName)
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	raw[len(raw)-10] ^= 0xFF
+	require.NoError(t, os.WriteFile(path, raw, 0600))
+
+	entries, unreadable, err = service.FindArchivedEntityHistory(models.AuditEntityResident, "7", 10)
+	require.NoError(t, err)
+	require.Len(t, unreadable, 1)
+	assert.Equal(t, "2025-02", unreadable[0].Period)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Januari ke-2", entries[0].Detail)
+}
+
+func TestAuditRetention_DetectsTampering(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tamper func(t *testing.T, db *gorm.DB, archives []models.AuditArchive)
+		check  func(t *testing.T, service *auditLogService, archives []models.AuditArchive)
+	}{
+		{
+			name: "File Arsip Diubah",
+			tamper: func(t *testing.T, db *gorm.DB, archives []models.AuditArchive) {
+				path := filepath.Join(AuditArchiveDir, archives[0].FileName)
+				raw, err := os.ReadFile(path)
+				require.NoError(t, err)
+				raw[len(raw)-10] ^= 0xFF
+				require.NoError(t, os.WriteFile(path, raw, 0600))
+			},
+			check: func(t *testing.T, service *auditLogService, archives []models.AuditArchive) {
+				_, err := service.SearchArchive(archives[0].ID, dto.DataTableRequest{})
+				assert.ErrorIs(t, err, ErrAuditArchiveTampered)
+			},
+		},
+		{
+			name: "Arsip Dihapus Dari Daftar",
+			tamper: func(t *testing.T, db *gorm.DB, archives []models.AuditArchive) {
+				require.NoError(t, db.Delete(&models.AuditArchive{}, archives[0].ID).Error)
+			},
+			check: func(t *testing.T, service *auditLogService, archives []models.AuditArchive) {
+				report, err := service.VerifyChain()
+				require.NoError(t, err)
+				assert.False(t, report.Valid)
+				assert.Equal(t, archives[1].ID, report.BrokenArchiveID)
+			},
+		},
+		{
+			name: "Data Arsip Diubah",
+			tamper: func(t *testing.T, db *gorm.DB, archives []models.AuditArchive) {
+				require.NoError(t, db.Model(&models.AuditArchive{}).Where("id = ?", archives[1].ID).Update("entry_count", 1).Error)
+			},
+			check: func(t *testing.T, service *auditLogService, archives []models.AuditArchive) {
+				report, err := service.VerifyChain()
+				require.NoError(t, err)
+				assert.False(t, report.Valid)
+				assert.Contains(t, report.Reason, "tanda tangan arsip")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, db, wg := setupAuditRetention(t)
+			result, err := service.ApplyRetention(context.Background(), 30, 1)
+			require.NoError(t, err)
+			wg.Wait()
+
+			tc.tamper(t, db, result.Archives)
+			tc.check(t, service, result.Archives)
+		})
+	}
+}
+
+func TestAuditRetention_RefusesBrokenChain(t *testing.T) {
+	service, db, _ := setupAuditRetention(t)
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("id = ?", 2).Update("detail", "Diubah").Error)
+
+	_, err := service.ApplyRetention(context.Background(), 30, 1)
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, int64(7), countAuditLogs(t, db))
+
+	var archives int64
+	require.NoError(t, db.Model(&models.AuditArchive{}).Count(&archives).Error)
+	assert.Zero(t, archives)
+
+	_, err = service.ApplyRetention(context.Background(), 0, 1)
+	assert.ErrorIs(t, err, ErrAuditRetentionDisabled)
+}
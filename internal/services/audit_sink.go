@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"log"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Antrean per sink; bila tujuan lambat/putus entri baru dibuang (database tetap sumber utama)
+const auditSinkQueueSize = 4096
+
+// auditSink adalah tujuan penerusan log audit selain database (syslog, file).
+// Write dipanggil dari satu goroutine per sink, berurutan sesuai rantai hash.
+type auditSink interface {
+	Name() string
+	// Target ditampilkan di halaman admin, mis. "tcp://10.0.0.5:514"
+	Target() string
+	Write(entry dto.AuditArchiveEntry) error
+	Close() error
+}
+
+type auditSinkWorker struct {
+	sink  auditSink
+	queue chan dto.AuditArchiveEntry
+	done  chan struct{}
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+
+	mu          sync.Mutex
+	lastSentAt  time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func newAuditSinkWorker(sink auditSink) *auditSinkWorker {
+	w := &auditSinkWorker{
+		sink:  sink,
+		queue: make(chan dto.AuditArchiveEntry, auditSinkQueueSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *auditSinkWorker) run() {
+	defer close(w.done)
+	for entry := range w.queue {
+		err := w.sink.Write(entry)
+		w.mu.Lock()
+		if err != nil {
+			w.failed.Add(1)
+			w.lastError = err.Error()
+			w.lastErrorAt = time.Now()
+		} else {
+			w.sent.Add(1)
+			w.lastSentAt = time.Now()
+		}
+		w.mu.Unlock()
+		if err != nil {
+			log.Printf("WARN: log audit #%d gagal diteruskan ke %s: %v", entry.ID, w.sink.Name(), err)
+		}
+	}
+}
+
+// stop menunggu antrean terkirim (atau ctx habis) lalu menutup sink.
+func (w *auditSinkWorker) stop(ctx context.Context) {
+	close(w.queue)
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		log.Printf("WARN: %d log audit belum terkirim ke %s saat ditutup", len(w.queue), w.sink.Name())
+	}
+	if err := w.sink.Close(); err != nil {
+		log.Printf("WARN: gagal menutup sink log audit %s: %v", w.sink.Name(), err)
+	}
+}
+
+func (w *auditSinkWorker) status() dto.AuditSinkStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := dto.AuditSinkStatus{
+		Name:    w.sink.Name(),
+		Target:  w.sink.Target(),
+		Sent:    w.sent.Load(),
+		Failed:  w.failed.Load(),
+		Dropped: w.dropped.Load(),
+		Pending: len(w.queue),
+	}
+	if !w.lastSentAt.IsZero() {
+		sentAt := w.lastSentAt
+		status.LastSentAt = &sentAt
+	}
+	if w.lastError != "" {
+		errorAt := w.lastErrorAt
+		status.LastError = w.lastError
+		status.LastErrorAt = &errorAt
+	}
+	return status
+}
+
+// forwardToSinks dipanggil writer setelah batch tersimpan di database,
+// sehingga yang diteruskan sudah lengkap dengan ID dan hash.
+func (s *auditLogService) forwardToSinks(entries []*models.AuditLog) {
+	s.sinkMu.RLock()
+	defer s.sinkMu.RUnlock()
+	for _, worker := range s.sinks {
+		for _, entry := range entries {
+			select {
+			case worker.queue <- newAuditArchiveEntry(entry):
+			default:
+				worker.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// ConfigureSinks menyusun ulang sink sesuai pengaturan. Kesalahan koneksi tidak
+// dicek di sini; hasilnya terlihat di status pengiriman tiap sink.
+func (s *auditLogService) ConfigureSinks(config *dto.AppConfig) {
+	s.setSinks(newAuditSinks(config))
+}
+
+// setSinks mengganti daftar sink. Sink lama dikuras dan ditutup di latar belakang.
+func (s *auditLogService) setSinks(sinks []auditSink) {
+	workers := make([]*auditSinkWorker, 0, len(sinks))
+	for _, sink := range sinks {
+		workers = append(workers, newAuditSinkWorker(sink))
+	}
+
+	s.sinkMu.Lock()
+	old := s.sinks
+	s.sinks = workers
+	s.sinkMu.Unlock()
+
+	if len(old) > 0 {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			for _, worker := range old {
+				worker.stop(ctx)
+			}
+		}()
+	}
+}
+
+func (s *auditLogService) SinkStatus() []dto.AuditSinkStatus {
+	s.sinkMu.RLock()
+	defer s.sinkMu.RUnlock()
+	statuses := make([]dto.AuditSinkStatus, 0, len(s.sinks))
+	for _, worker := range s.sinks {
+		statuses = append(statuses, worker.status())
+	}
+	return statuses
+}
+
+// closeSinks dipanggil Close setelah writer selesai, agar entri terakhir ikut terkirim.
+func (s *auditLogService) closeSinks(ctx context.Context) {
+	s.sinkMu.Lock()
+	workers := s.sinks
+	s.sinks = nil
+	s.sinkMu.Unlock()
+	for _, worker := range workers {
+		worker.stop(ctx)
+	}
+}
+
+// newAuditSinks menyusun sink yang diaktifkan di pengaturan.
+func newAuditSinks(config *dto.AppConfig) []auditSink {
+	var sinks []auditSink
+	if config == nil {
+		return sinks
+	}
+	if config.AuditSyslog.Enabled && config.AuditSyslog.Address != "" {
+		sinks = append(sinks, newSyslogAuditSink(config.AuditSyslog))
+	}
+	if config.AuditFile.Enabled && config.AuditFile.Path != "" {
+		// Pengaturan lama bisa saja menunjuk ke luar folder data
+		if path, err := ResolveAuditFilePath(config.AuditFile.Path); err != nil {
+			log.Printf("WARN: sink file log audit dimatikan: %v", err)
+		} else {
+			fileConfig := config.AuditFile
+			fileConfig.Path = path
+			sinks = append(sinks, newFileAuditSink(fileConfig))
+		}
+	}
+	return sinks
+}
+
+// ReloadAuditSinks membaca ulang pengaturan penerusan log audit, dipanggil saat
+// start dan setiap kali pengaturan syslog/file diubah.
+func ReloadAuditSinks(auditService AuditLogService, configService ConfigService) error {
+	config, err := configService.GetConfig()
+	if err != nil {
+		return err
+	}
+	auditService.ConfigureSinks(config)
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/utils"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ResolveAuditFilePath memastikan file log audit berada di folder data
+// aplikasi; path relatif dianggap relatif ke folder itu. Sink file menambah,
+// merotasi dan mengganti nama file tersebut sebagai user server, jadi path di
+// luar folder data (termasuk lewat symlink) ditolak.
+func ResolveAuditFilePath(path string) (string, error) {
+	dataDir, err := filepath.Abs(utils.GetAppDataDir())
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dataDir, path)
+	}
+	resolvedDir, err := resolveExistingPath(dataDir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := resolveExistingPath(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(resolvedDir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("file log audit harus berada di folder data aplikasi (" + dataDir + ")")
+	}
+	return resolved, nil
+}
+
+// resolveExistingPath menyelesaikan symlink pada bagian path yang sudah ada;
+// sisa path yang belum dibuat ditempel apa adanya.
+func resolveExistingPath(path string) (string, error) {
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// fileAuditSink menulis log audit sebagai JSON lines (format sama dengan baris
+// arsip) dan merotasi file berdasarkan ukuran.
+type fileAuditSink struct {
+	path   string
+	logger *lumberjack.Logger
+}
+
+func newFileAuditSink(config dto.AuditFileConfig) *fileAuditSink {
+	return &fileAuditSink{
+		path: config.Path,
+		logger: &lumberjack.Logger{
+			Filename:   config.Path,
+			MaxSize:    config.MaxSizeMB,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAgeDays,
+			Compress:   config.Compress,
+		},
+	}
+}
+
+func (s *fileAuditSink) Name() string { return "file" }
+
+func (s *fileAuditSink) Target() string { return s.path }
+
+func (s *fileAuditSink) Write(entry dto.AuditArchiveEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.logger.Write(append(line, '\n'))
+	return err
+}
+
+func (s *fileAuditSink) Close() error {
+	return s.logger.Close()
+}
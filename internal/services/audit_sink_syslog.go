@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"simdokpol/internal/dto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	syslogDialTimeout  = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+	// Severity "notice" (5): kejadian normal tapi penting
+	syslogSeverityNotice = 5
+	// SD-ID memakai nomor enterprise contoh dari RFC 5612
+	syslogStructuredDataID = "audit@32473"
+	// Batas aman satu datagram UDP
+	syslogMaxUDPMessage = 60 * 1024
+)
+
+// syslogAuditSink mengirim log audit dalam format RFC 5424. UDP satu pesan per
+// datagram; TCP/TLS memakai octet counting (RFC 6587 / RFC 5425).
+type syslogAuditSink struct {
+	config   dto.AuditSyslogConfig
+	hostname string
+	procID   string
+
+	mu   sync.Mutex // Close bisa dipanggil saat Write masih berjalan (shutdown timeout)
+	conn net.Conn
+}
+
+func newSyslogAuditSink(config dto.AuditSyslogConfig) *syslogAuditSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogAuditSink{
+		config:   config,
+		hostname: syslogToken(hostname, 255),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+}
+
+func (s *syslogAuditSink) Name() string { return "syslog" }
+
+func (s *syslogAuditSink) Target() string {
+	return s.config.Network + "://" + s.config.Address
+}
+
+func (s *syslogAuditSink) Write(entry dto.AuditArchiveEntry) error {
+	message, err := s.format(entry)
+	if err != nil {
+		return err
+	}
+	if s.config.Network == "udp" && len(message) > syslogMaxUDPMessage {
+		message = message[:syslogMaxUDPMessage]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Koneksi bisa putus diam-diam (server restart); sambung ulang sekali
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				return err
+			}
+		}
+		if err = s.send(message); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *syslogAuditSink) send(message []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+	if s.config.Network != "udp" {
+		message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+	_, err := s.conn.Write(message)
+	return err
+}
+
+func (s *syslogAuditSink) dial() (net.Conn, error) {
+	switch s.config.Network {
+	case "udp", "tcp":
+		return net.DialTimeout(s.config.Network, s.config.Address, syslogDialTimeout)
+	case "tls":
+		tlsConfig, err := syslogTLSConfig(s.config)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: syslogDialTimeout}
+		return tls.DialWithDialer(dialer, "tcp", s.config.Address, tlsConfig)
+	default:
+		return nil, fmt.Errorf("protokol syslog %q tidak dikenal", s.config.Network)
+	}
+}
+
+func (s *syslogAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func syslogTLSConfig(config dto.AuditSyslogConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify, // #nosec G402 -- opsi eksplisit dari admin untuk lab/uji coba
+	}
+	if host, _, err := net.SplitHostPort(config.Address); err == nil {
+		tlsConfig.ServerName = host
+	}
+	if config.CACertPath != "" {
+		pem, err := os.ReadFile(config.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca sertifikat CA syslog: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("sertifikat CA syslog tidak valid")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// format menyusun pesan RFC 5424:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] BOM+JSON
+func (s *syslogAuditSink) format(entry dto.AuditArchiveEntry) ([]byte, error) {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []struct{ name, value string }{
+		{"id", strconv.FormatUint(uint64(entry.ID), 10)},
+		{"user", strconv.FormatUint(uint64(entry.UserID), 10)},
+		{"action", entry.Aksi},
+		{"entityType", entry.EntityType},
+		{"entityId", entry.EntityID},
+		{"ip", entry.IPAddress},
+		{"requestId", entry.RequestID},
+		{"hash", entry.Hash},
+	}
+	var sd strings.Builder
+	sd.WriteString("[" + syslogStructuredDataID)
+	for _, param := range params {
+		if param.value == "" {
+			continue
+		}
+		sd.WriteString(" " + param.name + `="` + syslogEscapeParam(param.value) + `"`)
+	}
+	sd.WriteString("]")
+
+	priority := s.config.Facility*8 + syslogSeverityNotice
+	header := fmt.Sprintf("<%d>1 %s %s %s %s AUDIT %s ",
+		priority,
+		entry.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"),
+		s.hostname,
+		syslogToken(s.config.AppName, 48),
+		s.procID,
+		sd.String(),
+	)
+
+	message := make([]byte, 0, len(header)+3+len(payload))
+	message = append(message, header...)
+	message = append(message, 0xEF, 0xBB, 0xBF) // BOM: MSG berisi UTF-8
+	message = append(message, payload...)
+	return message, nil
+}
+
+// syslogToken menyesuaikan field header: ASCII tercetak tanpa spasi, "-" bila kosong.
+func syslogToken(value string, maxLen int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+	}
+	token := b.String()
+	if token == "" {
+		return "-"
+	}
+	if len(token) > maxLen {
+		token = token[:maxLen]
+	}
+	return token
+}
+
+func syslogEscapeParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
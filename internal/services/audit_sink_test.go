@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func testSinkEntry() dto.AuditArchiveEntry {
+	return dto.AuditArchiveEntry{
+		ID:         42,
+		Timestamp:  time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC),
+		UserID:     7,
+		Aksi:       models.AuditUpdateDocument,
+		Detail:     `Ubah "lokasi" [pasar]`,
+		EntityType: models.AuditEntityDocument,
+		EntityID:   "15",
+		IPAddress:  "10.0.0.9",
+		Hash:       "abc123",
+	}
+}
+
+// readOctetFramed membaca satu pesan "LEN SP MSG" (RFC 6587 octet counting).
+// Dipanggil dari goroutine server, jadi galat dikembalikan (bukan t.Fatal).
+func readOctetFramed(reader *bufio.Reader) (string, error) {
+	lengthStr, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(lengthStr))
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func assertRFC5424(t *testing.T, message string) {
+	assert.True(t, strings.HasPrefix(message, "<109>1 2025-03-01T08:30:00.000Z "), message)
+	assert.Contains(t, message, " simdokpol ")
+	assert.Contains(t, message, ` AUDIT [audit@32473 id="42" user="7" action="UPDATE DOKUMEN" entityType="document" entityId="15" ip="10.0.0.9" hash="abc123"] `)
+
+	jsonStart := strings.Index(message, "\xEF\xBB\xBF")
+	require.NotEqual(t, -1, jsonStart)
+	var payload dto.AuditArchiveEntry
+	require.NoError(t, json.Unmarshal([]byte(message[jsonStart+3:]), &payload))
+	assert.Equal(t, `Ubah "lokasi" [pasar]`, payload.Detail)
+}
+
+func TestSyslogAuditSink_UDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	sink := newSyslogAuditSink(dto.AuditSyslogConfig{Network: "udp", Address: listener.LocalAddr().String(), AppName: "simdokpol", Facility: 13})
+	defer sink.Close()
+	require.NoError(t, sink.Write(testSinkEntry()))
+
+	buf := make([]byte, 64*1024)
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := listener.ReadFrom(buf)
+	require.NoError(t, err)
+	assertRFC5424(t, string(buf[:n]))
+}
+
+func TestSyslogAuditSink_TCPReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if message, err := readOctetFramed(bufio.NewReader(conn)); err == nil {
+				messages <- message
+			}
+			// Server "restart": tutup koneksi setelah satu pesan
+			conn.Close()
+		}
+	}()
+
+	sink := newSyslogAuditSink(dto.AuditSyslogConfig{Network: "tcp", Address: listener.Addr().String(), AppName: "simdokpol", Facility: 13})
+	defer sink.Close()
+	require.NoError(t, sink.Write(testSinkEntry()))
+	assertRFC5424(t, <-messages)
+
+	// Koneksi lama sudah ditutup server; tulis sampai sink menyambung ulang
+	deadline := time.After(5 * time.Second)
+	for received := false; !received; {
+		_ = sink.Write(testSinkEntry())
+		select {
+		case message := <-messages:
+			assertRFC5424(t, message)
+			received = true
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("sink tidak menyambung ulang ke server syslog")
+		}
+	}
+}
+
+func TestSyslogAuditSink_TLS(t *testing.T) {
+	certPEM, keyPEM := generateTestCertificate(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if message, err := readOctetFramed(bufio.NewReader(conn)); err == nil {
+			messages <- message
+		}
+	}()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, certPEM, 0600))
+
+	sink := newSyslogAuditSink(dto.AuditSyslogConfig{Network: "tls", Address: listener.Addr().String(), AppName: "simdokpol", Facility: 13, CACertPath: caPath})
+	defer sink.Close()
+	require.NoError(t, sink.Write(testSinkEntry()))
+
+	select {
+	case message := <-messages:
+		assertRFC5424(t, message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("pesan syslog TLS tidak diterima")
+	}
+}
+
+func generateTestCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "syslog-uji"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestAuditLogService_ForwardsToSinks(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// Port TCP yang sudah ditutup: pengiriman harus gagal dan tercatat di status
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	dataDir := t.TempDir()
+	require.NoError(t, utils.SetAppDataDir(dataDir))
+	filePath := filepath.Join(dataDir, "audit-logs", "audit.jsonl")
+	service := NewAuditLogService(repositories.NewAuditLogRepository(db)).(*auditLogService)
+	service.ConfigureSinks(&dto.AppConfig{
+		AuditSyslog: dto.AuditSyslogConfig{Enabled: true, Network: "udp", Address: listener.LocalAddr().String(), AppName: "simdokpol", Facility: 13},
+		AuditFile:   dto.AuditFileConfig{Enabled: true, Path: filePath, MaxSizeMB: 1, MaxBackups: 1},
+	})
+	service.sinks = append(service.sinks, newAuditSinkWorker(newSyslogAuditSink(dto.AuditSyslogConfig{Network: "tcp", Address: closedAddr, Facility: 13})))
+
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+	service.LogEvent(context.Background(), dto.AuditEvent{UserID: 1, Action: models.AuditSettingsUpdated, Detail: "Ubah pengaturan", EntityType: models.AuditEntitySetting, EntityID: "zona_waktu"})
+	service.LogActivity(1, models.AuditBackupCreated, "Backup harian")
+	wg.Wait()
+
+	// Status dibaca sebelum Close (Close mengosongkan daftar sink)
+	require.Eventually(t, func() bool {
+		for _, status := range service.SinkStatus() {
+			if status.Sent+status.Failed < 2 {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 20*time.Millisecond)
+
+	statuses := service.SinkStatus()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "syslog", statuses[0].Name)
+	assert.Equal(t, int64(2), statuses[0].Sent)
+	assert.NotNil(t, statuses[0].LastSentAt)
+	assert.Equal(t, "file", statuses[1].Name)
+	assert.Equal(t, filePath, statuses[1].Target)
+	assert.Equal(t, int64(2), statuses[1].Sent)
+	assert.Equal(t, int64(2), statuses[2].Failed)
+	assert.NotEmpty(t, statuses[2].LastError)
+
+	require.NoError(t, service.Close(context.Background()))
+
+	raw, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 2)
+	var first dto.AuditArchiveEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, models.AuditSettingsUpdated, first.Aksi)
+	assert.NotEmpty(t, first.Hash, "yang diteruskan adalah entri yang sudah masuk rantai")
+
+	var stored models.AuditLog
+	require.NoError(t, db.First(&stored, first.ID).Error)
+	assert.Equal(t, stored.Hash, first.Hash)
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditQueueSize     = 1024
+	auditBatchSize     = 100
+	auditWriteAttempts = 5
+	// Journal dicoba ditulis ulang ke database secara berkala walau tidak ada entri baru.
+	auditReplayInterval = 30 * time.Second
+	// Setelah batch gagal, entri ditulis satu per satu. Sekian kegagalan
+	// berturut-turut yang bukan pelanggaran constraint dianggap database putus.
+	auditMaxConsecutiveFailures = 3
+)
+
+// Jeda retry berupa var agar bisa dipercepat di test.
+var (
+	auditRetryBaseDelay = 200 * time.Millisecond
+	auditRetryMaxDelay  = 5 * time.Second
+)
+
+// auditWriterStats dibaca endpoint metrics tanpa lock.
+type auditWriterStats struct {
+	written        atomic.Int64
+	failed         atomic.Int64
+	spilled        atomic.Int64
+	replayed       atomic.Int64
+	dropped        atomic.Int64
+	deadLettered   atomic.Int64
+	journalPending atomic.Int64
+}
+
+// auditJournalEntry adalah satu baris JSON di journal. Hash tidak disimpan:
+// entri baru disambungkan ke rantai saat berhasil ditulis ke database.
+type auditJournalEntry struct {
+	UserID     uint      `json:"user_id"`
+	Aksi       string    `json:"aksi"`
+	Detail     string    `json:"detail"`
+	Timestamp  time.Time `json:"timestamp"`
+	EntityType string    `json:"entity_type,omitempty"`
+	EntityID   string    `json:"entity_id,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Before     string    `json:"before,omitempty"`
+	After      string    `json:"after,omitempty"`
+}
+
+func newAuditJournalEntry(entry *models.AuditLog) auditJournalEntry {
+	return auditJournalEntry{
+		UserID:     entry.UserID,
+		Aksi:       entry.Aksi,
+		Detail:     entry.Detail,
+		Timestamp:  entry.Timestamp,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IPAddress:  entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Before:     entry.Before,
+		After:      entry.After,
+	}
+}
+
+// auditDeadLetterEntry satu baris di file dead-letter: entri beserta alasan
+// database menolaknya, untuk diperiksa admin.
+type auditDeadLetterEntry struct {
+	auditJournalEntry
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func (e auditJournalEntry) toAuditLog() *models.AuditLog {
+	return &models.AuditLog{
+		UserID:     e.UserID,
+		Aksi:       e.Aksi,
+		Detail:     e.Detail,
+		Timestamp:  e.Timestamp,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		IPAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		Before:     e.Before,
+		After:      e.After,
+	}
+}
+
+// enqueue menitipkan entri ke antrean writer tanpa memblokir request.
+// Bila antrean penuh atau writer sudah ditutup, entri langsung ke journal.
+func (s *auditLogService) enqueue(entry *models.AuditLog) {
+	s.writerMu.RLock()
+	if !s.closed {
+		if s.wg != nil {
+			s.wg.Add(1)
+		}
+		select {
+		case s.queue <- entry:
+			s.writerMu.RUnlock()
+			return
+		default:
+			if s.wg != nil {
+				s.wg.Done()
+			}
+		}
+	}
+	s.writerMu.RUnlock()
+
+	log.Printf("WARN: antrean log audit penuh/ditutup, entri %q dialihkan ke journal", entry.Aksi)
+	s.spill([]*models.AuditLog{entry})
+}
+
+// runWriter adalah satu-satunya goroutine yang menulis log audit, sehingga
+// urutan rantai hash sama dengan urutan antrean.
+func (s *auditLogService) runWriter() {
+	defer close(s.done)
+	s.initJournal()
+
+	ticker := time.NewTicker(auditReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-s.queue:
+			if !ok {
+				return
+			}
+			batch := []*models.AuditLog{entry}
+		collect:
+			for len(batch) < auditBatchSize {
+				select {
+				case next, ok := <-s.queue:
+					if !ok {
+						break collect
+					}
+					batch = append(batch, next)
+				default:
+					break collect
+				}
+			}
+			// Selama restore mengganti database, penulisan ditahan dulu
+			leave, _ := Maintenance.Hold(context.Background())
+			s.flush(batch)
+			leave()
+		case <-ticker.C:
+			if s.stats.journalPending.Load() > 0 {
+				leave, _ := Maintenance.Hold(context.Background())
+				if err := s.replayJournal(); err != nil {
+					log.Printf("WARN: journal log audit belum bisa ditulis ulang: %v", err)
+				}
+				leave()
+			}
+		}
+	}
+}
+
+func (s *auditLogService) flush(batch []*models.AuditLog) {
+	defer func() {
+		if s.wg != nil {
+			for range batch {
+				s.wg.Done()
+			}
+		}
+	}()
+
+	// Journal lama ditulis dulu agar urutannya tidak didahului entri baru
+	if s.stats.journalPending.Load() > 0 {
+		if err := s.replayJournal(); err != nil {
+			s.spill(batch)
+			return
+		}
+	}
+
+	if err := s.writeWithRetry(batch); err != nil {
+		// Satu entri yang tidak mungkin masuk tidak boleh ikut menggagalkan
+		// entri lain: tulis satu per satu, sisanya (database putus) ke journal
+		written, pending, err := s.writeEach(batch)
+		s.stats.written.Add(int64(written))
+		if len(pending) > 0 {
+			log.Printf("ERROR: gagal menyimpan %d log audit: %v", len(pending), err)
+			s.stats.failed.Add(int64(len(pending)))
+			s.spill(pending)
+		}
+		return
+	}
+	s.stats.written.Add(int64(len(batch)))
+}
+
+// writeEach menulis entri satu per satu setelah batch-nya gagal. Entri yang
+// ditolak karena melanggar constraint, atau gagal padahal entri sesudahnya
+// berhasil (database jelas bisa ditulis), tidak akan pernah masuk dan
+// dipindah ke dead-letter. Bila beberapa entri berturut-turut gagal tanpa
+// alasan yang jelas, database dianggap putus dan sisa batch dikembalikan
+// sebagai pending.
+func (s *auditLogService) writeEach(batch []*models.AuditLog) (written int, pending []*models.AuditLog, err error) {
+	var failed []*models.AuditLog
+	var failedErrs []error
+	for i, entry := range batch {
+		entryErr := s.appendToChain([]*models.AuditLog{entry})
+		if entryErr == nil {
+			written++
+			for j, bad := range failed {
+				s.deadLetter(bad, failedErrs[j])
+			}
+			failed, failedErrs = nil, nil
+			continue
+		}
+		if isPermanentAuditError(entryErr) {
+			s.deadLetter(entry, entryErr)
+			continue
+		}
+		err = entryErr
+		failed = append(failed, entry)
+		failedErrs = append(failedErrs, entryErr)
+		if len(failed) >= auditMaxConsecutiveFailures {
+			return written, append(failed, batch[i+1:]...), err
+		}
+	}
+	return written, failed, err
+}
+
+// isPermanentAuditError mengenali penolakan database yang tidak akan hilang
+// dengan diulang (foreign key, NOT NULL, unik, data terlalu panjang) di
+// SQLite, MySQL dan PostgreSQL.
+func isPermanentAuditError(err error) bool {
+	if errors.Is(err, gorm.ErrForeignKeyViolated) || errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, gorm.ErrCheckConstraintViolated) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"constraint failed",              // sqlite
+		"violates",                       // postgres: foreign key/not-null/unique/check
+		"value too long",                 // postgres
+		"invalid byte sequence",          // postgres
+		"a foreign key constraint fails", // mysql 1451/1452
+		"cannot be null",                 // mysql 1048
+		"duplicate entry",                // mysql 1062
+		"data too long",                  // mysql 1406
+		"incorrect string value",         // mysql 1366
+	} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// deadLetter menyimpan entri yang ditolak database ke file dead-letter.
+func (s *auditLogService) deadLetter(entry *models.AuditLog, cause error) {
+	path := AuditDeadLetterPath
+	if path == "" {
+		s.stats.dropped.Add(1)
+		log.Printf("ERROR: log audit %q ditolak database dan dibuang: %v", entry.Aksi, cause)
+		return
+	}
+	if err := appendAuditDeadLetter(path, entry, cause); err != nil {
+		s.stats.dropped.Add(1)
+		log.Printf("ERROR: log audit %q ditolak database (%v) dan gagal disimpan ke dead-letter: %v", entry.Aksi, cause, err)
+		return
+	}
+	s.stats.deadLettered.Add(1)
+	log.Printf("ERROR: log audit %q ditolak database, dipindah ke %s: %v", entry.Aksi, path, cause)
+}
+
+// writeWithRetry mengulang penulisan dengan jeda yang makin panjang.
+// Saat shutdown tidak ada jeda lagi; entri yang gagal langsung ke journal.
+// Pelanggaran constraint tidak diulang.
+func (s *auditLogService) writeWithRetry(batch []*models.AuditLog) error {
+	delay := auditRetryBaseDelay
+	var err error
+	for attempt := 1; attempt <= auditWriteAttempts; attempt++ {
+		if err = s.appendToChain(batch); err == nil {
+			return nil
+		}
+		if attempt == auditWriteAttempts || isPermanentAuditError(err) {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-s.stopCh:
+			return err
+		}
+		delay *= 2
+		if delay > auditRetryMaxDelay {
+			delay = auditRetryMaxDelay
+		}
+	}
+	return err
+}
+
+// initJournal menghitung entri journal yang tertinggal dari sesi sebelumnya.
+func (s *auditLogService) initJournal() {
+	path := AuditJournalPath
+	if path == "" {
+		return
+	}
+	entries, err := readAuditJournal(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("WARN: journal log audit tidak terbaca: %v", err)
+		}
+		return
+	}
+	s.stats.journalPending.Store(int64(len(entries)))
+	if len(entries) > 0 {
+		log.Printf("INFO: %d log audit tertunda di journal akan ditulis ulang", len(entries))
+	}
+}
+
+// spill menyimpan entri ke journal di disk. Tanpa journal, entri hilang dan dihitung dropped.
+func (s *auditLogService) spill(entries []*models.AuditLog) {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	path := AuditJournalPath
+	if path == "" {
+		s.stats.dropped.Add(int64(len(entries)))
+		log.Printf("ERROR: %d log audit hilang (journal tidak dikonfigurasi)", len(entries))
+		return
+	}
+	if err := appendAuditJournal(path, entries); err != nil {
+		s.stats.dropped.Add(int64(len(entries)))
+		log.Printf("ERROR: %d log audit hilang, journal gagal ditulis: %v", len(entries), err)
+		return
+	}
+	s.stats.spilled.Add(int64(len(entries)))
+	s.stats.journalPending.Add(int64(len(entries)))
+}
+
+// replayJournal menulis ulang isi journal ke database per batch. Batch yang
+// sudah masuk dibuang dari journal, sisanya tetap menunggu percobaan berikutnya.
+// Entri yang ditolak database dipindah ke dead-letter agar tidak menahan
+// journal selamanya.
+func (s *auditLogService) replayJournal() error {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	path := AuditJournalPath
+	if path == "" {
+		return nil
+	}
+	entries, err := readAuditJournal(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.stats.journalPending.Store(0)
+			return nil
+		}
+		return err
+	}
+
+	for start := 0; start < len(entries); start += auditBatchSize {
+		end := start + auditBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		batch := make([]*models.AuditLog, 0, end-start)
+		for _, entry := range entries[start:end] {
+			batch = append(batch, entry.toAuditLog())
+		}
+		if err := s.appendToChain(batch); err != nil {
+			written, pending, err := s.writeEach(batch)
+			s.stats.replayed.Add(int64(written))
+			if len(pending) > 0 {
+				remaining := make([]auditJournalEntry, 0, len(pending)+len(entries)-end)
+				for _, entry := range pending {
+					remaining = append(remaining, newAuditJournalEntry(entry))
+				}
+				remaining = append(remaining, entries[end:]...)
+				if writeErr := writeAuditJournal(path, remaining); writeErr != nil {
+					log.Printf("ERROR: journal log audit gagal diperbarui: %v", writeErr)
+				}
+				s.stats.journalPending.Store(int64(len(remaining)))
+				return err
+			}
+			continue
+		}
+		s.stats.replayed.Add(int64(len(batch)))
+	}
+
+	s.stats.journalPending.Store(0)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	log.Printf("INFO: %d log audit dari journal berhasil ditulis ke database", len(entries))
+	return nil
+}
+
+func appendAuditJournal(path string, entries []*models.AuditLog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(newAuditJournalEntry(entry)); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func appendAuditDeadLetter(path string, entry *models.AuditLog, cause error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	record := auditDeadLetterEntry{auditJournalEntry: newAuditJournalEntry(entry), Error: cause.Error(), FailedAt: time.Now()}
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeAuditJournal mengganti isi journal secara atomik (tulis file sementara lalu rename).
+func writeAuditJournal(path string, entries []auditJournalEntry) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func readAuditJournal(path string) ([]auditJournalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []auditJournalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry auditJournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Baris terakhir bisa terpotong bila aplikasi mati saat menulis
+			log.Printf("WARN: baris %d journal log audit rusak, dilewati: %v", line, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Close berhenti menerima entri baru lalu menunggu antrean selesai ditulis
+// (atau dialihkan ke journal). Dipanggil saat aplikasi shutdown.
+func (s *auditLogService) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		s.writerMu.Lock()
+		s.closed = true
+		close(s.stopCh)
+		close(s.queue)
+		s.writerMu.Unlock()
+	})
+
+	select {
+	case <-s.done:
+		s.closeSinks(ctx)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d log audit belum selesai ditulis: %w", len(s.queue), ctx.Err())
+	}
+}
+
+func (s *auditLogService) WriterStats() dto.AuditWriterStats {
+	return dto.AuditWriterStats{
+		QueueLength:    len(s.queue),
+		QueueCapacity:  cap(s.queue),
+		Written:        s.stats.written.Load(),
+		Failed:         s.stats.failed.Load(),
+		Spilled:        s.stats.spilled.Load(),
+		Replayed:       s.stats.replayed.Load(),
+		Dropped:        s.stats.dropped.Load(),
+		DeadLettered:   s.stats.deadLettered.Load(),
+		JournalPending: s.stats.journalPending.Load(),
+	}
+}
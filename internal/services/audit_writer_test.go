@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// flakyAuditRepo meniru database yang putus: semua penulisan gagal selama down.
+// Batch yang memuat entri dengan Detail == poison selalu ditolak dengan poisonErr.
+type flakyAuditRepo struct {
+	repositories.AuditLogRepository
+	down      atomic.Bool
+	batches   atomic.Int64
+	poison    string
+	poisonErr error
+}
+
+var errAuditDBDown = errors.New("database putus")
+
+func (r *flakyAuditRepo) FindLast() (*models.AuditLog, error) {
+	if r.down.Load() {
+		return nil, errAuditDBDown
+	}
+	return r.AuditLogRepository.FindLast()
+}
+
+// WithChainLock tanpa transaksi agar penulisan tetap lewat FindLast/CreateBatch di atas
+func (r *flakyAuditRepo) WithChainLock(fn func(repo repositories.AuditLogRepository) error) error {
+	return fn(r)
+}
+
+func (r *flakyAuditRepo) CreateBatch(logs []*models.AuditLog) error {
+	if r.down.Load() {
+		return errAuditDBDown
+	}
+	for _, entry := range logs {
+		if r.poison != "" && entry.Detail == r.poison {
+			return r.poisonErr
+		}
+	}
+	r.batches.Add(1)
+	return r.AuditLogRepository.CreateBatch(logs)
+}
+
+func setupAuditWriter(t *testing.T, journal bool) (*auditLogService, *flakyAuditRepo, *gorm.DB) {
+	t.Helper()
+	AuditSigningKey = []byte("kunci-uji-audit")
+	baseDelay, maxDelay := auditRetryBaseDelay, auditRetryMaxDelay
+	auditRetryBaseDelay, auditRetryMaxDelay = time.Millisecond, time.Millisecond
+	AuditJournalPath, AuditDeadLetterPath = "", ""
+	if journal {
+		AuditJournalPath = filepath.Join(t.TempDir(), "audit-journal.jsonl")
+		AuditDeadLetterPath = filepath.Join(t.TempDir(), "audit-deadletter.jsonl")
+	}
+	t.Cleanup(func() {
+		AuditSigningKey = nil
+		AuditJournalPath, AuditDeadLetterPath = "", ""
+		auditRetryBaseDelay, auditRetryMaxDelay = baseDelay, maxDelay
+	})
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}, &models.AuditCheckpoint{}, &models.AuditArchive{}))
+
+	repo := &flakyAuditRepo{AuditLogRepository: repositories.NewAuditLogRepository(db)}
+	service := NewAuditLogService(repo).(*auditLogService)
+	return service, repo, db
+}
+
+func countAuditLogs(t *testing.T, db *gorm.DB) int64 {
+	var total int64
+	require.NoError(t, db.Model(&models.AuditLog{}).Count(&total).Error)
+	return total
+}
+
+func TestAuditWriter_DrainOnClose(t *testing.T) {
+	service, repo, db := setupAuditWriter(t, false)
+
+	for i := 0; i < 250; i++ {
+		service.LogActivity(1, models.AuditUpdateDocument, "Memperbarui dokumen")
+	}
+	require.NoError(t, service.Close(context.Background()))
+
+	assert.Equal(t, int64(250), countAuditLogs(t, db))
+	assert.Less(t, repo.batches.Load(), int64(250), "entri seharusnya ditulis per batch")
+	stats := service.WriterStats()
+	assert.Equal(t, int64(250), stats.Written)
+	assert.Zero(t, stats.Dropped)
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, int64(250), report.CheckedEntries)
+
+	// Setelah ditutup, entri baru tidak boleh membuat panic
+	service.LogActivity(1, models.AuditUpdateDocument, "Terlambat")
+	assert.Equal(t, int64(1), service.WriterStats().Dropped)
+}
+
+func TestAuditWriter_SpillAndReplay(t *testing.T) {
+	service, repo, db := setupAuditWriter(t, true)
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+
+	service.LogActivity(1, models.AuditCreateDocument, "Sebelum putus")
+	wg.Wait()
+
+	repo.down.Store(true)
+	for i := 0; i < 3; i++ {
+		service.LogActivity(1, models.AuditUpdateDocument, "Saat database putus")
+		wg.Wait()
+	}
+	// Hanya batch pertama yang dicoba ke database; selama journal belum
+	// tertulis ulang, entri berikutnya langsung menyusul ke journal
+	stats := service.WriterStats()
+	assert.Equal(t, int64(1), stats.Failed)
+	assert.Equal(t, int64(3), stats.Spilled)
+	assert.Equal(t, int64(3), stats.JournalPending)
+	assert.FileExists(t, AuditJournalPath)
+	assert.Equal(t, int64(1), countAuditLogs(t, db))
+
+	// Database pulih: journal ditulis dulu, baru entri baru
+	repo.down.Store(false)
+	service.LogActivity(1, models.AuditDeleteDocument, "Setelah pulih")
+	wg.Wait()
+
+	var logs []models.AuditLog
+	require.NoError(t, db.Order("id asc").Find(&logs).Error)
+	require.Len(t, logs, 5)
+	assert.Equal(t, "Saat database putus", logs[1].Detail)
+	assert.Equal(t, "Setelah pulih", logs[4].Detail)
+	_, err := os.Stat(AuditJournalPath)
+	assert.True(t, os.IsNotExist(err), "journal seharusnya dihapus setelah ditulis ulang")
+
+	stats = service.WriterStats()
+	assert.Equal(t, int64(3), stats.Replayed)
+	assert.Zero(t, stats.JournalPending)
+	assert.Zero(t, stats.Dropped)
+
+	report, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	require.NoError(t, service.Close(context.Background()))
+}
+
+func TestAuditWriter_JournalSurvivesRestart(t *testing.T) {
+	service, repo, db := setupAuditWriter(t, true)
+	repo.down.Store(true)
+	service.LogActivity(1, models.AuditCreateDocument, "Tertunda")
+	require.NoError(t, service.Close(context.Background()))
+	assert.Equal(t, int64(1), service.WriterStats().Spilled)
+
+	// Proses baru membaca journal yang tertinggal
+	restarted := NewAuditLogService(repositories.NewAuditLogRepository(db)).(*auditLogService)
+	var wg sync.WaitGroup
+	restarted.SetWaitGroup(&wg)
+	restarted.LogActivity(1, models.AuditUpdateDocument, "Setelah restart")
+	wg.Wait()
+	require.NoError(t, restarted.Close(context.Background()))
+
+	var logs []models.AuditLog
+	require.NoError(t, db.Order("id asc").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "Tertunda", logs[0].Detail)
+	assert.Equal(t, "Setelah restart", logs[1].Detail)
+}
+
+func TestAuditWriter_PoisonEntryDoesNotJamJournal(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		// Dikenali sebagai pelanggaran constraint: langsung ke dead-letter
+		{"Pelanggaran Constraint", errors.New("FOREIGN KEY constraint failed")},
+		// Alasan tidak dikenali: masuk journal dulu, lalu ketahuan racun
+		// begitu entri sesudahnya berhasil ditulis
+		{"Error Tidak Dikenal", errors.New("kesalahan aneh dari driver")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, db := setupAuditWriter(t, true)
+			repo.poison, repo.poisonErr = "Racun", tc.err
+			var wg sync.WaitGroup
+			service.SetWaitGroup(&wg)
+
+			for _, detail := range []string{"Racun", "Sesudah racun", "Berikutnya", "Terakhir"} {
+				service.LogActivity(1, models.AuditUpdateDocument, detail)
+				wg.Wait()
+			}
+
+			var details []string
+			require.NoError(t, db.Model(&models.AuditLog{}).Order("id").Pluck("detail", &details).Error)
+			assert.Equal(t, []string{"Sesudah racun", "Berikutnya", "Terakhir"}, details)
+			_, err := os.Stat(AuditJournalPath)
+			assert.True(t, os.IsNotExist(err), "journal tidak boleh tertahan entri racun")
+
+			stats := service.WriterStats()
+			assert.Equal(t, int64(1), stats.DeadLettered)
+			assert.Zero(t, stats.JournalPending)
+			assert.Zero(t, stats.Dropped)
+
+			deadLetter, err := os.ReadFile(AuditDeadLetterPath)
+			require.NoError(t, err)
+			assert.Contains(t, string(deadLetter), `"detail":"Racun"`)
+			assert.Contains(t, string(deadLetter), tc.err.Error())
+
+			report, err := service.VerifyChain()
+			require.NoError(t, err)
+			assert.True(t, report.Valid, report.Reason)
+		})
+	}
+}
+
+func TestAuditWriter_OutageIsNotDeadLettered(t *testing.T) {
+	service, repo, db := setupAuditWriter(t, true)
+	var wg sync.WaitGroup
+	service.SetWaitGroup(&wg)
+
+	repo.down.Store(true)
+	for i := 0; i < 5; i++ {
+		service.LogActivity(1, models.AuditUpdateDocument, "Saat database putus")
+	}
+	wg.Wait()
+	assert.Zero(t, service.WriterStats().DeadLettered, "database putus bukan alasan membuang entri")
+
+	repo.down.Store(false)
+	service.LogActivity(1, models.AuditUpdateDocument, "Setelah pulih")
+	wg.Wait()
+	assert.Equal(t, int64(6), countAuditLogs(t, db))
+	assert.NoFileExists(t, AuditDeadLetterPath)
+}
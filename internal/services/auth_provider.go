@@ -0,0 +1,164 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AuthProvider memverifikasi NRP + kata sandi dan mengembalikan pengguna lokal.
+// Kembalikan ErrUserNotInProvider bila NRP bukan urusan provider ini agar
+// authService bisa mencoba provider berikutnya.
+type AuthProvider interface {
+	Name() string
+	Authenticate(nrp string, password string) (*models.User, error)
+}
+
+// localAuthProvider adalah login bawaan: kata sandi bcrypt di tabel users.
+type localAuthProvider struct {
+	userRepo repositories.UserRepository
+}
+
+func NewLocalAuthProvider(userRepo repositories.UserRepository) AuthProvider {
+	return &localAuthProvider{userRepo: userRepo}
+}
+
+func (p *localAuthProvider) Name() string {
+	return models.AuthSourceLocal
+}
+
+func (p *localAuthProvider) Authenticate(nrp string, password string) (*models.User, error) {
+	user, err := p.userRepo.FindByNRP(nrp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotInProvider
+		}
+		return nil, err
+	}
+
+	// Akun hasil LDAP / SSO tidak punya kata sandi lokal yang bisa dipakai
+	if user.AuthSource != "" && user.AuthSource != models.AuthSourceLocal {
+		return nil, ErrUserNotInProvider
+	}
+
+	if user.DeletedAt.Valid {
+		return nil, errors.New("akun Anda tidak aktif. Silakan hubungi Super Admin")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// matchGroupMapping mencari pemetaan pertama yang cocok dengan nilai grup pengguna.
+// Untuk grup berbentuk DN, CN-nya juga dicocokkan.
+func matchGroupMapping(groups []string, mappings []dto.GroupMapping) *dto.GroupMapping {
+	for i := range mappings {
+		target := strings.TrimSpace(mappings[i].Group)
+		if target == "" {
+			continue
+		}
+		for _, group := range groups {
+			if strings.EqualFold(group, target) || strings.EqualFold(groupCN(group), target) {
+				return &mappings[i]
+			}
+		}
+	}
+	return nil
+}
+
+// groupCN mengambil nilai RDN pertama, mis. "CN=KANIT,OU=Grup,DC=polri" -> "KANIT"
+func groupCN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return dn
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+// syncExternalUser membuat akun secara just-in-time atau menyelaraskan data
+// pengguna dari provider eksternal (LDAP / SSO). Akun yang sudah ada hanya
+// diselaraskan bila AuthSource-nya sama dengan provider; akun lokal (mis.
+// Super Admin darurat) harus ditautkan Super Admin dulu lewat edit pengguna.
+func syncExternalUser(userRepo repositories.UserRepository, auditService AuditLogService, source, nrp, nama string, mapping *dto.GroupMapping) (*models.User, error) {
+	label := authSourceLabel(source)
+	user, err := userRepo.FindByNRP(nrp)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err == nil {
+		if user.DeletedAt.Valid {
+			return nil, ErrAccountInactive
+		}
+		if user.AuthSource != source {
+			return nil, ErrExternalAccountNotLinked
+		}
+		changed := user.Peran != mapping.Peran
+		user.Peran = mapping.Peran
+		if nama != "" && user.NamaLengkap != nama {
+			user.NamaLengkap, changed = nama, true
+		}
+		if mapping.Pangkat != "" && user.Pangkat != mapping.Pangkat {
+			user.Pangkat, changed = mapping.Pangkat, true
+		}
+		if mapping.Jabatan != "" && user.Jabatan != mapping.Jabatan {
+			user.Jabatan, changed = mapping.Jabatan, true
+		}
+		if changed {
+			if err := userRepo.Update(user); err != nil {
+				return nil, err
+			}
+			auditService.LogActivity(user.ID, models.AuditUpdateUser, fmt.Sprintf("Data pengguna '%s' diselaraskan dari %s (peran %s).", user.NamaLengkap, label, user.Peran))
+		}
+		return user, nil
+	}
+
+	// Kata sandi lokal acak: akun eksternal tidak bisa login lewat provider bcrypt
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(raw)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if nama == "" {
+		nama = nrp
+	}
+
+	user = &models.User{
+		NamaLengkap: nama,
+		NRP:         nrp,
+		KataSandi:   string(hashed),
+		Pangkat:     mapping.Pangkat,
+		Peran:       mapping.Peran,
+		Jabatan:     mapping.Jabatan,
+		AuthSource:  source,
+	}
+	if err := userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	auditService.LogActivity(user.ID, models.AuditCreateUser, fmt.Sprintf("Pengguna baru '%s' dibuat otomatis dari %s (peran %s).", user.NamaLengkap, label, user.Peran))
+	return user, nil
+}
+
+func authSourceLabel(source string) string {
+	switch source {
+	case models.AuthSourceLDAP:
+		return "LDAP"
+	case models.AuthSourceOIDC:
+		return "SSO"
+	}
+	return source
+}
@@ -2,12 +2,12 @@ package services
 
 import (
 	"errors"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // VAR JWTSecretKey SUDAH DIHAPUS DARI SINI (Pindah ke vars.go)
@@ -18,47 +18,93 @@ type AuthService interface {
 
 type authService struct {
 	userRepo      repositories.UserRepository
-	configService ConfigService 
+	configService ConfigService
+	auditService  AuditLogService
+	ldapDial      ldapDialer // bisa diganti saat pengujian
 }
 
-func NewAuthService(userRepo repositories.UserRepository, configService ConfigService) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, configService ConfigService, auditService AuditLogService) AuthService {
 	return &authService{
 		userRepo:      userRepo,
 		configService: configService,
+		auditService:  auditService,
+		ldapDial:      dialLDAP,
 	}
 }
 
-func (s *authService) Login(nrp string, password string) (string, error) {
-	user, err := s.userRepo.FindByNRP(nrp)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "", errors.New("NRP atau kata sandi salah")
+// providers menyusun urutan provider sesuai pengaturan auth_provider.
+// Mode LDAP tetap menyertakan login lokal sebagai cadangan (mis. akun
+// Super Admin darurat) kecuali admin mematikannya.
+func (s *authService) providers(config *dto.AppConfig) []AuthProvider {
+	local := NewLocalAuthProvider(s.userRepo)
+	if config == nil || config.AuthProvider != models.AuthSourceLDAP {
+		return []AuthProvider{local}
+	}
+
+	list := []AuthProvider{newLDAPAuthProvider(config.LDAP, s.userRepo, s.auditService, s.ldapDial)}
+	if config.LDAP.AllowLocalFallback {
+		list = append(list, local)
+	}
+	return list
+}
+
+func (s *authService) authenticate(config *dto.AppConfig, nrp string, password string) (*models.User, error) {
+	lastErr := ErrUserNotInProvider
+	for _, provider := range s.providers(config) {
+		user, err := provider.Authenticate(nrp, password)
+		if err == nil {
+			return user, nil
 		}
-		return "", err
+		// Lanjut ke provider berikutnya hanya bila provider ini memang tidak mengenal NRP-nya
+		if errors.Is(err, ErrUserNotInProvider) || errors.Is(err, ErrDirectoryUnavailable) {
+			lastErr = err
+			continue
+		}
+		return nil, err
 	}
 
-	if user.DeletedAt.Valid {
-		return "", errors.New("akun Anda tidak aktif. Silakan hubungi Super Admin")
+	if errors.Is(lastErr, ErrUserNotInProvider) {
+		return nil, ErrInvalidCredentials
 	}
+	return nil, lastErr
+}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password))
+func (s *authService) Login(nrp string, password string) (string, error) {
+	config, _ := s.configService.GetConfig()
+
+	user, err := s.authenticate(config, nrp, password)
 	if err != nil {
-		return "", errors.New("NRP atau kata sandi salah")
+		return "", err
 	}
 
-	config, _ := s.configService.GetConfig()
+	return issueSessionToken(user, config, nil)
+}
+
+// sessionLifetime masa berlaku JWT sesi dari pengaturan session_timeout (menit).
+func sessionLifetime(config *dto.AppConfig) time.Duration {
 	timeoutMinutes := 480 // Default 8 Jam
 	if config != nil && config.SessionTimeout > 0 {
 		timeoutMinutes = config.SessionTimeout
 	}
-	
-	expirationTime := time.Now().Add(time.Duration(timeoutMinutes) * time.Minute)
+	return time.Duration(timeoutMinutes) * time.Minute
+}
+
+// issueSessionToken membuat JWT untuk cookie sesi. extra berisi klaim
+// tambahan, mis. sid/sub dari SSO agar sesi bisa dicabut saat logout di IdP.
+func issueSessionToken(user *models.User, config *dto.AppConfig, extra jwt.MapClaims) (string, error) {
+	now := time.Now()
+	expirationTime := now.Add(sessionLifetime(config))
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		"userID": user.ID,
 		"role":   user.Peran,
+		"iat":    now.Unix(),
 		"exp":    expirationTime.Unix(),
-	})
+	}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	
 	// Gunakan variabel global dari vars.go
 	tokenString, err := token.SignedString(JWTSecretKey)
@@ -66,4 +112,4 @@ func (s *authService) Login(nrp string, password string) (string, error) {
 		return "", err
 	}
 	return tokenString, nil
-}
\ No newline at end of file
+}
@@ -106,9 +106,10 @@ func TestAuthService_Login(t *testing.T) {
 			mockConfigSvc := new(mocks.ConfigService) // <-- Init Mock Config
 			
 			tc.setupMock(mockUserRepo, mockConfigSvc)
+			// Login selalu membaca config dulu untuk memilih provider
+			mockConfigSvc.On("GetConfig").Return(mockAppConfig, nil).Maybe()
 			
-			// Inject 2 dependency
-			authService := NewAuthService(mockUserRepo, mockConfigSvc) 
+			authService := NewAuthService(mockUserRepo, mockConfigSvc, new(mocks.AuditLogService))
 			token, err := authService.Login(tc.nrp, tc.password)
 
 			if tc.expectToken {
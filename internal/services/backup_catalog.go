@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Katalog backup: daftar file di folder backup beserta aksi unduh,
+// verifikasi, hapus, dan restore tanpa unggah ulang. Semua aksi memakai
+// nama file saja; path di luar folder backup tidak bisa diakses.
+
+var backupFileExtensions = []string{".db", ".dump", ".sql", ".tar.gz", ".enc"}
+
+func isBackupFileName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range backupFileExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupFilePath menerjemahkan nama file dari API ke path di folder backup.
+func (s *backupService) backupFilePath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") || !isBackupFileName(name) {
+		return "", ErrBackupNotFound
+	}
+	dir, err := s.backupDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || s.isLiveDatabase(path) {
+		return "", ErrBackupNotFound
+	}
+	return path, nil
+}
+
+// isLiveDatabase mencegah file database SQLite yang sedang dipakai ikut
+// terdaftar bila folder backup diarahkan ke folder data aplikasi.
+func (s *backupService) isLiveDatabase(path string) bool {
+	if s.cfg.DBDialect != "sqlite" || s.cfg.DBDSN == "" {
+		return false
+	}
+	live, err := filepath.Abs(s.getCleanDBPath())
+	if err != nil {
+		return false
+	}
+	candidate, err := filepath.Abs(path)
+	return err == nil && candidate == live
+}
+
+// recordedRuns: riwayat backup yang file-nya masih ada, per nama file.
+func (s *backupService) recordedRuns() map[string]models.BackupRun {
+	recorded := make(map[string]models.BackupRun)
+	if s.runRepo == nil {
+		return recorded
+	}
+	runs, err := s.runRepo.FindRetained()
+	if err != nil {
+		log.Printf("WARN: gagal membaca riwayat backup: %v", err)
+		return recorded
+	}
+	for _, run := range runs {
+		if _, ok := recorded[run.FileName]; !ok && run.FileName != "" {
+			recorded[run.FileName] = run
+		}
+	}
+	return recorded
+}
+
+// ListBackups mengurutkan file terbaru dulu. Checksum diambil dari riwayat
+// bila ada; file yang tidak tercatat dihitung saat itu juga.
+func (s *backupService) ListBackups() ([]dto.BackupFile, error) {
+	dir, err := s.backupDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca folder backup: %w", err)
+	}
+	recorded := s.recordedRuns()
+
+	files := []dto.BackupFile{}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.Type().IsRegular() || !isBackupFileName(entry.Name()) || strings.HasPrefix(entry.Name(), ".") || s.isLiveDatabase(path) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file := dto.BackupFile{
+			Name:       entry.Name(),
+			SizeBytes:  info.Size(),
+			ModifiedAt: info.ModTime(),
+			Encrypted:  strings.HasSuffix(entry.Name(), encryptedBackupExtension),
+		}
+		run, ok := recorded[entry.Name()]
+		if ok {
+			file.Kind, file.Method, file.RunID = run.Kind, run.Method, run.ID
+		}
+		if ok && run.SHA256 != "" && run.SizeBytes == info.Size() {
+			file.SHA256 = run.SHA256
+		} else if _, file.SHA256, err = fileSizeAndSHA256(path); err != nil {
+			log.Printf("WARN: gagal menghitung checksum %s: %v", entry.Name(), err)
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModifiedAt.After(files[j].ModifiedAt) })
+	return files, nil
+}
+
+func (s *backupService) DownloadBackup(ctx context.Context, name string, actorID uint) (string, error) {
+	path, err := s.backupFilePath(name)
+	if err != nil {
+		return "", err
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditBackupDownloaded,
+		Detail:     "Unduh file backup " + name,
+		EntityType: models.AuditEntityBackup,
+		EntityID:   name,
+	})
+	return path, nil
+}
+
+// VerifyBackup membandingkan checksum file dengan riwayat lalu memeriksa
+// isinya dengan pemeriksaan yang sama seperti validasi restore. Backup
+// terenkripsi didekripsi ke folder temp lokal dan langsung dihapus.
+func (s *backupService) VerifyBackup(ctx context.Context, name string, key *dto.BackupKey) (*dto.BackupVerification, error) {
+	path, err := s.backupFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.BackupVerification{Name: name, Problems: []string{}, Warnings: []string{}, CheckedAt: time.Now()}
+	if result.SizeBytes, result.SHA256, err = fileSizeAndSHA256(path); err != nil {
+		return nil, err
+	}
+	if run, ok := s.recordedRuns()[name]; ok && run.SHA256 != "" {
+		result.RecordedSHA256 = run.SHA256
+		if run.SHA256 != result.SHA256 {
+			result.Problems = append(result.Problems, "Checksum SHA-256 berbeda dengan yang tercatat saat backup dibuat; file berubah atau rusak.")
+		}
+	} else {
+		result.Warnings = append(result.Warnings, "File tidak tercatat di riwayat backup; checksum tidak bisa dibandingkan.")
+	}
+
+	s.verifyBackupContent(ctx, path, key, result)
+	result.OK = len(result.Problems) == 0
+	return result, nil
+}
+
+func (s *backupService) verifyBackupContent(ctx context.Context, path string, key *dto.BackupKey, result *dto.BackupVerification) {
+	format, err := detectBackupFormat(path)
+	if err != nil {
+		result.Problems = append(result.Problems, "Format file tidak dikenali.")
+		return
+	}
+	if format == formatEncrypted {
+		result.Encrypted = true
+		plainDir, err := os.MkdirTemp("", "simdokpol-verify-*")
+		if err != nil {
+			result.Problems = append(result.Problems, "Gagal membuat folder temp: "+err.Error())
+			return
+		}
+		defer os.RemoveAll(plainDir)
+
+		plainPath := filepath.Join(plainDir, restoreStagedFile)
+		if _, err := decryptBackupFile(path, plainPath, s.restoreKeyMaterial(key)); err != nil {
+			if errors.Is(err, ErrBackupKeyRequired) || errors.Is(err, ErrBackupWrongKey) {
+				result.Warnings = append(result.Warnings, "Isi backup terenkripsi belum diperiksa: "+err.Error())
+			} else {
+				result.Problems = append(result.Problems, err.Error())
+			}
+			return
+		}
+		path = plainPath
+		if format, err = detectBackupFormat(path); err != nil || format == formatEncrypted {
+			result.Problems = append(result.Problems, "Isi backup terenkripsi tidak dikenali.")
+			return
+		}
+	}
+	result.Format = format
+
+	preview := &dto.RestorePreview{Warnings: []string{}, Errors: []string{}}
+	if err := s.inspectBackupFile(ctx, path, format, preview); err != nil {
+		if errors.Is(err, ErrBackupMethodUnavailable) {
+			result.Warnings = append(result.Warnings, "Isi file belum diperiksa: "+err.Error())
+		} else {
+			result.Problems = append(result.Problems, err.Error())
+		}
+	}
+	result.IntegrityCheck = preview.IntegrityCheck
+	result.Problems = append(result.Problems, preview.Errors...)
+	result.Warnings = append(result.Warnings, preview.Warnings...)
+}
+
+// DeleteBackup menghapus file dan menandai riwayatnya seperti file yang
+// dihapus retensi, agar retensi tidak mencoba menghapusnya lagi.
+func (s *backupService) DeleteBackup(ctx context.Context, name string, actorID uint) error {
+	path, err := s.backupFilePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("gagal menghapus backup: %w", err)
+	}
+	if run, ok := s.recordedRuns()[name]; ok {
+		if err := s.runRepo.MarkPruned([]uint{run.ID}, time.Now()); err != nil {
+			log.Printf("WARN: gagal memperbarui riwayat backup %s: %v", name, err)
+		}
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditBackupDeleted,
+		Detail:     "Hapus file backup " + name,
+		EntityType: models.AuditEntityBackup,
+		EntityID:   name,
+	})
+	return nil
+}
+
+// ValidateStoredRestore menyiapkan restore dari file yang sudah ada di
+// folder backup; lanjutkan dengan ConfirmRestore seperti file unggahan.
+func (s *backupService) ValidateStoredRestore(ctx context.Context, name string, key *dto.BackupKey) (*dto.RestorePreview, error) {
+	path, err := s.backupFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	preview, err := s.ValidateRestore(ctx, file, key)
+	if err != nil {
+		return nil, err
+	}
+	preview.FileName = name
+	return preview, nil
+}
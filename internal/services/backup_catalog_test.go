@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupService_ListBackups(t *testing.T) {
+	service, db, _ := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+	dir, err := service.backupDir()
+	require.NoError(t, err)
+
+	portablePath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+	sqlitePath, err := service.CreateBackup(context.Background(), BackupMethodSQLite, 1)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "salinan-manual.db"), []byte("SQLite format 3\x00"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "catatan.txt"), []byte("bukan backup"), 0600))
+
+	files, err := service.ListBackups()
+	require.NoError(t, err)
+	byName := make(map[string]int)
+	for i, file := range files {
+		byName[file.Name] = i
+	}
+	require.Len(t, files, 3)
+
+	recorded := files[byName[filepath.Base(portablePath)]]
+	_, sha, err := fileSizeAndSHA256(portablePath)
+	require.NoError(t, err)
+	assert.Equal(t, sha, recorded.SHA256)
+	assert.Equal(t, models.BackupKindManual, recorded.Kind)
+	assert.Equal(t, BackupMethodPortable, recorded.Method)
+	assert.NotZero(t, files[byName[filepath.Base(sqlitePath)]].RunID)
+
+	manual := files[byName["salinan-manual.db"]]
+	assert.Empty(t, manual.Kind, "file yang tidak tercatat tetap terdaftar")
+	assert.Len(t, manual.SHA256, 64)
+
+	_, err = service.DownloadBackup(context.Background(), "../simdokpol.db", 1)
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+	_, err = service.DownloadBackup(context.Background(), "catatan.txt", 1)
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+	path, err := service.DownloadBackup(context.Background(), filepath.Base(sqlitePath), 1)
+	require.NoError(t, err)
+	assert.Equal(t, sqlitePath, path)
+}
+
+func TestBackupService_ListBackupsSkipsLiveDatabase(t *testing.T) {
+	service, _, dbPath := newSQLiteRestoreService(t)
+	appConfig, err := service.configService.GetConfig()
+	require.NoError(t, err)
+	appConfig.BackupPath = filepath.Dir(dbPath)
+
+	files, err := service.ListBackups()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	assert.ErrorIs(t, service.DeleteBackup(context.Background(), filepath.Base(dbPath), 1), ErrBackupNotFound)
+	assert.FileExists(t, dbPath)
+}
+
+func TestBackupService_VerifyBackup(t *testing.T) {
+	service, db, _ := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+
+	portablePath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+	sqlitePath, err := service.CreateBackup(context.Background(), BackupMethodSQLite, 1)
+	require.NoError(t, err)
+
+	t.Run("Utuh", func(t *testing.T) {
+		result, err := service.VerifyBackup(context.Background(), filepath.Base(sqlitePath), nil)
+		require.NoError(t, err)
+		assert.True(t, result.OK, result.Problems)
+		assert.Equal(t, "ok", result.IntegrityCheck)
+		assert.Equal(t, result.SHA256, result.RecordedSHA256)
+		assert.Equal(t, BackupMethodSQLite, result.Format)
+	})
+
+	t.Run("File Berubah", func(t *testing.T) {
+		file, err := os.OpenFile(portablePath, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = file.Write([]byte("sampah"))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		result, err := service.VerifyBackup(context.Background(), filepath.Base(portablePath), nil)
+		require.NoError(t, err)
+		assert.False(t, result.OK)
+		assert.Contains(t, result.Problems[0], "Checksum SHA-256 berbeda")
+	})
+}
+
+func TestBackupService_DeleteAndRestoreStoredBackup(t *testing.T) {
+	service, db, _ := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+
+	keepPath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+	deletePath, err := service.CreateBackup(context.Background(), BackupMethodSQLite, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteBackup(context.Background(), filepath.Base(deletePath), 1))
+	assert.NoFileExists(t, deletePath)
+	retained, err := service.runRepo.FindRetained()
+	require.NoError(t, err)
+	require.Len(t, retained, 1, "riwayat file yang dihapus ditandai")
+	assert.Equal(t, filepath.Base(keepPath), retained[0].FileName)
+
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+	preview, err := service.ValidateStoredRestore(context.Background(), filepath.Base(keepPath), nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Base(keepPath), preview.FileName)
+	require.NoError(t, service.ConfirmRestore(context.Background(), preview.Token, 1))
+	assertBackupDataRestored(t, db)
+	assert.FileExists(t, keepPath, "file backup tetap di folder backup")
+}
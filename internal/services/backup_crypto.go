@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"simdokpol/internal/dto"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Mode enkripsi backup (backup_encryption_mode)
+const (
+	BackupEncryptionNone       = "none"
+	BackupEncryptionPassphrase = "passphrase"
+	BackupEncryptionKeyFile    = "keyfile"
+)
+
+// Wadah backup terenkripsi:
+//
+//	magic (8 byte) | panjang header (uint32) | header JSON | chunk...
+//
+// Tiap chunk = panjang ciphertext (uint32) + AES-256-GCM dari maksimal
+// ChunkSize byte isi. Nonce = prefix acak (7) + nomor chunk (4) + penanda
+// chunk terakhir (1), dan header ikut sebagai additional data, sehingga
+// header yang diubah, chunk yang ditukar atau file yang dipotong gagal
+// diverifikasi.
+const (
+	encryptedBackupMagic     = "SDPENC\x00\x01"
+	encryptedBackupFormat    = "simdokpol-encrypted"
+	encryptedBackupVersion   = 1
+	encryptedBackupExtension = ".enc"
+	encryptedBackupChunkSize = 64 * 1024
+	encryptedBackupMaxHeader = 64 * 1024
+
+	kdfArgon2id   = "argon2id"
+	kdfHKDFSHA256 = "hkdf-sha256"
+
+	// Parameter argon2id untuk passphrase; tetap ringan untuk HP (Termux)
+	backupArgonTime    = 3
+	backupArgonMemory  = 64 * 1024
+	backupArgonThreads = 2
+
+	// Batas atas parameter argon2id dari header, agar file backup hasil
+	// rekayasa tidak bisa memaksa server menghitung KDF berjam-jam
+	maxBackupArgonTime   = 10
+	maxBackupArgonMemory = 1024 * 1024
+
+	// File kunci minimal 32 byte acak
+	minBackupKeyFileSize = 32
+)
+
+// backupKeyMaterial adalah bahan kunci sebelum diturunkan dengan salt.
+type backupKeyMaterial struct {
+	passphrase string
+	keyFile    []byte
+}
+
+func (m backupKeyMaterial) empty() bool {
+	return m.passphrase == "" && len(m.keyFile) == 0
+}
+
+// encryptionKeyMaterial membaca kunci dari pengaturan. Mode none menghasilkan
+// material kosong (backup tidak dienkripsi).
+func encryptionKeyMaterial(settings dto.BackupEncryptionConfig) (string, backupKeyMaterial, error) {
+	switch settings.Mode {
+	case BackupEncryptionPassphrase:
+		if settings.Passphrase == "" {
+			return "", backupKeyMaterial{}, ErrBackupKeyRequired
+		}
+		return kdfArgon2id, backupKeyMaterial{passphrase: settings.Passphrase}, nil
+	case BackupEncryptionKeyFile:
+		keyFile, err := readBackupKeyFile(settings.KeyFile)
+		if err != nil {
+			return "", backupKeyMaterial{}, err
+		}
+		return kdfHKDFSHA256, backupKeyMaterial{keyFile: keyFile}, nil
+	}
+	return "", backupKeyMaterial{}, nil
+}
+
+func readBackupKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, ErrBackupKeyRequired
+	}
+	keyFile, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file kunci backup: %w", err)
+	}
+	if len(keyFile) < minBackupKeyFileSize {
+		return nil, fmt.Errorf("file kunci backup minimal %d byte", minBackupKeyFileSize)
+	}
+	return keyFile, nil
+}
+
+// deriveBackupKey menurunkan kunci AES-256 sesuai KDF di header.
+func deriveBackupKey(header *dto.EncryptedBackupHeader, material backupKeyMaterial) ([]byte, error) {
+	switch header.KDF {
+	case kdfArgon2id:
+		if material.passphrase == "" {
+			return nil, ErrBackupKeyRequired
+		}
+		if header.KDFTime == 0 || header.KDFMemory == 0 || header.KDFThreads == 0 {
+			return nil, ErrBackupTampered
+		}
+		if header.KDFTime > maxBackupArgonTime || header.KDFMemory > maxBackupArgonMemory {
+			return nil, ErrInvalidBackup
+		}
+		return argon2.IDKey([]byte(material.passphrase), header.Salt, header.KDFTime, header.KDFMemory, header.KDFThreads, 32), nil
+	case kdfHKDFSHA256:
+		if len(material.keyFile) == 0 {
+			return nil, ErrBackupKeyRequired
+		}
+		return hkdf.Key(sha256.New, material.keyFile, header.Salt, encryptedBackupFormat, 32)
+	}
+	return nil, ErrBackupTampered
+}
+
+func backupKeyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("simdokpol-backup-key-check"))
+	return mac.Sum(nil)[:8]
+}
+
+func backupChunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func newBackupAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptBackupFile mengenkripsi plainPath ke destinationPath.
+func encryptBackupFile(plainPath, destinationPath string, header dto.EncryptedBackupHeader, material backupKeyMaterial) error {
+	header.Format = encryptedBackupFormat
+	header.Version = encryptedBackupVersion
+	header.ChunkSize = encryptedBackupChunkSize
+	header.Salt = make([]byte, 16)
+	header.NoncePrefix = make([]byte, 7)
+	if _, err := rand.Read(header.Salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(header.NoncePrefix); err != nil {
+		return err
+	}
+	if header.KDF == kdfArgon2id {
+		header.KDFTime, header.KDFMemory, header.KDFThreads = backupArgonTime, backupArgonMemory, backupArgonThreads
+	}
+	key, err := deriveBackupKey(&header, material)
+	if err != nil {
+		return err
+	}
+	header.KeyCheck = backupKeyCheck(key)
+	aead, err := newBackupAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, 0, len(encryptedBackupMagic)+4+len(headerJSON))
+	prefix = append(prefix, encryptedBackupMagic...)
+	prefix = binary.BigEndian.AppendUint32(prefix, uint32(len(headerJSON)))
+	prefix = append(prefix, headerJSON...)
+
+	in, err := os.Open(plainPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(out)
+	if err := writeEncryptedChunks(writer, bufio.NewReader(in), aead, header.NoncePrefix, prefix); err != nil {
+		out.Close()
+		os.Remove(destinationPath)
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		out.Close()
+		os.Remove(destinationPath)
+		return err
+	}
+	return out.Close()
+}
+
+func writeEncryptedChunks(w io.Writer, r io.Reader, aead cipher.AEAD, noncePrefix, additional []byte) error {
+	if _, err := w.Write(additional); err != nil {
+		return err
+	}
+	current := make([]byte, encryptedBackupChunkSize)
+	next := make([]byte, encryptedBackupChunkSize)
+	n, err := io.ReadFull(r, current)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	var lengthBuf [4]byte
+	for counter := uint32(0); ; counter++ {
+		// Baca chunk berikutnya dulu untuk tahu apakah chunk ini yang terakhir
+		m, err := io.ReadFull(r, next)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		final := m == 0
+		sealed := aead.Seal(nil, backupChunkNonce(noncePrefix, counter, final), current[:n], additional)
+		binary.BigEndian.PutUint32(lengthBuf[:], uint32(len(sealed)))
+		if _, err := w.Write(lengthBuf[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("file backup terlalu besar untuk dienkripsi")
+		}
+		current, next = next, current
+		n = m
+	}
+}
+
+// isEncryptedBackup mengecek magic di awal file.
+func isEncryptedBackup(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(encryptedBackupMagic))
+}
+
+// readEncryptedBackupHeader membaca header tanpa mendekripsi isi.
+func readEncryptedBackupHeader(r io.Reader) (*dto.EncryptedBackupHeader, []byte, error) {
+	prefix := make([]byte, len(encryptedBackupMagic)+4)
+	if _, err := io.ReadFull(r, prefix); err != nil || !isEncryptedBackup(prefix) {
+		return nil, nil, ErrBackupTampered
+	}
+	length := binary.BigEndian.Uint32(prefix[len(encryptedBackupMagic):])
+	if length == 0 || length > encryptedBackupMaxHeader {
+		return nil, nil, ErrBackupTampered
+	}
+	headerJSON := make([]byte, length)
+	if _, err := io.ReadFull(r, headerJSON); err != nil {
+		return nil, nil, ErrBackupTampered
+	}
+	var header dto.EncryptedBackupHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, ErrBackupTampered
+	}
+	if header.Format != encryptedBackupFormat || header.Version != encryptedBackupVersion ||
+		header.ChunkSize <= 0 || header.ChunkSize > 16*1024*1024 || len(header.NoncePrefix) != 7 || len(header.Salt) == 0 {
+		return nil, nil, ErrBackupTampered
+	}
+	return &header, append(prefix, headerJSON...), nil
+}
+
+// decryptBackupFile memverifikasi dan mendekripsi seluruh isi ke plainPath.
+// Bila gagal, plainPath dihapus; restore tidak boleh lanjut.
+func decryptBackupFile(encryptedPath, plainPath string, material backupKeyMaterial) (*dto.EncryptedBackupHeader, error) {
+	in, err := os.Open(encryptedPath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	reader := bufio.NewReader(in)
+
+	header, additional, err := readEncryptedBackupHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveBackupKey(header, material)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(backupKeyCheck(key), header.KeyCheck) {
+		return nil, ErrBackupWrongKey
+	}
+	aead, err := newBackupAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.OpenFile(plainPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(out)
+	err = readEncryptedChunks(writer, reader, aead, header, additional)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(plainPath)
+		return nil, err
+	}
+	return header, nil
+}
+
+func readEncryptedChunks(w io.Writer, r *bufio.Reader, aead cipher.AEAD, header *dto.EncryptedBackupHeader, additional []byte) error {
+	maxSealed := header.ChunkSize + aead.Overhead()
+	sealed := make([]byte, maxSealed)
+	var lengthBuf [4]byte
+	for counter := uint32(0); ; counter++ {
+		if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+			// Berhenti sebelum chunk bertanda terakhir = file terpotong
+			return ErrBackupTampered
+		}
+		length := int(binary.BigEndian.Uint32(lengthBuf[:]))
+		if length < aead.Overhead() || length > maxSealed {
+			return ErrBackupTampered
+		}
+		if _, err := io.ReadFull(r, sealed[:length]); err != nil {
+			return ErrBackupTampered
+		}
+		_, peekErr := r.Peek(1)
+		final := errors.Is(peekErr, io.EOF)
+		plain, err := aead.Open(sealed[:0:0], backupChunkNonce(header.NoncePrefix, counter, final), sealed[:length], additional)
+		if err != nil {
+			return ErrBackupTampered
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// newEncryptedBackupHeader mengisi metadata yang dicatat di header.
+func newEncryptedBackupHeader(kdf, dialect, method string, schemaVersion int) dto.EncryptedBackupHeader {
+	return dto.EncryptedBackupHeader{
+		AppVersion:    AppVersion,
+		SchemaVersion: schemaVersion,
+		Dialect:       dialect,
+		Method:        method,
+		CreatedAt:     time.Now().UTC(),
+		KDF:           kdf,
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptBackupFile_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := make([]byte, 32)
+	_, _ = rand.Read(keyFile)
+	material := backupKeyMaterial{keyFile: keyFile}
+
+	// Kosong, tepat satu chunk, dan beberapa chunk dengan sisa
+	for name, size := range map[string]int{"Kosong": 0, "Pas Satu Chunk": encryptedBackupChunkSize, "Banyak Chunk": 3*encryptedBackupChunkSize + 123} {
+		t.Run(name, func(t *testing.T) {
+			plain := make([]byte, size)
+			_, _ = rand.Read(plain)
+			plainPath := filepath.Join(dir, name+".db")
+			encPath := plainPath + encryptedBackupExtension
+			outPath := plainPath + ".out"
+			require.NoError(t, os.WriteFile(plainPath, plain, 0600))
+
+			header := newEncryptedBackupHeader(kdfHKDFSHA256, "sqlite", BackupMethodSQLite, models.SchemaVersion)
+			require.NoError(t, encryptBackupFile(plainPath, encPath, header, material))
+
+			decoded, err := decryptBackupFile(encPath, outPath, material)
+			require.NoError(t, err)
+			assert.Equal(t, "sqlite", decoded.Dialect)
+			assert.Equal(t, models.SchemaVersion, decoded.SchemaVersion)
+
+			got, err := os.ReadFile(outPath)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(plain, got))
+		})
+	}
+}
+
+func TestEncryptBackupFile_RejectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	material := backupKeyMaterial{keyFile: bytes.Repeat([]byte("k"), 32)}
+	plainPath := filepath.Join(dir, "asli.db")
+	require.NoError(t, os.WriteFile(plainPath, bytes.Repeat([]byte("data penduduk "), 20000), 0600))
+	encPath := plainPath + encryptedBackupExtension
+	header := newEncryptedBackupHeader(kdfHKDFSHA256, "sqlite", BackupMethodSQLite, models.SchemaVersion)
+	require.NoError(t, encryptBackupFile(plainPath, encPath, header, material))
+	original, err := os.ReadFile(encPath)
+	require.NoError(t, err)
+
+	headerEnd := len(encryptedBackupMagic) + 4 + int(binary.BigEndian.Uint32(original[len(encryptedBackupMagic):]))
+	cases := map[string]func([]byte) []byte{
+		"Isi Diubah": func(b []byte) []byte { b[headerEnd+100] ^= 0x01; return b },
+		"Header Diubah": func(b []byte) []byte {
+			return bytes.Replace(b, []byte(`"dialect":"sqlite"`), []byte(`"dialect":"mysql!"`), 1)
+		},
+		// Buang chunk terakhir: chunk sebelumnya tidak bertanda terakhir
+		"Terpotong": func(b []byte) []byte {
+			firstLen := int(binary.BigEndian.Uint32(b[headerEnd:]))
+			return b[:headerEnd+4+firstLen]
+		},
+		"Ditambah": func(b []byte) []byte { return append(b, 0, 0, 0, 0) },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".enc")
+			require.NoError(t, os.WriteFile(path, mutate(append([]byte(nil), original...)), 0600))
+			outPath := path + ".out"
+			_, err := decryptBackupFile(path, outPath, material)
+			assert.ErrorIs(t, err, ErrBackupTampered)
+			assert.NoFileExists(t, outPath, "isi sebagian tidak boleh tertinggal")
+		})
+	}
+
+	t.Run("Kunci Salah", func(t *testing.T) {
+		_, err := decryptBackupFile(encPath, filepath.Join(dir, "salah.out"), backupKeyMaterial{keyFile: bytes.Repeat([]byte("x"), 32)})
+		assert.ErrorIs(t, err, ErrBackupWrongKey)
+	})
+	t.Run("Tanpa Kunci", func(t *testing.T) {
+		_, err := decryptBackupFile(encPath, filepath.Join(dir, "kosong.out"), backupKeyMaterial{passphrase: "bukan-file-kunci"})
+		assert.ErrorIs(t, err, ErrBackupKeyRequired)
+	})
+}
+
+func TestDeriveBackupKey_RejectsOversizedArgonParams(t *testing.T) {
+	material := backupKeyMaterial{passphrase: "rahasia-kantor"}
+	cases := map[string]dto.EncryptedBackupHeader{
+		"Waktu Berlebih":  {KDF: kdfArgon2id, KDFTime: maxBackupArgonTime + 1, KDFMemory: backupArgonMemory, KDFThreads: backupArgonThreads},
+		"Waktu Ekstrem":   {KDF: kdfArgon2id, KDFTime: 1 << 31, KDFMemory: backupArgonMemory, KDFThreads: backupArgonThreads},
+		"Memori Berlebih": {KDF: kdfArgon2id, KDFTime: backupArgonTime, KDFMemory: maxBackupArgonMemory + 1, KDFThreads: backupArgonThreads},
+	}
+	for name, header := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := deriveBackupKey(&header, material)
+			assert.ErrorIs(t, err, ErrInvalidBackup)
+		})
+	}
+
+	header := dto.EncryptedBackupHeader{KDF: kdfArgon2id, KDFTime: 1, KDFMemory: 8 * 1024, KDFThreads: 1, Salt: bytes.Repeat([]byte("s"), 16)}
+	key, err := deriveBackupKey(&header, material)
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+}
+
+func TestBackupService_EncryptedPassphraseRoundTrip(t *testing.T) {
+	const passphrase = "kata sandi backup polsek"
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	appConfig := &dto.AppConfig{
+		BackupPath:       t.TempDir(),
+		BackupEncryption: dto.BackupEncryptionConfig{Mode: BackupEncryptionPassphrase, Passphrase: passphrase},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(backupPath, ".tar.gz.enc"))
+
+	// Tidak ada file polos di folder backup, dan isi tidak terbaca
+	entries, err := os.ReadDir(appConfig.BackupPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	content, err := os.ReadFile(backupPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "3201010101010001")
+
+	file, err := os.Open(backupPath)
+	require.NoError(t, err)
+	header, _, err := readEncryptedBackupHeader(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, AppVersion, header.AppVersion)
+	assert.Equal(t, models.SchemaVersion, header.SchemaVersion)
+	assert.Equal(t, "sqlite", header.Dialect)
+	assert.Equal(t, BackupMethodPortable, header.Method)
+	assert.Equal(t, kdfArgon2id, header.KDF)
+
+	restore := func(target *backupService, key *dto.BackupKey) error {
+		file, err := os.Open(backupPath)
+		require.NoError(t, err)
+		defer file.Close()
+		return target.RestoreBackup(context.Background(), file, key, 1)
+	}
+
+	// Instalasi baru tanpa pengaturan enkripsi: kunci harus diberikan admin
+	fresh := openBackupTestDB(t, "tujuan.db")
+	freshService := newTestBackupService(t, fresh, "sqlite")
+	assert.ErrorIs(t, restore(freshService, nil), ErrBackupKeyRequired)
+	assert.ErrorIs(t, restore(freshService, &dto.BackupKey{Passphrase: "passphrase yang salah"}), ErrBackupWrongKey)
+	require.NoError(t, restore(freshService, &dto.BackupKey{Passphrase: passphrase}))
+	assertBackupDataRestored(t, fresh)
+
+	// Di instalasi asal, passphrase dari pengaturan dipakai otomatis
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+	require.NoError(t, restore(service, nil))
+	assertBackupDataRestored(t, db)
+}
+
+func TestBackupService_EncryptedRejectsNewerSchema(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	material := backupKeyMaterial{keyFile: bytes.Repeat([]byte("k"), 32)}
+	keyPath := filepath.Join(t.TempDir(), "backup.key")
+	require.NoError(t, os.WriteFile(keyPath, material.keyFile, 0600))
+	appConfig := &dto.AppConfig{
+		BackupPath:       t.TempDir(),
+		BackupEncryption: dto.BackupEncryptionConfig{Mode: BackupEncryptionKeyFile, KeyFile: keyPath},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	plainPath := filepath.Join(t.TempDir(), "masa-depan.tar.gz")
+	require.NoError(t, service.writePortableBackup(context.Background(), plainPath))
+	encPath := plainPath + encryptedBackupExtension
+	header := newEncryptedBackupHeader(kdfHKDFSHA256, "sqlite", BackupMethodPortable, models.SchemaVersion+1)
+	require.NoError(t, encryptBackupFile(plainPath, encPath, header, material))
+
+	file, err := os.Open(encPath)
+	require.NoError(t, err)
+	defer file.Close()
+	err = service.RestoreBackup(context.Background(), file, nil, 1)
+	assert.ErrorIs(t, err, ErrBackupFormatUnsupported)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"simdokpol/internal/dto"
+	"strings"
+	"time"
+)
+
+// Batas keluaran stderr alat dump yang disertakan di pesan galat
+const dumpStderrLimit = 2048
+
+// detectDumpTool mencari alat dump/restore di PATH dan membaca versinya.
+func detectDumpTool(name, label, extension, dumpTool, restoreTool string) dto.BackupMethod {
+	method := dto.BackupMethod{Name: name, Label: label, Extension: extension}
+
+	dumpPath, err := exec.LookPath(dumpTool)
+	if err != nil {
+		method.Reason = dumpTool + " tidak ditemukan di PATH server"
+		return method
+	}
+	method.Available = true
+	method.Tool = dumpPath
+	method.Version = toolVersion(dumpPath)
+
+	if _, err := exec.LookPath(restoreTool); err == nil {
+		method.CanRestore = true
+	} else {
+		method.Reason = restoreTool + " tidak ditemukan, restore dari file ini belum bisa dilakukan di server ini"
+	}
+	return method
+}
+
+func toolVersion(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+}
+
+// dbHostPort memisahkan "host:port" yang kadang diisi langsung di DB_HOST.
+func (s *backupService) dbHostPort(defaultPort string) (string, string) {
+	host, port := s.cfg.DBHost, s.cfg.DBPort
+	if parts := strings.Split(host, ":"); len(parts) == 2 {
+		host, port = parts[0], parts[1]
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = defaultPort
+	}
+	return host, port
+}
+
+// postgresEnv: password lewat PGPASSWORD agar tidak terlihat di daftar proses.
+func (s *backupService) postgresEnv() []string {
+	sslMode := s.cfg.DBSSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	return append(os.Environ(), "PGPASSWORD="+s.cfg.DBPass, "PGSSLMODE="+sslMode)
+}
+
+func (s *backupService) postgresArgs() []string {
+	host, port := s.dbHostPort("5432")
+	return []string{"--host=" + host, "--port=" + port, "--username=" + s.cfg.DBUser, "--dbname=" + s.cfg.DBName}
+}
+
+func (s *backupService) runPgDump(ctx context.Context, tool, destinationPath string) error {
+	args := append([]string{"--format=custom", "--no-owner", "--no-privileges", "--file=" + destinationPath}, s.postgresArgs()...)
+	return runDumpCommand(ctx, tool, args, s.postgresEnv(), nil)
+}
+
+func (s *backupService) runPgRestore(ctx context.Context, dumpPath string) error {
+	tool, err := exec.LookPath("pg_restore")
+	if err != nil {
+		return fmt.Errorf("%w: pg_restore tidak ditemukan", ErrBackupMethodUnavailable)
+	}
+	args := append([]string{"--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction"}, s.postgresArgs()...)
+	return runDumpCommand(ctx, tool, append(args, dumpPath), s.postgresEnv(), nil)
+}
+
+func (s *backupService) runPsql(ctx context.Context, scriptPath string) error {
+	tool, err := exec.LookPath("psql")
+	if err != nil {
+		return fmt.Errorf("%w: psql tidak ditemukan", ErrBackupMethodUnavailable)
+	}
+	args := append([]string{"--single-transaction", "--set=ON_ERROR_STOP=1", "--file=" + scriptPath}, s.postgresArgs()...)
+	return runDumpCommand(ctx, tool, args, s.postgresEnv(), nil)
+}
+
+// mysqlOptionFile menulis password ke file opsi sementara (--defaults-extra-file)
+// agar tidak muncul di argumen proses. Pemanggil wajib menghapus file-nya.
+func (s *backupService) mysqlOptionFile() (string, error) {
+	file, err := os.CreateTemp("", "simdokpol-mysql-*.cnf")
+	if err != nil {
+		return "", err
+	}
+	password := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s.cfg.DBPass)
+	_, err = fmt.Fprintf(file, "[client]\npassword=\"%s\"\n", password)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0600)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// mysqlArgs: --defaults-extra-file wajib jadi argumen pertama.
+func (s *backupService) mysqlArgs(optionFile string) []string {
+	host, port := s.dbHostPort("3306")
+	args := []string{"--defaults-extra-file=" + optionFile, "--host=" + host, "--port=" + port, "--user=" + s.cfg.DBUser}
+	switch s.cfg.DBSSLMode {
+	case "require":
+		args = append(args, "--ssl-mode=REQUIRED")
+	case "verify-full":
+		args = append(args, "--ssl-mode=VERIFY_IDENTITY")
+	}
+	return args
+}
+
+func (s *backupService) runMySQLDump(ctx context.Context, tool, destinationPath string) error {
+	optionFile, err := s.mysqlOptionFile()
+	if err != nil {
+		return fmt.Errorf("gagal menyiapkan kredensial mysqldump: %w", err)
+	}
+	defer os.Remove(optionFile)
+
+	args := append(s.mysqlArgs(optionFile),
+		"--single-transaction", "--routines", "--triggers", "--add-drop-table",
+		"--default-character-set=utf8mb4", "--result-file="+destinationPath, s.cfg.DBName)
+	return runDumpCommand(ctx, tool, args, nil, nil)
+}
+
+func (s *backupService) runMySQLRestore(ctx context.Context, dumpPath string) error {
+	tool, err := exec.LookPath("mysql")
+	if err != nil {
+		return fmt.Errorf("%w: klien mysql tidak ditemukan", ErrBackupMethodUnavailable)
+	}
+	optionFile, err := s.mysqlOptionFile()
+	if err != nil {
+		return fmt.Errorf("gagal menyiapkan kredensial mysql: %w", err)
+	}
+	defer os.Remove(optionFile)
+
+	dump, err := os.Open(dumpPath)
+	if err != nil {
+		return err
+	}
+	defer dump.Close()
+
+	args := append(s.mysqlArgs(optionFile), "--default-character-set=utf8mb4", "--database="+s.cfg.DBName)
+	return runDumpCommand(ctx, tool, args, nil, dump)
+}
+
+func runDumpCommand(ctx context.Context, tool string, args, env []string, stdin *os.File) error {
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Env = env
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > dumpStderrLimit {
+			message = message[:dumpStderrLimit] + "..."
+		}
+		if message == "" {
+			return fmt.Errorf("%s gagal: %w", tool, err)
+		}
+		return fmt.Errorf("%s gagal: %w: %s", tool, err, message)
+	}
+	return nil
+}
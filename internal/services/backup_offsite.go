@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Tujuan backup off-site (backup_offsite_type)
+const (
+	BackupOffsiteNone   = "none"
+	BackupOffsiteSFTP   = "sftp"
+	BackupOffsiteS3     = "s3"
+	BackupOffsiteFolder = "folder"
+)
+
+// Jeda sebelum percobaan ulang pertama; berlipat dua di percobaan berikutnya.
+var offsiteRetryDelay = 10 * time.Second
+
+const offsiteDialTimeout = 30 * time.Second
+
+// offsiteDestination adalah satu koneksi ke tujuan off-site. Upload harus
+// atomik (file setengah jadi tidak pernah terlihat dengan nama akhir) dan
+// memastikan ukuran file di tujuan sama dengan file lokal.
+type offsiteDestination interface {
+	Upload(ctx context.Context, localPath, name string) error
+	// Delete tidak mengembalikan error bila file memang sudah tidak ada
+	Delete(ctx context.Context, name string) error
+	Close() error
+}
+
+// offsiteTargetLabel menggambarkan tujuan untuk riwayat dan log, tanpa kredensial.
+func offsiteTargetLabel(offsite dto.BackupOffsiteConfig) string {
+	switch offsite.Type {
+	case BackupOffsiteSFTP:
+		return fmt.Sprintf("sftp://%s@%s%s", offsite.SFTPUser, net.JoinHostPort(offsite.SFTPHost, strconv.Itoa(offsite.SFTPPort)), path.Join("/", offsite.SFTPPath))
+	case BackupOffsiteS3:
+		return "s3://" + path.Join(offsite.S3Bucket, offsite.S3Prefix)
+	case BackupOffsiteFolder:
+		return offsite.FolderPath
+	}
+	return ""
+}
+
+func openOffsiteDestination(ctx context.Context, offsite dto.BackupOffsiteConfig) (offsiteDestination, error) {
+	switch offsite.Type {
+	case BackupOffsiteSFTP:
+		if offsite.SFTPHost == "" || offsite.SFTPUser == "" {
+			return nil, ErrOffsiteNotConfigured
+		}
+		return openSFTPDestination(ctx, offsite)
+	case BackupOffsiteS3:
+		if offsite.S3Endpoint == "" || offsite.S3Bucket == "" {
+			return nil, ErrOffsiteNotConfigured
+		}
+		return openS3Destination(ctx, offsite)
+	case BackupOffsiteFolder:
+		if offsite.FolderPath == "" {
+			return nil, ErrOffsiteNotConfigured
+		}
+		if err := os.MkdirAll(offsite.FolderPath, 0755); err != nil {
+			return nil, fmt.Errorf("folder tujuan tidak bisa dibuat: %w", err)
+		}
+		return folderDestination{dir: offsite.FolderPath}, nil
+	}
+	return nil, ErrOffsiteNotConfigured
+}
+
+// --- Folder jaringan (share yang sudah di-mount) ---
+
+type folderDestination struct {
+	dir string
+}
+
+func (d folderDestination) Upload(ctx context.Context, localPath, name string) error {
+	finalPath := filepath.Join(d.dir, name)
+	tempPath := finalPath + ".partial"
+	if err := copyFileSynced(localPath, tempPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, finalPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return verifyOffsiteSize(localPath, func() (int64, error) {
+		info, err := os.Stat(finalPath)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	})
+}
+
+func (d folderDestination) Delete(ctx context.Context, name string) error {
+	if err := os.Remove(filepath.Join(d.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d folderDestination) Close() error { return nil }
+
+// copyFileSynced seperti copyFile tetapi memaksa isi tertulis ke share
+// sebelum di-rename, agar file tidak kosong bila koneksi putus.
+func copyFileSynced(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// --- SFTP ---
+
+type sftpDestination struct {
+	conn   *ssh.Client
+	client *sftp.Client
+	dir    string
+}
+
+func openSFTPDestination(ctx context.Context, offsite dto.BackupOffsiteConfig) (*sftpDestination, error) {
+	var auth []ssh.AuthMethod
+	if offsite.SFTPKeyFile != "" {
+		pemBytes, err := os.ReadFile(offsite.SFTPKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca private key SFTP: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) && offsite.SFTPPassword != "" {
+			// Password dipakai sebagai passphrase private key
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(offsite.SFTPPassword))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("private key SFTP tidak valid: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if offsite.SFTPPassword != "" {
+		auth = append(auth, ssh.Password(offsite.SFTPPassword))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("%w: isi password atau private key SFTP", ErrOffsiteNotConfigured)
+	}
+
+	expected := offsite.SFTPHostKey
+	sshConfig := &ssh.ClientConfig{
+		User: offsite.SFTPUser,
+		Auth: auth,
+		// Host key wajib dicocokkan dengan sidik jari di pengaturan; tanpa itu
+		// backup bisa terkirim ke server palsu.
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			fingerprint := ssh.FingerprintSHA256(key)
+			if expected == "" {
+				return fmt.Errorf("%w; sidik jari server: %s", ErrOffsiteHostKeyUnknown, fingerprint)
+			}
+			if fingerprint != expected {
+				return fmt.Errorf("%w; sidik jari server: %s", ErrOffsiteHostKeyMismatch, fingerprint)
+			}
+			return nil
+		},
+		Timeout: offsiteDialTimeout,
+	}
+
+	address := net.JoinHostPort(offsite.SFTPHost, strconv.Itoa(offsite.SFTPPort))
+	dialer := net.Dialer{Timeout: offsiteDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("gagal terhubung ke %s: %w", address, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, address, sshConfig)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	conn := ssh.NewClient(sshConn, chans, reqs)
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("server tidak mendukung SFTP: %w", err)
+	}
+
+	dir := offsite.SFTPPath
+	if dir == "" {
+		dir = "."
+	}
+	if err := client.MkdirAll(dir); err != nil {
+		client.Close()
+		conn.Close()
+		return nil, fmt.Errorf("folder %s di server SFTP tidak bisa dibuat: %w", dir, err)
+	}
+	return &sftpDestination{conn: conn, client: client, dir: dir}, nil
+}
+
+func (d *sftpDestination) Upload(ctx context.Context, localPath, name string) error {
+	in, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	finalPath := path.Join(d.dir, name)
+	tempPath := finalPath + ".partial"
+	out, err := d.client.Create(tempPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		d.client.Remove(tempPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		d.client.Remove(tempPath)
+		return err
+	}
+	// PosixRename menimpa file lama; server tanpa ekstensi itu memakai Rename biasa
+	if err := d.client.PosixRename(tempPath, finalPath); err != nil {
+		d.client.Remove(finalPath)
+		if err := d.client.Rename(tempPath, finalPath); err != nil {
+			d.client.Remove(tempPath)
+			return err
+		}
+	}
+	return verifyOffsiteSize(localPath, func() (int64, error) {
+		info, err := d.client.Stat(finalPath)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	})
+}
+
+func (d *sftpDestination) Delete(ctx context.Context, name string) error {
+	if err := d.client.Remove(path.Join(d.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d *sftpDestination) Close() error {
+	d.client.Close()
+	return d.conn.Close()
+}
+
+// --- S3-compatible (AWS S3, MinIO, dll) ---
+
+type s3Destination struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func openS3Destination(ctx context.Context, offsite dto.BackupOffsiteConfig) (*s3Destination, error) {
+	// Endpoint boleh ditulis dengan skema; skema menentukan TLS
+	endpoint, secure := offsite.S3Endpoint, offsite.S3UseSSL
+	if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		endpoint, secure = rest, true
+	} else if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		endpoint, secure = rest, false
+	}
+	client, err := minio.New(strings.TrimRight(endpoint, "/"), &minio.Options{
+		Creds:  credentials.NewStaticV4(offsite.S3AccessKey, offsite.S3SecretKey, ""),
+		Secure: secure,
+		Region: offsite.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("endpoint S3 tidak valid: %w", err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, offsiteDialTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(checkCtx, offsite.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi S3: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s tidak ditemukan", offsite.S3Bucket)
+	}
+	return &s3Destination{client: client, bucket: offsite.S3Bucket, prefix: offsite.S3Prefix}, nil
+}
+
+func (d *s3Destination) key(name string) string {
+	if d.prefix == "" {
+		return name
+	}
+	return d.prefix + "/" + name
+}
+
+// Upload ke S3 sudah atomik: objek baru terlihat setelah unggahan selesai.
+func (d *s3Destination) Upload(ctx context.Context, localPath, name string) error {
+	if _, err := d.client.FPutObject(ctx, d.bucket, d.key(name), localPath, minio.PutObjectOptions{ContentType: "application/octet-stream"}); err != nil {
+		return err
+	}
+	return verifyOffsiteSize(localPath, func() (int64, error) {
+		info, err := d.client.StatObject(ctx, d.bucket, d.key(name), minio.StatObjectOptions{})
+		return info.Size, err
+	})
+}
+
+func (d *s3Destination) Delete(ctx context.Context, name string) error {
+	return d.client.RemoveObject(ctx, d.bucket, d.key(name), minio.RemoveObjectOptions{})
+}
+
+func (d *s3Destination) Close() error { return nil }
+
+func verifyOffsiteSize(localPath string, remoteSize func() (int64, error)) error {
+	local, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	size, err := remoteSize()
+	if err != nil {
+		return fmt.Errorf("gagal memeriksa file di tujuan: %w", err)
+	}
+	if size != local.Size() {
+		return fmt.Errorf("ukuran file di tujuan %d byte, seharusnya %d byte", size, local.Size())
+	}
+	return nil
+}
+
+// --- Replikasi & retensi ---
+
+// uploadOffsite mengirim satu file dengan percobaan ulang. Kesalahan host
+// key dan pengaturan tidak diulang karena hasilnya pasti sama.
+func uploadOffsite(ctx context.Context, offsite dto.BackupOffsiteConfig, localPath, name string) (attempts int, err error) {
+	delay := offsiteRetryDelay
+	for attempts = 1; ; attempts++ {
+		var destination offsiteDestination
+		destination, err = openOffsiteDestination(ctx, offsite)
+		if err == nil {
+			err = destination.Upload(ctx, localPath, name)
+			destination.Close()
+		}
+		if err == nil || attempts > offsite.Retries || isPermanentOffsiteError(err) {
+			return attempts, err
+		}
+		log.Printf("WARN: kirim backup %s ke %s gagal (percobaan %d): %v", name, offsiteTargetLabel(offsite), attempts, err)
+
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func isPermanentOffsiteError(err error) bool {
+	return errors.Is(err, ErrOffsiteNotConfigured) || errors.Is(err, ErrOffsiteHostKeyUnknown) || errors.Is(err, ErrOffsiteHostKeyMismatch)
+}
+
+// replicateBackup mengirim backup yang baru dibuat ke tujuan off-site dan
+// mencatat hasilnya di riwayat. Tidak melakukan apa-apa bila replikasi nonaktif.
+func (s *backupService) replicateBackup(ctx context.Context, run *models.BackupRun) error {
+	appConfig, err := s.configService.GetConfig()
+	if err != nil {
+		return err
+	}
+	offsite := appConfig.BackupOffsite
+	if offsite.Type == "" || offsite.Type == BackupOffsiteNone || run.Status != models.BackupStatusSuccess {
+		return nil
+	}
+
+	attempts, err := uploadOffsite(ctx, offsite, run.FilePath, run.FileName)
+	backupOffsiteTotal.inc(metricResult(err))
+	now := time.Now()
+	run.OffsiteTarget = offsiteTargetLabel(offsite)
+	run.OffsiteAttempts = attempts
+	run.OffsiteAt = &now
+	if err != nil {
+		run.OffsiteStatus = models.BackupOffsiteFailed
+		run.OffsiteError = err.Error()
+	} else {
+		run.OffsiteStatus = models.BackupOffsiteUploaded
+		run.OffsiteError = ""
+	}
+	if s.runRepo != nil {
+		if recordErr := s.runRepo.UpdateOffsite(run); recordErr != nil {
+			log.Printf("WARN: gagal mencatat status off-site backup: %v", recordErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w (%s, %d percobaan): %v", ErrOffsiteUploadFailed, run.OffsiteTarget, attempts, err)
+	}
+
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     run.ActorID,
+		Action:     models.AuditBackupReplicated,
+		Detail:     fmt.Sprintf("Backup %s dikirim ke %s", run.FileName, run.OffsiteTarget),
+		EntityType: models.AuditEntityBackup,
+		EntityID:   run.FileName,
+	})
+	return nil
+}
+
+// pruneOffsite menerapkan kebijakan retensi yang sama pada salinan off-site.
+// Dihitung terpisah dari file lokal, jadi menghapus file lokal lewat katalog
+// tidak ikut menghapus salinannya. Salinan yang dikirim ke tujuan lain
+// (pengaturan sudah diganti) dilewati.
+func (s *backupService) pruneOffsite(ctx context.Context, offsite dto.BackupOffsiteConfig, schedule dto.BackupScheduleConfig, loc *time.Location, actorID uint) error {
+	if offsite.Type == "" || offsite.Type == BackupOffsiteNone {
+		return nil
+	}
+	runs, err := s.runRepo.FindReplicated()
+	if err != nil {
+		return err
+	}
+	target := offsiteTargetLabel(offsite)
+	var prune []models.BackupRun
+	for _, run := range selectBackupsToPrune(runs, schedule, loc) {
+		if run.OffsiteTarget == target {
+			prune = append(prune, run)
+		}
+	}
+	if len(prune) == 0 {
+		return nil
+	}
+
+	destination, err := openOffsiteDestination(ctx, offsite)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	removed := 0
+	for i := range prune {
+		run := &prune[i]
+		if err := destination.Delete(ctx, run.FileName); err != nil {
+			log.Printf("WARN: gagal menghapus salinan off-site %s: %v", run.FileName, err)
+			continue
+		}
+		run.OffsiteStatus = models.BackupOffsiteRemoved
+		if err := s.runRepo.UpdateOffsite(run); err != nil {
+			return err
+		}
+		removed++
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditBackupPruned,
+		Detail:     fmt.Sprintf("Retensi backup off-site %s: %d salinan lama dihapus", target, removed),
+		EntityType: models.AuditEntityBackup,
+	})
+	return nil
+}
+
+// TestOffsiteDestination mencoba tujuan dari form pengaturan (belum tentu
+// tersimpan): terhubung, menulis file uji kecil lalu menghapusnya. Password
+// dan secret key yang dikosongkan memakai nilai tersimpan.
+func (s *backupService) TestOffsiteDestination(ctx context.Context, settings map[string]string) (string, error) {
+	offsite := BackupOffsiteFromSettings(settings)
+	if saved, err := s.configService.GetConfig(); err == nil && saved != nil {
+		if offsite.SFTPPassword == "" {
+			offsite.SFTPPassword = saved.BackupOffsite.SFTPPassword
+		}
+		if offsite.S3SecretKey == "" {
+			offsite.S3SecretKey = saved.BackupOffsite.S3SecretKey
+		}
+	}
+	if offsite.Type == BackupOffsiteNone {
+		return "", ErrOffsiteNotConfigured
+	}
+
+	probeDir, err := os.MkdirTemp("", "simdokpol-offsite-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(probeDir)
+	probeName := fmt.Sprintf(".simdokpol-uji-%d.txt", time.Now().UnixNano())
+	probePath := filepath.Join(probeDir, probeName)
+	if err := os.WriteFile(probePath, []byte("Uji tujuan backup SIMDOKPOL. File ini aman dihapus.\n"), 0600); err != nil {
+		return "", err
+	}
+
+	destination, err := openOffsiteDestination(ctx, offsite)
+	if err != nil {
+		return "", err
+	}
+	defer destination.Close()
+	if err := destination.Upload(ctx, probePath, probeName); err != nil {
+		return "", fmt.Errorf("gagal menulis file uji: %w", err)
+	}
+	if err := destination.Delete(ctx, probeName); err != nil {
+		return "", fmt.Errorf("file uji terkirim tetapi gagal dihapus: %w", err)
+	}
+	return offsiteTargetLabel(offsite), nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSFTPServer menjalankan server SFTP lokal (user backup, password
+// rahasia) yang melayani file system asli, lalu mengembalikan alamat dan
+// sidik jari host key-nya.
+func startTestSFTPServer(t *testing.T) (string, string) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "backup" && string(password) == "rahasia" {
+				return nil, nil
+			}
+			return nil, errors.New("ditolak")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSFTP(conn, config)
+		}
+	}()
+	return listener.Addr().String(), ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func serveTestSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range channelRequests {
+				req.Reply(req.Type == "subsystem", nil)
+			}
+		}()
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			return
+		}
+		go func() {
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func sftpTestSettings(address, hostKey, dir string) map[string]string {
+	host, port, _ := net.SplitHostPort(address)
+	return map[string]string{
+		"backup_offsite_type":          BackupOffsiteSFTP,
+		"backup_offsite_sftp_host":     host,
+		"backup_offsite_sftp_port":     port,
+		"backup_offsite_sftp_user":     "backup",
+		"backup_offsite_sftp_password": "rahasia",
+		"backup_offsite_sftp_host_key": hostKey,
+		"backup_offsite_sftp_path":     dir,
+	}
+}
+
+func TestBackupService_TestOffsiteDestination(t *testing.T) {
+	service := newTestBackupService(t, nil, "sqlite")
+
+	t.Run("Folder", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "share", "simdokpol")
+		target, err := service.TestOffsiteDestination(context.Background(), map[string]string{
+			"backup_offsite_type": BackupOffsiteFolder, "backup_offsite_folder_path": dir,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, dir, target)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "file uji dihapus lagi")
+	})
+
+	t.Run("Belum Diatur", func(t *testing.T) {
+		_, err := service.TestOffsiteDestination(context.Background(), map[string]string{"backup_offsite_type": BackupOffsiteS3})
+		assert.ErrorIs(t, err, ErrOffsiteNotConfigured)
+	})
+
+	t.Run("SFTP", func(t *testing.T) {
+		address, fingerprint := startTestSFTPServer(t)
+		dir := t.TempDir()
+
+		_, err := service.TestOffsiteDestination(context.Background(), sftpTestSettings(address, "", dir))
+		assert.ErrorIs(t, err, ErrOffsiteHostKeyUnknown)
+		assert.Contains(t, err.Error(), fingerprint, "sidik jari server ditampilkan agar bisa disalin")
+
+		_, err = service.TestOffsiteDestination(context.Background(), sftpTestSettings(address, "SHA256:bukan-server-ini", dir))
+		assert.ErrorIs(t, err, ErrOffsiteHostKeyMismatch)
+
+		target, err := service.TestOffsiteDestination(context.Background(), sftpTestSettings(address, fingerprint, dir))
+		require.NoError(t, err)
+		assert.Contains(t, target, "sftp://backup@")
+	})
+}
+
+func TestBackupService_ReplicateScheduledBackup(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	offsiteDir := filepath.Join(t.TempDir(), "offsite")
+	appConfig := &dto.AppConfig{
+		BackupPath:     t.TempDir(),
+		BackupSchedule: dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "02:00", KeepDaily: 7},
+		BackupOffsite:  dto.BackupOffsiteConfig{Type: BackupOffsiteFolder, FolderPath: offsiteDir, Retries: 2},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	run, err := service.RunDueBackup(context.Background(), time.Now(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, models.BackupOffsiteUploaded, run.OffsiteStatus)
+	assert.Equal(t, 1, run.OffsiteAttempts)
+	_, localSHA, err := fileSizeAndSHA256(run.FilePath)
+	require.NoError(t, err)
+	_, remoteSHA, err := fileSizeAndSHA256(filepath.Join(offsiteDir, run.FileName))
+	require.NoError(t, err)
+	assert.Equal(t, localSHA, remoteSHA)
+
+	var stored models.BackupRun
+	require.NoError(t, db.First(&stored, run.ID).Error)
+	assert.Equal(t, models.BackupOffsiteUploaded, stored.OffsiteStatus)
+	assert.Equal(t, offsiteDir, stored.OffsiteTarget)
+}
+
+func TestBackupService_ReplicateRetriesThenFails(t *testing.T) {
+	previousDelay := offsiteRetryDelay
+	offsiteRetryDelay = time.Millisecond
+	t.Cleanup(func() { offsiteRetryDelay = previousDelay })
+
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	// Folder tujuan berupa file biasa: setiap percobaan gagal
+	blocked := filepath.Join(t.TempDir(), "bukan-folder")
+	require.NoError(t, os.WriteFile(blocked, []byte("x"), 0600))
+	appConfig := &dto.AppConfig{
+		BackupPath:     t.TempDir(),
+		BackupSchedule: dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "02:00"},
+		BackupOffsite:  dto.BackupOffsiteConfig{Type: BackupOffsiteFolder, FolderPath: blocked, Retries: 2},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	run, err := service.RunDueBackup(context.Background(), time.Now(), 1)
+	require.NoError(t, err, "backup lokal tetap berhasil")
+	assert.Equal(t, models.BackupStatusSuccess, run.Status)
+	assert.Equal(t, models.BackupOffsiteFailed, run.OffsiteStatus)
+	assert.Equal(t, 3, run.OffsiteAttempts)
+	assert.NotEmpty(t, run.OffsiteError)
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Failing)
+	assert.True(t, status.OffsiteFailing)
+}
+
+func TestBackupService_PruneOffsiteMirrorsRetention(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	offsiteDir := t.TempDir()
+	offsite := dto.BackupOffsiteConfig{Type: BackupOffsiteFolder, FolderPath: offsiteDir}
+	service := newTestBackupService(t, db, "sqlite")
+
+	base := time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC)
+	for day := 0; day < 4; day++ {
+		name := "backup-" + base.AddDate(0, 0, -day).Format("2006-01-02") + ".db"
+		require.NoError(t, os.WriteFile(filepath.Join(offsiteDir, name), []byte("isi"), 0600))
+		// Salinan tetap dihitung walau file lokalnya sudah dihapus dari katalog
+		run := models.BackupRun{
+			Kind: models.BackupKindScheduled, Method: BackupMethodSQLite, Status: models.BackupStatusSuccess, FileName: name,
+			StartedAt: base.AddDate(0, 0, -day), FinishedAt: base.AddDate(0, 0, -day), PrunedAt: &base,
+			OffsiteStatus: models.BackupOffsiteUploaded, OffsiteTarget: offsiteDir,
+		}
+		require.NoError(t, db.Create(&run).Error)
+	}
+	otherTarget := models.BackupRun{
+		Kind: models.BackupKindScheduled, Method: BackupMethodSQLite, Status: models.BackupStatusSuccess, FileName: "backup-lama.db",
+		StartedAt: base.AddDate(0, -1, 0), FinishedAt: base.AddDate(0, -1, 0), OffsiteStatus: models.BackupOffsiteUploaded, OffsiteTarget: "s3://lama",
+	}
+	require.NoError(t, db.Create(&otherTarget).Error)
+
+	require.NoError(t, service.pruneOffsite(context.Background(), offsite, dto.BackupScheduleConfig{KeepDaily: 2}, time.UTC, 1))
+
+	entries, err := os.ReadDir(offsiteDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.FileExists(t, filepath.Join(offsiteDir, "backup-2025-03-10.db"))
+	assert.FileExists(t, filepath.Join(offsiteDir, "backup-2025-03-09.db"))
+
+	var removed int64
+	require.NoError(t, db.Model(&models.BackupRun{}).Where("offsite_status = ?", models.BackupOffsiteRemoved).Count(&removed).Error)
+	assert.Equal(t, int64(2), removed)
+	require.NoError(t, db.First(&otherTarget, otherTarget.ID).Error)
+	assert.Equal(t, models.BackupOffsiteUploaded, otherTarget.OffsiteStatus, "salinan di tujuan lama tidak disentuh")
+}
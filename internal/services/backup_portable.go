@@ -0,0 +1,469 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	portableBackupFormat    = "simdokpol-portable"
+	portableBackupVersion   = 1
+	portableBackupExtension = ".tar.gz"
+	portableManifestName    = "manifest.json"
+	portableInsertBatch     = 200
+)
+
+// portableBackupModels: semua tabel model dalam urutan aman FK (induk dulu).
+// Restore menghapus dengan urutan terbalik lalu mengisi dengan urutan ini.
+func portableBackupModels() []interface{} {
+	return []interface{}{
+		&models.Configuration{},
+		&models.ItemTemplate{},
+		&models.Role{},
+		&models.JobPosition{},
+		&models.User{},
+		&models.License{},
+		&models.APIToken{},
+		&models.Resident{},
+		&models.LostDocument{},
+		&models.LostItem{},
+		&models.AuditLog{},
+		&models.AuditCheckpoint{},
+		&models.AuditArchive{},
+	}
+}
+
+func parseModelSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// columnFields: field yang punya kolom di tabel (relasi seperti User/Resident dilewati).
+func columnFields(sch *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(sch.Fields))
+	for _, field := range sch.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// writePortableBackup menulis arsip portabel ke file backup.
+func (s *backupService) writePortableBackup(ctx context.Context, destinationPath string) error {
+	file, err := os.Create(destinationPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := writePortableArchive(ctx, s.db, s.cfg.DBDialect, file, nil); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writePortableArchive menulis arsip tar.gz berisi satu file JSON lines per
+// tabel dan manifest.json (paling akhir, memuat versi skema, daftar kolom,
+// jumlah baris dan SHA-256). Nilai tiap kolom di-encode sesuai tipe Go-nya
+// sehingga bisa dipulihkan ke dialek database mana pun.
+func writePortableArchive(ctx context.Context, db *gorm.DB, dialect string, w io.Writer, omit portableRowFilter) (*dto.PortableBackupManifest, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest := &dto.PortableBackupManifest{
+		Format:        portableBackupFormat,
+		Version:       portableBackupVersion,
+		CreatedAt:     time.Now(),
+		SourceDialect: dialect,
+		SchemaVersion: models.SchemaVersion,
+	}
+
+	for _, model := range portableBackupModels() {
+		sch, err := parseModelSchema(db, model)
+		if err != nil {
+			return nil, err
+		}
+		table, err := writePortableTable(ctx, db, tw, sch, omit)
+		if err != nil {
+			return nil, fmt.Errorf("gagal backup tabel %s: %w", sch.Table, err)
+		}
+		manifest.Tables = append(manifest.Tables, table)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarEntry(tw, portableManifestName, manifestJSON); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// portableRowFilter mengembalikan true untuk baris yang tidak ikut ditulis
+// ke arsip. nil = semua baris ditulis (backup).
+type portableRowFilter func(table string, line map[string]json.RawMessage) bool
+
+// portableColumns mendeskripsikan kolom tabel di manifest agar arsip bisa
+// dibaca tanpa kode model aplikasi.
+func portableColumns(sch *schema.Schema) []dto.PortableBackupColumn {
+	fields := columnFields(sch)
+	columns := make([]dto.PortableBackupColumn, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, dto.PortableBackupColumn{
+			Name:       field.DBName,
+			Type:       string(field.DataType),
+			Nullable:   field.FieldType.Kind() == reflect.Ptr || field.FieldType == reflect.TypeOf(gorm.DeletedAt{}),
+			PrimaryKey: field.PrimaryKey,
+		})
+	}
+	return columns
+}
+
+// writePortableTable menulis isi tabel ke file sementara dulu karena header
+// tar butuh ukuran file sebelum isinya ditulis.
+func writePortableTable(ctx context.Context, db *gorm.DB, tw *tar.Writer, sch *schema.Schema, omit portableRowFilter) (dto.PortableBackupTable, error) {
+	table := dto.PortableBackupTable{Name: sch.Table, File: "tables/" + sch.Table + ".jsonl", Columns: portableColumns(sch)}
+
+	tmp, err := os.CreateTemp("", "simdokpol-backup-*.jsonl")
+	if err != nil {
+		return table, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(tmp, hash))
+	fields := columnFields(sch)
+
+	query := db.WithContext(ctx).Unscoped().Model(reflect.New(sch.ModelType).Interface())
+	for _, field := range sch.PrimaryFields {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: field.DBName}})
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return table, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record := reflect.New(sch.ModelType)
+		if err := db.ScanRows(rows, record.Interface()); err != nil {
+			return table, err
+		}
+		line := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			value, _ := field.ValueOf(ctx, record.Elem())
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return table, fmt.Errorf("kolom %s: %w", field.DBName, err)
+			}
+			line[field.DBName] = raw
+		}
+		if omit != nil && omit(sch.Table, line) {
+			continue
+		}
+		if err := encoder.Encode(line); err != nil {
+			return table, err
+		}
+		table.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return table, err
+	}
+	table.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return table, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return table, err
+	}
+	header := &tar.Header{Name: table.File, Mode: 0600, Size: info.Size(), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return table, err
+	}
+	_, err = io.Copy(tw, tmp)
+	return table, err
+}
+
+func writeTarEntry(tw *tar.Writer, name string, content []byte) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// forEachTarEntry membuka arsip portabel dan memanggil fn untuk tiap file.
+func forEachTarEntry(path string, fn func(name string, r io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupFormatUnsupported, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrBackupFormatUnsupported, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readPortableManifest membaca manifest lalu mencocokkan SHA-256 dan jumlah
+// baris tiap tabel, sebelum database disentuh sama sekali.
+func readPortableManifest(path string) (*dto.PortableBackupManifest, error) {
+	var manifest *dto.PortableBackupManifest
+	type digest struct {
+		sha  string
+		rows int64
+	}
+	digests := make(map[string]digest)
+
+	err := forEachTarEntry(path, func(name string, r io.Reader) error {
+		if name == portableManifestName {
+			manifest = &dto.PortableBackupManifest{}
+			return json.NewDecoder(r).Decode(manifest)
+		}
+		hash := sha256.New()
+		lines := &lineCounter{}
+		if _, err := io.Copy(io.MultiWriter(hash, lines), r); err != nil {
+			return err
+		}
+		digests[filepath.ToSlash(name)] = digest{sha: hex.EncodeToString(hash.Sum(nil)), rows: lines.count}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || manifest.Format != portableBackupFormat {
+		return nil, fmt.Errorf("%w: manifest arsip portabel tidak ditemukan", ErrBackupFormatUnsupported)
+	}
+	if manifest.Version > portableBackupVersion {
+		return nil, fmt.Errorf("%w: arsip versi %d lebih baru dari aplikasi ini", ErrBackupFormatUnsupported, manifest.Version)
+	}
+	if manifest.SchemaVersion > models.SchemaVersion {
+		return nil, fmt.Errorf("%w: arsip berasal dari skema versi %d, aplikasi ini baru mendukung versi %d", ErrBackupFormatUnsupported, manifest.SchemaVersion, models.SchemaVersion)
+	}
+	for _, table := range manifest.Tables {
+		got, ok := digests[table.File]
+		if !ok {
+			return nil, fmt.Errorf("%w: file %s hilang dari arsip", ErrBackupFormatUnsupported, table.File)
+		}
+		if got.sha != table.SHA256 || got.rows != table.Rows {
+			return nil, fmt.Errorf("%w: isi %s tidak cocok dengan manifest", ErrBackupFormatUnsupported, table.File)
+		}
+	}
+	return manifest, nil
+}
+
+type lineCounter struct{ count int64 }
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' {
+			c.count++
+		}
+	}
+	return len(p), nil
+}
+
+func (s *backupService) restorePortableBackup(ctx context.Context, path string) (*dto.PortableBackupManifest, error) {
+	return replacePortableData(ctx, s.db, s.cfg.DBDialect, path)
+}
+
+// replacePortableData mengganti isi semua tabel yang ada di manifest dalam
+// satu transaksi; bila satu baris gagal atau jumlah baris akhirnya tidak
+// cocok dengan manifest, database kembali seperti semula.
+func replacePortableData(ctx context.Context, db *gorm.DB, dialect, path string) (*dto.PortableBackupManifest, error) {
+	manifest, err := readPortableManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make(map[string]*schema.Schema)
+	var ordered []*schema.Schema
+	for _, model := range portableBackupModels() {
+		sch, err := parseModelSchema(db, model)
+		if err != nil {
+			return nil, err
+		}
+		schemas["tables/"+sch.Table+".jsonl"] = sch
+		ordered = append(ordered, sch)
+	}
+	included := make(map[string]bool)
+	for _, table := range manifest.Tables {
+		if _, ok := schemas[table.File]; !ok {
+			return nil, fmt.Errorf("%w: tabel %s tidak dikenal", ErrBackupFormatUnsupported, table.Name)
+		}
+		included[table.File] = true
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if dialect == "sqlite" {
+			tx.Exec("PRAGMA defer_foreign_keys = ON")
+		}
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if !included["tables/"+ordered[i].Table+".jsonl"] {
+				continue
+			}
+			if err := tx.Exec("DELETE FROM ?", clause.Table{Name: ordered[i].Table}).Error; err != nil {
+				return fmt.Errorf("gagal mengosongkan tabel %s: %w", ordered[i].Table, err)
+			}
+		}
+
+		// Arsip ditulis dengan urutan FK yang sama, jadi cukup dibaca berurutan
+		err := forEachTarEntry(path, func(name string, r io.Reader) error {
+			sch, ok := schemas[filepath.ToSlash(name)]
+			if !ok {
+				return nil
+			}
+			if err := importPortableTable(tx, sch, r); err != nil {
+				return fmt.Errorf("gagal restore tabel %s: %w", sch.Table, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if dialect == "postgres" {
+			for _, sch := range ordered {
+				if err := resetPostgresSequence(tx, sch); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Jumlah baris dicocokkan dengan manifest sebelum transaksi di-commit
+		for _, table := range manifest.Tables {
+			var rows int64
+			if err := tx.Table(table.Name).Count(&rows).Error; err != nil {
+				return fmt.Errorf("gagal menghitung tabel %s: %w", table.Name, err)
+			}
+			if rows != table.Rows {
+				return fmt.Errorf("tabel %s berisi %d baris, seharusnya %d", table.Name, rows, table.Rows)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRestoreRolledBack, err)
+	}
+	return manifest, nil
+}
+
+// importPortableTable menyisipkan baris lewat map agar nilai false/0 tidak
+// diganti default kolom (mis. is_active default true).
+func importPortableTable(tx *gorm.DB, sch *schema.Schema, r io.Reader) error {
+	fields := columnFields(sch)
+	decoder := json.NewDecoder(r)
+	batch := make([]map[string]interface{}, 0, portableInsertBatch)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := tx.Table(sch.Table).Create(batch).Error
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		var line map[string]json.RawMessage
+		if err := decoder.Decode(&line); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		row, err := decodePortableRow(fields, line)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, row)
+		if len(batch) == portableInsertBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// decodePortableRow mengubah satu baris JSON menjadi map kolom dengan nilai
+// bertipe sesuai field model; kolom yang tidak ada di arsip diisi nilai nol.
+func decodePortableRow(fields []*schema.Field, line map[string]json.RawMessage) (map[string]interface{}, error) {
+	row := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		value := reflect.New(field.FieldType)
+		if raw, ok := line[field.DBName]; ok {
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return nil, fmt.Errorf("kolom %s: %w", field.DBName, err)
+			}
+		}
+		row[field.DBName] = value.Elem().Interface()
+	}
+	return row, nil
+}
+
+// resetPostgresSequence menyelaraskan sequence SERIAL setelah ID disisipkan manual.
+func resetPostgresSequence(tx *gorm.DB, sch *schema.Schema) error {
+	field := sch.PrioritizedPrimaryField
+	if field == nil || !field.AutoIncrement {
+		return nil
+	}
+	query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 1), MAX(%s) IS NOT NULL) FROM %s",
+		sch.Table, field.DBName, tx.Statement.Quote(field.DBName), tx.Statement.Quote(field.DBName), tx.Statement.Quote(sch.Table))
+	if err := tx.Exec(query).Error; err != nil {
+		return fmt.Errorf("gagal menyelaraskan sequence %s: %w", sch.Table, err)
+	}
+	return nil
+}
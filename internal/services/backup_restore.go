@@ -0,0 +1,728 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Restore berjalan dua tahap. ValidateRestore menaruh file di folder staging
+// lalu memeriksa integritas, versi skema dan jumlah baris tanpa menyentuh
+// database aktif. Setelah admin melihat ringkasannya, ConfirmRestore
+// menerapkan file tersebut, memeriksa ulang hasilnya, dan mengembalikan
+// database ke keadaan semula bila pemeriksaan gagal.
+
+const (
+	restoreStagingTTL   = 30 * time.Minute
+	restoreStagedFile   = "backup"
+	restorePreviewFile  = "preview.json"
+	restoreSafetyBackup = "sebelum-restore" + portableBackupExtension
+)
+
+// coreRestoreTables wajib ada agar file dianggap database SIMDOKPOL.
+var coreRestoreTables = map[string]bool{"configurations": true, "users": true, "residents": true, "lost_documents": true}
+
+var restoreSchemaCache sync.Map
+
+func restoreStagingRoot() string {
+	return filepath.Join(os.TempDir(), "simdokpol-restore")
+}
+
+// restoreStagingPath menolak token yang bukan hex agar tidak bisa dipakai
+// untuk keluar dari folder staging.
+func restoreStagingPath(token string) (string, error) {
+	if len(token) != 32 {
+		return "", ErrRestoreStagingNotFound
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return "", ErrRestoreStagingNotFound
+	}
+	return filepath.Join(restoreStagingRoot(), token), nil
+}
+
+// cleanupRestoreStaging menghapus staging yang ditinggal tanpa konfirmasi.
+func cleanupRestoreStaging(now time.Time) {
+	entries, err := os.ReadDir(restoreStagingRoot())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && now.Sub(info.ModTime()) > restoreStagingTTL {
+			os.RemoveAll(filepath.Join(restoreStagingRoot(), entry.Name()))
+		}
+	}
+}
+
+// restoreTableSchemas: skema tabel yang dicek dan dihitung, urutan sama
+// dengan arsip portabel.
+func restoreTableSchemas() ([]*schema.Schema, error) {
+	tableModels := portableBackupModels()
+	schemas := make([]*schema.Schema, 0, len(tableModels))
+	for _, model := range tableModels {
+		sch, err := schema.Parse(model, &restoreSchemaCache, schema.NamingStrategy{})
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, sch)
+	}
+	return schemas, nil
+}
+
+// RestoreBackup adalah restore satu langkah (validasi lalu langsung
+// diterapkan), dipakai wizard setup dan klien API lama.
+func (s *backupService) RestoreBackup(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey, actorID uint) error {
+	preview, err := s.ValidateRestore(ctx, uploadedFile, key)
+	if err != nil {
+		return err
+	}
+	if !preview.Compatible {
+		s.DiscardRestore(preview.Token)
+		return fmt.Errorf("%w: %s", ErrBackupValidationFailed, strings.Join(preview.Errors, "; "))
+	}
+	return s.ConfirmRestore(ctx, preview.Token, actorID)
+}
+
+func (s *backupService) ValidateRestore(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey) (*dto.RestorePreview, error) {
+	cleanupRestoreStaging(time.Now())
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	dir := filepath.Join(restoreStagingRoot(), token)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("gagal membuat folder staging restore: %w", err)
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	uploadPath := filepath.Join(dir, "upload")
+	if err := writeUploadedFile(uploadPath, uploadedFile); err != nil {
+		return nil, fmt.Errorf("gagal copy data: %w", err)
+	}
+	format, err := detectBackupFormat(uploadPath)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &dto.RestorePreview{
+		Token:                token,
+		TargetDialect:        s.cfg.DBDialect,
+		CurrentSchemaVersion: models.SchemaVersion,
+		Tables:               []dto.RestoreTablePreview{},
+		Warnings:             []string{},
+		Errors:               []string{},
+	}
+	stagedPath := filepath.Join(dir, restoreStagedFile)
+	if format == formatEncrypted {
+		// Seluruh isi diverifikasi dulu; isi polos hanya ada di folder staging
+		header, err := decryptBackupFile(uploadPath, stagedPath, s.restoreKeyMaterial(key))
+		if err != nil {
+			return nil, err
+		}
+		os.Remove(uploadPath)
+		if header.SchemaVersion > models.SchemaVersion {
+			return nil, fmt.Errorf("%w: backup dari skema versi %d (aplikasi %s), skema aplikasi ini %d", ErrBackupFormatUnsupported, header.SchemaVersion, header.AppVersion, models.SchemaVersion)
+		}
+		preview.Encrypted = true
+		preview.AppVersion = header.AppVersion
+		preview.SchemaVersion = header.SchemaVersion
+		preview.SourceDialect = header.Dialect
+		createdAt := header.CreatedAt
+		preview.CreatedAt = &createdAt
+		if format, err = detectBackupFormat(stagedPath); err != nil || format == formatEncrypted {
+			return nil, ErrBackupFormatUnsupported
+		}
+	} else if err := os.Rename(uploadPath, stagedPath); err != nil {
+		return nil, err
+	}
+	preview.Format = format
+
+	if !s.canRestoreFormat(format) {
+		return nil, fmt.Errorf("%w: file %s tidak bisa dipulihkan ke %s", ErrBackupFormatUnsupported, format, s.cfg.DBDialect)
+	}
+	if preview.SizeBytes, preview.SHA256, err = fileSizeAndSHA256(stagedPath); err != nil {
+		return nil, err
+	}
+
+	if err := s.inspectBackupFile(ctx, stagedPath, format, preview); err != nil {
+		return nil, err
+	}
+	s.fillCurrentRowCounts(ctx, preview)
+
+	preview.Compatible = len(preview.Errors) == 0
+	preview.ExpiresAt = time.Now().Add(restoreStagingTTL)
+	content, err := json.Marshal(preview)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, restorePreviewFile), content, 0600); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan ringkasan restore: %w", err)
+	}
+	keep = true
+	return preview, nil
+}
+
+func (s *backupService) DiscardRestore(token string) error {
+	dir, err := restoreStagingPath(token)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ErrRestoreStagingNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+func (s *backupService) ConfirmRestore(ctx context.Context, token string, actorID uint) error {
+	if !s.restoreMu.TryLock() {
+		return ErrRestoreInProgress
+	}
+	defer s.restoreMu.Unlock()
+
+	dir, err := restoreStagingPath(token)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, restorePreviewFile))
+	if err != nil {
+		return ErrRestoreStagingNotFound
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	var preview dto.RestorePreview
+	if err := json.Unmarshal(content, &preview); err != nil || time.Now().After(preview.ExpiresAt) {
+		return ErrRestoreStagingNotFound
+	}
+	if !preview.Compatible {
+		return fmt.Errorf("%w: %s", ErrBackupValidationFailed, strings.Join(preview.Errors, "; "))
+	}
+	stagedPath := filepath.Join(dir, restoreStagedFile)
+	if _, sha, err := fileSizeAndSHA256(stagedPath); err != nil || sha != preview.SHA256 {
+		return fmt.Errorf("%w: file staging berubah sejak divalidasi", ErrBackupValidationFailed)
+	}
+
+	var detail, entityID string
+	switch preview.Format {
+	case BackupMethodSQLite:
+		var backupPath string
+		if backupPath, err = s.applySQLiteRestore(ctx, stagedPath, &preview); err == nil {
+			detail = "Restore DB Sukses"
+			entityID = filepath.Base(backupPath)
+		}
+	case BackupMethodPortable:
+		if _, err = s.restorePortableBackup(ctx, stagedPath); err == nil {
+			detail = fmt.Sprintf("Restore arsip portabel (%s, %d tabel)", preview.SourceDialect, restoredTableCount(&preview))
+			entityID = preview.CreatedAt.Format("20060102150405")
+		}
+	default:
+		if keep, err = s.applyDumpRestore(ctx, stagedPath, &preview); err == nil {
+			detail = "Restore dump PostgreSQL"
+			if preview.Format == formatSQLText {
+				detail = fmt.Sprintf("Restore skrip SQL %s", s.cfg.DBDialect)
+			}
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrRestoreRolledBack) {
+			s.auditService.LogEvent(ctx, dto.AuditEvent{
+				UserID:     actorID,
+				Action:     models.AuditRestoreRolledBack,
+				Detail:     err.Error(),
+				EntityType: models.AuditEntityBackup,
+				EntityID:   preview.SHA256[:12],
+			})
+		}
+		return err
+	}
+
+	if preview.Encrypted {
+		detail += fmt.Sprintf(" [terenkripsi, dibuat %s oleh versi %s]", preview.CreatedAt.Format(time.RFC3339), preview.AppVersion)
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditRestoreFromFile,
+		Detail:     detail,
+		EntityType: models.AuditEntityBackup,
+		EntityID:   entityID,
+	})
+	return nil
+}
+
+// inspectBackupFile mengisi preview sesuai format file yang sudah didekripsi.
+func (s *backupService) inspectBackupFile(ctx context.Context, path, format string, preview *dto.RestorePreview) error {
+	switch format {
+	case BackupMethodSQLite:
+		return s.inspectSQLiteBackup(path, preview)
+	case BackupMethodPortable:
+		return inspectPortableBackup(path, preview)
+	case BackupMethodPgDump:
+		return inspectPgDumpBackup(ctx, path, preview)
+	case formatSQLText:
+		return inspectSQLScript(path, preview)
+	}
+	return ErrBackupFormatUnsupported
+}
+
+func (s *backupService) canRestoreFormat(format string) bool {
+	switch format {
+	case BackupMethodPortable:
+		return true
+	case BackupMethodSQLite:
+		return s.cfg.DBDialect == "sqlite"
+	case BackupMethodPgDump:
+		return s.cfg.DBDialect == "postgres"
+	case formatSQLText:
+		return s.cfg.DBDialect == "postgres" || s.cfg.DBDialect == "mysql"
+	}
+	return false
+}
+
+// inspectSQLiteBackup membuka salinan staging secara read-only, jadi file
+// yang rusak atau bukan milik SIMDOKPOL tidak pernah menggantikan database aktif.
+func (s *backupService) inspectSQLiteBackup(path string, preview *dto.RestorePreview) error {
+	backupDB, err := gorm.Open(sqlite.Open("file:"+filepath.ToSlash(path)+"?mode=ro"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupFormatUnsupported, err)
+	}
+	sqlDB, err := backupDB.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	preview.IntegrityCheck = sqliteIntegrityCheck(backupDB)
+	if preview.IntegrityCheck != "ok" {
+		preview.Errors = append(preview.Errors, "Pemeriksaan integritas SQLite gagal: "+preview.IntegrityCheck)
+		return nil
+	}
+	preview.SourceDialect = "sqlite"
+
+	// user_version diisi saat backup dibuat; 0 berarti backup versi lama
+	var userVersion int
+	backupDB.Raw("PRAGMA user_version").Scan(&userVersion)
+	if userVersion > 0 {
+		preview.SchemaVersion = userVersion
+	}
+	if preview.SchemaVersion > models.SchemaVersion {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("Backup dari skema versi %d, aplikasi ini baru mendukung versi %d. Perbarui aplikasi dulu.", preview.SchemaVersion, models.SchemaVersion))
+	} else if preview.SchemaVersion == 0 {
+		preview.Warnings = append(preview.Warnings, "Versi skema tidak tercatat di backup (dibuat versi lama); kolom yang kurang akan ditambahkan otomatis.")
+	}
+
+	schemas, err := restoreTableSchemas()
+	if err != nil {
+		return err
+	}
+	migrator := backupDB.Migrator()
+	for _, sch := range schemas {
+		table := dto.RestoreTablePreview{Name: sch.Table, BackupRows: -1, CurrentRows: -1}
+		if !migrator.HasTable(sch.Table) {
+			table.Missing = true
+			preview.Tables = append(preview.Tables, table)
+			addMissingTable(preview, sch.Table)
+			continue
+		}
+		if err := backupDB.Table(sch.Table).Count(&table.BackupRows).Error; err != nil {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("Tabel %s tidak bisa dibaca: %v", sch.Table, err))
+		}
+		preview.Tables = append(preview.Tables, table)
+
+		columnTypes, err := migrator.ColumnTypes(sch.Table)
+		if err != nil {
+			continue
+		}
+		present := make(map[string]bool, len(columnTypes))
+		for _, column := range columnTypes {
+			present[column.Name()] = true
+		}
+		var missing []string
+		for _, field := range columnFields(sch) {
+			if !present[field.DBName] {
+				missing = append(missing, field.DBName)
+			}
+			delete(present, field.DBName)
+		}
+		if len(missing) > 0 {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("Tabel %s belum punya kolom %s; akan ditambahkan otomatis.", sch.Table, strings.Join(missing, ", ")))
+		}
+		if len(present) > 0 {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("Tabel %s punya %d kolom yang tidak dikenal aplikasi ini; backup mungkin dari versi yang lebih baru.", sch.Table, len(present)))
+		}
+	}
+	return nil
+}
+
+// inspectPortableBackup: checksum dan jumlah baris diambil dari manifest
+// yang sudah dicocokkan dengan isi arsip.
+func inspectPortableBackup(path string, preview *dto.RestorePreview) error {
+	manifest, err := readPortableManifest(path)
+	if err != nil {
+		return err
+	}
+	preview.IntegrityCheck = "ok"
+	if preview.SourceDialect == "" {
+		preview.SourceDialect = manifest.SourceDialect
+	}
+	if preview.CreatedAt == nil {
+		createdAt := manifest.CreatedAt
+		preview.CreatedAt = &createdAt
+	}
+	found := make(map[string]int64, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		found[table.Name] = table.Rows
+	}
+	return previewFoundTables(preview, found)
+}
+
+// inspectPgDumpBackup membaca daftar isi arsip lewat pg_restore --list;
+// jumlah baris baru diketahui setelah restore.
+func inspectPgDumpBackup(ctx context.Context, path string, preview *dto.RestorePreview) error {
+	tool, err := exec.LookPath("pg_restore")
+	if err != nil {
+		return fmt.Errorf("%w: pg_restore tidak ditemukan", ErrBackupMethodUnavailable)
+	}
+	listCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	out, err := exec.CommandContext(listCtx, tool, "--list", path).Output()
+	if err != nil {
+		return fmt.Errorf("%w: arsip pg_dump tidak terbaca: %v", ErrBackupFormatUnsupported, err)
+	}
+	preview.IntegrityCheck = "ok"
+	preview.SourceDialect = "postgres"
+
+	// Baris data berbentuk "3345; 0 16390 TABLE DATA public users simdokpol"
+	found := make(map[string]int64)
+	for _, line := range strings.Split(string(out), "\n") {
+		_, rest, ok := strings.Cut(line, " TABLE DATA ")
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(rest); len(fields) >= 2 {
+			found[fields[1]] = -1
+		}
+	}
+	return previewFoundTables(preview, found)
+}
+
+var sqlCreateTablePattern = regexp.MustCompile("(?i)^CREATE TABLE (?:IF NOT EXISTS )?(?:[`\"]?\\w+[`\"]?\\.)?[`\"]?(\\w+)[`\"]?")
+
+// inspectSQLScript hanya bisa melihat tabel yang dibuat skrip; isi skrip
+// baru diperiksa database saat dijalankan.
+func inspectSQLScript(path string, preview *dto.RestorePreview) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	found := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if match := sqlCreateTablePattern.FindStringSubmatch(scanner.Text()); match != nil {
+			found[match[1]] = -1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: skrip SQL tidak terbaca: %v", ErrBackupFormatUnsupported, err)
+	}
+	preview.IntegrityCheck = "tidak tersedia untuk skrip SQL"
+	preview.Warnings = append(preview.Warnings, "Jumlah baris skrip SQL baru bisa dihitung setelah restore.")
+	return previewFoundTables(preview, found)
+}
+
+// previewFoundTables mengisi daftar tabel dari tabel yang ada di backup
+// (jumlah baris -1 bila belum diketahui).
+func previewFoundTables(preview *dto.RestorePreview, found map[string]int64) error {
+	schemas, err := restoreTableSchemas()
+	if err != nil {
+		return err
+	}
+	for _, sch := range schemas {
+		rows, ok := found[sch.Table]
+		if !ok {
+			preview.Tables = append(preview.Tables, dto.RestoreTablePreview{Name: sch.Table, BackupRows: -1, CurrentRows: -1, Missing: true})
+			addMissingTable(preview, sch.Table)
+			continue
+		}
+		preview.Tables = append(preview.Tables, dto.RestoreTablePreview{Name: sch.Table, BackupRows: rows, CurrentRows: -1})
+	}
+	return nil
+}
+
+func addMissingTable(preview *dto.RestorePreview, table string) {
+	if coreRestoreTables[table] {
+		preview.Errors = append(preview.Errors, fmt.Sprintf("Tabel %s tidak ada: file ini bukan backup database SIMDOKPOL.", table))
+		return
+	}
+	preview.Warnings = append(preview.Warnings, fmt.Sprintf("Tabel %s tidak ada di backup; akan kosong setelah restore.", table))
+}
+
+func (s *backupService) fillCurrentRowCounts(ctx context.Context, preview *dto.RestorePreview) {
+	if s.db == nil {
+		return
+	}
+	db := s.db.WithContext(ctx)
+	for i := range preview.Tables {
+		if db.Migrator().HasTable(preview.Tables[i].Name) {
+			db.Table(preview.Tables[i].Name).Count(&preview.Tables[i].CurrentRows)
+		}
+	}
+}
+
+func restoredTableCount(preview *dto.RestorePreview) int {
+	count := 0
+	for _, table := range preview.Tables {
+		if !table.Missing {
+			count++
+		}
+	}
+	return count
+}
+
+// verifyRestoredDatabase membandingkan database setelah restore dengan
+// ringkasan validasi.
+func (s *backupService) verifyRestoredDatabase(ctx context.Context, preview *dto.RestorePreview) error {
+	db := s.db.WithContext(ctx)
+	if s.cfg.DBDialect == "sqlite" {
+		if result := sqliteIntegrityCheck(db); result != "ok" {
+			return fmt.Errorf("integritas database setelah restore: %s", result)
+		}
+	}
+	for _, table := range preview.Tables {
+		if table.Missing {
+			continue
+		}
+		if !db.Migrator().HasTable(table.Name) {
+			return fmt.Errorf("tabel %s hilang setelah restore", table.Name)
+		}
+		if table.BackupRows < 0 {
+			continue
+		}
+		var rows int64
+		if err := db.Table(table.Name).Count(&rows).Error; err != nil {
+			return fmt.Errorf("gagal menghitung tabel %s: %w", table.Name, err)
+		}
+		if rows != table.BackupRows {
+			return fmt.Errorf("tabel %s berisi %d baris, seharusnya %d", table.Name, rows, table.BackupRows)
+		}
+	}
+	return nil
+}
+
+// applySQLiteRestore mengganti file database lalu membuka ulang pool koneksi
+// yang sama (*gorm.DB dipakai bersama semua repository). Bila migrasi atau
+// pemeriksaan setelahnya gagal, file lama dipasang kembali.
+func (s *backupService) applySQLiteRestore(ctx context.Context, stagedPath string, preview *dto.RestorePreview) (string, error) {
+	// Pool koneksi dipakai bersama semua request dan job: tutup gerbang dan
+	// tunggu semuanya selesai sebelum pool ditutup dan diganti
+	endMaintenance, err := Maintenance.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer endMaintenance()
+
+	targetPath := s.getCleanDBPath()
+	tempNewPath := targetPath + ".new"
+	if err := copyFile(stagedPath, tempNewPath); err != nil {
+		os.Remove(tempNewPath)
+		return "", fmt.Errorf("gagal copy data: %w", err)
+	}
+
+	// Isi WAL dipindah ke file utama dulu agar file .bak lengkap
+	s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	if err := s.closeSQLitePool(); err != nil {
+		os.Remove(tempNewPath)
+		return "", err
+	}
+
+	timestamp := time.Now().Format("20060102150405")
+	backupPath := targetPath + ".bak." + timestamp
+	if err := swapSQLiteFiles(targetPath, backupPath, tempNewPath); err != nil {
+		os.Remove(tempNewPath)
+		if reopenErr := s.reopenSQLitePool(); reopenErr != nil {
+			log.Printf("ERROR: gagal membuka ulang database lama: %v", reopenErr)
+		}
+		return "", err
+	}
+
+	err = s.reopenSQLitePool()
+	if err == nil {
+		if _, err = NewSchemaMigrationService(s.db, "sqlite").Up(ctx); err != nil {
+			err = fmt.Errorf("migrasi skema setelah restore: %w", err)
+		}
+	}
+	if err == nil {
+		err = s.verifyRestoredDatabase(ctx, preview)
+	}
+	if err == nil {
+		return backupPath, nil
+	}
+
+	// Rollback: file hasil restore disimpan sebagai .gagal untuk diperiksa
+	log.Printf("ERROR: restore SQLite gagal diverifikasi, mengembalikan database lama: %v", err)
+	if closeErr := s.closeSQLitePool(); closeErr != nil {
+		log.Printf("WARN: gagal menutup database hasil restore: %v", closeErr)
+	}
+	rollbackErr := swapSQLiteFiles(targetPath, targetPath+".gagal."+timestamp, backupPath)
+	if reopenErr := s.reopenSQLitePool(); rollbackErr == nil {
+		rollbackErr = reopenErr
+	}
+	if rollbackErr != nil {
+		return "", fmt.Errorf("restore gagal (%v) dan database lama tidak bisa dipasang kembali: %v; salinan ada di %s", err, rollbackErr, backupPath)
+	}
+	return "", fmt.Errorf("%w: %v", ErrRestoreRolledBack, err)
+}
+
+// swapSQLiteFiles memindahkan current ke aside (beserta -wal/-shm) lalu
+// memasang replacement di current.
+func swapSQLiteFiles(current, aside, replacement string) error {
+	if err := os.Rename(current, aside); err != nil {
+		if strings.Contains(err.Error(), "process cannot access") {
+			return fmt.Errorf("DB TERKUNCI: Tutup aplikasi dan rename file .db secara manual.")
+		}
+		return fmt.Errorf("gagal backup file lama: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(current + suffix); err == nil {
+			os.Rename(current+suffix, aside+suffix)
+		}
+	}
+	if err := os.Rename(replacement, current); err != nil {
+		os.Rename(aside, current)
+		return fmt.Errorf("gagal aktifkan DB baru: %w", err)
+	}
+	return nil
+}
+
+func (s *backupService) closeSQLitePool() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.Close(); err != nil {
+		return fmt.Errorf("gagal menutup koneksi database: %w", err)
+	}
+	return nil
+}
+
+// reopenSQLitePool membuka file database lagi dengan pengaturan yang sama
+// seperti saat aplikasi start, lalu memasang pool barunya ke *gorm.DB yang
+// sudah dipegang semua repository. Hanya boleh dipanggil di dalam
+// Maintenance.Begin, saat tidak ada yang sedang memakai pool.
+func (s *backupService) reopenSQLitePool() error {
+	reopened, err := gorm.Open(sqlite.Open(s.cfg.DBDSN), &gorm.Config{Logger: s.db.Logger})
+	if err != nil {
+		return fmt.Errorf("gagal membuka ulang database: %w", err)
+	}
+	reopened.Exec("PRAGMA journal_mode=WAL;")
+	reopened.Exec("PRAGMA synchronous=NORMAL;")
+	reopened.Exec("PRAGMA foreign_keys=ON;")
+	sqlDB, err := reopened.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	s.db.ConnPool = sqlDB
+	s.db.Statement.ConnPool = sqlDB
+	return nil
+}
+
+// applyDumpRestore menjalankan pg_restore/psql/mysql. Sebelumnya isi database
+// disimpan sebagai arsip portabel di folder staging; bila restore atau
+// pemeriksaannya gagal, arsip itu dipulihkan. keep true berarti folder
+// staging harus dipertahankan karena arsip pengaman masih dibutuhkan.
+func (s *backupService) applyDumpRestore(ctx context.Context, stagedPath string, preview *dto.RestorePreview) (keep bool, err error) {
+	safetyPath := filepath.Join(filepath.Dir(stagedPath), restoreSafetyBackup)
+	if err := s.writePortableBackup(ctx, safetyPath); err != nil {
+		return false, fmt.Errorf("gagal membuat cadangan pengaman sebelum restore: %w", err)
+	}
+
+	switch {
+	case preview.Format == BackupMethodPgDump:
+		err = s.runPgRestore(ctx, stagedPath)
+	case s.cfg.DBDialect == "postgres":
+		err = s.runPsql(ctx, stagedPath)
+	default:
+		err = s.runMySQLRestore(ctx, stagedPath)
+	}
+	if err == nil {
+		err = s.verifyRestoredDatabase(ctx, preview)
+	}
+	if err == nil {
+		return false, nil
+	}
+
+	log.Printf("ERROR: restore dump gagal, memulihkan cadangan pengaman: %v", err)
+	if _, rollbackErr := s.restorePortableBackup(ctx, safetyPath); rollbackErr != nil {
+		return true, fmt.Errorf("restore gagal (%v) dan cadangan pengaman gagal dipulihkan: %v; arsip pengaman ada di %s", err, rollbackErr, safetyPath)
+	}
+	return false, fmt.Errorf("%w: %v", ErrRestoreRolledBack, err)
+}
+
+func sqliteIntegrityCheck(db *gorm.DB) string {
+	rows, err := db.Raw("PRAGMA integrity_check").Rows()
+	if err != nil {
+		return err.Error()
+	}
+	defer rows.Close()
+	var results []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err.Error()
+		}
+		results = append(results, line)
+	}
+	if len(results) == 0 {
+		return "tidak ada hasil"
+	}
+	return strings.Join(results, "; ")
+}
+
+func writeUploadedFile(path string, r io.Reader) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteRestoreService memakai DSN yang sama dengan koneksi uji agar
+// restore SQLite bisa menukar file dan membuka ulang pool-nya.
+func newSQLiteRestoreService(t *testing.T) (*backupService, *gorm.DB, string) {
+	dsn := filepath.Join(t.TempDir(), "simdokpol.db") + "?_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(append(portableBackupModels(), &models.BackupRun{})...))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	service := newTestBackupService(t, db, "sqlite")
+	service.cfg.DBDSN = dsn
+	return service, db, service.getCleanDBPath()
+}
+
+func validateRestoreFile(t *testing.T, service *backupService, path string) (*dto.RestorePreview, error) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	return service.ValidateRestore(context.Background(), file, nil)
+}
+
+func findTablePreview(t *testing.T, preview *dto.RestorePreview, name string) dto.RestoreTablePreview {
+	for _, table := range preview.Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	t.Fatalf("tabel %s tidak ada di ringkasan", name)
+	return dto.RestoreTablePreview{}
+}
+
+func TestBackupService_StagedSQLiteRestore(t *testing.T) {
+	service, db, dbPath := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodSQLite, 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+
+	preview, err := validateRestoreFile(t, service, backupPath)
+	require.NoError(t, err)
+	assert.True(t, preview.Compatible, preview.Errors)
+	assert.Equal(t, "ok", preview.IntegrityCheck)
+	assert.Equal(t, models.SchemaVersion, preview.SchemaVersion, "versi skema dicatat di user_version")
+	positions := findTablePreview(t, preview, "job_positions")
+	assert.Equal(t, int64(1), positions.BackupRows)
+	assert.Equal(t, int64(2), positions.CurrentRows)
+	assert.Equal(t, int64(2), findTablePreview(t, preview, "users").BackupRows, "baris soft delete ikut dihitung")
+
+	// Validasi tidak menyentuh database aktif
+	var count int64
+	require.NoError(t, db.Model(&models.JobPosition{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, service.ConfirmRestore(context.Background(), preview.Token, 1))
+
+	// *gorm.DB yang sama kini menunjuk ke file hasil restore
+	assertBackupDataRestored(t, db)
+	backups, _ := filepath.Glob(dbPath + ".bak.*")
+	assert.Len(t, backups, 1)
+	assert.NoDirExists(t, filepath.Join(restoreStagingRoot(), preview.Token))
+
+	assert.ErrorIs(t, service.ConfirmRestore(context.Background(), preview.Token, 1), ErrRestoreStagingNotFound)
+}
+
+func TestBackupService_StagedSQLiteRestoreRollsBack(t *testing.T) {
+	service, db, dbPath := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodSQLite, 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+
+	preview, err := validateRestoreFile(t, service, backupPath)
+	require.NoError(t, err)
+
+	// Ringkasan diubah agar pemeriksaan setelah restore gagal
+	previewPath := filepath.Join(restoreStagingRoot(), preview.Token, restorePreviewFile)
+	for i := range preview.Tables {
+		if preview.Tables[i].Name == "users" {
+			preview.Tables[i].BackupRows = 99
+		}
+	}
+	content, err := json.Marshal(preview)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(previewPath, content, 0600))
+
+	err = service.ConfirmRestore(context.Background(), preview.Token, 1)
+	assert.ErrorIs(t, err, ErrRestoreRolledBack)
+
+	// Database lama kembali terpasang dan koneksinya tetap bisa dipakai
+	var count int64
+	require.NoError(t, db.Model(&models.JobPosition{}).Where("nama = ?", "BARU").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "SETELAH ROLLBACK"}).Error)
+	failed, _ := filepath.Glob(dbPath + ".gagal.*")
+	assert.Len(t, failed, 1)
+}
+
+func TestBackupService_ValidateRestoreRejectsForeignSQLite(t *testing.T) {
+	service, db, _ := newSQLiteRestoreService(t)
+	seedBackupData(t, db)
+
+	foreignPath := filepath.Join(t.TempDir(), "lain.db")
+	foreign, err := gorm.Open(sqlite.Open(foreignPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, foreign.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, nama TEXT)").Error)
+	require.NoError(t, foreign.Exec("PRAGMA user_version = 999").Error)
+	sqlDB, _ := foreign.DB()
+	sqlDB.Close()
+
+	preview, err := validateRestoreFile(t, service, foreignPath)
+	require.NoError(t, err)
+	assert.False(t, preview.Compatible)
+	assert.Equal(t, 999, preview.SchemaVersion)
+	assert.Contains(t, preview.Errors[0], "skema versi 999")
+	assert.Contains(t, preview.Errors, "Tabel residents tidak ada: file ini bukan backup database SIMDOKPOL.")
+	assert.ErrorIs(t, service.ConfirmRestore(context.Background(), preview.Token, 1), ErrBackupValidationFailed)
+
+	file, err := os.Open(foreignPath)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.ErrorIs(t, service.RestoreBackup(context.Background(), file, nil, 1), ErrBackupValidationFailed)
+
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Unscoped().Count(&count).Error)
+	assert.Equal(t, int64(2), count, "database tidak boleh tersentuh")
+}
+
+func TestBackupService_ValidatePortableAndDiscard(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	service := newTestBackupService(t, db, "sqlite")
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+
+	preview, err := validateRestoreFile(t, service, backupPath)
+	require.NoError(t, err)
+	assert.True(t, preview.Compatible)
+	assert.Equal(t, BackupMethodPortable, preview.Format)
+	assert.Equal(t, "sqlite", preview.SourceDialect)
+	assert.Equal(t, int64(1), findTablePreview(t, preview, "lost_items").BackupRows)
+	assert.Equal(t, int64(1), findTablePreview(t, preview, "lost_items").CurrentRows)
+
+	require.NoError(t, service.DiscardRestore(preview.Token))
+	assert.ErrorIs(t, service.ConfirmRestore(context.Background(), preview.Token, 1), ErrRestoreStagingNotFound)
+	assert.ErrorIs(t, service.DiscardRestore("../../etc"), ErrRestoreStagingNotFound)
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"sync"
+	"time"
+)
+
+// Jadwal dicek tiap menit; slot yang terlewat (aplikasi mati saat jadwal)
+// dijalankan begitu aplikasi hidup lagi.
+const backupSchedulerTick = time.Minute
+
+// previousBackupSlot mengembalikan waktu jadwal terakhir yang <= now.
+func previousBackupSlot(now time.Time, schedule dto.BackupScheduleConfig, loc *time.Location) time.Time {
+	clock, err := time.Parse("15:04", schedule.Time)
+	if err != nil {
+		clock, _ = time.Parse("15:04", "02:00")
+	}
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+
+	if schedule.Frequency == "weekly" {
+		slot = slot.AddDate(0, 0, -((int(slot.Weekday()) - schedule.Weekday + 7) % 7))
+		if slot.After(local) {
+			slot = slot.AddDate(0, 0, -7)
+		}
+		return slot
+	}
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return slot
+}
+
+func nextBackupSlot(now time.Time, schedule dto.BackupScheduleConfig, loc *time.Location) time.Time {
+	previous := previousBackupSlot(now, schedule, loc)
+	if schedule.Frequency == "weekly" {
+		return previous.AddDate(0, 0, 7)
+	}
+	return previous.AddDate(0, 0, 1)
+}
+
+func (s *backupService) location() *time.Location {
+	if loc, err := s.configService.GetLocation(); err == nil && loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+func (s *backupService) RunDueBackup(ctx context.Context, now time.Time, actorID uint) (*models.BackupRun, error) {
+	if s.runRepo == nil {
+		return nil, nil
+	}
+	appConfig, err := s.configService.GetConfig()
+	if err != nil || !appConfig.BackupSchedule.Enabled {
+		return nil, err
+	}
+	schedule := appConfig.BackupSchedule
+	loc := s.location()
+
+	last, err := s.runRepo.FindLatest(models.BackupKindScheduled)
+	if err != nil {
+		return nil, err
+	}
+	// Slot yang sudah dicoba (berhasil atau gagal) tidak diulang sampai slot berikutnya
+	if last != nil && !last.StartedAt.Before(previousBackupSlot(now, schedule, loc)) {
+		return nil, nil
+	}
+
+	run, err := s.runBackup(ctx, models.BackupKindScheduled, schedule.Method, actorID)
+	if err != nil {
+		return run, err
+	}
+	// Gagal kirim off-site tidak menggagalkan backup; tercatat di
+	// run.OffsiteStatus dan dilaporkan penjadwal
+	if err := s.replicateBackup(ctx, run); err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+	if err := s.pruneBackups(ctx, schedule, loc, actorID); err != nil {
+		log.Printf("WARN: retensi backup gagal: %v", err)
+	}
+	if err := s.pruneOffsite(ctx, appConfig.BackupOffsite, schedule, loc, actorID); err != nil {
+		log.Printf("WARN: retensi backup off-site gagal: %v", err)
+	}
+	return run, nil
+}
+
+// selectBackupsToPrune memilih file backup yang tidak lagi disimpan:
+// per jenis (harian/mingguan/bulanan) disimpan backup terbaru dari N periode
+// terakhir yang punya backup. Semua nilai 0 berarti retensi nonaktif.
+// runs harus terurut dari yang terbaru.
+func selectBackupsToPrune(runs []models.BackupRun, schedule dto.BackupScheduleConfig, loc *time.Location) []models.BackupRun {
+	if schedule.KeepDaily == 0 && schedule.KeepWeekly == 0 && schedule.KeepMonthly == 0 {
+		return nil
+	}
+
+	keep := make(map[uint]bool)
+	tiers := []struct {
+		limit  int
+		period func(t time.Time) string
+	}{
+		{schedule.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{schedule.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{schedule.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, tier := range tiers {
+		seen := make(map[string]bool)
+		for _, run := range runs {
+			if len(seen) >= tier.limit {
+				break
+			}
+			period := tier.period(run.StartedAt.In(loc))
+			if !seen[period] {
+				seen[period] = true
+				keep[run.ID] = true
+			}
+		}
+	}
+
+	var prune []models.BackupRun
+	for _, run := range runs {
+		if !keep[run.ID] {
+			prune = append(prune, run)
+		}
+	}
+	return prune
+}
+
+// pruneBackups menghapus file backup di luar kebijakan retensi. Hanya file
+// yang tercatat di riwayat yang disentuh; file lain di folder backup dibiarkan.
+func (s *backupService) pruneBackups(ctx context.Context, schedule dto.BackupScheduleConfig, loc *time.Location, actorID uint) error {
+	runs, err := s.runRepo.FindRetained()
+	if err != nil {
+		return err
+	}
+	prune := selectBackupsToPrune(runs, schedule, loc)
+	if len(prune) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(prune))
+	for _, run := range prune {
+		if run.FilePath != "" {
+			if err := os.Remove(run.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("WARN: gagal menghapus backup lama %s: %v", run.FileName, err)
+				continue
+			}
+		}
+		ids = append(ids, run.ID)
+	}
+	if err := s.runRepo.MarkPruned(ids, time.Now()); err != nil {
+		return err
+	}
+
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditBackupPruned,
+		Detail:     fmt.Sprintf("Retensi backup: %d file lama dihapus (simpan %d harian, %d mingguan, %d bulanan)", len(ids), schedule.KeepDaily, schedule.KeepWeekly, schedule.KeepMonthly),
+		EntityType: models.AuditEntityBackup,
+	})
+	return nil
+}
+
+func (s *backupService) ListRuns(limit int) ([]models.BackupRun, error) {
+	if s.runRepo == nil {
+		return []models.BackupRun{}, nil
+	}
+	return s.runRepo.FindRecent(limit)
+}
+
+func (s *backupService) Status() (*dto.BackupStatus, error) {
+	appConfig, err := s.configService.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	status := &dto.BackupStatus{Schedule: appConfig.BackupSchedule}
+	if appConfig.BackupSchedule.Enabled {
+		next := nextBackupSlot(time.Now(), appConfig.BackupSchedule, s.location())
+		status.NextRunAt = &next
+	}
+	if s.runRepo == nil {
+		return status, nil
+	}
+
+	if status.LastRun, err = s.runRepo.FindLatest(""); err != nil {
+		return nil, err
+	}
+	if status.LastScheduled, err = s.runRepo.FindLatest(models.BackupKindScheduled); err != nil {
+		return nil, err
+	}
+	if status.LastSuccess, err = s.runRepo.FindLatestByStatus(models.BackupStatusSuccess); err != nil {
+		return nil, err
+	}
+	status.Failing = status.LastScheduled != nil && status.LastScheduled.Status == models.BackupStatusFailed
+	status.OffsiteFailing = status.LastSuccess != nil && status.LastSuccess.OffsiteStatus == models.BackupOffsiteFailed
+	return status, nil
+}
+
+// StartBackupScheduler menjalankan backup terjadwal di latar belakang atas
+// nama akun sistem actorID. notify (boleh nil) dipanggil saat backup
+// terjadwal atau pengiriman off-site-nya gagal, mis. untuk notifikasi
+// desktop; kegagalan juga tampil di dashboard lewat Status.
+func StartBackupScheduler(backupService BackupService, actorID uint, notify func(title, message string)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(backupSchedulerTick)
+		defer ticker.Stop()
+		for {
+			var run *models.BackupRun
+			leave, err := Maintenance.Hold(ctx)
+			if err == nil {
+				run, err = backupService.RunDueBackup(ctx, time.Now(), actorID)
+				leave()
+			}
+			if err != nil {
+				log.Printf("ERROR: backup terjadwal gagal: %v", err)
+				if run != nil && notify != nil {
+					notify("Backup SIMDOKPOL Gagal", err.Error())
+				}
+			} else if run != nil {
+				log.Printf("INFO: backup terjadwal selesai: %s (%d byte)", run.FileName, run.SizeBytes)
+				if run.OffsiteStatus == models.BackupOffsiteFailed && notify != nil {
+					notify("Backup SIMDOKPOL Tidak Terkirim", run.OffsiteError)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(cancel) }
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousBackupSlot(t *testing.T) {
+	// Rabu, 12 Maret 2025 10:30 UTC
+	now := time.Date(2025, 3, 12, 10, 30, 0, 0, time.UTC)
+
+	daily := dto.BackupScheduleConfig{Frequency: "daily", Time: "02:00"}
+	assert.Equal(t, time.Date(2025, 3, 12, 2, 0, 0, 0, time.UTC), previousBackupSlot(now, daily, time.UTC))
+	daily.Time = "23:15"
+	assert.Equal(t, time.Date(2025, 3, 11, 23, 15, 0, 0, time.UTC), previousBackupSlot(now, daily, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 12, 23, 15, 0, 0, time.UTC), nextBackupSlot(now, daily, time.UTC))
+
+	weekly := dto.BackupScheduleConfig{Frequency: "weekly", Time: "02:00", Weekday: int(time.Monday)}
+	assert.Equal(t, time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC), previousBackupSlot(now, weekly, time.UTC))
+	weekly.Weekday = int(time.Wednesday)
+	weekly.Time = "11:00"
+	assert.Equal(t, time.Date(2025, 3, 5, 11, 0, 0, 0, time.UTC), previousBackupSlot(now, weekly, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 12, 11, 0, 0, 0, time.UTC), nextBackupSlot(now, weekly, time.UTC))
+
+	// Jam dibaca di zona waktu kantor, bukan UTC
+	wib := time.FixedZone("WIB", 7*3600)
+	daily.Time = "02:00"
+	assert.Equal(t, time.Date(2025, 3, 12, 2, 0, 0, 0, wib), previousBackupSlot(now, daily, wib))
+}
+
+func TestSelectBackupsToPrune(t *testing.T) {
+	// Satu backup per hari selama 100 hari, terbaru dulu
+	newest := time.Date(2025, 6, 30, 2, 0, 0, 0, time.UTC)
+	var runs []models.BackupRun
+	for i := 0; i < 100; i++ {
+		runs = append(runs, models.BackupRun{ID: uint(100 - i), StartedAt: newest.AddDate(0, 0, -i)})
+	}
+
+	t.Run("Nonaktif", func(t *testing.T) {
+		assert.Empty(t, selectBackupsToPrune(runs, dto.BackupScheduleConfig{}, time.UTC))
+	})
+
+	t.Run("Harian Mingguan Bulanan", func(t *testing.T) {
+		prune := selectBackupsToPrune(runs, dto.BackupScheduleConfig{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 3}, time.UTC)
+		pruned := make(map[uint]bool)
+		for _, run := range prune {
+			pruned[run.ID] = true
+		}
+		kept := len(runs) - len(prune)
+
+		for id := uint(94); id <= 100; id++ {
+			assert.False(t, pruned[id], "7 hari terakhir disimpan")
+		}
+		// Terbaru per bulan: 30 Juni, 31 Mei, 30 April
+		for _, day := range []time.Time{newest, time.Date(2025, 5, 31, 2, 0, 0, 0, time.UTC), time.Date(2025, 4, 30, 2, 0, 0, 0, time.UTC)} {
+			id := uint(100 - int(newest.Sub(day).Hours()/24))
+			assert.False(t, pruned[id], day.Format("2006-01-02"))
+		}
+		// 7 harian + 2 mingguan lama (2 lainnya sudah tercakup harian) + 2 bulanan lama
+		assert.Equal(t, 11, kept)
+		assert.True(t, pruned[1], "backup paling lama dihapus")
+	})
+}
+
+func TestBackupService_RunDueBackup(t *testing.T) {
+	db := openBackupTestDB(t, "jadwal.db")
+	seedBackupData(t, db)
+	appConfig := &dto.AppConfig{
+		BackupPath:     t.TempDir(),
+		BackupSchedule: dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "00:00", KeepDaily: 1},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	// Backup lama tercatat di riwayat; retensi 1 harian harus menghapusnya
+	oldPath := filepath.Join(appConfig.BackupPath, "backup-lama.db")
+	require.NoError(t, os.WriteFile(oldPath, []byte("lama"), 0600))
+	old := models.BackupRun{Kind: models.BackupKindManual, Method: BackupMethodSQLite, Status: models.BackupStatusSuccess,
+		FileName: "backup-lama.db", FilePath: oldPath, StartedAt: time.Now().AddDate(0, 0, -3), FinishedAt: time.Now().AddDate(0, 0, -3)}
+	require.NoError(t, db.Create(&old).Error)
+
+	run, err := service.RunDueBackup(context.Background(), time.Now(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, models.BackupKindScheduled, run.Kind)
+	assert.Equal(t, models.BackupStatusSuccess, run.Status)
+	assert.Len(t, run.SHA256, 64)
+	assert.Greater(t, run.SizeBytes, int64(0))
+	assert.FileExists(t, run.FilePath)
+
+	assert.NoFileExists(t, oldPath)
+	require.NoError(t, db.First(&old, old.ID).Error)
+	assert.NotNil(t, old.PrunedAt)
+
+	// Slot yang sama tidak dijalankan dua kali
+	again, err := service.RunDueBackup(context.Background(), time.Now(), 1)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Failing)
+	require.NotNil(t, status.NextRunAt)
+	assert.Equal(t, run.ID, status.LastSuccess.ID)
+}
+
+func TestBackupService_RunDueBackupFailure(t *testing.T) {
+	db := openBackupTestDB(t, "jadwal.db")
+	appConfig := &dto.AppConfig{
+		BackupPath: t.TempDir(),
+		// pg_dump tidak berlaku untuk SQLite: backup gagal tapi tetap tercatat
+		BackupSchedule: dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "00:00", Method: BackupMethodPgDump},
+	}
+	service := newScheduledBackupService(t, db, "sqlite", appConfig)
+
+	run, err := service.RunDueBackup(context.Background(), time.Now(), 1)
+	assert.ErrorIs(t, err, ErrBackupMethodUnavailable)
+	require.NotNil(t, run)
+	assert.Equal(t, models.BackupStatusFailed, run.Status)
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.True(t, status.Failing)
+	require.NotNil(t, status.LastScheduled)
+	assert.NotEmpty(t, status.LastScheduled.Error)
+
+	runs, err := service.ListRuns(10)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
+
+// Backup terjadwal tidak punya pengguna login; log audit-nya harus atas nama
+// akun sistem agar lolos foreign key audit_logs.user_id -> users.id.
+func TestBackupService_RunDueBackupAuditsAsSystemUser(t *testing.T) {
+	AuditSigningKey = []byte("kunci-uji-audit")
+	t.Cleanup(func() { AuditSigningKey = nil })
+
+	ctx := context.Background()
+	db := openEmptySQLiteDB(t)
+	_, err := NewSchemaMigrationService(db, "sqlite").Up(ctx)
+	require.NoError(t, err)
+	require.Error(t, db.Create(&models.AuditLog{UserID: 999, Aksi: "UJI", Timestamp: time.Now()}).Error, "foreign key harus aktif")
+
+	system, err := utils.EnsureSystemUser(db)
+	require.NoError(t, err)
+	auditService := NewAuditLogService(repositories.NewAuditLogRepository(db))
+
+	offsiteDir := t.TempDir()
+	appConfig := &dto.AppConfig{
+		BackupPath:     t.TempDir(),
+		BackupSchedule: dto.BackupScheduleConfig{Enabled: true, Frequency: "daily", Time: "00:00", KeepDaily: 1},
+		BackupOffsite:  dto.BackupOffsiteConfig{Type: BackupOffsiteFolder, FolderPath: offsiteDir},
+	}
+	mockConfigService := new(mocks.ConfigService)
+	mockConfigService.On("GetConfig").Return(appConfig, nil)
+	mockConfigService.On("GetLocation").Return(time.UTC, nil)
+	cfg := &config.Config{AppConfig: &dto.AppConfig{DBDialect: "sqlite"}}
+	service := NewBackupService(db, cfg, mockConfigService, auditService, repositories.NewBackupRunRepository(db))
+
+	// Riwayat lama (juga ada salinan off-site) agar retensi lokal dan off-site
+	// ikut jalan dan mencatat log audit
+	require.NoError(t, os.WriteFile(filepath.Join(offsiteDir, "backup-lama.db"), []byte("lama"), 0600))
+	require.NoError(t, db.Create(&models.BackupRun{Kind: models.BackupKindScheduled, Method: BackupMethodSQLite, Status: models.BackupStatusSuccess,
+		FileName: "backup-lama.db", StartedAt: time.Now().AddDate(0, 0, -3), FinishedAt: time.Now().AddDate(0, 0, -3),
+		OffsiteStatus: models.BackupOffsiteUploaded, OffsiteTarget: offsiteDir}).Error)
+
+	run, err := service.RunDueBackup(ctx, time.Now(), system.ID)
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, system.ID, run.ActorID)
+	require.NoError(t, auditService.Close(ctx))
+
+	stats := auditService.WriterStats()
+	assert.Zero(t, stats.Failed+stats.Spilled+stats.Dropped, "tidak ada entri yang ditolak database")
+	var actions []string
+	require.NoError(t, db.Model(&models.AuditLog{}).Where("user_id = ?", system.ID).Order("id").Pluck("aksi", &actions).Error)
+	assert.Equal(t, []string{models.AuditBackupCreated, models.AuditBackupReplicated, models.AuditBackupPruned, models.AuditBackupPruned}, actions)
+	assert.NoFileExists(t, filepath.Join(offsiteDir, "backup-lama.db"))
+}
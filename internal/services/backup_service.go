@@ -1,22 +1,71 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
 	"simdokpol/internal/utils"
 	"strings"
+	"sync"
 	"time"
 
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Metode backup. CreateBackup dengan method kosong memakai metode bawaan
+// dialek (lihat Capabilities).
+const (
+	BackupMethodSQLite    = "sqlite"
+	BackupMethodPgDump    = "pg_dump"
+	BackupMethodMySQLDump = "mysqldump"
+	BackupMethodPortable  = "portable"
 )
 
 type BackupService interface {
-	CreateBackup(actorID uint) (backupPath string, err error)
-	RestoreBackup(uploadedFile io.Reader, actorID uint) error
+	CreateBackup(ctx context.Context, method string, actorID uint) (backupPath string, err error)
+	// RestoreBackup menerima backup biasa maupun terenkripsi (.enc). key boleh
+	// nil; backup terenkripsi lalu dibuka dengan kunci dari pengaturan.
+	RestoreBackup(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey, actorID uint) error
+	// ValidateRestore menaruh file di staging dan memeriksanya tanpa menyentuh
+	// database aktif. Preview.Token lalu dipakai ConfirmRestore atau DiscardRestore.
+	ValidateRestore(ctx context.Context, uploadedFile io.Reader, key *dto.BackupKey) (*dto.RestorePreview, error)
+	ConfirmRestore(ctx context.Context, token string, actorID uint) error
+	DiscardRestore(token string) error
+	Capabilities() dto.BackupCapabilities
+	// ListBackups, DownloadBackup, VerifyBackup, DeleteBackup dan
+	// ValidateStoredRestore bekerja pada file di folder backup berdasarkan
+	// nama file (tanpa path).
+	ListBackups() ([]dto.BackupFile, error)
+	DownloadBackup(ctx context.Context, name string, actorID uint) (path string, err error)
+	VerifyBackup(ctx context.Context, name string, key *dto.BackupKey) (*dto.BackupVerification, error)
+	DeleteBackup(ctx context.Context, name string, actorID uint) error
+	ValidateStoredRestore(ctx context.Context, name string, key *dto.BackupKey) (*dto.RestorePreview, error)
+	// TestOffsiteDestination mencoba tujuan off-site dari key backup_offsite_*
+	// di form pengaturan dan mengembalikan label tujuannya.
+	TestOffsiteDestination(ctx context.Context, settings map[string]string) (target string, err error)
+	// RunDueBackup menjalankan backup terjadwal bila slot jadwal terakhir belum
+	// dikerjakan; nil tanpa error berarti belum waktunya. actorID akun sistem
+	// (utils.EnsureSystemUser) yang tercatat di riwayat dan log audit.
+	RunDueBackup(ctx context.Context, now time.Time, actorID uint) (*models.BackupRun, error)
+	ListRuns(limit int) ([]models.BackupRun, error)
+	Status() (*dto.BackupStatus, error)
+	// Close menunggu pengiriman off-site yang masih berjalan, dipanggil saat
+	// shutdown dan sebelum perintah CLI selesai
+	Close(ctx context.Context) error
 }
 
 type backupService struct {
@@ -24,14 +73,18 @@ type backupService struct {
 	cfg           *config.Config
 	configService ConfigService
 	auditService  AuditLogService
+	runRepo       repositories.BackupRunRepository
+	restoreMu     sync.Mutex
+	replications  sync.WaitGroup
 }
 
-func NewBackupService(db *gorm.DB, cfg *config.Config, configService ConfigService, auditService AuditLogService) BackupService {
+func NewBackupService(db *gorm.DB, cfg *config.Config, configService ConfigService, auditService AuditLogService, runRepo repositories.BackupRunRepository) BackupService {
 	return &backupService{
 		db:            db,
 		cfg:           cfg,
 		configService: configService,
 		auditService:  auditService,
+		runRepo:       runRepo,
 	}
 }
 
@@ -40,7 +93,7 @@ func (s *backupService) getCleanDBPath() string {
 	return dsnParts[0]
 }
 
-func (s *backupService) CreateBackup(actorID uint) (string, error) {
+func (s *backupService) backupDir() (string, error) {
 	appConfig, err := s.configService.GetConfig()
 	if err != nil {
 		return "", fmt.Errorf("gagal konfigurasi: %w", err)
@@ -50,62 +103,313 @@ func (s *backupService) CreateBackup(actorID uint) (string, error) {
 	if backupDir == "" {
 		backupDir = filepath.Join(utils.GetAppDataDir(), "backups")
 	}
-	os.MkdirAll(backupDir, 0755)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("gagal membuat folder backup: %w", err)
+	}
+	return backupDir, nil
+}
+
+func (s *backupService) CreateBackup(ctx context.Context, method string, actorID uint) (string, error) {
+	run, err := s.runBackup(ctx, models.BackupKindManual, method, actorID)
+	if err != nil {
+		return "", err
+	}
+	// Pengiriman off-site (dengan percobaan ulang) tidak menahan respons;
+	// hasilnya tercatat di riwayat backup
+	s.replications.Add(1)
+	go func() {
+		defer s.replications.Done()
+		leave, _ := Maintenance.Hold(context.Background())
+		defer leave()
+		if err := s.replicateBackup(context.Background(), run); err != nil {
+			log.Printf("ERROR: %v", err)
+		}
+	}()
+	return run.FilePath, nil
+}
+
+func (s *backupService) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.replications.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("pengiriman backup off-site belum selesai: %w", ctx.Err())
+	}
+}
 
+// runBackup membuat backup lalu mencatat hasilnya (berhasil atau gagal) di
+// riwayat beserta ukuran, durasi dan checksum file.
+func (s *backupService) runBackup(ctx context.Context, kind, method string, actorID uint) (*models.BackupRun, error) {
+	capabilities := s.Capabilities()
+	if method == "" {
+		method = capabilities.DefaultMethod
+	}
+	run := &models.BackupRun{Kind: kind, Method: method, ActorID: actorID, StartedAt: time.Now()}
+
+	path, err := s.createBackupFile(ctx, capabilities, method)
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	if err == nil {
+		run.FilePath = path
+		run.FileName = filepath.Base(path)
+		run.SizeBytes, run.SHA256, err = fileSizeAndSHA256(path)
+	}
+	if err != nil {
+		run.Status = models.BackupStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = models.BackupStatusSuccess
+	}
+	backupRunsTotal.inc(kind, method, metricResult(err))
+
+	if s.runRepo != nil {
+		if recordErr := s.runRepo.Create(run); recordErr != nil {
+			log.Printf("WARN: gagal mencatat riwayat backup: %v", recordErr)
+		}
+	}
+	if err != nil {
+		return run, err
+	}
+
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditBackupCreated,
+		Detail:     fmt.Sprintf("Backup %s (%s): %s", kind, method, run.FileName),
+		EntityType: models.AuditEntityBackup,
+		EntityID:   run.FileName,
+	})
+	return run, nil
+}
+
+func (s *backupService) createBackupFile(ctx context.Context, capabilities dto.BackupCapabilities, method string) (string, error) {
+	info, ok := findBackupMethod(capabilities, method)
+	if !ok || !info.Available {
+		return "", fmt.Errorf("%w: %s", ErrBackupMethodUnavailable, method)
+	}
+
+	backupDir, err := s.backupDir()
+	if err != nil {
+		return "", err
+	}
+	appConfig, err := s.configService.GetConfig()
+	if err != nil {
+		return "", fmt.Errorf("gagal konfigurasi: %w", err)
+	}
+	kdf, material, err := encryptionKeyMaterial(appConfig.BackupEncryption)
+	if err != nil {
+		return "", err
+	}
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	destinationPath := filepath.Join(backupDir, fmt.Sprintf("backup-%s.db", timestamp))
+	finalPath := filepath.Join(backupDir, fmt.Sprintf("backup-%s%s", timestamp, info.Extension))
+
+	// Saat enkripsi aktif, file polos dibuat di folder temp lokal, bukan di
+	// folder backup (yang sering berupa flashdisk atau folder berbagi)
+	destinationPath := finalPath
+	if !material.empty() {
+		plainDir, err := os.MkdirTemp("", "simdokpol-backup-*")
+		if err != nil {
+			return "", fmt.Errorf("gagal membuat folder temp: %w", err)
+		}
+		defer os.RemoveAll(plainDir)
+		destinationPath = filepath.Join(plainDir, filepath.Base(finalPath))
+	}
 
-	if s.cfg.DBDialect == "sqlite" {
+	switch method {
+	case BackupMethodSQLite:
 		os.Remove(destinationPath)
-		if err := s.db.Exec("VACUUM INTO ?", destinationPath).Error; err != nil {
-			return "", fmt.Errorf("gagal SQLite Hot Backup: %w", err)
+		err = s.db.Exec("VACUUM INTO ?", destinationPath).Error
+		if err != nil {
+			err = fmt.Errorf("gagal SQLite Hot Backup: %w", err)
+		} else {
+			err = stampSQLiteSchemaVersion(destinationPath)
 		}
-	} else {
-		// Fallback copy manual (hanya jika connection closed, risky)
-		return "", fmt.Errorf("backup hanya support SQLite")
+	case BackupMethodPgDump:
+		err = s.runPgDump(ctx, info.Tool, destinationPath)
+	case BackupMethodMySQLDump:
+		err = s.runMySQLDump(ctx, info.Tool, destinationPath)
+	case BackupMethodPortable:
+		err = s.writePortableBackup(ctx, destinationPath)
+	}
+	if err != nil {
+		os.Remove(destinationPath)
+		return "", err
+	}
+	if material.empty() {
+		return destinationPath, nil
+	}
+
+	finalPath += encryptedBackupExtension
+	header := newEncryptedBackupHeader(kdf, s.cfg.DBDialect, method, models.SchemaVersion)
+	if err := encryptBackupFile(destinationPath, finalPath, header, material); err != nil {
+		return "", fmt.Errorf("gagal mengenkripsi backup: %w", err)
+	}
+	return finalPath, nil
+}
+
+// Capabilities melaporkan metode backup yang bisa dipakai untuk dialek aktif.
+// Alat eksternal dicari di PATH setiap kali dipanggil, jadi memasang pg_dump
+// tidak perlu restart aplikasi.
+func (s *backupService) Capabilities() dto.BackupCapabilities {
+	capabilities := dto.BackupCapabilities{Dialect: s.cfg.DBDialect}
+	portable := dto.BackupMethod{
+		Name: BackupMethodPortable, Label: "Arsip portabel (JSON per tabel)", Extension: portableBackupExtension,
+		Available: true, CanRestore: true,
+	}
+
+	switch s.cfg.DBDialect {
+	case "sqlite":
+		capabilities.Methods = []dto.BackupMethod{
+			{Name: BackupMethodSQLite, Label: "File database SQLite", Extension: ".db", Available: true, CanRestore: true},
+			portable,
+		}
+		capabilities.RestoreExtensions = []string{".db", portableBackupExtension}
+	case "postgres":
+		capabilities.Methods = []dto.BackupMethod{
+			detectDumpTool(BackupMethodPgDump, "Dump PostgreSQL (pg_dump)", ".dump", "pg_dump", "pg_restore"),
+			portable,
+		}
+		capabilities.RestoreExtensions = []string{".dump", ".sql", portableBackupExtension}
+	case "mysql":
+		capabilities.Methods = []dto.BackupMethod{
+			detectDumpTool(BackupMethodMySQLDump, "Dump MySQL (mysqldump)", ".sql", "mysqldump", "mysql"),
+			portable,
+		}
+		capabilities.RestoreExtensions = []string{".sql", portableBackupExtension}
+	default:
+		capabilities.Methods = []dto.BackupMethod{portable}
+		capabilities.RestoreExtensions = []string{portableBackupExtension}
 	}
 
-	s.auditService.LogActivity(actorID, models.AuditBackupCreated, "Backup: "+filepath.Base(destinationPath))
-	return destinationPath, nil
+	capabilities.Encryption = BackupEncryptionNone
+	if appConfig, err := s.configService.GetConfig(); err == nil && appConfig != nil && appConfig.BackupEncryption.Mode != "" {
+		capabilities.Encryption = appConfig.BackupEncryption.Mode
+	}
+	capabilities.RestoreExtensions = append(capabilities.RestoreExtensions, encryptedBackupExtension)
+
+	for _, method := range capabilities.Methods {
+		if method.Available {
+			capabilities.DefaultMethod = method.Name
+			break
+		}
+	}
+	return capabilities
 }
 
-func (s *backupService) RestoreBackup(uploadedFile io.Reader, actorID uint) error {
-	if s.cfg.DBDialect != "sqlite" {
-		return fmt.Errorf("restore hanya support SQLite")
+// restoreKeyMaterial memakai kunci dari admin bila ada; selain itu passphrase
+// dan file kunci dari pengaturan (apa pun mode aktifnya, agar backup lama tetap
+// bisa dibuka setelah mode diganti).
+func (s *backupService) restoreKeyMaterial(key *dto.BackupKey) backupKeyMaterial {
+	if key != nil && (key.Passphrase != "" || len(key.KeyFile) > 0) {
+		return backupKeyMaterial{passphrase: key.Passphrase, keyFile: key.KeyFile}
+	}
+	var material backupKeyMaterial
+	if appConfig, err := s.configService.GetConfig(); err == nil && appConfig != nil {
+		material.passphrase = appConfig.BackupEncryption.Passphrase
+		if appConfig.BackupEncryption.KeyFile != "" {
+			material.keyFile, _ = readBackupKeyFile(appConfig.BackupEncryption.KeyFile)
+		}
 	}
+	return material
+}
 
-	targetPath := s.getCleanDBPath()
-	tempNewPath := targetPath + ".new"
-	
-	newFile, err := os.Create(tempNewPath)
+// stampSQLiteSchemaVersion mencatat versi skema di PRAGMA user_version file
+// backup agar restore bisa menolak backup dari aplikasi yang lebih baru.
+func stampSQLiteSchemaVersion(path string) error {
+	backupDB, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := backupDB.DB()
 	if err != nil {
-		return fmt.Errorf("gagal buat temp file: %w", err)
-	}
-	
-	_, copyErr := io.Copy(newFile, uploadedFile)
-	newFile.Close()
-	
-	if copyErr != nil {
-		os.Remove(tempNewPath)
-		return fmt.Errorf("gagal copy data: %w", copyErr)
-	}
-
-	backupPath := targetPath + ".bak." + time.Now().Format("20060102150405")
-	
-	// Rename file asli ke backup (Windows mungkin fail jika terkunci)
-	if err := os.Rename(targetPath, backupPath); err != nil {
-		os.Remove(tempNewPath)
-		if strings.Contains(err.Error(), "process cannot access") {
-			return fmt.Errorf("DB TERKUNCI: Tutup aplikasi dan rename file .db secara manual.")
+		return err
+	}
+	defer sqlDB.Close()
+	return backupDB.Exec(fmt.Sprintf("PRAGMA user_version = %d", models.SchemaVersion)).Error
+}
+
+func findBackupMethod(capabilities dto.BackupCapabilities, name string) (dto.BackupMethod, bool) {
+	for _, method := range capabilities.Methods {
+		if method.Name == name {
+			return method, true
 		}
-		return fmt.Errorf("gagal backup file lama: %w", err)
 	}
+	return dto.BackupMethod{}, false
+}
+
+// formatSQLText: skrip SQL biasa (mysqldump, atau pg_dump --format=plain)
+const formatSQLText = "sql"
 
-	if err := os.Rename(tempNewPath, targetPath); err != nil {
-		os.Rename(backupPath, targetPath) // Rollback
-		return fmt.Errorf("gagal aktifkan DB baru: %w", err)
+// formatEncrypted: wadah backup terenkripsi (lihat backup_crypto.go)
+const formatEncrypted = "encrypted"
+
+// detectBackupFormat membaca beberapa byte awal file untuk menentukan jenisnya.
+func detectBackupFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header, err := bufio.NewReader(file).Peek(16)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	switch {
+	case isEncryptedBackup(header):
+		return formatEncrypted, nil
+	case bytes.HasPrefix(header, []byte("SQLite format 3\x00")):
+		return BackupMethodSQLite, nil
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return BackupMethodPortable, nil
+	case bytes.HasPrefix(header, []byte("PGDMP")):
+		return BackupMethodPgDump, nil
+	case len(header) > 0 && isTextHeader(header):
+		return formatSQLText, nil
+	}
+	return "", ErrBackupFormatUnsupported
+}
+
+func isTextHeader(header []byte) bool {
+	for _, b := range header {
+		if b < 0x09 || (b > 0x0d && b < 0x20) {
+			return false
+		}
+	}
+	return true
+}
+
+func fileSizeAndSHA256(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
 	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
 
-	s.auditService.LogActivity(actorID, models.AuditRestoreFromFile, "Restore DB Sukses")
-	return nil
-}
\ No newline at end of file
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
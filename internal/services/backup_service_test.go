@@ -0,0 +1,253 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openBackupTestDB(t *testing.T, name string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)+"?_foreign_keys=on"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(append(portableBackupModels(), &models.BackupRun{})...))
+	return db
+}
+
+func newTestBackupService(t *testing.T, db *gorm.DB, dialect string) *backupService {
+	return newScheduledBackupService(t, db, dialect, &dto.AppConfig{BackupPath: t.TempDir()})
+}
+
+func newScheduledBackupService(t *testing.T, db *gorm.DB, dialect string, appConfig *dto.AppConfig) *backupService {
+	mockConfigService := new(mocks.ConfigService)
+	mockConfigService.On("GetConfig").Return(appConfig, nil)
+	mockConfigService.On("GetLocation").Return(time.UTC, nil)
+	mockAuditService := new(mocks.AuditLogService)
+	mockAuditService.On("LogEvent", mock.Anything, mock.Anything).Return()
+
+	cfg := &config.Config{AppConfig: &dto.AppConfig{DBDialect: dialect, DBHost: "db.local:5433", DBUser: "simdokpol", DBName: "simdokpol"}, DBPass: "rahasia"}
+	var runRepo repositories.BackupRunRepository
+	if db != nil {
+		runRepo = repositories.NewBackupRunRepository(db)
+	}
+	return NewBackupService(db, cfg, mockConfigService, mockAuditService, runRepo).(*backupService)
+}
+
+func seedBackupData(t *testing.T, db *gorm.DB) {
+	now := time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)
+	approver := uint(1)
+	require.NoError(t, db.Create(&models.Configuration{Key: "nama_kantor", Value: "POLSEK UJI"}).Error)
+	require.NoError(t, db.Create(&models.Role{Nama: "OPERATOR", Permissions: models.JSONStringArray{"document:view"}, IsSystem: true}).Error)
+	require.NoError(t, db.Create(&models.ItemTemplate{NamaBarang: "KTP", FieldsConfig: models.JSONFieldArray{{Label: "NIK", Type: "text", DataLabel: "NIK"}}}).Error)
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "KANIT"}).Error)
+	// IsActive false harus tetap false setelah restore (kolom default true)
+	require.NoError(t, db.Model(&models.JobPosition{}).Where("nama = ?", "KANIT").Update("is_active", false).Error)
+	require.NoError(t, db.Create(&models.User{ID: 1, NamaLengkap: "Admin", NRP: "111", KataSandi: "hash-sandi", Peran: models.RoleSuperAdmin}).Error)
+	require.NoError(t, db.Create(&models.User{ID: 2, NamaLengkap: "Mantan Operator", NRP: "222", KataSandi: "hash-2"}).Error)
+	require.NoError(t, db.Delete(&models.User{}, 2).Error)
+	require.NoError(t, db.Create(&models.License{Key: "LIC-1", Status: "ACTIVE", ActivatedAt: &now, ActivatedByID: &approver}).Error)
+	require.NoError(t, db.Create(&models.Resident{ID: 7, NIK: "3201010101010001", NamaLengkap: "BUDI", TempatLahir: "BOGOR", TanggalLahir: now, JenisKelamin: "Laki-laki", Agama: "Islam", Pekerjaan: "Swasta", Alamat: "Jl. Uji"}).Error)
+	require.NoError(t, db.Create(&models.LostDocument{
+		ID: 5, NomorSurat: "SKH/1/II/2025", TanggalLaporan: now, ResidentID: 7, PetugasPelaporID: 2, OperatorID: 1, PejabatPersetujuID: &approver,
+		LostItems: []models.LostItem{{NamaBarang: "KTP", Deskripsi: "NIK: 3201010101010001"}},
+	}).Error)
+	require.NoError(t, db.Create(&models.AuditLog{UserID: 1, Aksi: models.AuditBackupCreated, Detail: "uji", Timestamp: now, Hash: "h1"}).Error)
+}
+
+func TestBackupService_PortableRoundTrip(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	service := newTestBackupService(t, db, "sqlite")
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(backupPath, ".tar.gz"))
+
+	manifest, err := readPortableManifest(backupPath)
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", manifest.SourceDialect)
+	assert.Len(t, manifest.Tables, len(portableBackupModels()))
+
+	// Data berubah setelah backup; restore harus mengembalikan keadaan semula
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+	require.NoError(t, db.Exec("DELETE FROM lost_items").Error)
+
+	restoreFrom := func(t *testing.T, target *backupService) {
+		file, err := os.Open(backupPath)
+		require.NoError(t, err)
+		defer file.Close()
+		require.NoError(t, target.RestoreBackup(context.Background(), file, nil, 1))
+	}
+
+	t.Run("Ke Database Yang Sama", func(t *testing.T) {
+		restoreFrom(t, service)
+		assertBackupDataRestored(t, db)
+	})
+
+	t.Run("Ke Database Kosong", func(t *testing.T) {
+		fresh := openBackupTestDB(t, "tujuan.db")
+		restoreFrom(t, newTestBackupService(t, fresh, "sqlite"))
+		assertBackupDataRestored(t, fresh)
+
+		// Auto-increment tetap jalan setelah ID disisipkan manual
+		next := models.JobPosition{Nama: "KAPOLSEK"}
+		require.NoError(t, fresh.Create(&next).Error)
+		assert.Greater(t, next.ID, uint(1))
+	})
+}
+
+func assertBackupDataRestored(t *testing.T, db *gorm.DB) {
+	var positions []models.JobPosition
+	require.NoError(t, db.Find(&positions).Error)
+	require.Len(t, positions, 1)
+	assert.False(t, positions[0].IsActive)
+
+	var deleted models.User
+	require.NoError(t, db.Unscoped().First(&deleted, 2).Error)
+	assert.True(t, deleted.DeletedAt.Valid)
+	var admin models.User
+	require.NoError(t, db.First(&admin, 1).Error)
+	assert.Equal(t, "hash-sandi", admin.KataSandi)
+
+	var doc models.LostDocument
+	require.NoError(t, db.Preload("LostItems").First(&doc, 5).Error)
+	require.Len(t, doc.LostItems, 1)
+	require.NotNil(t, doc.PejabatPersetujuID)
+	assert.Equal(t, time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC), doc.TanggalLaporan.UTC())
+
+	var template models.ItemTemplate
+	require.NoError(t, db.First(&template).Error)
+	require.Len(t, template.FieldsConfig, 1)
+	assert.Equal(t, "NIK", template.FieldsConfig[0].Label)
+
+	var role models.Role
+	require.NoError(t, db.First(&role, "nama = ?", "OPERATOR").Error)
+	assert.Equal(t, models.JSONStringArray{"document:view"}, role.Permissions)
+
+	var license models.License
+	require.NoError(t, db.First(&license, "key = ?", "LIC-1").Error)
+	require.NotNil(t, license.ActivatedByID)
+}
+
+func TestBackupService_PortableRejectsTamperedArchive(t *testing.T) {
+	db := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, db)
+	service := newTestBackupService(t, db, "sqlite")
+
+	backupPath, err := service.CreateBackup(context.Background(), BackupMethodPortable, 1)
+	require.NoError(t, err)
+
+	// Tulis ulang arsip dengan isi tabel penduduk diubah, manifest tetap
+	var rewritten bytes.Buffer
+	gz := gzip.NewWriter(&rewritten)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, forEachTarEntry(backupPath, func(name string, r io.Reader) error {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if name == "tables/residents.jsonl" {
+			content = bytes.ReplaceAll(content, []byte("BUDI"), []byte("ANDI"))
+		}
+		return writeTarEntry(tw, name, content)
+	}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	require.NoError(t, db.Create(&models.JobPosition{Nama: "BARU"}).Error)
+	err = service.RestoreBackup(context.Background(), &rewritten, nil, 1)
+	assert.ErrorIs(t, err, ErrBackupFormatUnsupported)
+
+	var count int64
+	require.NoError(t, db.Model(&models.JobPosition{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count, "database tidak boleh tersentuh")
+}
+
+func TestBackupService_RestoreRejectsMismatchedFormat(t *testing.T) {
+	service := newTestBackupService(t, nil, "postgres")
+	t.Setenv("PATH", t.TempDir())
+
+	err := service.RestoreBackup(context.Background(), strings.NewReader("SQLite format 3\x00isi"), nil, 1)
+	assert.ErrorIs(t, err, ErrBackupFormatUnsupported)
+
+	err = service.RestoreBackup(context.Background(), bytes.NewReader([]byte{0x00, 0x01, 0x02}), nil, 1)
+	assert.ErrorIs(t, err, ErrBackupFormatUnsupported)
+}
+
+func TestBackupService_Capabilities(t *testing.T) {
+	t.Run("SQLite", func(t *testing.T) {
+		capabilities := newTestBackupService(t, nil, "sqlite").Capabilities()
+		assert.Equal(t, BackupMethodSQLite, capabilities.DefaultMethod)
+		assert.Equal(t, []string{".db", ".tar.gz", ".enc"}, capabilities.RestoreExtensions)
+	})
+
+	t.Run("Postgres Tanpa pg_dump", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		service := newTestBackupService(t, nil, "postgres")
+		capabilities := service.Capabilities()
+
+		assert.Equal(t, BackupMethodPortable, capabilities.DefaultMethod)
+		require.Len(t, capabilities.Methods, 2)
+		assert.False(t, capabilities.Methods[0].Available)
+		assert.Contains(t, capabilities.Methods[0].Reason, "pg_dump")
+
+		_, err := service.CreateBackup(context.Background(), BackupMethodPgDump, 1)
+		assert.ErrorIs(t, err, ErrBackupMethodUnavailable)
+	})
+}
+
+func TestBackupService_PgDumpInvocation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skrip palsu pg_dump memakai sh")
+	}
+	binDir := t.TempDir()
+	fakePgDump := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "pg_dump (PostgreSQL) 16.2"; exit 0; fi
+for arg in "$@"; do case "$arg" in --file=*) out="${arg#--file=}";; esac; done
+echo "PGDMP password=$PGPASSWORD sslmode=$PGSSLMODE args=$*" > "$out"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "pg_dump"), []byte(fakePgDump), 0755))
+	t.Setenv("PATH", binDir)
+
+	service := newTestBackupService(t, nil, "postgres")
+	capabilities := service.Capabilities()
+	assert.Equal(t, BackupMethodPgDump, capabilities.DefaultMethod)
+	assert.Equal(t, "pg_dump (PostgreSQL) 16.2", capabilities.Methods[0].Version)
+	assert.False(t, capabilities.Methods[0].CanRestore, "pg_restore tidak ada")
+
+	backupPath, err := service.CreateBackup(context.Background(), "", 1)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(backupPath, ".dump"))
+
+	content, err := os.ReadFile(backupPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "password=rahasia sslmode=disable")
+	assert.Contains(t, string(content), "--format=custom")
+	assert.Contains(t, string(content), "--host=db.local --port=5433")
+	assert.NotContains(t, strings.SplitN(string(content), "args=", 2)[1], "rahasia", "password tidak boleh lewat argumen")
+
+	// File hasil pg_dump dikenali, tapi pg_restore tidak tersedia
+	file, err := os.Open(backupPath)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.ErrorIs(t, service.RestoreBackup(context.Background(), file, nil, 1), ErrBackupMethodUnavailable)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
@@ -23,6 +24,9 @@ type ConfigService interface {
 	GetConfig() (*dto.AppConfig, error)
 	SaveConfig(configData map[string]string) error
 	GetLocation() (*time.Location, error)
+	// ValidateSettings memeriksa pengaturan sebelum disimpan; allowPrivileged
+	// true bila pengubahnya Super Admin atau CLI admin.
+	ValidateSettings(settings map[string]string, allowPrivileged bool) error
 }
 
 type configService struct {
@@ -164,6 +168,20 @@ func (s *configService) GetConfig() (*dto.AppConfig, error) {
 	}
 
 	archiveDays, _ := strconv.Atoi(allConfigs["archive_duration_days"])
+	auditRetentionDays, _ := strconv.Atoi(allConfigs["audit_retention_days"])
+	if auditRetentionDays < 0 {
+		auditRetentionDays = 0
+	}
+	// Audit baca aktif kecuali dimatikan admin secara eksplisit
+	auditReadEnabled := allConfigs["audit_read_enabled"] != "false"
+	auditReadSample, _ := strconv.Atoi(allConfigs["audit_read_sample_percent"])
+	if auditReadSample <= 0 || auditReadSample > 100 {
+		auditReadSample = 100
+	}
+	auditReadDedup, _ := strconv.Atoi(allConfigs["audit_read_dedup_minutes"])
+	if auditReadDedup < 0 {
+		auditReadDedup = 0
+	}
 	backupPath := allConfigs["backup_path"]
 	if backupPath == "" {
 		backupPath = filepath.Join(utils.GetAppDataDir(), "backups")
@@ -203,6 +221,11 @@ func (s *configService) GetConfig() (*dto.AppConfig, error) {
 		ZonaWaktu:           allConfigs["zona_waktu"],
 		BackupPath:          backupPath,
 		ArchiveDurationDays: archiveDays,
+		AuditRetentionDays:  auditRetentionDays,
+
+		AuditReadEnabled:       auditReadEnabled,
+		AuditReadSamplePercent: auditReadSample,
+		AuditReadDedupMinutes:  auditReadDedup,
 
 		SessionTimeout: sessionTimeout,
 		IdleTimeout:    idleTimeout,
@@ -218,6 +241,130 @@ func (s *configService) GetConfig() (*dto.AppConfig, error) {
 		LicenseStatus: allConfigs["license_status"],
 	}
 
+	appConfig.AuditSyslog = dto.AuditSyslogConfig{
+		Enabled:            allConfigs["audit_syslog_enabled"] == "true",
+		Network:            strings.ToLower(allConfigs["audit_syslog_network"]),
+		Address:            allConfigs["audit_syslog_address"],
+		AppName:            allConfigs["audit_syslog_app_name"],
+		CACertPath:         allConfigs["audit_syslog_ca_cert_path"],
+		InsecureSkipVerify: allConfigs["audit_syslog_insecure_skip_verify"] == "true",
+	}
+	if appConfig.AuditSyslog.Network == "" {
+		appConfig.AuditSyslog.Network = "udp"
+	}
+	if appConfig.AuditSyslog.AppName == "" {
+		appConfig.AuditSyslog.AppName = "simdokpol"
+	}
+	appConfig.AuditSyslog.Facility = 13
+	if facility, err := strconv.Atoi(allConfigs["audit_syslog_facility"]); err == nil && facility >= 0 && facility <= 23 {
+		appConfig.AuditSyslog.Facility = facility
+	}
+
+	appConfig.AuditFile = dto.AuditFileConfig{
+		Enabled:  allConfigs["audit_file_enabled"] == "true",
+		Path:     allConfigs["audit_file_path"],
+		Compress: allConfigs["audit_file_compress"] != "false",
+	}
+	if appConfig.AuditFile.Path == "" {
+		appConfig.AuditFile.Path = filepath.Join(utils.GetAppDataDir(), "audit-logs", "audit.jsonl")
+	}
+	appConfig.AuditFile.MaxSizeMB, _ = strconv.Atoi(allConfigs["audit_file_max_size_mb"])
+	if appConfig.AuditFile.MaxSizeMB <= 0 {
+		appConfig.AuditFile.MaxSizeMB = 100
+	}
+	appConfig.AuditFile.MaxBackups, _ = strconv.Atoi(allConfigs["audit_file_max_backups"])
+	if appConfig.AuditFile.MaxBackups <= 0 {
+		appConfig.AuditFile.MaxBackups = 10
+	}
+	appConfig.AuditFile.MaxAgeDays, _ = strconv.Atoi(allConfigs["audit_file_max_age_days"])
+	if appConfig.AuditFile.MaxAgeDays < 0 {
+		appConfig.AuditFile.MaxAgeDays = 0
+	}
+
+	appConfig.BackupSchedule = dto.BackupScheduleConfig{
+		Enabled:   allConfigs["backup_schedule_enabled"] == "true",
+		Frequency: allConfigs["backup_schedule_frequency"],
+		Time:      allConfigs["backup_schedule_time"],
+		Method:    allConfigs["backup_schedule_method"],
+	}
+	if appConfig.BackupSchedule.Frequency != "weekly" {
+		appConfig.BackupSchedule.Frequency = "daily"
+	}
+	if _, err := time.Parse("15:04", appConfig.BackupSchedule.Time); err != nil {
+		appConfig.BackupSchedule.Time = "02:00"
+	}
+	if weekday, err := strconv.Atoi(allConfigs["backup_schedule_weekday"]); err == nil && weekday >= 0 && weekday <= 6 {
+		appConfig.BackupSchedule.Weekday = weekday
+	}
+	appConfig.BackupEncryption = dto.BackupEncryptionConfig{
+		Mode:          allConfigs["backup_encryption_mode"],
+		Passphrase:    allConfigs["backup_encryption_passphrase"],
+		HasPassphrase: allConfigs["backup_encryption_passphrase"] != "",
+		KeyFile:       allConfigs["backup_encryption_key_file"],
+	}
+	if appConfig.BackupEncryption.Mode != BackupEncryptionPassphrase && appConfig.BackupEncryption.Mode != BackupEncryptionKeyFile {
+		appConfig.BackupEncryption.Mode = BackupEncryptionNone
+	}
+	appConfig.BackupOffsite = BackupOffsiteFromSettings(allConfigs)
+	// Nilai 0 sah (jenis itu tidak disimpan); kosong/tidak valid memakai bawaan
+	keepCounts := []struct {
+		key      string
+		target   *int
+		fallback int
+	}{
+		{"backup_schedule_keep_daily", &appConfig.BackupSchedule.KeepDaily, 7},
+		{"backup_schedule_keep_weekly", &appConfig.BackupSchedule.KeepWeekly, 4},
+		{"backup_schedule_keep_monthly", &appConfig.BackupSchedule.KeepMonthly, 6},
+	}
+	for _, keep := range keepCounts {
+		value, err := strconv.Atoi(allConfigs[keep.key])
+		if err != nil || value < 0 {
+			value = keep.fallback
+		}
+		*keep.target = value
+	}
+
+	appConfig.AuthProvider = allConfigs["auth_provider"]
+	if appConfig.AuthProvider == "" {
+		appConfig.AuthProvider = "local"
+	}
+	appConfig.LDAP = dto.LDAPConfig{
+		URL:                allConfigs["ldap_url"],
+		StartTLS:           allConfigs["ldap_start_tls"] == "true",
+		InsecureSkipVerify: allConfigs["ldap_insecure_skip_verify"] == "true",
+		CACertPath:         allConfigs["ldap_ca_cert_path"],
+		BindDN:             allConfigs["ldap_bind_dn"],
+		BindPassword:       allConfigs["ldap_bind_password"],
+		BaseDN:             allConfigs["ldap_base_dn"],
+		UserFilter:         allConfigs["ldap_user_filter"],
+		NameAttribute:      allConfigs["ldap_name_attribute"],
+		GroupAttribute:     allConfigs["ldap_group_attribute"],
+		AllowLocalFallback: allConfigs["ldap_allow_local_fallback"] != "false",
+	}
+	if raw := allConfigs["ldap_group_mappings"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &appConfig.LDAP.GroupMappings); err != nil {
+			log.Printf("WARN: ldap_group_mappings tidak valid: %v", err)
+		}
+	}
+
+	appConfig.OIDC = dto.OIDCConfig{
+		Enabled:      allConfigs["oidc_enabled"] == "true",
+		Issuer:       strings.TrimRight(allConfigs["oidc_issuer"], "/"),
+		ClientID:     allConfigs["oidc_client_id"],
+		ClientSecret: allConfigs["oidc_client_secret"],
+		RedirectURL:  allConfigs["oidc_redirect_url"],
+		Scopes:       strings.Fields(strings.ReplaceAll(allConfigs["oidc_scopes"], ",", " ")),
+		NRPClaim:     allConfigs["oidc_nrp_claim"],
+		NameClaim:    allConfigs["oidc_name_claim"],
+		RoleClaim:    allConfigs["oidc_role_claim"],
+		ButtonLabel:  allConfigs["oidc_button_label"],
+	}
+	if raw := allConfigs["oidc_role_mappings"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &appConfig.OIDC.RoleMappings); err != nil {
+			log.Printf("WARN: oidc_role_mappings tidak valid: %v", err)
+		}
+	}
+
 	if appConfig.DBDialect == "" {
 		appConfig.DBDialect = strings.ToLower(os.Getenv("DB_DIALECT"))
 		if appConfig.DBDialect == "" {
@@ -262,3 +409,41 @@ func (s *configService) GetConfig() (*dto.AppConfig, error) {
 
 	return appConfig, nil
 }
+
+// BackupOffsiteFromSettings membaca key backup_offsite_* (dari database atau
+// dari form pengaturan yang belum disimpan) beserta nilai bawaannya.
+func BackupOffsiteFromSettings(values map[string]string) dto.BackupOffsiteConfig {
+	offsite := dto.BackupOffsiteConfig{
+		Type:            strings.ToLower(values["backup_offsite_type"]),
+		SFTPHost:        strings.TrimSpace(values["backup_offsite_sftp_host"]),
+		SFTPUser:        values["backup_offsite_sftp_user"],
+		SFTPPassword:    values["backup_offsite_sftp_password"],
+		HasSFTPPassword: values["backup_offsite_sftp_password"] != "",
+		SFTPKeyFile:     values["backup_offsite_sftp_key_file"],
+		SFTPHostKey:     strings.TrimSpace(values["backup_offsite_sftp_host_key"]),
+		SFTPPath:        values["backup_offsite_sftp_path"],
+		S3Endpoint:      strings.TrimSpace(values["backup_offsite_s3_endpoint"]),
+		S3Bucket:        strings.TrimSpace(values["backup_offsite_s3_bucket"]),
+		S3Region:        values["backup_offsite_s3_region"],
+		S3AccessKey:     values["backup_offsite_s3_access_key"],
+		S3SecretKey:     values["backup_offsite_s3_secret_key"],
+		HasS3SecretKey:  values["backup_offsite_s3_secret_key"] != "",
+		S3Prefix:        strings.Trim(values["backup_offsite_s3_prefix"], "/"),
+		S3UseSSL:        values["backup_offsite_s3_use_ssl"] != "false",
+		FolderPath:      values["backup_offsite_folder_path"],
+	}
+	switch offsite.Type {
+	case BackupOffsiteSFTP, BackupOffsiteS3, BackupOffsiteFolder:
+	default:
+		offsite.Type = BackupOffsiteNone
+	}
+	offsite.SFTPPort = 22
+	if port, err := strconv.Atoi(values["backup_offsite_sftp_port"]); err == nil && port > 0 && port <= 65535 {
+		offsite.SFTPPort = port
+	}
+	offsite.Retries = 3
+	if retries, err := strconv.Atoi(values["backup_offsite_retries"]); err == nil && retries >= 0 && retries <= 10 {
+		offsite.Retries = retries
+	}
+	return offsite
+}
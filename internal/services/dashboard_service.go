@@ -18,13 +18,15 @@ type dashboardService struct {
 	docRepo       repositories.LostDocumentRepository
 	userRepo      repositories.UserRepository
 	configService ConfigService
+	roleService   RoleService
 }
 
-func NewDashboardService(docRepo repositories.LostDocumentRepository, userRepo repositories.UserRepository, configService ConfigService) DashboardService {
+func NewDashboardService(docRepo repositories.LostDocumentRepository, userRepo repositories.UserRepository, configService ConfigService, roleService RoleService) DashboardService {
 	return &dashboardService{
 		docRepo:       docRepo,
 		userRepo:      userRepo,
 		configService: configService,
+		roleService:   roleService,
 	}
 }
 
@@ -49,10 +51,13 @@ func (s *dashboardService) GetExpiringDocumentsForUser(userID uint, notification
 		return nil, err
 	}
 
-	// 2. Logic Percabangan Role
-	if user.Peran == models.RoleSuperAdmin {
-		// Super Admin: Lihat SEMUA notifikasi global
+	// 2. Logic Percabangan Izin
+	if s.roleService.HasPermission(user, models.PermDocumentReadAll) {
+		// Pemegang izin lihat semua: notifikasi global
 		return s.docRepo.FindAllExpiringDocuments(expiryDateStart, expiryDateEnd)
+	} else if user.Regu != "" && s.roleService.HasPermission(user, models.PermDocumentRegu) {
+		// Kepala regu: notifikasi untuk seluruh surat anggota regunya
+		return s.docRepo.FindExpiringDocumentsForRegu(user.Regu, expiryDateStart, expiryDateEnd)
 	} else {
 		// Operator: Hanya lihat dokumen miliknya sendiri
 		return s.docRepo.FindExpiringDocumentsForUser(userID, expiryDateStart, expiryDateEnd)
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DataExchangeService mengekspor dan mengimpor seluruh data aplikasi dalam
+// arsip portabel (format yang sama dengan backup portabel: manifest.json dan
+// satu file JSON lines per tabel), tidak bergantung pada dialek SQL.
+type DataExchangeService interface {
+	// Export tidak menyertakan nilai rahasia pengaturan (password DB, bind
+	// password LDAP, client secret OIDC, kunci backup).
+	Export(ctx context.Context, w io.Writer, actorID uint) (*dto.PortableBackupManifest, error)
+	// Import dengan mode merge menggabungkan data kantor lain: ID baru
+	// diberikan database ini dan semua kolom relasi dipetakan ulang;
+	// pengaturan tidak digabung dan pengguna baru masuk dalam keadaan
+	// nonaktif. Mode replace mengganti seluruh isi tabel persis seperti arsip
+	// kecuali nilai rahasia pengaturan milik instalasi ini.
+	Import(ctx context.Context, r io.Reader, mode string, actorID uint) (*dto.DataImportReport, error)
+}
+
+type dataExchangeService struct {
+	db           *gorm.DB
+	dialect      string
+	auditService AuditLogService
+}
+
+func NewDataExchangeService(db *gorm.DB, dialect string, auditService AuditLogService) DataExchangeService {
+	return &dataExchangeService{db: db, dialect: dialect, auditService: auditService}
+}
+
+// portableMergeRule mengatur cara satu tabel digabung ke database yang sudah
+// berisi data.
+type portableMergeRule struct {
+	// matchColumn: kolom unik untuk mengenali baris yang sudah ada. Baris yang
+	// cocok tidak disisipkan dan ID-nya dipetakan ke baris yang sudah ada.
+	matchColumn string
+	// conflictOnMatch: baris yang cocok dianggap bentrok (bukan data yang
+	// sama) dan dilewati beserta baris anaknya.
+	conflictOnMatch bool
+	// refs: kolom relasi -> tabel induk yang ID-nya dipetakan ulang
+	refs map[string]string
+	// skip diisi alasan bila tabel tidak ikut digabung
+	skip string
+	// prepare dipanggil sebelum baris baru disisipkan
+	prepare func(row map[string]interface{}, report *dto.DataImportReport)
+}
+
+var portableMergeRules = map[string]portableMergeRule{
+	"configurations": {skip: "pengaturan instalasi ini tidak ditimpa"},
+	"item_templates": {matchColumn: "nama_barang"},
+	"roles":          {matchColumn: "nama"},
+	"job_positions":  {matchColumn: "nama"},
+	"users":          {matchColumn: "nrp", prepare: prepareImportedUser},
+	"licenses":       {skip: "lisensi terikat ke perangkat asal"},
+	"api_tokens":     {skip: "token API hanya berlaku di instalasi asal"},
+	"residents":      {matchColumn: "nik"},
+	"lost_documents": {
+		matchColumn:     "nomor_surat",
+		conflictOnMatch: true,
+		refs: map[string]string{
+			"resident_id":          "residents",
+			"petugas_pelapor_id":   "users",
+			"pejabat_persetuju_id": "users",
+			"operator_id":          "users",
+			"last_updated_by_id":   "users",
+		},
+	},
+	"lost_items":        {refs: map[string]string{"lost_document_id": "lost_documents"}},
+	"audit_logs":        {skip: "rantai hash log audit milik instalasi asal"},
+	"audit_checkpoints": {skip: "rantai hash log audit milik instalasi asal"},
+	"audit_archives":    {skip: "rantai hash log audit milik instalasi asal"},
+}
+
+func (s *dataExchangeService) Export(ctx context.Context, w io.Writer, actorID uint) (*dto.PortableBackupManifest, error) {
+	manifest, err := writePortableArchive(ctx, s.db, s.dialect, w, omitSecretSettings)
+	if err != nil {
+		return nil, fmt.Errorf("gagal ekspor data: %w", err)
+	}
+	var rows int64
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditDataExported,
+		Detail:     fmt.Sprintf("Data diekspor ke arsip portabel: %d baris dari %d tabel (tanpa nilai rahasia pengaturan)", rows, len(manifest.Tables)),
+		EntityType: models.AuditEntitySetting,
+		EntityID:   "data-export",
+	})
+	return manifest, nil
+}
+
+func (s *dataExchangeService) Import(ctx context.Context, r io.Reader, mode string, actorID uint) (*dto.DataImportReport, error) {
+	if mode == "" {
+		mode = dto.DataImportMerge
+	}
+	if mode != dto.DataImportMerge && mode != dto.DataImportReplace {
+		return nil, ErrDataImportModeInvalid
+	}
+
+	// Arsip dibaca beberapa kali (verifikasi manifest lalu isi), jadi disimpan dulu
+	tmpDir, err := os.MkdirTemp("", "simdokpol-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "import"+portableBackupExtension)
+	if err := writeUploadedFile(path, r); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	var report *dto.DataImportReport
+	if mode == dto.DataImportReplace {
+		// Arsip ekspor tidak membawa nilai rahasia; simpan milik instalasi ini
+		keys := make([]interface{}, 0, len(secretSettingKeys))
+		for key := range secretSettingKeys {
+			keys = append(keys, key)
+		}
+		var secrets []models.Configuration
+		// clause.IN agar kolom "key" di-quote (kata kunci di MySQL)
+		if err := s.db.WithContext(ctx).Where(clause.IN{Column: clause.Column{Name: "key"}, Values: keys}).Find(&secrets).Error; err != nil {
+			return nil, err
+		}
+		manifest, err := replacePortableData(ctx, s.db, s.dialect, path)
+		if err != nil {
+			if errors.Is(err, ErrRestoreRolledBack) {
+				return nil, fmt.Errorf("%w: %v", ErrDataImportRolledBack, err)
+			}
+			return nil, err
+		}
+		if len(secrets) > 0 {
+			if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&secrets).Error; err != nil {
+				return nil, fmt.Errorf("gagal memulihkan nilai rahasia pengaturan: %w", err)
+			}
+		}
+		report = newDataImportReport(mode, manifest)
+		for _, table := range manifest.Tables {
+			report.Tables = append(report.Tables, dto.DataImportTableReport{Table: table.Name, Rows: table.Rows, Inserted: table.Rows})
+			report.Inserted += table.Rows
+		}
+	} else {
+		if report, err = s.mergePortableData(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+	report.DurationMs = time.Since(started).Milliseconds()
+
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditDataImported,
+		Detail:     fmt.Sprintf("Data diimpor dari arsip portabel (mode %s, sumber %s): %d baris baru, %d sudah ada, %d bentrok, %d pengguna baru nonaktif", mode, report.SourceDialect, report.Inserted, report.Matched, report.Conflicts, len(report.ImportedUsers)),
+		EntityType: models.AuditEntitySetting,
+		EntityID:   "data-import",
+	})
+	return report, nil
+}
+
+func newDataImportReport(mode string, manifest *dto.PortableBackupManifest) *dto.DataImportReport {
+	return &dto.DataImportReport{
+		Mode:          mode,
+		SourceDialect: manifest.SourceDialect,
+		ExportedAt:    manifest.CreatedAt,
+		SchemaVersion: manifest.SchemaVersion,
+	}
+}
+
+// mergePortableData menyisipkan isi arsip ke database yang sudah berisi data
+// dalam satu transaksi. Tabel dibaca dalam urutan FK (sama dengan urutan
+// tulis arsip) sehingga ID induk sudah dipetakan sebelum baris anaknya.
+func (s *dataExchangeService) mergePortableData(ctx context.Context, path string) (*dto.DataImportReport, error) {
+	manifest, err := readPortableManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	report := newDataImportReport(dto.DataImportMerge, manifest)
+
+	schemas := make(map[string]*schema.Schema)
+	for _, model := range portableBackupModels() {
+		sch, err := parseModelSchema(s.db, model)
+		if err != nil {
+			return nil, err
+		}
+		schemas["tables/"+sch.Table+".jsonl"] = sch
+	}
+	for _, table := range manifest.Tables {
+		if _, ok := schemas[table.File]; !ok {
+			return nil, fmt.Errorf("%w: tabel %s tidak dikenal", ErrBackupFormatUnsupported, table.Name)
+		}
+	}
+
+	// idMaps[tabel][ID di arsip] = ID di database ini; 0 berarti baris
+	// tersebut bentrok sehingga baris anaknya ikut dilewati
+	idMaps := make(map[string]map[uint64]uint64)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return forEachTarEntry(path, func(name string, r io.Reader) error {
+			sch, ok := schemas[filepath.ToSlash(name)]
+			if !ok {
+				return nil
+			}
+			tableReport, err := mergePortableTable(tx, sch, r, idMaps, report)
+			if err != nil {
+				return fmt.Errorf("gagal impor tabel %s: %w", sch.Table, err)
+			}
+			report.Tables = append(report.Tables, tableReport)
+			report.Inserted += tableReport.Inserted
+			report.Matched += tableReport.Matched
+			report.Conflicts += tableReport.Conflicts
+			return nil
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrBackupFormatUnsupported) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDataImportRolledBack, err)
+	}
+	return report, nil
+}
+
+func mergePortableTable(tx *gorm.DB, sch *schema.Schema, r io.Reader, idMaps map[string]map[uint64]uint64, importReport *dto.DataImportReport) (dto.DataImportTableReport, error) {
+	rule := portableMergeRules[sch.Table]
+	report := dto.DataImportTableReport{Table: sch.Table, Note: rule.skip}
+	fields := columnFields(sch)
+
+	// Hanya tabel dengan ID angka otomatis yang dipetakan ulang; tabel
+	// berkunci teks (konfigurasi, peran) memakai kuncinya apa adanya
+	idField := sch.PrioritizedPrimaryField
+	remapID := idField != nil && idField.AutoIncrement
+	ids := make(map[uint64]uint64)
+	idMaps[sch.Table] = ids
+
+	decoder := json.NewDecoder(r)
+	for {
+		var line map[string]json.RawMessage
+		if err := decoder.Decode(&line); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return report, err
+		}
+		report.Rows++
+		if rule.skip != "" {
+			report.Skipped++
+			continue
+		}
+
+		row, err := decodePortableRow(fields, line)
+		if err != nil {
+			return report, err
+		}
+		var oldID uint64
+		if remapID {
+			oldID = uintValue(row[idField.DBName])
+			delete(row, idField.DBName)
+		}
+
+		if !remapPortableRefs(row, rule.refs, idMaps) {
+			// Induknya bentrok atau tidak ada di arsip
+			report.Skipped++
+			continue
+		}
+
+		if rule.matchColumn != "" {
+			existingID, found, err := findPortableMatch(tx, sch, rule.matchColumn, row[rule.matchColumn])
+			if err != nil {
+				return report, err
+			}
+			if found {
+				if rule.conflictOnMatch {
+					report.Conflicts++
+					existingID = 0
+				} else {
+					report.Matched++
+				}
+				if remapID {
+					ids[oldID] = existingID
+				}
+				continue
+			}
+		}
+
+		if rule.prepare != nil {
+			rule.prepare(row, importReport)
+		}
+		// Lewat Model agar ID baru diisi balik ke map setelah insert
+		if err := tx.Model(reflect.New(sch.ModelType).Interface()).Create(row).Error; err != nil {
+			return report, err
+		}
+		if remapID {
+			ids[oldID] = uintValue(row[idField.DBName])
+		}
+		report.Inserted++
+	}
+	return report, nil
+}
+
+// omitSecretSettings membuang nilai rahasia pengaturan dari arsip ekspor.
+// Backup tetap menyimpan semuanya.
+func omitSecretSettings(table string, line map[string]json.RawMessage) bool {
+	if table != "configurations" {
+		return false
+	}
+	var key string
+	_ = json.Unmarshal(line["key"], &key)
+	return secretSettingKeys[key]
+}
+
+// prepareImportedUser menonaktifkan pengguna baru dari arsip kantor lain dan
+// menurunkan peran Super Admin ke Operator; Super Admin instalasi ini yang
+// memutuskan siapa yang diaktifkan. Semuanya dicantumkan di laporan.
+func prepareImportedUser(row map[string]interface{}, report *dto.DataImportReport) {
+	imported := dto.DataImportUser{}
+	imported.NRP, _ = row["nrp"].(string)
+	imported.NamaLengkap, _ = row["nama_lengkap"].(string)
+	imported.PeranAsal, _ = row["peran"].(string)
+	imported.Peran = imported.PeranAsal
+	if imported.PeranAsal == models.RoleSuperAdmin {
+		imported.Peran = models.RoleOperator
+		row["peran"] = imported.Peran
+	}
+	if deletedAt, ok := row["deleted_at"].(gorm.DeletedAt); !ok || !deletedAt.Valid {
+		row["deleted_at"] = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	}
+	report.ImportedUsers = append(report.ImportedUsers, imported)
+}
+
+// remapPortableRefs mengganti nilai kolom relasi dengan ID di database ini.
+// false berarti baris induknya tidak ikut disisipkan.
+func remapPortableRefs(row map[string]interface{}, refs map[string]string, idMaps map[string]map[uint64]uint64) bool {
+	for column, parent := range refs {
+		value := reflect.ValueOf(row[column])
+		if !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+			continue
+		}
+		newID, ok := idMaps[parent][uintValue(row[column])]
+		if !ok || newID == 0 {
+			return false
+		}
+		mapped := uint(newID)
+		if value.Kind() == reflect.Ptr {
+			row[column] = &mapped
+		} else {
+			row[column] = mapped
+		}
+	}
+	return true
+}
+
+// findPortableMatch mencari baris yang sudah ada berdasarkan kolom unik,
+// termasuk yang terhapus (soft delete) karena constraint unik tetap berlaku.
+func findPortableMatch(tx *gorm.DB, sch *schema.Schema, column string, value interface{}) (uint64, bool, error) {
+	pk := sch.PrioritizedPrimaryField
+	var rows []map[string]interface{}
+	err := tx.Table(sch.Table).Select(pk.DBName).Where(clause.Eq{Column: clause.Column{Name: column}, Value: value}).Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return 0, false, err
+	}
+	return uintValue(rows[0][pk.DBName]), true, nil
+}
+
+// uintValue membaca ID apa pun tipe angkanya (uint, *uint, int64 dari driver).
+func uintValue(value interface{}) uint64 {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() > 0 {
+			return uint64(v.Int())
+		}
+	}
+	return 0
+}
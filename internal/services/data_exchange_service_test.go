@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDataExchangeService(db *gorm.DB) DataExchangeService {
+	mockAuditService := new(mocks.AuditLogService)
+	mockAuditService.On("LogEvent", mock.Anything, mock.Anything).Return()
+	return NewDataExchangeService(db, "sqlite", mockAuditService)
+}
+
+func exportTestData(t *testing.T, db *gorm.DB) *bytes.Buffer {
+	var archive bytes.Buffer
+	manifest, err := newTestDataExchangeService(db).Export(context.Background(), &archive, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, manifest.SchemaVersion)
+	require.Len(t, manifest.Tables, len(portableBackupModels()))
+	for _, table := range manifest.Tables {
+		assert.NotEmpty(t, table.Columns, table.Name)
+	}
+	return &archive
+}
+
+func gunzipString(t *testing.T, archive []byte) string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestDataExchangeService_ExportAndReplace(t *testing.T) {
+	source := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, source)
+	require.NoError(t, source.Create(&models.Configuration{Key: "ldap_bind_password", Value: "rahasia-kantor-a"}).Error)
+	archive := exportTestData(t, source)
+	assert.NotContains(t, gunzipString(t, archive.Bytes()), "rahasia-kantor-a", "nilai rahasia tidak ikut diekspor")
+
+	target := openBackupTestDB(t, "tujuan.db")
+	require.NoError(t, target.Create(&models.JobPosition{Nama: "LAMA"}).Error)
+	require.NoError(t, target.Create(&models.Configuration{Key: "oidc_client_secret", Value: "rahasia-kantor-b"}).Error)
+	report, err := newTestDataExchangeService(target).Import(context.Background(), archive, dto.DataImportReplace, 1)
+	require.NoError(t, err)
+	assert.Equal(t, dto.DataImportReplace, report.Mode)
+	assert.Equal(t, models.SchemaVersion, report.SchemaVersion)
+	assertBackupDataRestored(t, target)
+	var secret models.Configuration
+	require.NoError(t, target.First(&secret, &models.Configuration{Key: "oidc_client_secret"}).Error)
+	assert.Equal(t, "rahasia-kantor-b", secret.Value, "nilai rahasia instalasi tujuan dipertahankan")
+
+	_, err = newTestDataExchangeService(target).Import(context.Background(), strings.NewReader("x"), "timpa", 1)
+	assert.ErrorIs(t, err, ErrDataImportModeInvalid)
+	_, err = newTestDataExchangeService(target).Import(context.Background(), strings.NewReader("bukan arsip"), dto.DataImportMerge, 1)
+	assert.ErrorIs(t, err, ErrBackupFormatUnsupported)
+}
+
+func TestDataExchangeService_MergeRemapsIDs(t *testing.T) {
+	source := openBackupTestDB(t, "kantor-a.db")
+	seedBackupData(t, source)
+	now := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, source.Create(&models.LostDocument{
+		ID: 6, NomorSurat: "SKH/2/II/2025", TanggalLaporan: now, ResidentID: 7, PetugasPelaporID: 2, OperatorID: 1,
+		LostItems: []models.LostItem{{NamaBarang: "SIM", Deskripsi: "SIM C"}},
+	}).Error)
+	require.NoError(t, source.Create(&models.User{ID: 3, NamaLengkap: "Admin A", NRP: "333", KataSandi: "hash-3", Peran: models.RoleSuperAdmin}).Error)
+	archive := exportTestData(t, source)
+
+	// Kantor B sudah punya data sendiri dengan ID yang tumpang tindih
+	target := openBackupTestDB(t, "kantor-b.db")
+	require.NoError(t, target.Create(&models.Configuration{Key: "nama_kantor", Value: "POLSEK B"}).Error)
+	require.NoError(t, target.Create(&models.User{ID: 1, NamaLengkap: "Admin B", NRP: "900", KataSandi: "x", Peran: models.RoleSuperAdmin}).Error)
+	require.NoError(t, target.Create(&models.User{ID: 2, NamaLengkap: "Admin", NRP: "111", KataSandi: "x"}).Error)
+	require.NoError(t, target.Create(&models.Resident{ID: 1, NIK: "3201010101010001", NamaLengkap: "BUDI", TempatLahir: "BOGOR", TanggalLahir: now, JenisKelamin: "Laki-laki", Agama: "Islam", Pekerjaan: "Swasta", Alamat: "Jl. Uji"}).Error)
+	require.NoError(t, target.Create(&models.LostDocument{
+		ID: 5, NomorSurat: "SKH/1/II/2025", TanggalLaporan: now, ResidentID: 1, PetugasPelaporID: 1, OperatorID: 1,
+		LostItems: []models.LostItem{{NamaBarang: "KK", Deskripsi: "milik kantor B"}},
+	}).Error)
+	require.NoError(t, target.Create(&models.AuditLog{UserID: 1, Aksi: models.AuditBackupCreated, Timestamp: now, Hash: "b1"}).Error)
+
+	report, err := newTestDataExchangeService(target).Import(context.Background(), archive, dto.DataImportMerge, 1)
+	require.NoError(t, err)
+	tables := make(map[string]dto.DataImportTableReport)
+	for _, table := range report.Tables {
+		tables[table.Table] = table
+	}
+	assert.Equal(t, int64(1), tables["configurations"].Skipped)
+	assert.Equal(t, int64(1), tables["users"].Matched)
+	assert.Equal(t, int64(2), tables["users"].Inserted)
+	assert.ElementsMatch(t, []dto.DataImportUser{
+		{NRP: "222", NamaLengkap: "Mantan Operator", Peran: models.RoleOperator, PeranAsal: models.RoleOperator},
+		{NRP: "333", NamaLengkap: "Admin A", Peran: models.RoleOperator, PeranAsal: models.RoleSuperAdmin},
+	}, report.ImportedUsers)
+	assert.Equal(t, int64(1), tables["residents"].Matched)
+	assert.Equal(t, int64(1), tables["lost_documents"].Conflicts)
+	assert.Equal(t, int64(1), tables["lost_documents"].Inserted)
+	assert.Equal(t, int64(1), tables["lost_items"].Skipped, "barang milik surat yang bentrok ikut dilewati")
+	assert.Equal(t, int64(1), tables["licenses"].Skipped)
+	assert.NotEmpty(t, tables["audit_logs"].Note)
+
+	var config models.Configuration
+	require.NoError(t, target.First(&config, "key = ?", "nama_kantor").Error)
+	assert.Equal(t, "POLSEK B", config.Value, "pengaturan yang sudah ada tidak ditimpa")
+
+	var former models.User
+	require.NoError(t, target.Unscoped().First(&former, "nrp = ?", "222").Error)
+	assert.Greater(t, former.ID, uint(2))
+	assert.True(t, former.DeletedAt.Valid)
+
+	var imported models.User
+	require.NoError(t, target.Unscoped().First(&imported, "nrp = ?", "333").Error)
+	assert.True(t, imported.DeletedAt.Valid, "pengguna baru dari arsip masuk nonaktif")
+	assert.Equal(t, models.RoleOperator, imported.Peran, "Super Admin kantor lain tidak ikut jadi Super Admin")
+
+	var merged models.LostDocument
+	require.NoError(t, target.Preload("LostItems").First(&merged, "nomor_surat = ?", "SKH/2/II/2025").Error)
+	assert.Equal(t, uint(1), merged.ResidentID, "NIK sama dipetakan ke penduduk yang sudah ada")
+	assert.Equal(t, uint(2), merged.OperatorID, "NRP sama dipetakan ke pengguna yang sudah ada")
+	assert.Equal(t, former.ID, merged.PetugasPelaporID)
+	require.Len(t, merged.LostItems, 1)
+	assert.Equal(t, "SIM", merged.LostItems[0].NamaBarang)
+
+	var existing models.LostDocument
+	require.NoError(t, target.Preload("LostItems").First(&existing, 5).Error)
+	require.Len(t, existing.LostItems, 1)
+	assert.Equal(t, "KK", existing.LostItems[0].NamaBarang)
+
+	var jabatan models.JobPosition
+	require.NoError(t, target.First(&jabatan, "nama = ?", "KANIT").Error)
+	assert.False(t, jabatan.IsActive)
+
+	var licenses, audits int64
+	require.NoError(t, target.Model(&models.License{}).Count(&licenses).Error)
+	require.NoError(t, target.Model(&models.AuditLog{}).Count(&audits).Error)
+	assert.Zero(t, licenses)
+	assert.Equal(t, int64(1), audits)
+}
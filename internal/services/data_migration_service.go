@@ -1,10 +1,17 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
 	"strings"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -15,7 +22,11 @@ import (
 )
 
 type DataMigrationService interface {
-	MigrateDataTo(targetConfig dto.DBTestRequest, actorID uint, progressChan chan<- dto.MigrationProgress) error
+	// MigrateDataTo menyalin seluruh data ke database target lalu memverifikasi
+	// jumlah baris dan checksum isi tiap tabel. Progres disimpan di target
+	// sehingga migrasi yang terputus tinggal dijalankan ulang. Laporan akhir
+	// dikirim sebagai progres terakhir (Step "report") dan juga dikembalikan.
+	MigrateDataTo(ctx context.Context, req dto.DataMigrationRequest, actorID uint, progressChan chan<- dto.MigrationProgress) (*dto.DataMigrationReport, error)
 }
 
 type dataMigrationService struct {
@@ -24,6 +35,37 @@ type dataMigrationService struct {
 	configService ConfigService
 }
 
+// dataMigrationTable satu tabel yang disalin; urutannya aman FK (induk dulu).
+type dataMigrationTable struct {
+	Label string
+	Model interface{}
+}
+
+var (
+	dataMigrationBatchSize = 500
+	// Perkiraan biaya tulis per baris di target untuk estimasi dry-run
+	dataMigrationRowWriteCost = 200 * time.Microsecond
+)
+
+func dataMigrationTables() []dataMigrationTable {
+	return []dataMigrationTable{
+		{"Konfigurasi", &models.Configuration{}},
+		{"Template Barang", &models.ItemTemplate{}},
+		{"Peran", &models.Role{}},
+		{"Jabatan", &models.JobPosition{}},
+		{"Pengguna", &models.User{}},
+		{"Lisensi", &models.License{}},
+		{"Token API", &models.APIToken{}},
+		{"Penduduk", &models.Resident{}},
+		{"Dokumen", &models.LostDocument{}},
+		{"Barang Hilang", &models.LostItem{}},
+		{"Log Audit", &models.AuditLog{}},
+		{"Checkpoint Audit", &models.AuditCheckpoint{}},
+		{"Arsip Audit", &models.AuditArchive{}},
+		{"Riwayat Backup", &models.BackupRun{}},
+	}
+}
+
 func NewDataMigrationService(currentDB *gorm.DB, auditService AuditLogService, configService ConfigService) DataMigrationService {
 	return &dataMigrationService{
 		currentDB:     currentDB,
@@ -32,35 +74,58 @@ func NewDataMigrationService(currentDB *gorm.DB, auditService AuditLogService, c
 	}
 }
 
-func (s *dataMigrationService) MigrateDataTo(target dto.DBTestRequest, actorID uint, progressChan chan<- dto.MigrationProgress) error {
-	report := func(step string, pct int, msg string) {
-		if progressChan != nil {
-			progressChan <- dto.MigrationProgress{Step: step, Percent: pct, Message: msg}
+func (s *dataMigrationService) MigrateDataTo(ctx context.Context, req dto.DataMigrationRequest, actorID uint, progressChan chan<- dto.MigrationProgress) (*dto.DataMigrationReport, error) {
+	send := func(progress dto.MigrationProgress) {
+		if progressChan == nil {
+			return
+		}
+		select {
+		case progressChan <- progress:
+		case <-ctx.Done():
 		}
 	}
+	report := func(step string, pct int, msg string) {
+		send(dto.MigrationProgress{Step: step, Percent: pct, Message: msg})
+	}
+	started := time.Now()
+	result := &dto.DataMigrationReport{DryRun: req.DryRun, TargetDialect: req.DBDialect, Tables: []dto.DataMigrationTableReport{}}
+	finish := func() {
+		result.DurationMs = time.Since(started).Milliseconds()
+		send(dto.MigrationProgress{Step: "report", Percent: 100, Message: "Laporan migrasi", Report: result})
+	}
 
-	report("connect", 5, "Menghubungkan ke database target...")
-	targetDB, err := s.openTargetConnection(target)
+	report("connect", 2, "Menghubungkan ke database target...")
+	targetDB, err := s.openTargetConnection(req.DBTestRequest)
 	if err != nil {
-		return fmt.Errorf("gagal koneksi ke target database: %w", err)
+		return nil, fmt.Errorf("gagal koneksi ke target database: %w", err)
+	}
+	sqlDB, err := targetDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("gagal koneksi ke target database: %w", err)
 	}
-	
-	sqlDB, _ := targetDB.DB()
 	defer sqlDB.Close()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("gagal koneksi ke target database: %w", err)
+	}
 
-	report("schema", 10, "Membuat struktur tabel...")
-	// AutoMigrate akan membuat tabel di target
-	err = targetDB.AutoMigrate(
-		&models.Configuration{}, &models.User{}, &models.Resident{},
-		&models.LostDocument{}, &models.LostItem{}, &models.AuditLog{},
-		&models.ItemTemplate{}, &models.License{},
-	)
-	if err != nil {
-		return fmt.Errorf("gagal membuat tabel di target: %w", err)
+	tables := dataMigrationTables()
+	if req.DryRun {
+		if err := s.estimate(ctx, targetDB, tables, result, report); err != nil {
+			return nil, err
+		}
+		finish()
+		return result, nil
 	}
 
+	report("schema", 5, "Menyiapkan struktur tabel di target...")
+	if _, err := NewSchemaMigrationService(targetDB, req.DBDialect).Up(ctx); err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel di target: %w", err)
+	}
+	// Satu koneksi saja agar pengaturan sesi di bawah berlaku untuk semua query
+	sqlDB.SetMaxOpenConns(1)
+
 	// Coba Matikan FK Checks (Best Effort - Tergantung Permission User DB)
-	switch target.DBDialect {
+	switch req.DBDialect {
 	case "mysql":
 		targetDB.Exec("SET FOREIGN_KEY_CHECKS = 0")
 		defer targetDB.Exec("SET FOREIGN_KEY_CHECKS = 1")
@@ -73,48 +138,364 @@ func (s *dataMigrationService) MigrateDataTo(target dto.DBTestRequest, actorID u
 		defer targetDB.Exec("SET session_replication_role = 'origin'")
 	}
 
-	// --- FIX UTAMA: GUNAKAN SLICE, BUKAN STRUCT TUNGGAL ---
-	// GORM butuh slice pointer (&[]Model{}) agar FindInBatches bekerja benar.
-	tables := []struct {
-		Name  string
-		Model interface{} // Pointer to Slice
-		Pct   int
-	}{
-		{"Konfigurasi", &[]models.Configuration{}, 15},
-		{"Lisensi", &[]models.License{}, 20},
-		{"Template Barang", &[]models.ItemTemplate{}, 25},
-		
-		// Urutan SANGAT PENTING agar FK valid:
-		{"Pengguna", &[]models.User{}, 35},        // User dulu (referensi oleh Doc)
-		{"Penduduk", &[]models.Resident{}, 45},    // Resident dulu (referensi oleh Doc)
-		{"Dokumen", &[]models.LostDocument{}, 60}, // Baru Dokumen
-		{"Barang Hilang", &[]models.LostItem{}, 80}, // Item referensi Doc
-		{"Log Audit", &[]models.AuditLog{}, 90},
+	progress, err := s.prepareTarget(ctx, targetDB, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalRows, doneRows int64
+	sourceCounts := make([]int64, len(tables))
+	for i, table := range tables {
+		if err := s.currentDB.WithContext(ctx).Unscoped().Model(table.Model).Count(&sourceCounts[i]).Error; err != nil {
+			return nil, fmt.Errorf("gagal menghitung data %s: %w", table.Label, err)
+		}
+		totalRows += sourceCounts[i]
+	}
+	copyPercent := func() int {
+		if totalRows == 0 {
+			return 80
+		}
+		return 10 + int(70*doneRows/totalRows)
+	}
+
+	copied := make([]int64, len(tables))
+	resumed := make([]bool, len(tables))
+	for i, table := range tables {
+		tableProgress := progress[modelTableName(s.currentDB, table.Model)]
+		resumed[i] = tableProgress.Rows > 0 || tableProgress.Done
+		doneRows += tableProgress.Rows
+		if tableProgress.Done {
+			report(table.Label, copyPercent(), fmt.Sprintf("%s sudah tersalin sebelumnya, dilewati", table.Label))
+			continue
+		}
+		report(table.Label, copyPercent(), fmt.Sprintf("Menyalin data %s...", table.Label))
+		copied[i], err = s.copyTable(ctx, targetDB, table, tableProgress, func(rows int) {
+			doneRows += int64(rows)
+			report(table.Label, copyPercent(), fmt.Sprintf("Menyalin data %s (%d baris)...", table.Label, tableProgress.Rows))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gagal menyalin %s (bisa dilanjutkan dengan menjalankan ulang migrasi): %w", table.Label, err)
+		}
 	}
 
-	for _, t := range tables {
-		report(t.Name, t.Pct, fmt.Sprintf("Menyalin data %s...", t.Name))
-		if err := s.copyTable(s.currentDB, targetDB, t.Model); err != nil {
-			return fmt.Errorf("gagal menyalin %s: %w", t.Name, err)
+	report("sequence", 82, "Menyesuaikan penghitung ID di target...")
+	if err := resetTargetSequences(ctx, targetDB, req.DBDialect, tables); err != nil {
+		return nil, fmt.Errorf("gagal menyesuaikan sequence: %w", err)
+	}
+
+	var mismatched []string
+	result.Verified = true
+	for i, table := range tables {
+		report("verify", 85+14*i/len(tables), fmt.Sprintf("Memverifikasi %s...", table.Label))
+		tableReport := dto.DataMigrationTableReport{
+			Table: modelTableName(s.currentDB, table.Model), Label: table.Label, CopiedRows: copied[i], Resumed: resumed[i],
+		}
+		if tableReport.SourceRows, tableReport.SourceChecksum, err = tableChecksum(ctx, s.currentDB, table.Model); err != nil {
+			return nil, fmt.Errorf("gagal menghitung checksum sumber %s: %w", table.Label, err)
 		}
+		if tableReport.TargetRows, tableReport.TargetChecksum, err = tableChecksum(ctx, targetDB, table.Model); err != nil {
+			return nil, fmt.Errorf("gagal menghitung checksum target %s: %w", table.Label, err)
+		}
+		tableReport.Match = tableReport.SourceRows == tableReport.TargetRows && tableReport.SourceChecksum == tableReport.TargetChecksum
+		if !tableReport.Match {
+			result.Verified = false
+			mismatched = append(mismatched, table.Label)
+		}
+		result.TotalRows += tableReport.SourceRows
+		result.Tables = append(result.Tables, tableReport)
 	}
 
-	report("finish", 100, "Finalisasi migrasi...")
-	s.auditService.LogActivity(actorID, models.AuditSettingsUpdated, fmt.Sprintf("Data berhasil disalin ke database baru (%s)", target.DBDialect))
+	if !result.Verified {
+		// Progres tetap disimpan; data sumber yang berubah selama migrasi bisa
+		// disalin ulang ke database kosong
+		finish()
+		return result, fmt.Errorf("%w: %s", ErrDataMigrationMismatch, strings.Join(mismatched, ", "))
+	}
+	if err := targetDB.Migrator().DropTable(&models.DataMigrationProgress{}); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Tabel progres migrasi tidak bisa dihapus: %v", err))
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     models.AuditDataMigrated,
+		Detail:     fmt.Sprintf("Data berhasil disalin ke database baru (%s): %d baris, %d tabel terverifikasi", req.DBDialect, result.TotalRows, len(result.Tables)),
+		EntityType: models.AuditEntitySetting,
+		EntityID:   req.DBDialect,
+	})
+	finish()
+	return result, nil
+}
 
+// prepareTarget membaca progres migrasi sebelumnya di target. Bila belum ada,
+// target harus kosong: baris bawaan hasil migrasi skema (template barang,
+// jabatan, peran) dihapus agar tidak bentrok dengan ID dari sumber.
+func (s *dataMigrationService) prepareTarget(ctx context.Context, targetDB *gorm.DB, tables []dataMigrationTable) (map[string]*models.DataMigrationProgress, error) {
+	db := targetDB.WithContext(ctx)
+	if err := db.AutoMigrate(&models.DataMigrationProgress{}); err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan tabel progres migrasi: %w", err)
+	}
+	var rows []models.DataMigrationProgress
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("gagal membaca progres migrasi: %w", err)
+	}
+	progress := make(map[string]*models.DataMigrationProgress, len(tables))
+	for i := range rows {
+		progress[rows[i].Name] = &rows[i]
+	}
+
+	if len(rows) == 0 {
+		var users int64
+		if err := db.Unscoped().Model(&models.User{}).Count(&users).Error; err != nil {
+			return nil, err
+		}
+		if users > 0 {
+			return nil, ErrDataMigrationTargetNotEmpty
+		}
+		for i := len(tables) - 1; i >= 0; i-- {
+			if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(tables[i].Model).Error; err != nil {
+				return nil, fmt.Errorf("gagal mengosongkan tabel %s di target: %w", tables[i].Label, err)
+			}
+		}
+	}
+	for _, table := range tables {
+		name := modelTableName(s.currentDB, table.Model)
+		if progress[name] == nil {
+			progress[name] = &models.DataMigrationProgress{Name: name}
+		}
+	}
+	return progress, nil
+}
+
+// copyTable menyalin tabel per batch. Tiap batch dan catatan progresnya
+// di-commit bersama, jadi setelah terputus penyalinan lanjut dari ID
+// terakhir. Tabel berkunci teks (konfigurasi, lisensi) kecil dan disalin
+// sekaligus.
+func (s *dataMigrationService) copyTable(ctx context.Context, targetDB *gorm.DB, table dataMigrationTable, progress *models.DataMigrationProgress, onBatch func(rows int)) (int64, error) {
+	tableSchema, err := parseModelSchema(s.currentDB, table.Model)
+	if err != nil {
+		return 0, err
+	}
+	primary := tableSchema.PrioritizedPrimaryField
+	incremental := primary != nil && primary.AutoIncrement
+	fields := columnFields(tableSchema)
+	sliceType := reflect.SliceOf(reflect.TypeOf(table.Model).Elem())
+
+	var copied int64
+	for {
+		batch := reflect.New(sliceType)
+		query := s.currentDB.WithContext(ctx).Unscoped().Model(table.Model)
+		if incremental {
+			query = query.Where(clause.Gt{Column: clause.Column{Name: primary.DBName}, Value: progress.LastID}).
+				Order(clause.OrderByColumn{Column: clause.Column{Name: primary.DBName}}).
+				Limit(dataMigrationBatchSize)
+		}
+		if err := query.Find(batch.Interface()).Error; err != nil {
+			return copied, err
+		}
+		rows := batch.Elem()
+		if rows.Len() > 0 && incremental {
+			lastID, _ := primary.ValueOf(ctx, rows.Index(rows.Len()-1))
+			progress.LastID = uint(reflect.ValueOf(lastID).Uint())
+		}
+		progress.Rows += int64(rows.Len())
+		progress.Done = !incremental || rows.Len() < dataMigrationBatchSize
+
+		// Disisipkan lewat map agar nilai false/0 tidak diganti default kolom
+		// (sama seperti impor backup portabel)
+		values := make([]map[string]interface{}, 0, rows.Len())
+		for i := 0; i < rows.Len(); i++ {
+			row := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				row[field.DBName], _ = field.ValueOf(ctx, rows.Index(i))
+			}
+			values = append(values, row)
+		}
+		err := targetDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if len(values) > 0 {
+				if err := tx.Table(tableSchema.Table).Clauses(clause.OnConflict{DoNothing: true}).Create(values).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(progress).Error
+		})
+		if err != nil {
+			progress.Rows -= int64(rows.Len())
+			return copied, err
+		}
+		copied += int64(rows.Len())
+		onBatch(rows.Len())
+		if progress.Done {
+			return copied, nil
+		}
+	}
+}
+
+// resetTargetSequences menyamakan sequence (PostgreSQL) dan AUTO_INCREMENT
+// (MySQL) dengan ID terbesar hasil salinan; tanpa ini data baru di target
+// bentrok dengan ID lama. SQLite menyesuaikan sendiri.
+func resetTargetSequences(ctx context.Context, targetDB *gorm.DB, dialect string, tables []dataMigrationTable) error {
+	if dialect != "postgres" && dialect != "mysql" {
+		return nil
+	}
+	db := targetDB.WithContext(ctx)
+	for _, table := range tables {
+		tableSchema, err := parseModelSchema(db, table.Model)
+		if err != nil {
+			return err
+		}
+		primary := tableSchema.PrioritizedPrimaryField
+		if primary == nil || !primary.AutoIncrement {
+			continue
+		}
+		if dialect == "postgres" {
+			if err := resetPostgresSequence(db, tableSchema); err != nil {
+				return err
+			}
+			continue
+		}
+		var maxID int64
+		if err := db.Table(tableSchema.Table).Select("COALESCE(MAX(?), 0)", clause.Column{Name: primary.DBName}).Row().Scan(&maxID); err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE `%s` AUTO_INCREMENT = %d", tableSchema.Table, maxID+1)).Error; err != nil {
+			return fmt.Errorf("gagal menyelaraskan AUTO_INCREMENT %s: %w", tableSchema.Table, err)
+		}
+	}
 	return nil
 }
 
-func (s *dataMigrationService) copyTable(src, dest *gorm.DB, modelSlice interface{}) error {
-	// Gunakan Unscoped() agar data soft-deleted tetap tersalin (mencegah FK error jika referensi ke user yg dihapus)
-	// Model(modelSlice) memberitahu GORM tabel mana yang dipakai.
-	// FindInBatches(modelSlice) mengisi slice tersebut.
-	
-	return src.Unscoped().Model(modelSlice).FindInBatches(modelSlice, 100, func(tx *gorm.DB, batch int) error {
-		// Insert Batch ke Target
-		// Clause OnConflict DoNothing untuk idempotency (kalau diulang tidak double)
-		return dest.Clauses(clause.OnConflict{DoNothing: true}).Create(tx.Statement.Dest).Error
+// estimate menjalankan dry-run: menghitung baris dan checksum sumber,
+// mengukur latensi ke target, dan membaca progres migrasi sebelumnya tanpa
+// mengubah apa pun di target.
+func (s *dataMigrationService) estimate(ctx context.Context, targetDB *gorm.DB, tables []dataMigrationTable, result *dto.DataMigrationReport, report func(string, int, string)) error {
+	sqlDB, err := targetDB.DB()
+	if err != nil {
+		return err
+	}
+	pingStarted := time.Now()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("gagal koneksi ke target database: %w", err)
+	}
+	roundTrip := time.Since(pingStarted)
+
+	progress := make(map[string]models.DataMigrationProgress)
+	if targetDB.Migrator().HasTable(&models.DataMigrationProgress{}) {
+		var rows []models.DataMigrationProgress
+		if err := targetDB.WithContext(ctx).Find(&rows).Error; err != nil {
+			return fmt.Errorf("gagal membaca progres migrasi: %w", err)
+		}
+		for _, row := range rows {
+			progress[row.Name] = row
+		}
+		if len(rows) > 0 {
+			result.Warnings = append(result.Warnings, "Target berisi migrasi yang belum selesai; penyalinan akan dilanjutkan.")
+		}
+	}
+	if len(progress) == 0 && targetDB.Migrator().HasTable(&models.User{}) {
+		var users int64
+		targetDB.WithContext(ctx).Unscoped().Model(&models.User{}).Count(&users)
+		if users > 0 {
+			result.Warnings = append(result.Warnings, ErrDataMigrationTargetNotEmpty.Error())
+		}
+	}
+
+	scanStarted := time.Now()
+	var remainingRows, batches int64
+	for i, table := range tables {
+		report(table.Label, 5+90*i/len(tables), fmt.Sprintf("Menghitung data %s...", table.Label))
+		name := modelTableName(s.currentDB, table.Model)
+		tableReport := dto.DataMigrationTableReport{Table: name, Label: table.Label}
+		if tableReport.SourceRows, tableReport.SourceChecksum, err = tableChecksum(ctx, s.currentDB, table.Model); err != nil {
+			return fmt.Errorf("gagal menghitung checksum sumber %s: %w", table.Label, err)
+		}
+		previous := progress[name]
+		tableReport.Resumed = previous.Rows > 0 || previous.Done
+		remaining := tableReport.SourceRows
+		if previous.Done {
+			remaining = 0
+		} else if previous.Rows < remaining {
+			remaining -= previous.Rows
+		}
+		remainingRows += remaining
+		batches += (remaining + int64(dataMigrationBatchSize) - 1) / int64(dataMigrationBatchSize)
+		result.TotalRows += tableReport.SourceRows
+		result.Tables = append(result.Tables, tableReport)
+	}
+
+	// Salin = baca sumber + tulis target; verifikasi = baca sumber dan target lagi
+	scan := time.Since(scanStarted)
+	estimate := 3*scan + time.Duration(batches)*2*roundTrip + time.Duration(remainingRows)*dataMigrationRowWriteCost
+	result.EstimatedSeconds = int64(estimate.Round(time.Second) / time.Second)
+	if result.EstimatedSeconds < 1 {
+		result.EstimatedSeconds = 1
+	}
+	return nil
+}
+
+func modelTableName(db *gorm.DB, model interface{}) string {
+	tableSchema, err := parseModelSchema(db, model)
+	if err != nil {
+		return fmt.Sprintf("%T", model)
+	}
+	return tableSchema.Table
+}
+
+// tableChecksum menghitung jumlah baris dan checksum isi tabel yang tidak
+// bergantung dialek: tiap baris diubah ke JSON dengan nilai ternormalisasi
+// (waktu dalam UTC, presisi milidetik) lalu hash SHA-256-nya di-XOR. XOR
+// membuat hasilnya tidak bergantung urutan/collation kunci teks; baris
+// kembar tidak mungkin karena tiap baris punya primary key.
+func tableChecksum(ctx context.Context, db *gorm.DB, model interface{}) (int64, string, error) {
+	tableSchema, err := parseModelSchema(db, model)
+	if err != nil {
+		return 0, "", err
+	}
+	var sum [sha256.Size]byte
+	var count int64
+	batch := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	err = db.WithContext(ctx).Unscoped().Model(model).FindInBatches(batch.Interface(), dataMigrationBatchSize, func(tx *gorm.DB, _ int) error {
+		rows := batch.Elem()
+		for i := 0; i < rows.Len(); i++ {
+			values := make([]interface{}, 0, len(tableSchema.DBNames))
+			for _, name := range tableSchema.DBNames {
+				value, _ := tableSchema.FieldsByDBName[name].ValueOf(ctx, rows.Index(i))
+				values = append(values, checksumValue(value))
+			}
+			encoded, err := json.Marshal(values)
+			if err != nil {
+				return err
+			}
+			rowHash := sha256.Sum256(encoded)
+			for j := range sum {
+				sum[j] ^= rowHash[j]
+			}
+			count++
+		}
+		return nil
 	}).Error
+	if err != nil {
+		return 0, "", err
+	}
+	return count, hex.EncodeToString(sum[:]), nil
+}
+
+func checksumValue(value interface{}) interface{} {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if converted, err := valuer.Value(); err == nil {
+			value = converted
+		}
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Round(time.Millisecond).Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return checksumValue(*v)
+	case []byte:
+		return string(v)
+	}
+	return value
 }
 
 func (s *dataMigrationService) openTargetConnection(req dto.DBTestRequest) (*gorm.DB, error) {
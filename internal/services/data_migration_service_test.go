@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDataMigrationService(t *testing.T, source *gorm.DB) *dataMigrationService {
+	mockAuditService := new(mocks.AuditLogService)
+	mockAuditService.On("LogEvent", mock.Anything, mock.Anything).Return()
+	return NewDataMigrationService(source, mockAuditService, new(mocks.ConfigService)).(*dataMigrationService)
+}
+
+func sqliteMigrationTarget(path string, dryRun bool) dto.DataMigrationRequest {
+	return dto.DataMigrationRequest{DBTestRequest: dto.DBTestRequest{DBDialect: "sqlite", DBName: path + "?_foreign_keys=on"}, DryRun: dryRun}
+}
+
+func openMigrationTarget(t *testing.T, path string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(path+"?_foreign_keys=on"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestDataMigrationTables_CoverAllModels(t *testing.T) {
+	db := openBackupTestDB(t, "x.db")
+	var names []string
+	for _, table := range dataMigrationTables() {
+		names = append(names, modelTableName(db, table.Model))
+	}
+	for _, model := range append(portableBackupModels(), &models.BackupRun{}) {
+		assert.Contains(t, names, modelTableName(db, model))
+	}
+}
+
+func TestDataMigrationService_MigrateAndVerify(t *testing.T) {
+	source := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, source)
+	service := newTestDataMigrationService(t, source)
+	targetPath := filepath.Join(t.TempDir(), "tujuan.db")
+
+	progressChan := make(chan dto.MigrationProgress, 1000)
+	report, err := service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, false), 1, progressChan)
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	require.Len(t, report.Tables, len(dataMigrationTables()))
+	for _, table := range report.Tables {
+		assert.True(t, table.Match, table.Label)
+		assert.Equal(t, table.SourceChecksum, table.TargetChecksum, table.Label)
+	}
+	close(progressChan)
+	var last dto.MigrationProgress
+	for progress := range progressChan {
+		last = progress
+	}
+	assert.Equal(t, "report", last.Step)
+	assert.Same(t, report, last.Report)
+
+	target := openMigrationTarget(t, targetPath)
+	assertBackupDataRestored(t, target)
+	assert.False(t, target.Migrator().HasTable(&models.DataMigrationProgress{}), "tabel progres dihapus setelah terverifikasi")
+
+	// Checksum mendeteksi perubahan isi walau jumlah baris sama
+	_, before, err := tableChecksum(context.Background(), target, &models.Resident{})
+	require.NoError(t, err)
+	require.NoError(t, target.Model(&models.Resident{}).Where("id = ?", 7).Update("alamat", "Jl. Lain").Error)
+	_, after, err := tableChecksum(context.Background(), target, &models.Resident{})
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
+
+func TestDataMigrationService_ResumesAfterFailure(t *testing.T) {
+	previousBatch := dataMigrationBatchSize
+	dataMigrationBatchSize = 1
+	t.Cleanup(func() { dataMigrationBatchSize = previousBatch })
+
+	source := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, source)
+	require.NoError(t, source.Create(&models.AuditLog{UserID: 1, Aksi: models.AuditBackupCreated, Detail: "kedua", Timestamp: time.Now(), Hash: "h2"}).Error)
+	service := newTestDataMigrationService(t, source)
+	targetPath := filepath.Join(t.TempDir(), "tujuan.db")
+
+	// Target menolak log audit kedua: migrasi pertama berhenti di tengah tabel
+	target := openMigrationTarget(t, targetPath)
+	_, err := NewSchemaMigrationService(target, "sqlite").Up(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, target.Exec("CREATE TRIGGER tolak_audit BEFORE INSERT ON audit_logs WHEN NEW.hash = 'h2' BEGIN SELECT RAISE(ABORT, 'ditolak'); END").Error)
+
+	_, err = service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, false), 1, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Log Audit")
+	var progress models.DataMigrationProgress
+	require.NoError(t, target.First(&progress, "name = ?", "audit_logs").Error)
+	assert.Equal(t, int64(1), progress.Rows)
+	assert.False(t, progress.Done)
+
+	estimate, err := service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, true), 1, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, estimate.Warnings, "dry-run memberi tahu migrasi akan dilanjutkan")
+
+	require.NoError(t, target.Exec("DROP TRIGGER tolak_audit").Error)
+	report, err := service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, false), 1, nil)
+	require.NoError(t, err)
+	assert.True(t, report.Verified)
+	for _, table := range report.Tables {
+		switch table.Table {
+		case "users":
+			assert.True(t, table.Resumed)
+			assert.Zero(t, table.CopiedRows, "tabel yang selesai tidak disalin ulang")
+		case "audit_logs":
+			assert.True(t, table.Resumed)
+			assert.Equal(t, int64(1), table.CopiedRows)
+		}
+	}
+	assertBackupDataRestored(t, target)
+}
+
+func TestDataMigrationService_DryRunAndNonEmptyTarget(t *testing.T) {
+	source := openBackupTestDB(t, "sumber.db")
+	seedBackupData(t, source)
+	service := newTestDataMigrationService(t, source)
+	targetPath := filepath.Join(t.TempDir(), "tujuan.db")
+
+	report, err := service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, true), 1, nil)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.False(t, report.Verified)
+	assert.GreaterOrEqual(t, report.EstimatedSeconds, int64(1))
+	assert.Positive(t, report.TotalRows)
+	for _, table := range report.Tables {
+		assert.Len(t, table.SourceChecksum, 64)
+	}
+	target := openMigrationTarget(t, targetPath)
+	assert.False(t, target.Migrator().HasTable(&models.User{}), "dry-run tidak mengubah target")
+
+	require.NoError(t, target.AutoMigrate(append(portableBackupModels(), &models.BackupRun{})...))
+	require.NoError(t, target.Create(&models.User{NamaLengkap: "Lain", NRP: "999", KataSandi: "x"}).Error)
+	_, err = service.MigrateDataTo(context.Background(), sqliteMigrationTarget(targetPath, false), 1, nil)
+	assert.ErrorIs(t, err, ErrDataMigrationTargetNotEmpty)
+	var users int64
+	require.NoError(t, target.Model(&models.User{}).Count(&users).Error)
+	assert.Equal(t, int64(1), users)
+}
@@ -30,4 +30,182 @@ var (
 	// ErrOldPasswordMismatch dikembalikan saat mengubah kata sandi tetapi
 	// kata sandi lama yang dimasukkan tidak cocok.
 	ErrOldPasswordMismatch = errors.New("kata sandi saat ini yang Anda masukkan salah")
-)
\ No newline at end of file
+
+	// ErrRoleProtected dikembalikan saat mencoba mengubah atau menghapus
+	// peran bawaan sistem yang dilindungi.
+	ErrRoleProtected = errors.New("peran bawaan sistem tidak dapat diubah atau dihapus")
+
+	// ErrRoleInUse dikembalikan saat menghapus peran yang masih dipakai pengguna.
+	ErrRoleInUse = errors.New("peran masih digunakan oleh pengguna")
+
+	// ErrInvalidAPIToken dikembalikan saat token API tidak dikenal,
+	// sudah dicabut, kedaluwarsa, atau pemiliknya nonaktif.
+	ErrInvalidAPIToken = errors.New("token API tidak valid atau sudah kedaluwarsa")
+
+	// ErrUserNotInProvider dikembalikan AuthProvider saat NRP tidak dikenal
+	// oleh provider tersebut, sehingga provider berikutnya boleh mencoba.
+	ErrUserNotInProvider = errors.New("pengguna tidak ditemukan pada provider autentikasi")
+
+	// ErrNoGroupMapping dikembalikan saat akun direktori tidak tergabung
+	// dalam grup mana pun yang dipetakan ke peran SIMDOKPOL.
+	ErrNoGroupMapping = errors.New("akun direktori Anda tidak memiliki akses ke SIMDOKPOL, hubungi admin")
+
+	// ErrExternalAccountNotLinked dikembalikan saat login LDAP / SSO menemukan
+	// akun dengan NRP yang sama tetapi belum ditautkan ke provider tersebut.
+	ErrExternalAccountNotLinked = errors.New("akun dengan NRP ini belum ditautkan ke login direktori/SSO, hubungi Super Admin")
+
+	// ErrInvalidSetting dikembalikan saat nilai pengaturan yang akan disimpan
+	// tidak valid.
+	ErrInvalidSetting = errors.New("pengaturan tidak valid")
+
+	// ErrInvalidGroupMapping dikembalikan saat pemetaan grup LDAP / peran SSO
+	// bukan JSON yang valid.
+	ErrInvalidGroupMapping = errors.New("format pemetaan tidak valid")
+
+	// ErrPrivilegedGroupMapping dikembalikan saat pengguna selain Super Admin
+	// mengubah sumber login LDAP/SSO atau pemetaan grup ke peran Super Admin.
+	ErrPrivilegedGroupMapping = errors.New("hanya Super Admin yang dapat mengubah login LDAP/SSO dan pemetaan grup ke peran Super Admin")
+
+	// ErrDirectoryUnavailable dikembalikan saat server LDAP tidak dapat dihubungi.
+	ErrDirectoryUnavailable = errors.New("server direktori (LDAP) tidak dapat dihubungi")
+
+	// ErrSSODisabled dikembalikan saat SSO (OIDC) belum diaktifkan atau belum lengkap diatur.
+	ErrSSODisabled = errors.New("login SSO belum diaktifkan")
+
+	// ErrSSOProviderUnavailable dikembalikan saat metadata IdP tidak bisa diambil.
+	ErrSSOProviderUnavailable = errors.New("penyedia identitas (SSO) tidak dapat dihubungi")
+
+	// ErrSSOInvalidState dikembalikan saat callback SSO tidak cocok dengan
+	// permintaan login yang dibuat, sudah dipakai, atau kedaluwarsa.
+	ErrSSOInvalidState = errors.New("sesi login SSO tidak valid atau kedaluwarsa, silakan ulangi")
+
+	// ErrSSOLoginFailed dikembalikan saat pertukaran kode atau verifikasi id_token gagal.
+	ErrSSOLoginFailed = errors.New("login SSO gagal")
+
+	// ErrSSOInvalidLogoutToken dikembalikan saat logout_token back-channel tidak valid.
+	ErrSSOInvalidLogoutToken = errors.New("logout_token tidak valid")
+
+	// ErrInvalidAuditFilter dikembalikan saat filter tanggal log audit tidak valid.
+	ErrInvalidAuditFilter = errors.New("filter tanggal tidak valid, gunakan format YYYY-MM-DD")
+
+	// ErrAuditStoreUnavailable dikembalikan writer log audit saat database
+	// belum tersedia; entri dialihkan ke journal.
+	ErrAuditStoreUnavailable = errors.New("penyimpanan log audit tidak tersedia")
+
+	// ErrAuditRetentionDisabled dikembalikan saat retensi dijalankan padahal
+	// audit_retention_days belum diisi (log audit disimpan selamanya).
+	ErrAuditRetentionDisabled = errors.New("retensi log audit belum diatur")
+
+	// ErrAuditArchiveUnavailable dikembalikan bila kunci tanda tangan atau
+	// direktori arsip log audit belum tersedia.
+	ErrAuditArchiveUnavailable = errors.New("arsip log audit tidak tersedia (AUDIT_SIGNING_KEY atau direktori arsip belum diatur)")
+
+	// ErrAuditChainBroken membatalkan retensi bila rantai hash sudah rusak,
+	// agar entri bermasalah tidak ikut terhapus dari database.
+	ErrAuditChainBroken = errors.New("rantai log audit rusak, retensi dibatalkan")
+
+	// ErrAuditArchiveTampered dikembalikan saat isi file arsip tidak cocok
+	// dengan checksum, tanda tangan, atau rantai hash-nya.
+	ErrAuditArchiveTampered = errors.New("arsip log audit tidak valid")
+
+	// ErrBackupMethodUnavailable dikembalikan bila metode backup yang diminta
+	// tidak cocok dengan dialek database atau alatnya tidak terpasang di server.
+	ErrBackupMethodUnavailable = errors.New("metode backup tidak tersedia di server ini")
+
+	// ErrBackupFormatUnsupported dikembalikan saat file restore tidak dikenali
+	// atau tidak bisa dipulihkan ke dialek database yang aktif.
+	ErrBackupFormatUnsupported = errors.New("format file backup tidak dikenali atau tidak cocok dengan database aktif")
+
+	// ErrBackupKeyRequired dikembalikan saat memulihkan backup terenkripsi
+	// tanpa passphrase/file kunci, atau saat enkripsi aktif tapi kuncinya belum diatur.
+	ErrBackupKeyRequired = errors.New("backup terenkripsi: passphrase atau file kunci diperlukan")
+
+	// ErrBackupWrongKey dikembalikan bila kunci yang diberikan bukan kunci
+	// yang dipakai saat backup dibuat.
+	ErrBackupWrongKey = errors.New("passphrase atau file kunci backup salah")
+
+	// ErrBackupTampered dikembalikan bila isi backup terenkripsi gagal
+	// diverifikasi (diubah, terpotong, atau rusak). Database tidak disentuh.
+	ErrBackupTampered = errors.New("file backup terenkripsi rusak atau telah diubah")
+
+	// ErrInvalidBackup dikembalikan bila parameter KDF di header backup
+	// terenkripsi melewati batas yang wajar; KDF tidak dijalankan sama sekali.
+	ErrInvalidBackup = errors.New("parameter kunci backup terenkripsi di luar batas yang diizinkan")
+
+	// ErrBackupNotFound dikembalikan bila nama file tidak ada di folder backup
+	// atau bukan nama file backup yang sah.
+	ErrBackupNotFound = errors.New("file backup tidak ditemukan")
+
+	// ErrBackupValidationFailed dikembalikan saat file restore terbaca tapi
+	// gagal pemeriksaan pra-restore (integritas, versi skema, tabel inti).
+	ErrBackupValidationFailed = errors.New("validasi file restore gagal")
+
+	// ErrRestoreStagingNotFound dikembalikan bila token restore tidak dikenal
+	// atau sudah kedaluwarsa; file harus diunggah dan divalidasi ulang.
+	ErrRestoreStagingNotFound = errors.New("data restore tidak ditemukan atau sudah kedaluwarsa, silakan unggah ulang")
+
+	// ErrRestoreRolledBack dikembalikan bila restore gagal di tengah jalan atau
+	// pemeriksaan setelah restore gagal, dan database sudah dikembalikan.
+	ErrRestoreRolledBack = errors.New("restore gagal dan database dikembalikan ke keadaan sebelumnya")
+
+	// ErrRestoreInProgress dikembalikan bila restore lain sedang berjalan.
+	ErrRestoreInProgress = errors.New("restore lain sedang berjalan")
+
+	// ErrMaintenance dikembalikan ke request yang datang saat database
+	// sedang diganti.
+	ErrMaintenance = errors.New("database sedang dipulihkan, coba lagi sebentar lagi")
+
+	// ErrMaintenanceBusy dikembalikan bila request atau job lain tidak selesai
+	// memakai database dalam batas waktu sebelum restore.
+	ErrMaintenanceBusy = errors.New("masih ada proses lain yang memakai database, coba restore lagi nanti")
+
+	// ErrDataImportModeInvalid dikembalikan bila mode impor bukan merge/replace.
+	ErrDataImportModeInvalid = errors.New("mode impor tidak dikenal, gunakan merge atau replace")
+
+	// ErrDataImportRolledBack dikembalikan bila impor gagal di tengah jalan;
+	// semua baris yang sempat disisipkan dibatalkan.
+	ErrDataImportRolledBack = errors.New("impor data gagal dan dibatalkan seluruhnya")
+
+	// ErrOffsiteNotConfigured dikembalikan bila tujuan backup off-site belum
+	// dipilih atau isian wajibnya (host, bucket, folder) masih kosong.
+	ErrOffsiteNotConfigured = errors.New("tujuan backup off-site belum diatur lengkap")
+
+	// ErrOffsiteHostKeyUnknown dikembalikan saat sidik jari host key server
+	// SFTP belum diisi; pesan error menyertakan sidik jari yang diterima.
+	ErrOffsiteHostKeyUnknown = errors.New("host key server SFTP belum dipercaya")
+
+	// ErrOffsiteHostKeyMismatch dikembalikan bila host key server SFTP tidak
+	// sama dengan sidik jari di pengaturan (server diganti atau disadap).
+	ErrOffsiteHostKeyMismatch = errors.New("host key server SFTP tidak cocok dengan pengaturan")
+
+	// ErrOffsiteUploadFailed dikembalikan bila backup gagal dikirim ke tujuan
+	// off-site setelah semua percobaan ulang.
+	ErrOffsiteUploadFailed = errors.New("gagal mengirim backup ke tujuan off-site")
+
+	// ErrSchemaTooNew dikembalikan bila database sudah dimigrasi oleh versi
+	// aplikasi yang lebih baru; aplikasi lama tidak boleh memakainya.
+	ErrSchemaTooNew = errors.New("skema database lebih baru dari versi aplikasi ini")
+
+	// ErrSchemaDirty dikembalikan bila ada migrasi yang gagal di tengah jalan.
+	// Perbaiki database lalu tandai versinya dengan `migrate force`.
+	ErrSchemaDirty = errors.New("migrasi skema sebelumnya gagal di tengah jalan (dirty)")
+
+	// ErrSchemaLocked dikembalikan bila proses lain masih memegang kunci migrasi.
+	ErrSchemaLocked = errors.New("migrasi skema sedang dijalankan proses lain")
+
+	// ErrServerRunning dikembalikan `admin restore` saat masih ada server
+	// SIMDOKPOL yang memakai database yang sama.
+	ErrServerRunning = errors.New("server SIMDOKPOL masih berjalan memakai database ini, hentikan dulu")
+
+	// ErrMigrationNotFound dikembalikan bila file migrasi untuk versi yang
+	// diminta tidak ada di aplikasi ini.
+	ErrMigrationNotFound = errors.New("file migrasi tidak ditemukan")
+
+	// ErrDataMigrationTargetNotEmpty dikembalikan bila database target migrasi
+	// sudah berisi data pengguna dan bukan kelanjutan migrasi sebelumnya.
+	ErrDataMigrationTargetNotEmpty = errors.New("database target sudah berisi data, gunakan database kosong")
+
+	// ErrDataMigrationMismatch dikembalikan bila jumlah baris atau checksum isi
+	// tabel di target berbeda dengan sumber setelah penyalinan.
+	ErrDataMigrationMismatch = errors.New("verifikasi migrasi data gagal: isi target berbeda dengan sumber")
+)
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	defaultLDAPUserFilter     = "(|(uid=%s)(sAMAccountName=%s))"
+	defaultLDAPNameAttribute  = "displayName"
+	defaultLDAPGroupAttribute = "memberOf"
+	ldapTimeout               = 10 * time.Second
+)
+
+// ldapConn adalah bagian dari *ldap.Conn yang dipakai provider.
+// Dipisah agar bisa diganti direktori in-memory saat pengujian.
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+type ldapDialer func(cfg dto.LDAPConfig) (ldapConn, error)
+
+type ldapAuthProvider struct {
+	cfg          dto.LDAPConfig
+	userRepo     repositories.UserRepository
+	auditService AuditLogService
+	dial         ldapDialer
+}
+
+// NewLDAPAuthProvider membuat provider bind LDAP/Active Directory.
+func NewLDAPAuthProvider(cfg dto.LDAPConfig, userRepo repositories.UserRepository, auditService AuditLogService) AuthProvider {
+	return newLDAPAuthProvider(cfg, userRepo, auditService, dialLDAP)
+}
+
+func newLDAPAuthProvider(cfg dto.LDAPConfig, userRepo repositories.UserRepository, auditService AuditLogService, dial ldapDialer) *ldapAuthProvider {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = defaultLDAPUserFilter
+	}
+	if cfg.NameAttribute == "" {
+		cfg.NameAttribute = defaultLDAPNameAttribute
+	}
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = defaultLDAPGroupAttribute
+	}
+	return &ldapAuthProvider{cfg: cfg, userRepo: userRepo, auditService: auditService, dial: dial}
+}
+
+func (p *ldapAuthProvider) Name() string {
+	return models.AuthSourceLDAP
+}
+
+// ldapTLSConfig menyusun konfigurasi TLS untuk ldaps:// maupun StartTLS.
+func ldapTLSConfig(cfg dto.LDAPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, // #nosec G402 -- opsi eksplisit dari admin untuk lab/uji coba
+	}
+	if u, err := url.Parse(cfg.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if cfg.CACertPath != "" {
+		pem, err := os.ReadFile(cfg.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca sertifikat CA LDAP: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("sertifikat CA LDAP tidak valid")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func dialLDAP(cfg dto.LDAPConfig) (ldapConn, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL LDAP belum diatur")
+	}
+	tlsConfig, err := ldapTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := ldap.DialURL(cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if cfg.StartTLS && !strings.HasPrefix(strings.ToLower(cfg.URL), "ldaps://") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS gagal: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (p *ldapAuthProvider) Authenticate(nrp string, password string) (*models.User, error) {
+	// Password kosong akan menjadi "unauthenticated bind" yang selalu sukses
+	if strings.TrimSpace(nrp) == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := p.dial(p.cfg)
+	if err != nil {
+		log.Printf("WARN: koneksi LDAP gagal: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryUnavailable, err)
+	}
+	defer conn.Close()
+
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			log.Printf("WARN: bind akun layanan LDAP gagal: %v", err)
+			return nil, fmt.Errorf("%w: bind akun layanan gagal", ErrDirectoryUnavailable)
+		}
+	}
+
+	filter := strings.ReplaceAll(p.cfg.UserFilter, "%s", ldap.EscapeFilter(nrp))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		p.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		filter, []string{"dn", p.cfg.NameAttribute, "cn", p.cfg.GroupAttribute}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("pencarian LDAP gagal: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrUserNotInProvider
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("NRP %s ditemukan lebih dari sekali di direktori", nrp)
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	mapping := matchGroupMapping(entry.GetAttributeValues(p.cfg.GroupAttribute), p.cfg.GroupMappings)
+	if mapping == nil {
+		return nil, ErrNoGroupMapping
+	}
+
+	nama := entry.GetAttributeValue(p.cfg.NameAttribute)
+	if nama == "" {
+		nama = entry.GetAttributeValue("cn")
+	}
+	return syncExternalUser(p.userRepo, p.auditService, models.AuthSourceLDAP, nrp, nama, mapping)
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fakeDirectory adalah direktori LDAP in-memory untuk pengujian.
+type fakeDirectory struct {
+	serviceDN   string
+	servicePass string
+	passwords   map[string]string
+	entries     []*ldap.Entry
+	lastFilter  string
+}
+
+func (d *fakeDirectory) Bind(username, password string) error {
+	if username == d.serviceDN && password == d.servicePass {
+		return nil
+	}
+	if pass, ok := d.passwords[username]; ok && pass == password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.lastFilter = req.Filter
+	result := &ldap.SearchResult{}
+	for _, entry := range d.entries {
+		if strings.Contains(req.Filter, "(uid="+entry.GetAttributeValue("uid")+")") {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (d *fakeDirectory) Close() error { return nil }
+
+func newFakeDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		serviceDN:   "cn=simdokpol,dc=polri,dc=go,dc=id",
+		servicePass: "rahasia",
+		passwords: map[string]string{
+			"uid=88010001,ou=people,dc=polri,dc=go,dc=id": "sandi-kanit",
+			"uid=88010002,ou=people,dc=polri,dc=go,dc=id": "sandi-tamu",
+		},
+		entries: []*ldap.Entry{
+			ldap.NewEntry("uid=88010001,ou=people,dc=polri,dc=go,dc=id", map[string][]string{
+				"uid":         {"88010001"},
+				"displayName": {"Budi Santoso"},
+				"memberOf":    {"CN=SPKT-KANIT,OU=Grup,DC=polri,DC=go,DC=id"},
+			}),
+			ldap.NewEntry("uid=88010002,ou=people,dc=polri,dc=go,dc=id", map[string][]string{
+				"uid":      {"88010002"},
+				"cn":       {"Tamu"},
+				"memberOf": {"CN=Humas,OU=Grup,DC=polri,DC=go,DC=id"},
+			}),
+		},
+	}
+}
+
+func testLDAPConfig() dto.LDAPConfig {
+	return dto.LDAPConfig{
+		URL:          "ldap://directory.test",
+		BindDN:       "cn=simdokpol,dc=polri,dc=go,dc=id",
+		BindPassword: "rahasia",
+		BaseDN:       "ou=people,dc=polri,dc=go,dc=id",
+		GroupMappings: []dto.GroupMapping{
+			{Group: "spkt-kanit", Peran: models.RoleKanit, Pangkat: "IPTU", Jabatan: "KANIT SPKT"},
+			{Group: "CN=SPKT-Operator,OU=Grup,DC=polri,DC=go,DC=id", Peran: models.RoleOperator},
+		},
+		AllowLocalFallback: true,
+	}
+}
+
+func TestLDAPAuthProvider_Authenticate(t *testing.T) {
+	t.Run("Berhasil - Buat Pengguna JIT", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		mockAudit := new(mocks.AuditLogService)
+		mockRepo.On("FindByNRP", "88010001").Return(nil, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", mock.MatchedBy(func(u *models.User) bool {
+			return u.NRP == "88010001" && u.NamaLengkap == "Budi Santoso" && u.Peran == models.RoleKanit &&
+				u.Jabatan == "KANIT SPKT" && u.AuthSource == models.AuthSourceLDAP && u.KataSandi != ""
+		})).Return(nil)
+		mockAudit.On("LogActivity", mock.Anything, models.AuditCreateUser, mock.Anything).Return()
+
+		provider := newLDAPAuthProvider(testLDAPConfig(), mockRepo, mockAudit, func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+		user, err := provider.Authenticate("88010001", "sandi-kanit")
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.AuthSourceLDAP, user.AuthSource)
+		mockRepo.AssertExpectations(t)
+		mockAudit.AssertExpectations(t)
+	})
+
+	t.Run("Berhasil - Sinkron Peran Pengguna Lama", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		mockAudit := new(mocks.AuditLogService)
+		existing := &models.User{ID: 7, NRP: "88010001", NamaLengkap: "Budi", Peran: models.RoleOperator, AuthSource: models.AuthSourceLDAP}
+		mockRepo.On("FindByNRP", "88010001").Return(existing, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+			return u.ID == 7 && u.Peran == models.RoleKanit && u.NamaLengkap == "Budi Santoso" && u.AuthSource == models.AuthSourceLDAP
+		})).Return(nil)
+		mockAudit.On("LogActivity", uint(7), models.AuditUpdateUser, mock.Anything).Return()
+
+		provider := newLDAPAuthProvider(testLDAPConfig(), mockRepo, mockAudit, func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+		user, err := provider.Authenticate("88010001", "sandi-kanit")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), user.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Gagal - Akun Lokal Belum Ditautkan", func(t *testing.T) {
+		for _, source := range []string{"", models.AuthSourceLocal, models.AuthSourceOIDC} {
+			dir := newFakeDirectory()
+			mockRepo := new(mocks.UserRepository)
+			local := &models.User{ID: 1, NRP: "88010001", NamaLengkap: "Admin", Peran: models.RoleSuperAdmin, AuthSource: source}
+			mockRepo.On("FindByNRP", "88010001").Return(local, nil)
+			provider := newLDAPAuthProvider(testLDAPConfig(), mockRepo, new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+
+			_, err := provider.Authenticate("88010001", "sandi-kanit")
+			assert.ErrorIs(t, err, ErrExternalAccountNotLinked, "auth_source %q", source)
+			assert.Equal(t, models.RoleSuperAdmin, local.Peran, "peran akun lokal tidak boleh diambil alih")
+			assert.Equal(t, source, local.AuthSource)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		}
+	})
+
+	t.Run("Gagal - Kata Sandi Salah", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		provider := newLDAPAuthProvider(testLDAPConfig(), mockRepo, new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+
+		_, err := provider.Authenticate("88010001", "salah")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "FindByNRP", mock.Anything)
+	})
+
+	t.Run("Gagal - Kata Sandi Kosong", func(t *testing.T) {
+		called := false
+		provider := newLDAPAuthProvider(testLDAPConfig(), new(mocks.UserRepository), new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) {
+			called = true
+			return newFakeDirectory(), nil
+		})
+
+		_, err := provider.Authenticate("88010001", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.False(t, called, "tidak boleh ada bind anonim ke direktori")
+	})
+
+	t.Run("Gagal - Grup Tidak Dipetakan", func(t *testing.T) {
+		dir := newFakeDirectory()
+		provider := newLDAPAuthProvider(testLDAPConfig(), new(mocks.UserRepository), new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+
+		_, err := provider.Authenticate("88010002", "sandi-tamu")
+		assert.ErrorIs(t, err, ErrNoGroupMapping)
+	})
+
+	t.Run("Gagal - Pengguna Lokal Nonaktif", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		inactive := &models.User{ID: 9, NRP: "88010001", DeletedAt: gorm.DeletedAt{Valid: true}}
+		mockRepo.On("FindByNRP", "88010001").Return(inactive, nil)
+		provider := newLDAPAuthProvider(testLDAPConfig(), mockRepo, new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+
+		_, err := provider.Authenticate("88010001", "sandi-kanit")
+		assert.ErrorIs(t, err, ErrAccountInactive)
+	})
+
+	t.Run("Filter Di-escape", func(t *testing.T) {
+		dir := newFakeDirectory()
+		provider := newLDAPAuthProvider(testLDAPConfig(), new(mocks.UserRepository), new(mocks.AuditLogService), func(dto.LDAPConfig) (ldapConn, error) { return dir, nil })
+
+		_, err := provider.Authenticate("*)(uid=*", "apa saja")
+		assert.ErrorIs(t, err, ErrUserNotInProvider)
+		assert.Equal(t, `(|(uid=\2a\29\28uid=\2a)(sAMAccountName=\2a\29\28uid=\2a))`, dir.lastFilter)
+	})
+}
+
+func TestAuthService_Login_LDAP(t *testing.T) {
+	JWTSecretKey = []byte("test-secret")
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+	localAdmin := &models.User{ID: 1, NRP: "00000001", KataSandi: string(hashed), Peran: models.RoleSuperAdmin, AuthSource: models.AuthSourceLocal}
+
+	newService := func(cfg *dto.AppConfig, repo *mocks.UserRepository, dial ldapDialer) AuthService {
+		mockConfig := new(mocks.ConfigService)
+		mockConfig.On("GetConfig").Return(cfg, nil)
+		svc := NewAuthService(repo, mockConfig, new(mocks.AuditLogService)).(*authService)
+		svc.ldapDial = dial
+		return svc
+	}
+
+	t.Run("Fallback Lokal Saat NRP Tidak Ada di Direktori", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("FindByNRP", "00000001").Return(localAdmin, nil)
+		cfg := &dto.AppConfig{AuthProvider: models.AuthSourceLDAP, LDAP: testLDAPConfig()}
+
+		token, err := newService(cfg, mockRepo, func(dto.LDAPConfig) (ldapConn, error) { return dir, nil }).Login("00000001", "admin123")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("Fallback Lokal Saat Direktori Mati", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("FindByNRP", "00000001").Return(localAdmin, nil)
+		cfg := &dto.AppConfig{AuthProvider: models.AuthSourceLDAP, LDAP: testLDAPConfig()}
+
+		token, err := newService(cfg, mockRepo, func(dto.LDAPConfig) (ldapConn, error) {
+			return nil, errors.New("connection refused")
+		}).Login("00000001", "admin123")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("Tanpa Fallback - NRP Lokal Ditolak", func(t *testing.T) {
+		dir := newFakeDirectory()
+		mockRepo := new(mocks.UserRepository)
+		ldapCfg := testLDAPConfig()
+		ldapCfg.AllowLocalFallback = false
+		cfg := &dto.AppConfig{AuthProvider: models.AuthSourceLDAP, LDAP: ldapCfg}
+
+		_, err := newService(cfg, mockRepo, func(dto.LDAPConfig) (ldapConn, error) { return dir, nil }).Login("00000001", "admin123")
+		assert.EqualError(t, err, ErrInvalidCredentials.Error())
+		mockRepo.AssertNotCalled(t, "FindByNRP", mock.Anything)
+	})
+
+	t.Run("Akun LDAP Tidak Bisa Login Lokal", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		ldapUser := &models.User{ID: 5, NRP: "88010001", KataSandi: string(hashed), AuthSource: models.AuthSourceLDAP}
+		mockRepo.On("FindByNRP", "88010001").Return(ldapUser, nil)
+
+		_, err := newService(&dto.AppConfig{AuthProvider: models.AuthSourceLocal}, mockRepo, nil).Login("88010001", "admin123")
+		assert.EqualError(t, err, ErrInvalidCredentials.Error())
+	})
+}
+
+// TestLDAPAuthProvider_Integration menguji bind ke server sungguhan, mis.
+// OpenLDAP lokal via docker. Dilewati bila SIMDOKPOL_TEST_LDAP_URL kosong.
+func TestLDAPAuthProvider_Integration(t *testing.T) {
+	ldapURL := os.Getenv("SIMDOKPOL_TEST_LDAP_URL")
+	if ldapURL == "" {
+		t.Skip("SIMDOKPOL_TEST_LDAP_URL tidak diatur")
+	}
+
+	cfg := dto.LDAPConfig{
+		URL:                ldapURL,
+		StartTLS:           os.Getenv("SIMDOKPOL_TEST_LDAP_STARTTLS") == "true",
+		InsecureSkipVerify: true,
+		BindDN:             os.Getenv("SIMDOKPOL_TEST_LDAP_BIND_DN"),
+		BindPassword:       os.Getenv("SIMDOKPOL_TEST_LDAP_BIND_PASSWORD"),
+		BaseDN:             os.Getenv("SIMDOKPOL_TEST_LDAP_BASE_DN"),
+		UserFilter:         os.Getenv("SIMDOKPOL_TEST_LDAP_FILTER"),
+		GroupMappings: []dto.GroupMapping{
+			{Group: os.Getenv("SIMDOKPOL_TEST_LDAP_GROUP"), Peran: models.RoleOperator},
+		},
+	}
+	nrp := os.Getenv("SIMDOKPOL_TEST_LDAP_USER")
+	password := os.Getenv("SIMDOKPOL_TEST_LDAP_PASSWORD")
+
+	mockRepo := new(mocks.UserRepository)
+	mockAudit := new(mocks.AuditLogService)
+	mockRepo.On("FindByNRP", nrp).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	mockAudit.On("LogActivity", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	provider := NewLDAPAuthProvider(cfg, mockRepo, mockAudit)
+	user, err := provider.Authenticate(nrp, password)
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, nrp, user.NRP)
+	}
+
+	_, err = provider.Authenticate(nrp, password+"-salah")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
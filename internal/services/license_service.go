@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
 	"simdokpol/internal/utils"
@@ -30,7 +32,7 @@ var (
 )
 
 type LicenseService interface {
-	ActivateLicense(key string, actorID uint) (*models.License, error)
+	ActivateLicense(ctx context.Context, key string, actorID uint) (*models.License, error)
 	GetLicenseStatus() (string, error)
 	IsLicensed() bool
 	GetHardwareID() string
@@ -127,17 +129,24 @@ func (s *licenseService) GetLicenseStatus() (string, error) {
 	return config.LicenseStatus, nil
 }
 
-func (s *licenseService) ActivateLicense(inputKey string, actorID uint) (*models.License, error) {
+func (s *licenseService) ActivateLicense(ctx context.Context, inputKey string, actorID uint) (*models.License, error) {
 	cleanInputKey := utils.NormalizeActivationKey(inputKey)
 	hwid := s.GetHardwareID()
 
 	if !utils.VerifyActivationKey(hwid, inputKey) {
 		if actorID != 0 {
-			s.auditService.LogActivity(actorID, "GAGAL AKTIVASI", fmt.Sprintf("Key salah. HWID: %s", hwid))
+			s.auditService.LogEvent(ctx, dto.AuditEvent{
+				UserID:     actorID,
+				Action:     models.AuditLicenseFailed,
+				Detail:     fmt.Sprintf("Key salah. HWID: %s", hwid),
+				EntityType: models.AuditEntityLicense,
+				EntityID:   hwid,
+			})
 		}
 		return nil, ErrLicenseInvalid
 	}
 
+	previousStatus, _ := s.GetLicenseStatus()
 	now := time.Now()
 	actorIDUint := actorID
 
@@ -173,7 +182,15 @@ func (s *licenseService) ActivateLicense(inputKey string, actorID uint) (*models
 	os.Setenv(EnvLicenseKey, formattedKey)
 
 	if actorID != 0 {
-		s.auditService.LogActivity(actorID, "AKTIVASI LISENSI", "Lisensi PRO berhasil diaktifkan.")
+		s.auditService.LogEvent(ctx, dto.AuditEvent{
+			UserID:     actorID,
+			Action:     models.AuditLicenseActivated,
+			Detail:     "Lisensi PRO berhasil diaktifkan.",
+			EntityType: models.AuditEntityLicense,
+			EntityID:   hwid,
+			Before:     map[string]string{"status": previousStatus},
+			After:      map[string]string{"status": LicenseStatusValid},
+		})
 	}
 	return license, nil
 }
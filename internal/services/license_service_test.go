@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"simdokpol/internal/dto"
 	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
 	"simdokpol/internal/utils"
 	"testing"
 
@@ -65,10 +67,12 @@ func TestLicenseService_ActivateLicense(t *testing.T) {
 		mockRepo.On("SaveLicense", mock.Anything).Return(nil).Once()
 
 		mockConfig.On("SaveConfig", map[string]string{"license_status": "VALID"}).Return(nil).Once()
-		mockAudit.On("LogActivity", actorID, "AKTIVASI LISENSI", mock.AnythingOfType("string")).Once()
+		mockAudit.On("LogEvent", mock.Anything, mock.MatchedBy(func(event dto.AuditEvent) bool {
+			return event.UserID == actorID && event.Action == models.AuditLicenseActivated && event.EntityType == models.AuditEntityLicense
+		})).Once()
 
 		service := NewLicenseService(mockRepo, mockConfig, mockAudit)
-		_, err := service.ActivateLicense(validKey, actorID)
+		_, err := service.ActivateLicense(context.Background(), validKey, actorID)
 
 		assert.NoError(t, err)
 	})
@@ -81,10 +85,12 @@ func TestLicenseService_ActivateLicense(t *testing.T) {
 		mockConfig.On("GetConfig").Return(&dto.AppConfig{LicenseStatus: "UNLICENSED"}, nil).Maybe()
 		mockConfig.On("SaveConfig", mock.Anything).Return(nil).Maybe()
 
-		mockAudit.On("LogActivity", actorID, "GAGAL AKTIVASI", mock.AnythingOfType("string")).Once()
+		mockAudit.On("LogEvent", mock.Anything, mock.MatchedBy(func(event dto.AuditEvent) bool {
+			return event.UserID == actorID && event.Action == models.AuditLicenseFailed && event.EntityType == models.AuditEntityLicense
+		})).Once()
 
 		service := NewLicenseService(mockRepo, mockConfig, mockAudit)
-		_, err := service.ActivateLicense(invalidKey, actorID)
+		_, err := service.ActivateLicense(context.Background(), invalidKey, actorID)
 
 		assert.Error(t, err)
 		assert.Equal(t, ErrLicenseInvalid, err)
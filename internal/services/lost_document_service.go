@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -19,17 +20,22 @@ import (
 )
 
 type LostDocumentService interface {
-	CreateLostDocument(residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error)
-	UpdateLostDocument(docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error)
+	CreateLostDocument(ctx context.Context, residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error)
+	UpdateLostDocument(ctx context.Context, docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error)
 	FindAll(query string, statusFilter string) ([]models.LostDocument, error)
 	SearchGlobal(query string, limit int) ([]models.LostDocument, error)
-	FindByID(id uint, actorID uint) (*models.LostDocument, error)
-	DeleteLostDocument(id uint, loggedInUserID uint) error
-	ExportDocuments(query string, statusFilter string) (*bytes.Buffer, string, error)
-	GenerateDocumentPDF(docID uint, actorID uint) (*bytes.Buffer, string, error)
-
-	// FIX: Update Signature Interface (4 Parameter)
-	GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, userID uint, userRole string) (*dto.DataTableResponse, error)
+	// FindByID, FindForPrint, GenerateDocumentPDF dan ExportDocuments membuka
+	// data pribadi penduduk sehingga dicatat sebagai akses baca (lihat read_audit.go)
+	FindByID(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error)
+	FindForPrint(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error)
+	DeleteLostDocument(ctx context.Context, id uint, loggedInUserID uint) error
+	ExportDocuments(ctx context.Context, query string, statusFilter string, actorID uint) (*bytes.Buffer, string, error)
+	GenerateDocumentPDF(ctx context.Context, docID uint, actorID uint) (*bytes.Buffer, string, error)
+	// ResidentAccessReport: siapa saja yang pernah mengakses data penduduk ber-NIK tsb
+	ResidentAccessReport(nik string) (*dto.ResidentAccessReport, error)
+
+	GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, actor *models.User) (*dto.DataTableResponse, error)
+	GetShiftHandover(ctx context.Context, regu string, actor *models.User) ([]models.LostDocument, error)
 }
 
 type lostDocumentService struct {
@@ -39,12 +45,14 @@ type lostDocumentService struct {
 	userRepo      repositories.UserRepository
 	auditService  AuditLogService
 	configService ConfigService
+	roleService   RoleService
 	configRepo    repositories.ConfigRepository
 	exeDir        string
 	docNumMutex   sync.Mutex
+	readGate      *readAuditGate
 }
 
-func NewLostDocumentService(db *gorm.DB, docRepo repositories.LostDocumentRepository, residentRepo repositories.ResidentRepository, userRepo repositories.UserRepository, auditService AuditLogService, configService ConfigService, configRepo repositories.ConfigRepository, exeDir string) LostDocumentService {
+func NewLostDocumentService(db *gorm.DB, docRepo repositories.LostDocumentRepository, residentRepo repositories.ResidentRepository, userRepo repositories.UserRepository, auditService AuditLogService, configService ConfigService, roleService RoleService, configRepo repositories.ConfigRepository, exeDir string) LostDocumentService {
 	return &lostDocumentService{
 		db:            db,
 		docRepo:       docRepo,
@@ -52,13 +60,48 @@ func NewLostDocumentService(db *gorm.DB, docRepo repositories.LostDocumentReposi
 		userRepo:      userRepo,
 		auditService:  auditService,
 		configService: configService,
+		roleService:   roleService,
 		configRepo:    configRepo,
 		exeDir:        exeDir,
+		readGate:      newReadAuditGate(),
+	}
+}
+
+// canAccess: pemilik dokumen selalu boleh, selain itu butuh izin "lintas pemilik" yang sesuai.
+// Kepala regu boleh melihat, mengubah dan mencetak (bukan menghapus) surat anggota regunya.
+func (s *lostDocumentService) canAccess(actor *models.User, doc *models.LostDocument, permission string) bool {
+	if doc.OperatorID == actor.ID {
+		return true
+	}
+	if permission != models.PermDocumentDeleteAll && s.inActorRegu(actor, doc) {
+		return true
+	}
+	return s.roleService.HasPermission(actor, permission)
+}
+
+// inActorRegu: dokumen dibuat oleh anggota regu yang sama dan aktor memegang izin regu.
+func (s *lostDocumentService) inActorRegu(actor *models.User, doc *models.LostDocument) bool {
+	if actor.Regu == "" || doc.Operator.Regu != actor.Regu {
+		return false
 	}
+	return s.roleService.HasPermission(actor, models.PermDocumentRegu)
 }
 
-// FIX: Implementasi dengan 4 Parameter
-func (s *lostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, userID uint, userRole string) (*dto.DataTableResponse, error) {
+// documentScope menerjemahkan izin aktor menjadi batasan query repository.
+func (s *lostDocumentService) documentScope(actor *models.User) repositories.DocumentScope {
+	scope := repositories.DocumentScope{}
+	if actor == nil {
+		return scope
+	}
+	scope.UserID = actor.ID
+	scope.All = s.roleService.HasPermission(actor, models.PermDocumentReadAll)
+	if !scope.All && actor.Regu != "" && s.roleService.HasPermission(actor, models.PermDocumentRegu) {
+		scope.Regu = actor.Regu
+	}
+	return scope
+}
+
+func (s *lostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, statusFilter string, actor *models.User) (*dto.DataTableResponse, error) {
 	appConfig, err := s.configService.GetConfig()
 	if err != nil {
 		return nil, err
@@ -68,8 +111,7 @@ func (s *lostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, status
 		archiveDays = appConfig.ArchiveDurationDays
 	}
 
-	// Passing userID dan userRole ke Repository
-	docs, total, filtered, err := s.docRepo.FindAllPaged(req, statusFilter, archiveDays, userID, userRole)
+	docs, total, filtered, err := s.docRepo.FindAllPaged(req, statusFilter, archiveDays, s.documentScope(actor))
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +126,42 @@ func (s *lostDocumentService) GetDocumentsPaged(req dto.DataTableRequest, status
 	}, nil
 }
 
+// GetShiftHandover mengembalikan surat aktif (belum diarsipkan) buatan regu yang
+// akan diserahterimakan, agar regu pengganti bisa melanjutkan penanganannya.
+// Tanpa PermDocumentReadAll, aktor hanya bisa membuka regunya sendiri.
+func (s *lostDocumentService) GetShiftHandover(ctx context.Context, regu string, actor *models.User) ([]models.LostDocument, error) {
+	regu = strings.TrimSpace(regu)
+	if regu == "" {
+		return nil, errors.New("regu wajib diisi")
+	}
+	if actor == nil {
+		return nil, ErrAccessDenied
+	}
+	if !s.roleService.HasPermission(actor, models.PermDocumentReadAll) {
+		if actor.Regu == "" || regu != actor.Regu || !s.roleService.HasPermission(actor, models.PermDocumentRegu) {
+			return nil, ErrAccessDenied
+		}
+	}
+
+	archiveDays := 15
+	if appConfig, err := s.configService.GetConfig(); err == nil && appConfig != nil && appConfig.ArchiveDurationDays > 0 {
+		archiveDays = appConfig.ArchiveDurationDays
+	}
+	since := time.Now().Add(-time.Duration(archiveDays) * 24 * time.Hour)
+
+	docs, err := s.docRepo.FindActiveByRegu(regu, since)
+	if err != nil {
+		return nil, err
+	}
+	docs, _ = s.processDocsStatus(docs)
+
+	s.auditService.LogActivity(actor.ID, models.AuditShiftHandover, fmt.Sprintf("Menerima serah terima regu %s (%d surat aktif)", regu, len(docs)))
+	for i := range docs {
+		s.logResidentRead(ctx, actor.ID, models.AuditViewResident, &docs[i])
+	}
+	return docs, nil
+}
+
 func (s *lostDocumentService) generateTempNIK() string {
 	timestampNano := time.Now().UnixNano()
 	timestampStr := fmt.Sprintf("%d", timestampNano)
@@ -93,8 +171,8 @@ func (s *lostDocumentService) generateTempNIK() string {
 	return fmt.Sprintf("TEMP%s", timestampStr)
 }
 
-func (s *lostDocumentService) GenerateDocumentPDF(docID uint, actorID uint) (*bytes.Buffer, string, error) {
-	doc, err := s.FindByID(docID, actorID)
+func (s *lostDocumentService) GenerateDocumentPDF(ctx context.Context, docID uint, actorID uint) (*bytes.Buffer, string, error) {
+	doc, err := s.findByID(docID, actorID)
 	if err != nil {
 		return nil, "", err
 	}
@@ -105,10 +183,11 @@ func (s *lostDocumentService) GenerateDocumentPDF(docID uint, actorID uint) (*by
 	}
 
 	buffer, filename := utils.GenerateLostDocumentPDF(doc, config, s.exeDir)
+	s.logResidentRead(ctx, actorID, models.AuditDownloadPDF, doc)
 	return buffer, filename, nil
 }
 
-func (s *lostDocumentService) ExportDocuments(query string, statusFilter string) (*bytes.Buffer, string, error) {
+func (s *lostDocumentService) ExportDocuments(ctx context.Context, query string, statusFilter string, actorID uint) (*bytes.Buffer, string, error) {
 	docs, err := s.FindAll(query, statusFilter)
 	if err != nil {
 		return nil, "", err
@@ -166,10 +245,30 @@ func (s *lostDocumentService) ExportDocuments(query string, statusFilter string)
 	}
 
 	filename := fmt.Sprintf("Export_Dokumen_%s_%s.xlsx", statusFilter, time.Now().In(loc).Format("20060102_150405"))
+	s.logResidentExport(ctx, actorID, docs, filename)
 	return buffer, filename, nil
 }
 
-func (s *lostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocument, error) {
+func (s *lostDocumentService) FindByID(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error) {
+	doc, err := s.findByID(id, actorID)
+	if err != nil {
+		return nil, err
+	}
+	s.logResidentRead(ctx, actorID, models.AuditViewResident, doc)
+	return doc, nil
+}
+
+func (s *lostDocumentService) FindForPrint(ctx context.Context, id uint, actorID uint) (*models.LostDocument, error) {
+	doc, err := s.findByID(id, actorID)
+	if err != nil {
+		return nil, err
+	}
+	s.logResidentRead(ctx, actorID, models.AuditPrintDocument, doc)
+	return doc, nil
+}
+
+// findByID memuat dokumen + cek hak akses tanpa mencatat akses baca.
+func (s *lostDocumentService) findByID(id uint, actorID uint) (*models.LostDocument, error) {
 	doc, err := s.docRepo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -180,7 +279,7 @@ func (s *lostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocum
 		return nil, errors.New("pengguna tidak valid")
 	}
 
-	if actor.Peran != models.RoleSuperAdmin && doc.OperatorID != actorID {
+	if !s.canAccess(actor, doc, models.PermDocumentReadAll) {
 		return nil, ErrAccessDenied
 	}
 
@@ -300,7 +399,41 @@ func formatDocumentNumber(format string, values map[string]string) string {
 	return format
 }
 
-func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
+// documentAuditSnapshot merangkum dokumen untuk before/after log audit
+// (tanpa objek user yang di-preload agar snapshot tetap ringkas).
+func documentAuditSnapshot(doc *models.LostDocument) interface{} {
+	if doc == nil {
+		return nil
+	}
+	items := make([]map[string]interface{}, 0, len(doc.LostItems))
+	for _, item := range doc.LostItems {
+		items = append(items, map[string]interface{}{"nama_barang": item.NamaBarang, "deskripsi": item.Deskripsi})
+	}
+	return map[string]interface{}{
+		"nomor_surat":          doc.NomorSurat,
+		"status":               doc.Status,
+		"lokasi_hilang":        doc.LokasiHilang,
+		"resident":             doc.Resident,
+		"lost_items":           items,
+		"petugas_pelapor_id":   doc.PetugasPelaporID,
+		"pejabat_persetuju_id": doc.PejabatPersetujuID,
+		"operator_id":          doc.OperatorID,
+	}
+}
+
+func (s *lostDocumentService) logDocumentEvent(ctx context.Context, actorID uint, action, detail string, docID uint, before, after interface{}) {
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     action,
+		Detail:     detail,
+		EntityType: models.AuditEntityDocument,
+		EntityID:   strconv.FormatUint(uint64(docID), 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+func (s *lostDocumentService) CreateLostDocument(ctx context.Context, residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
 	var createdDocID uint
 	var finalDocNumber string
 
@@ -360,18 +493,19 @@ func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, i
 	if err != nil {
 		return nil, err
 	}
-
-	s.auditService.LogActivity(operatorID, models.AuditCreateDocument, fmt.Sprintf("Membuat surat keterangan hilang baru dengan nomor: %s", finalDocNumber))
+	documentsIssuedTotal.inc()
 
 	finalDoc, err := s.docRepo.FindByID(createdDocID)
+	s.logDocumentEvent(ctx, operatorID, models.AuditCreateDocument, fmt.Sprintf("Membuat surat keterangan hilang baru dengan nomor: %s", finalDocNumber), createdDocID, nil, documentAuditSnapshot(finalDoc))
 	if err != nil {
 		return nil, err
 	}
 	return finalDoc, nil
 }
 
-func (s *lostDocumentService) UpdateLostDocument(docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error) {
+func (s *lostDocumentService) UpdateLostDocument(ctx context.Context, docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error) {
 	var updatedDoc *models.LostDocument
+	var before interface{}
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		existingDoc, err := s.docRepo.FindByID(docID)
 		if err != nil {
@@ -383,9 +517,10 @@ func (s *lostDocumentService) UpdateLostDocument(docID uint, residentData models
 			return errors.New("pengguna tidak valid")
 		}
 
-		if loggedInUser.Peran != models.RoleSuperAdmin && existingDoc.OperatorID != loggedInUserID {
+		if !s.canAccess(loggedInUser, existingDoc, models.PermDocumentEditAll) {
 			return ErrAccessDenied
 		}
+		before = documentAuditSnapshot(existingDoc)
 
 		if !strings.HasPrefix(existingDoc.Resident.NIK, "TEMP") {
 			residentData.NIK = existingDoc.Resident.NIK
@@ -423,11 +558,11 @@ func (s *lostDocumentService) UpdateLostDocument(docID uint, residentData models
 		return nil, err
 	}
 
-	s.auditService.LogActivity(loggedInUserID, models.AuditUpdateDocument, fmt.Sprintf("Memperbarui dokumen dengan Nomor Surat: %s", updatedDoc.NomorSurat))
+	s.logDocumentEvent(ctx, loggedInUserID, models.AuditUpdateDocument, fmt.Sprintf("Memperbarui dokumen dengan Nomor Surat: %s", updatedDoc.NomorSurat), docID, before, documentAuditSnapshot(updatedDoc))
 	return updatedDoc, nil
 }
 
-func (s *lostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) error {
+func (s *lostDocumentService) DeleteLostDocument(ctx context.Context, id uint, loggedInUserID uint) error {
 	var docToDelete models.LostDocument
 	if err := s.db.First(&docToDelete, id).Error; err != nil {
 		return errors.New("dokumen tidak ditemukan")
@@ -439,7 +574,7 @@ func (s *lostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) e
 			return errors.New("pengguna tidak valid")
 		}
 
-		if loggedInUser.Peran != models.RoleSuperAdmin && docToDelete.OperatorID != loggedInUserID {
+		if !s.canAccess(loggedInUser, &docToDelete, models.PermDocumentDeleteAll) {
 			return ErrAccessDenied
 		}
 		modifiedNomorSurat := fmt.Sprintf("DELETED_%d_%s", time.Now().Unix(), docToDelete.NomorSurat)
@@ -454,7 +589,7 @@ func (s *lostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) e
 	if err != nil {
 		return err
 	}
-	s.auditService.LogActivity(loggedInUserID, models.AuditDeleteDocument, fmt.Sprintf("Menghapus dokumen dengan Nomor Surat: %s", originalNomorSurat))
+	s.logDocumentEvent(ctx, loggedInUserID, models.AuditDeleteDocument, fmt.Sprintf("Menghapus dokumen dengan Nomor Surat: %s", originalNomorSurat), id, documentAuditSnapshot(&docToDelete), nil)
 	return nil
 }
 
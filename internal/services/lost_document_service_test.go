@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"simdokpol/internal/dto"
@@ -84,7 +85,10 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 				dbMock.ExpectCommit()
 
 				auditService.On("SetWaitGroup", mock.AnythingOfType("*sync.WaitGroup")).Once()
-				auditService.On("LogActivity", operatorID, models.AuditCreateDocument, mock.AnythingOfType("string")).Once()
+				auditService.On("LogEvent", mock.Anything, mock.MatchedBy(func(event dto.AuditEvent) bool {
+					return event.UserID == operatorID && event.Action == models.AuditCreateDocument &&
+						event.EntityType == models.AuditEntityDocument && event.EntityID == "101"
+				})).Once()
 
 				finalDoc := &models.LostDocument{ID: 101, NomorSurat: "SKH/1/X/TUK.7.2.1/2025"}
 				docRepo.On("FindByID", uint(101)).Return(finalDoc, nil).Once()
@@ -135,12 +139,12 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 
 			tc.setupMocks(dbMock, mockDocRepo, mockResRepo, mockUserRepo, mockAuditService, mockConfigService, mockConfigRepo)
 
-			service := NewLostDocumentService(db, mockDocRepo, mockResRepo, mockUserRepo, mockAuditService, mockConfigService, mockConfigRepo, "")
+			service := NewLostDocumentService(db, mockDocRepo, mockResRepo, mockUserRepo, mockAuditService, mockConfigService, new(mocks.RoleService), mockConfigRepo, "")
 
 			var wg sync.WaitGroup
 			mockAuditService.SetWaitGroup(&wg) 
 			
-			_, err := service.CreateLostDocument(residentData, items, operatorID, "Jalan Sudirman", petugasPelaporID, pejabatPersetujuID)
+			_, err := service.CreateLostDocument(context.Background(), residentData, items, operatorID, "Jalan Sudirman", petugasPelaporID, pejabatPersetujuID)
 
 			wg.Wait() 
 			
@@ -158,4 +162,102 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 			assert.NoError(t, dbMock.ExpectationsWereMet())
 		})
 	}
-}
\ No newline at end of file
+}
+func TestLostDocumentService_FindByID_ReguScope(t *testing.T) {
+	kanit := &models.User{ID: 10, Peran: models.RoleKanit, Regu: "II"}
+
+	testCases := []struct {
+		name        string
+		operator    models.User
+		hasRegu     bool
+		hasReadAll  bool
+		expectError error
+	}{
+		{name: "Success - Surat anggota satu regu", operator: models.User{ID: 20, Regu: "II"}, hasRegu: true},
+		{name: "Failure - Surat regu lain", operator: models.User{ID: 21, Regu: "III"}, hasRegu: true, expectError: ErrAccessDenied},
+		{name: "Failure - Tanpa izin regu", operator: models.User{ID: 20, Regu: "II"}, hasRegu: false, expectError: ErrAccessDenied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDocRepo := new(mocks.LostDocumentRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			mockConfigService := new(mocks.ConfigService)
+			mockRoleService := new(mocks.RoleService)
+
+			doc := &models.LostDocument{ID: 1, OperatorID: tc.operator.ID, Operator: tc.operator}
+			mockDocRepo.On("FindByID", uint(1)).Return(doc, nil).Once()
+			mockUserRepo.On("FindByID", kanit.ID).Return(kanit, nil).Once()
+			mockRoleService.On("HasPermission", kanit, models.PermDocumentRegu).Return(tc.hasRegu).Maybe()
+			mockRoleService.On("HasPermission", kanit, models.PermDocumentReadAll).Return(tc.hasReadAll).Maybe()
+			mockConfigService.On("GetConfig").Return(&dto.AppConfig{ArchiveDurationDays: 15}, nil).Maybe()
+
+			service := NewLostDocumentService(nil, mockDocRepo, new(mocks.ResidentRepository), mockUserRepo, new(mocks.AuditLogService), mockConfigService, mockRoleService, new(mocks.ConfigRepository), "")
+
+			result, err := service.FindByID(context.Background(), 1, kanit.ID)
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), result.ID)
+			}
+		})
+	}
+}
+
+func TestLostDocumentService_GetShiftHandover(t *testing.T) {
+	kanit := &models.User{ID: 10, Peran: models.RoleKanit, Regu: "II"}
+	docs := []models.LostDocument{
+		{ID: 1, NomorSurat: "SKH/1/I/2025", ResidentID: 7, Status: "DITERBITKAN", TanggalLaporan: time.Now()},
+		{ID: 2, NomorSurat: "SKH/2/I/2025", ResidentID: 8, Status: "DITERBITKAN", TanggalLaporan: time.Now()},
+	}
+
+	testCases := []struct {
+		name        string
+		regu        string
+		hasReadAll  bool
+		expectError error
+	}{
+		{name: "Success - Regu sendiri", regu: "II"},
+		{name: "Failure - Regu lain", regu: "III", expectError: ErrAccessDenied},
+		{name: "Success - Regu lain dengan izin baca semua", regu: "III", hasReadAll: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDocRepo := new(mocks.LostDocumentRepository)
+			mockAuditService := new(mocks.AuditLogService)
+			mockConfigService := new(mocks.ConfigService)
+			mockRoleService := new(mocks.RoleService)
+
+			mockRoleService.On("HasPermission", kanit, models.PermDocumentRegu).Return(true).Maybe()
+			mockRoleService.On("HasPermission", kanit, models.PermDocumentReadAll).Return(tc.hasReadAll).Maybe()
+			mockConfigService.On("GetConfig").Return(&dto.AppConfig{ArchiveDurationDays: 15, AuditReadEnabled: true, AuditReadSamplePercent: 100}, nil).Maybe()
+			if tc.expectError == nil {
+				mockDocRepo.On("FindActiveByRegu", tc.regu, mock.AnythingOfType("time.Time")).Return(append([]models.LostDocument(nil), docs...), nil).Once()
+				mockAuditService.On("LogActivity", kanit.ID, models.AuditShiftHandover, mock.Anything).Return().Once()
+				for _, residentID := range []string{"7", "8"} {
+					residentID := residentID
+					mockAuditService.On("LogEvent", mock.Anything, mock.MatchedBy(func(e dto.AuditEvent) bool {
+						return e.Action == models.AuditViewResident && e.UserID == kanit.ID && e.EntityID == residentID
+					})).Return().Once()
+				}
+			}
+
+			service := NewLostDocumentService(nil, mockDocRepo, new(mocks.ResidentRepository), new(mocks.UserRepository), mockAuditService, mockConfigService, mockRoleService, new(mocks.ConfigRepository), "")
+
+			result, err := service.GetShiftHandover(context.Background(), tc.regu, kanit)
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				assert.Nil(t, result)
+				mockDocRepo.AssertNotCalled(t, "FindActiveByRegu", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, result, 2)
+			}
+			mockDocRepo.AssertExpectations(t)
+			mockAuditService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// maintenanceDrainTimeout batas menunggu request dan job yang sedang
+	// memakai database selesai sebelum restore dibatalkan
+	maintenanceDrainTimeout = 30 * time.Second
+	maintenanceDrainPoll    = 50 * time.Millisecond
+)
+
+// Maintenance gerbang database proses ini. Request HTTP masuk lewat
+// middleware, writer log audit dan job latar belakang lewat Hold, sehingga
+// restore SQLite bisa mengganti file dan pool koneksi tanpa ada yang sedang
+// memakainya.
+var Maintenance = &MaintenanceGate{}
+
+// MaintenanceGate menghitung pemakai database yang sedang berjalan dan
+// menahan pemakai baru selama maintenance.
+type MaintenanceGate struct {
+	mu       sync.Mutex
+	active   bool
+	inFlight int
+	resumed  chan struct{} // ditutup saat maintenance selesai
+}
+
+type maintenanceTicketKey struct{}
+
+// maintenanceTicket menandai context yang sudah masuk gerbang, supaya
+// Begin dari request yang sama tidak menunggu dirinya sendiri.
+type maintenanceTicket struct{ gate *MaintenanceGate }
+
+// Enter dipakai request HTTP: langsung ErrMaintenance bila database sedang
+// diganti. leave wajib dipanggil setelah request selesai.
+func (g *MaintenanceGate) Enter(ctx context.Context) (context.Context, func(), error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active {
+		return ctx, nil, ErrMaintenance
+	}
+	g.inFlight++
+	return context.WithValue(ctx, maintenanceTicketKey{}, &maintenanceTicket{gate: g}), g.leave, nil
+}
+
+// Hold dipakai writer log audit dan job latar belakang: menunggu
+// maintenance selesai lalu masuk.
+func (g *MaintenanceGate) Hold(ctx context.Context) (func(), error) {
+	for {
+		g.mu.Lock()
+		if !g.active {
+			g.inFlight++
+			g.mu.Unlock()
+			return g.leave, nil
+		}
+		resumed := g.resumed
+		g.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (g *MaintenanceGate) leave() {
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+}
+
+// Begin menutup gerbang lalu menunggu pemakai yang sedang berjalan selesai
+// (request pemanggil sendiri tidak dihitung). Bila dalam
+// maintenanceDrainTimeout masih ada yang berjalan, gerbang dibuka lagi dan
+// ErrMaintenanceBusy dikembalikan.
+func (g *MaintenanceGate) Begin(ctx context.Context) (end func(), err error) {
+	self := 0
+	if ticket, ok := ctx.Value(maintenanceTicketKey{}).(*maintenanceTicket); ok && ticket.gate == g {
+		self = 1
+	}
+
+	g.mu.Lock()
+	if g.active {
+		g.mu.Unlock()
+		return nil, ErrMaintenance
+	}
+	g.active = true
+	g.resumed = make(chan struct{})
+	g.mu.Unlock()
+
+	end = func() {
+		g.mu.Lock()
+		g.active = false
+		close(g.resumed)
+		g.mu.Unlock()
+	}
+
+	deadline := time.Now().Add(maintenanceDrainTimeout)
+	for {
+		g.mu.Lock()
+		busy := g.inFlight - self
+		g.mu.Unlock()
+		if busy <= 0 {
+			return end, nil
+		}
+		if time.Now().After(deadline) {
+			end()
+			return nil, ErrMaintenanceBusy
+		}
+		select {
+		case <-ctx.Done():
+			end()
+			return nil, ctx.Err()
+		case <-time.After(maintenanceDrainPoll):
+		}
+	}
+}
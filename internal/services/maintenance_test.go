@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceGate(t *testing.T) {
+	gate := &MaintenanceGate{}
+
+	// Request yang sedang berjalan ditunggu; request restore sendiri tidak
+	_, leaveOther, err := gate.Enter(context.Background())
+	require.NoError(t, err)
+	restoreCtx, leaveRestore, err := gate.Enter(context.Background())
+	require.NoError(t, err)
+	defer leaveRestore()
+
+	started := make(chan func())
+	go func() {
+		end, err := gate.Begin(restoreCtx)
+		assert.NoError(t, err)
+		started <- end
+	}()
+
+	require.Eventually(t, func() bool {
+		_, leave, err := gate.Enter(context.Background())
+		if err == nil {
+			leave()
+		}
+		return err == ErrMaintenance
+	}, time.Second, 5*time.Millisecond, "request baru ditolak selama menunggu")
+	select {
+	case <-started:
+		t.Fatal("Begin tidak boleh selesai sebelum request lain keluar")
+	case <-time.After(100 * time.Millisecond):
+	}
+	leaveOther()
+	end := <-started
+
+	// Job latar belakang menunggu sampai maintenance selesai
+	held := make(chan struct{})
+	go func() {
+		leave, err := gate.Hold(context.Background())
+		assert.NoError(t, err)
+		leave()
+		close(held)
+	}()
+	select {
+	case <-held:
+		t.Fatal("Hold harus menunggu maintenance selesai")
+	case <-time.After(100 * time.Millisecond):
+	}
+	end()
+	<-held
+
+	_, leave, err := gate.Enter(context.Background())
+	require.NoError(t, err)
+	leave()
+}
+
+func TestMaintenanceGate_BeginCanceled(t *testing.T) {
+	gate := &MaintenanceGate{}
+	_, leave, err := gate.Enter(context.Background())
+	require.NoError(t, err)
+	defer leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = gate.Begin(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, leaveNext, err := gate.Enter(context.Background())
+	require.NoError(t, err, "gerbang dibuka lagi setelah Begin gagal")
+	leaveNext()
+}
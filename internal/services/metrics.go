@@ -0,0 +1,216 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrik proses untuk /metrics (format teks Prometheus 0.0.4). Sengaja
+// tanpa client_golang: cukup counter dan histogram berlabel di sini, gauge
+// dihitung saat scrape oleh MetricsService.
+var (
+	httpRequestDuration = newMetricVec("simdokpol_http_request_duration_seconds", "histogram",
+		"Durasi request HTTP per rute.", httpLatencyBuckets, "method", "route", "status")
+	backupRunsTotal = newMetricVec("simdokpol_backup_runs_total", "counter",
+		"Backup yang dijalankan menurut jenis, metode dan hasil.", nil, "kind", "method", "status")
+	backupOffsiteTotal = newMetricVec("simdokpol_backup_offsite_uploads_total", "counter",
+		"Pengiriman backup ke tujuan off-site menurut hasil.", nil, "status")
+	auditArchiveRunsTotal = newMetricVec("simdokpol_audit_archive_runs_total", "counter",
+		"Jalannya retensi (pengarsipan) log audit menurut hasil.", nil, "status")
+	auditArchivedEntriesTotal = newMetricVec("simdokpol_audit_archived_entries_total", "counter",
+		"Entri log audit yang dipindah ke file arsip.", nil)
+	documentsIssuedTotal = newMetricVec("simdokpol_documents_issued_total", "counter",
+		"Surat keterangan hilang yang diterbitkan sejak proses start.", nil)
+
+	// lastAuditArchiveSuccess waktu (unix) retensi log audit terakhir berhasil
+	lastAuditArchiveSuccess atomic.Int64
+	processStartedAt        = time.Now()
+)
+
+// httpLatencyBuckets batas bucket histogram latensi dalam detik, sama dengan
+// bawaan client Prometheus.
+var httpLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const (
+	metricResultSuccess = "success"
+	metricResultFailure = "failure"
+)
+
+// ObserveHTTPRequest dicatat middleware metrik setelah request selesai.
+// route adalah pola rute gin (mis. /api/documents/:id), bukan path asli,
+// agar jumlah deret tetap kecil.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequestDuration.observe(duration.Seconds(), method, route, strconv.Itoa(status))
+}
+
+func metricResult(err error) string {
+	if err != nil {
+		return metricResultFailure
+	}
+	return metricResultSuccess
+}
+
+type metricSeries struct {
+	labelValues []string
+	value       float64  // counter
+	buckets     []uint64 // histogram, belum kumulatif
+	sum         float64
+	count       uint64
+}
+
+type metricVec struct {
+	name    string
+	kind    string
+	help    string
+	buckets []float64
+	labels  []string
+
+	mu     sync.Mutex
+	series map[string]*metricSeries
+}
+
+func newMetricVec(name, kind, help string, buckets []float64, labels ...string) *metricVec {
+	return &metricVec{name: name, kind: kind, help: help, buckets: buckets, labels: labels, series: map[string]*metricSeries{}}
+}
+
+func (m *metricVec) get(labelValues []string) *metricSeries {
+	key := strings.Join(labelValues, "\xff")
+	series, ok := m.series[key]
+	if !ok {
+		series = &metricSeries{labelValues: labelValues, buckets: make([]uint64, len(m.buckets))}
+		m.series[key] = series
+	}
+	return series
+}
+
+func (m *metricVec) add(value float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(labelValues).value += value
+}
+
+func (m *metricVec) inc(labelValues ...string) {
+	m.add(1, labelValues...)
+}
+
+func (m *metricVec) observe(value float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := m.get(labelValues)
+	for i, bound := range m.buckets {
+		if value <= bound {
+			series.buckets[i]++
+			break
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+// write menulis semua deret, urut label agar keluaran stabil.
+func (m *metricVec) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+	if m.kind == "counter" && len(m.labels) == 0 && len(keys) == 0 {
+		fmt.Fprintf(w, "%s 0\n", m.name)
+		return
+	}
+	for _, key := range keys {
+		series := m.series[key]
+		pairs := make([]string, 0, 2*len(m.labels)+2)
+		for i, label := range m.labels {
+			pairs = append(pairs, label, series.labelValues[i])
+		}
+		if m.kind != "histogram" {
+			writeSample(w, m.name, series.value, pairs...)
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += series.buckets[i]
+			writeSample(w, m.name+"_bucket", float64(cumulative), append(pairs, "le", formatMetricValue(bound))...)
+		}
+		writeSample(w, m.name+"_bucket", float64(series.count), append(pairs, "le", "+Inf")...)
+		writeSample(w, m.name+"_sum", series.sum, pairs...)
+		writeSample(w, m.name+"_count", float64(series.count), pairs...)
+	}
+}
+
+// metricSample satu deret gauge; labels berisi pasangan nama, nilai.
+type metricSample struct {
+	labels []string
+	value  float64
+}
+
+func writeGauge(w io.Writer, name, help string, samples ...metricSample) {
+	writeFamily(w, name, "gauge", help, samples)
+}
+
+// writeCounter untuk counter yang nilainya sudah dihitung di tempat lain
+// (mis. statistik pool database).
+func writeCounter(w io.Writer, name, help string, samples ...metricSample) {
+	writeFamily(w, name, "counter", help, samples)
+}
+
+func writeFamily(w io.Writer, name, kind, help string, samples []metricSample) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	for _, sample := range samples {
+		writeSample(w, name, sample.value, sample.labels...)
+	}
+}
+
+func writeSample(w io.Writer, name string, value float64, labelPairs ...string) {
+	if len(labelPairs) == 0 {
+		fmt.Fprintf(w, "%s %s\n", name, formatMetricValue(value))
+		return
+	}
+	pairs := make([]string, 0, len(labelPairs)/2)
+	for i := 0; i+1 < len(labelPairs); i += 2 {
+		pairs = append(pairs, labelPairs[i]+`="`+escapeLabelValue(labelPairs[i+1])+`"`)
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatMetricValue(value))
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func boolMetric(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func timestampMetric(t *time.Time) float64 {
+	if t == nil || t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixMilli()) / 1000
+}
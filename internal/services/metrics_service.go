@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type MetricsService interface {
+	// WritePrometheus menulis semua metrik dalam format teks Prometheus.
+	// Hanya satu query ringan (surat hari ini) yang menyentuh tabel data.
+	WritePrometheus(ctx context.Context, w io.Writer) error
+}
+
+type metricsService struct {
+	db             *gorm.DB
+	auditService   AuditLogService
+	backupService  BackupService
+	licenseService LicenseService
+	configService  ConfigService
+}
+
+// NewMetricsService menerima db nil (mode setup); metrik yang butuh
+// database lalu dilewati dan simdokpol_db_up bernilai 0.
+func NewMetricsService(db *gorm.DB, auditService AuditLogService, backupService BackupService, licenseService LicenseService, configService ConfigService) MetricsService {
+	return &metricsService{
+		db:             db,
+		auditService:   auditService,
+		backupService:  backupService,
+		licenseService: licenseService,
+		configService:  configService,
+	}
+}
+
+func (s *metricsService) WritePrometheus(ctx context.Context, w io.Writer) error {
+	writeGauge(w, "simdokpol_build_info", "Versi aplikasi yang berjalan.", metricSample{labels: []string{"version", AppVersion}, value: 1})
+	writeGauge(w, "simdokpol_process_start_time_seconds", "Waktu proses dimulai (unix).", metricSample{value: timestampMetric(&processStartedAt)})
+	httpRequestDuration.write(w)
+
+	s.writeDatabase(ctx, w)
+	s.writeAudit(w)
+	if s.db == nil {
+		return nil
+	}
+	s.writeBackup(w)
+	s.writeDocuments(ctx, w)
+	s.writeLicense(w)
+	return nil
+}
+
+func (s *metricsService) writeDatabase(ctx context.Context, w io.Writer) {
+	if s.db == nil {
+		writeGauge(w, "simdokpol_db_up", "1 bila database bisa dihubungi.", metricSample{value: 0})
+		return
+	}
+	sqlDB, err := s.db.DB()
+	up := err == nil && sqlDB.PingContext(ctx) == nil
+	writeGauge(w, "simdokpol_db_up", "1 bila database bisa dihubungi.", metricSample{value: boolMetric(up)})
+	if err != nil {
+		return
+	}
+
+	stats := sqlDB.Stats()
+	writeGauge(w, "simdokpol_db_connections", "Koneksi pool database menurut status.",
+		metricSample{labels: []string{"state", "in_use"}, value: float64(stats.InUse)},
+		metricSample{labels: []string{"state", "idle"}, value: float64(stats.Idle)},
+	)
+	writeGauge(w, "simdokpol_db_connections_max_open", "Batas koneksi terbuka pool database.", metricSample{value: float64(stats.MaxOpenConnections)})
+	writeCounter(w, "simdokpol_db_wait_count_total", "Request yang harus menunggu koneksi pool.", metricSample{value: float64(stats.WaitCount)})
+	writeCounter(w, "simdokpol_db_wait_duration_seconds_total", "Total waktu menunggu koneksi pool.", metricSample{value: stats.WaitDuration.Seconds()})
+	writeCounter(w, "simdokpol_db_connections_closed_total", "Koneksi pool yang ditutup menurut alasan.",
+		metricSample{labels: []string{"reason", "max_idle"}, value: float64(stats.MaxIdleClosed)},
+		metricSample{labels: []string{"reason", "max_idle_time"}, value: float64(stats.MaxIdleTimeClosed)},
+		metricSample{labels: []string{"reason", "max_lifetime"}, value: float64(stats.MaxLifetimeClosed)},
+	)
+}
+
+func (s *metricsService) writeAudit(w io.Writer) {
+	if s.auditService != nil {
+		stats := s.auditService.WriterStats()
+		writeGauge(w, "simdokpol_audit_queue_length", "Entri log audit yang menunggu ditulis.", metricSample{value: float64(stats.QueueLength)})
+		writeGauge(w, "simdokpol_audit_queue_capacity", "Kapasitas antrean log audit.", metricSample{value: float64(stats.QueueCapacity)})
+		writeGauge(w, "simdokpol_audit_journal_pending", "Entri di journal disk yang belum masuk database.", metricSample{value: float64(stats.JournalPending)})
+		writeCounter(w, "simdokpol_audit_entries_total", "Entri log audit menurut nasibnya sejak proses start.",
+			metricSample{labels: []string{"result", "written"}, value: float64(stats.Written)},
+			metricSample{labels: []string{"result", "failed"}, value: float64(stats.Failed)},
+			metricSample{labels: []string{"result", "spilled"}, value: float64(stats.Spilled)},
+			metricSample{labels: []string{"result", "replayed"}, value: float64(stats.Replayed)},
+			metricSample{labels: []string{"result", "dropped"}, value: float64(stats.Dropped)},
+			metricSample{labels: []string{"result", "dead_lettered"}, value: float64(stats.DeadLettered)},
+		)
+
+		sinks := s.auditService.SinkStatus()
+		pending := make([]metricSample, 0, len(sinks))
+		failed := make([]metricSample, 0, len(sinks))
+		for _, sink := range sinks {
+			pending = append(pending, metricSample{labels: []string{"sink", sink.Name}, value: float64(sink.Pending)})
+			failed = append(failed, metricSample{labels: []string{"sink", sink.Name}, value: float64(sink.Failed + sink.Dropped)})
+		}
+		writeGauge(w, "simdokpol_audit_sink_pending", "Entri yang menunggu diteruskan ke syslog/file.", pending...)
+		writeCounter(w, "simdokpol_audit_sink_failures_total", "Entri yang gagal atau dibuang saat diteruskan.", failed...)
+	}
+
+	auditArchiveRunsTotal.write(w)
+	auditArchivedEntriesTotal.write(w)
+	writeGauge(w, "simdokpol_audit_archive_last_success_timestamp_seconds", "Retensi log audit terakhir yang berhasil (unix, 0 = belum pernah sejak start).",
+		metricSample{value: float64(lastAuditArchiveSuccess.Load())})
+}
+
+func (s *metricsService) writeBackup(w io.Writer) {
+	backupRunsTotal.write(w)
+	backupOffsiteTotal.write(w)
+	if s.backupService == nil {
+		return
+	}
+	status, err := s.backupService.Status()
+	if err != nil {
+		return
+	}
+	var lastSuccess *time.Time
+	if status.LastSuccess != nil {
+		lastSuccess = &status.LastSuccess.FinishedAt
+	}
+	writeGauge(w, "simdokpol_backup_last_success_timestamp_seconds", "Backup terakhir yang berhasil (unix, 0 = belum ada).", metricSample{value: timestampMetric(lastSuccess)})
+	writeGauge(w, "simdokpol_backup_next_run_timestamp_seconds", "Jadwal backup berikutnya (unix, 0 = jadwal nonaktif).", metricSample{value: timestampMetric(status.NextRunAt)})
+	writeGauge(w, "simdokpol_backup_failing", "1 bila backup terjadwal terakhir gagal.", metricSample{value: boolMetric(status.Failing)})
+	writeGauge(w, "simdokpol_backup_offsite_failing", "1 bila backup terakhir gagal dikirim off-site.", metricSample{value: boolMetric(status.OffsiteFailing)})
+}
+
+func (s *metricsService) writeDocuments(ctx context.Context, w io.Writer) {
+	documentsIssuedTotal.write(w)
+
+	loc := time.Local
+	if s.configService != nil {
+		if configured, err := s.configService.GetLocation(); err == nil {
+			loc = configured
+		}
+	}
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	var today int64
+	if err := s.db.WithContext(ctx).Model(&models.LostDocument{}).Where("tanggal_laporan >= ?", startOfDay).Count(&today).Error; err != nil {
+		return
+	}
+	writeGauge(w, "simdokpol_documents_issued_today", "Surat yang diterbitkan hari ini (zona waktu kantor).", metricSample{value: float64(today)})
+}
+
+func (s *metricsService) writeLicense(w io.Writer) {
+	if s.licenseService == nil {
+		return
+	}
+	status, err := s.licenseService.GetLicenseStatus()
+	if err != nil {
+		return
+	}
+	writeGauge(w, "simdokpol_license_valid", "1 bila lisensi aktif dan valid.", metricSample{value: boolMetric(status == LicenseStatusValid)})
+	writeGauge(w, "simdokpol_license_status", "Status lisensi (nilai selalu 1, status di label).", metricSample{labels: []string{"status", status}, value: 1})
+}
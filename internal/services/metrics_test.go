@@ -0,0 +1,86 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func TestMetricVec_HistogramBucketsCumulative(t *testing.T) {
+	vec := newMetricVec("uji_durasi_seconds", "histogram", "Uji.", []float64{0.1, 1}, "route")
+	vec.observe(0.05, "/api/a")
+	vec.observe(0.5, "/api/a")
+	vec.observe(3, "/api/a")
+
+	var buf bytes.Buffer
+	vec.write(&buf)
+	assert.Equal(t, "# HELP uji_durasi_seconds Uji.\n# TYPE uji_durasi_seconds histogram\n"+
+		"uji_durasi_seconds_bucket{route=\"/api/a\",le=\"0.1\"} 1\n"+
+		"uji_durasi_seconds_bucket{route=\"/api/a\",le=\"1\"} 2\n"+
+		"uji_durasi_seconds_bucket{route=\"/api/a\",le=\"+Inf\"} 3\n"+
+		"uji_durasi_seconds_sum{route=\"/api/a\"} 3.55\n"+
+		"uji_durasi_seconds_count{route=\"/api/a\"} 3\n", buf.String())
+}
+
+func TestMetricVec_CounterLabelsEscapedAndUnlabeledZero(t *testing.T) {
+	vec := newMetricVec("uji_total", "counter", "Uji.", nil, "status")
+	vec.inc("a\"b\\c\nd")
+	vec.add(2, "a\"b\\c\nd")
+
+	var buf bytes.Buffer
+	vec.write(&buf)
+	assert.Contains(t, buf.String(), `uji_total{status="a\"b\\c\nd"} 3`+"\n")
+
+	buf.Reset()
+	newMetricVec("uji_kosong_total", "counter", "Uji.", nil).write(&buf)
+	assert.Contains(t, buf.String(), "uji_kosong_total 0\n", "counter tanpa label tetap muncul sebelum ada kejadian")
+}
+
+func TestMetricResult(t *testing.T) {
+	assert.Equal(t, metricResultSuccess, metricResult(nil))
+	assert.Equal(t, metricResultFailure, metricResult(errors.New("gagal")))
+}
+
+func TestMetricsService_WritePrometheus(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.LostDocument{}))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	for i, reported := range []time.Time{time.Now(), time.Now().AddDate(0, 0, -3)} {
+		doc := models.LostDocument{NomorSurat: fmt.Sprintf("SKH/%d", i), TanggalLaporan: reported, ResidentID: 1, PetugasPelaporID: 1, OperatorID: 1}
+		require.NoError(t, db.Omit(clause.Associations).Create(&doc).Error)
+	}
+
+	ObserveHTTPRequest("GET", "/api/documents/:id", 200, 20*time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewMetricsService(db, nil, nil, nil, nil).WritePrometheus(context.Background(), &buf))
+	out := buf.String()
+	assert.Contains(t, out, "simdokpol_db_up 1\n")
+	assert.Contains(t, out, "# TYPE simdokpol_http_request_duration_seconds histogram\n")
+	assert.Contains(t, out, `simdokpol_http_request_duration_seconds_bucket{method="GET",route="/api/documents/:id",status="200",le="0.025"}`)
+	assert.Contains(t, out, "simdokpol_documents_issued_today 1\n", "surat kemarin tidak dihitung")
+	assert.Contains(t, out, "# TYPE simdokpol_backup_runs_total counter\n")
+	assert.Contains(t, out, "simdokpol_audit_archive_last_success_timestamp_seconds")
+
+	buf.Reset()
+	require.NoError(t, NewMetricsService(nil, nil, nil, nil, nil).WritePrometheus(context.Background(), &buf))
+	assert.Contains(t, buf.String(), "simdokpol_db_up 0\n")
+	assert.NotContains(t, buf.String(), "simdokpol_documents_issued_today", "tanpa database metrik data dilewati")
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultOIDCNRPClaim  = "preferred_username"
+	defaultOIDCNameClaim = "name"
+	defaultOIDCRoleClaim = "groups"
+	oidcLoginTTL         = 10 * time.Minute
+
+	backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+)
+
+// OIDCLoginResult adalah hasil callback SSO yang berhasil.
+type OIDCLoginResult struct {
+	Token   string // JWT sesi SIMDOKPOL
+	IDToken string // id_token mentah untuk id_token_hint saat logout
+	User    *models.User
+}
+
+type OIDCService interface {
+	IsEnabled() bool
+	ButtonLabel() string
+	BeginLogin(ctx context.Context, redirectURL string) (authURL string, state string, err error)
+	CompleteLogin(ctx context.Context, state string, code string) (*OIDCLoginResult, error)
+	LogoutURL(ctx context.Context, idTokenHint string, postLogoutRedirect string) (string, error)
+	HandleBackchannelLogout(ctx context.Context, logoutToken string) error
+}
+
+// pendingOIDCLogin menyimpan verifier PKCE dan nonce antara redirect ke IdP
+// dan callback. Disimpan di memori karena aplikasi berjalan satu instance.
+type pendingOIDCLogin struct {
+	verifier    string
+	nonce       string
+	redirectURL string
+	expiresAt   time.Time
+}
+
+type oidcService struct {
+	userRepo      repositories.UserRepository
+	configService ConfigService
+	auditService  AuditLogService
+	revocations   SessionRevocationService
+
+	mu       sync.Mutex
+	pending  map[string]pendingOIDCLogin
+	provider *oidc.Provider
+	issuer   string
+}
+
+func NewOIDCService(userRepo repositories.UserRepository, configService ConfigService, auditService AuditLogService, revocations SessionRevocationService) OIDCService {
+	return &oidcService{
+		userRepo:      userRepo,
+		configService: configService,
+		auditService:  auditService,
+		revocations:   revocations,
+		pending:       make(map[string]pendingOIDCLogin),
+	}
+}
+
+func (s *oidcService) config() (*dto.OIDCConfig, error) {
+	appConfig, err := s.configService.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg := appConfig.OIDC
+	if !cfg.Enabled || cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, ErrSSODisabled
+	}
+	if cfg.NRPClaim == "" {
+		cfg.NRPClaim = defaultOIDCNRPClaim
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = defaultOIDCNameClaim
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = defaultOIDCRoleClaim
+	}
+	return &cfg, nil
+}
+
+// discover mengambil metadata .well-known IdP, di-cache per issuer.
+func (s *oidcService) discover(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	s.mu.Lock()
+	if s.provider != nil && s.issuer == issuer {
+		provider := s.provider
+		s.mu.Unlock()
+		return provider, nil
+	}
+	s.mu.Unlock()
+
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		log.Printf("WARN: discovery OIDC %s gagal: %v", issuer, err)
+		return nil, fmt.Errorf("%w: %v", ErrSSOProviderUnavailable, err)
+	}
+
+	s.mu.Lock()
+	s.provider, s.issuer = provider, issuer
+	s.mu.Unlock()
+	return provider, nil
+}
+
+func oauth2Config(cfg *dto.OIDCConfig, provider *oidc.Provider, redirectURL string) *oauth2.Config {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	hasOpenID := false
+	for _, scope := range scopes {
+		if scope == oidc.ScopeOpenID {
+			hasOpenID = true
+		}
+	}
+	if !hasOpenID {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}
+}
+
+func randomURLToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (s *oidcService) IsEnabled() bool {
+	_, err := s.config()
+	return err == nil
+}
+
+func (s *oidcService) ButtonLabel() string {
+	cfg, err := s.config()
+	if err != nil || cfg.ButtonLabel == "" {
+		return "Login dengan SSO"
+	}
+	return cfg.ButtonLabel
+}
+
+func (s *oidcService) BeginLogin(ctx context.Context, redirectURL string) (string, string, error) {
+	cfg, err := s.config()
+	if err != nil {
+		return "", "", err
+	}
+	if cfg.RedirectURL != "" {
+		redirectURL = cfg.RedirectURL
+	}
+	provider, err := s.discover(ctx, cfg.Issuer)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now()
+	s.mu.Lock()
+	for key, p := range s.pending {
+		if now.After(p.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = pendingOIDCLogin{verifier: verifier, nonce: nonce, redirectURL: redirectURL, expiresAt: now.Add(oidcLoginTTL)}
+	s.mu.Unlock()
+
+	authURL := oauth2Config(cfg, provider, redirectURL).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return authURL, state, nil
+}
+
+func (s *oidcService) takePending(state string) (pendingOIDCLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pending[state]
+	delete(s.pending, state) // state hanya boleh dipakai sekali
+	if !ok || time.Now().After(p.expiresAt) {
+		return pendingOIDCLogin{}, false
+	}
+	return p, true
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, state string, code string) (*OIDCLoginResult, error) {
+	cfg, err := s.config()
+	if err != nil {
+		return nil, err
+	}
+	pending, ok := s.takePending(state)
+	if !ok || code == "" {
+		return nil, ErrSSOInvalidState
+	}
+	provider, err := s.discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthCfg := oauth2Config(cfg, provider, pending.redirectURL)
+	oauthToken, err := oauthCfg.Exchange(ctx, code, oauth2.VerifierOption(pending.verifier))
+	if err != nil {
+		log.Printf("WARN: pertukaran kode OIDC gagal: %v", err)
+		return nil, fmt.Errorf("%w: pertukaran kode gagal", ErrSSOLoginFailed)
+	}
+
+	rawIDToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("%w: id_token tidak ada di respons IdP", ErrSSOLoginFailed)
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("WARN: verifikasi id_token gagal: %v", err)
+		return nil, fmt.Errorf("%w: id_token tidak valid", ErrSSOLoginFailed)
+	}
+	if idToken.Nonce != pending.nonce {
+		return nil, fmt.Errorf("%w: nonce tidak cocok", ErrSSOLoginFailed)
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOLoginFailed, err)
+	}
+	// Sebagian IdP hanya mengirim klaim profil lewat endpoint userinfo
+	if claimString(claims, cfg.NRPClaim) == "" || len(claimStrings(claims, cfg.RoleClaim)) == 0 {
+		if info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(oauthToken)); err == nil {
+			extra := map[string]interface{}{}
+			if info.Claims(&extra) == nil {
+				for key, value := range extra {
+					if _, exists := claims[key]; !exists {
+						claims[key] = value
+					}
+				}
+			}
+		}
+	}
+
+	nrp := strings.TrimSpace(claimString(claims, cfg.NRPClaim))
+	if nrp == "" {
+		return nil, fmt.Errorf("%w: klaim %s tidak ditemukan", ErrSSOLoginFailed, cfg.NRPClaim)
+	}
+	mapping := matchGroupMapping(claimStrings(claims, cfg.RoleClaim), cfg.RoleMappings)
+	if mapping == nil {
+		return nil, ErrNoGroupMapping
+	}
+
+	user, err := syncExternalUser(s.userRepo, s.auditService, models.AuthSourceOIDC, nrp, claimString(claims, cfg.NameClaim), mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig, _ := s.configService.GetConfig()
+	sid := claimString(claims, "sid")
+	token, err := issueSessionToken(user, appConfig, jwt.MapClaims{"sid": sid, "sub": idToken.Subject})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogActivity(user.ID, models.AuditSSOLogin, fmt.Sprintf("Pengguna '%s' login melalui SSO (%s).", user.NamaLengkap, cfg.Issuer))
+	return &OIDCLoginResult{Token: token, IDToken: rawIDToken, User: user}, nil
+}
+
+// LogoutURL mengembalikan end_session_endpoint IdP (RP-initiated logout).
+// String kosong berarti IdP tidak mendukungnya.
+func (s *oidcService) LogoutURL(ctx context.Context, idTokenHint string, postLogoutRedirect string) (string, error) {
+	cfg, err := s.config()
+	if err != nil {
+		return "", err
+	}
+	provider, err := s.discover(ctx, cfg.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	var meta struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := provider.Claims(&meta); err != nil || meta.EndSessionEndpoint == "" {
+		return "", nil
+	}
+
+	endSession, err := url.Parse(meta.EndSessionEndpoint)
+	if err != nil {
+		return "", nil
+	}
+	query := endSession.Query()
+	query.Set("client_id", cfg.ClientID)
+	if idTokenHint != "" {
+		query.Set("id_token_hint", idTokenHint)
+	}
+	if postLogoutRedirect != "" {
+		query.Set("post_logout_redirect_uri", postLogoutRedirect)
+	}
+	endSession.RawQuery = query.Encode()
+	return endSession.String(), nil
+}
+
+// HandleBackchannelLogout memverifikasi logout_token dari IdP lalu mencabut
+// sesi SIMDOKPOL yang terkait (OpenID Connect Back-Channel Logout 1.0).
+func (s *oidcService) HandleBackchannelLogout(ctx context.Context, logoutToken string) error {
+	cfg, err := s.config()
+	if err != nil {
+		return err
+	}
+	provider, err := s.discover(ctx, cfg.Issuer)
+	if err != nil {
+		return err
+	}
+
+	token, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(ctx, logoutToken)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSSOInvalidLogoutToken, err)
+	}
+
+	var claims struct {
+		SID    string                 `json:"sid"`
+		Nonce  string                 `json:"nonce"`
+		Events map[string]interface{} `json:"events"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrSSOInvalidLogoutToken, err)
+	}
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok || claims.Nonce != "" {
+		return ErrSSOInvalidLogoutToken
+	}
+	if claims.SID == "" && token.Subject == "" {
+		return ErrSSOInvalidLogoutToken
+	}
+
+	// sid mencabut satu sesi; tanpa sid berarti semua sesi milik sub tersebut
+	keys := SessionKeys("", token.Subject)
+	if claims.SID != "" {
+		keys = SessionKeys(claims.SID, "")
+	}
+	if err := s.revocations.Revoke(keys...); err != nil {
+		return fmt.Errorf("gagal mencatat pencabutan sesi: %w", err)
+	}
+	log.Printf("INFO: sesi SSO dicabut oleh IdP (sub %s, sid %s)", token.Subject, claims.SID)
+	return nil
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	switch value := claims[key].(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%.0f", value)
+	}
+	return ""
+}
+
+// claimStrings membaca klaim peran yang bisa berupa string tunggal atau array.
+// Nama klaim bertitik (mis. "realm_access.roles") ditelusuri sebagai objek.
+func claimStrings(claims map[string]interface{}, key string) []string {
+	var value interface{} = claims
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+	if _, direct := claims[key]; direct {
+		value = claims[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
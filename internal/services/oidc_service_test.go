@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// mockIdP adalah penyedia OIDC minimal: discovery, JWKS, token endpoint
+// dengan verifikasi PKCE, dan userinfo.
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockAuthCode
+	claims jwt.MapClaims
+}
+
+type mockAuthCode struct {
+	challenge string
+	nonce     string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &mockIdP{key: key, codes: map[string]mockAuthCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		base := idp.server.URL
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                base,
+			"authorization_endpoint":                base + "/authorize",
+			"token_endpoint":                        base + "/token",
+			"jwks_uri":                              base + "/jwks",
+			"userinfo_endpoint":                     base + "/userinfo",
+			"end_session_endpoint":                  base + "/logout",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "test", "use": "sig", "alg": "RS256",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		code, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{"nonce": code.nonce}
+		idp.mu.Lock()
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		idp.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-" + r.PostForm.Get("code"),
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     idp.sign(t, claims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"sub": "user-1", "groups": []string{"spkt-operator"}})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	base := jwt.MapClaims{
+		"iss": idp.server.URL,
+		"aud": "simdokpol",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range claims {
+		base[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, base)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(idp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// authorize mensimulasikan pengguna login di IdP lalu diarahkan balik dengan kode.
+func (idp *mockIdP) authorize(t *testing.T, authURL string) (state string, code string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.NotEmpty(t, query.Get("code_challenge"))
+	require.NotEmpty(t, query.Get("nonce"))
+
+	code = "code-" + query.Get("state")[:8]
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	idp.mu.Unlock()
+	return query.Get("state"), code
+}
+
+func newTestOIDCService(t *testing.T, idp *mockIdP, repo *mocks.UserRepository, audit *mocks.AuditLogService) OIDCService {
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig").Return(&dto.AppConfig{
+		SessionTimeout: 60,
+		OIDC: dto.OIDCConfig{
+			Enabled:   true,
+			Issuer:    idp.server.URL,
+			ClientID:  "simdokpol",
+			NRPClaim:  "nrp",
+			RoleClaim: "groups",
+			RoleMappings: []dto.GroupMapping{
+				{Group: "spkt-kanit", Peran: models.RoleKanit},
+				{Group: "spkt-operator", Peran: models.RoleOperator},
+			},
+		},
+	}, nil)
+	db := openEmptySQLiteDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SessionRevocation{}))
+	revocations := NewSessionRevocationService(repositories.NewSessionRevocationRepository(db), mockConfig)
+	return NewOIDCService(repo, mockConfig, audit, revocations)
+}
+
+func TestOIDCService_LoginFlow(t *testing.T) {
+	JWTSecretKey = []byte("test-secret")
+	ctx := context.Background()
+
+	t.Run("Berhasil - PKCE, Nonce dan Pemetaan Peran", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.claims = jwt.MapClaims{"sub": "user-1", "sid": "sesi-1", "nrp": "88010001", "name": "Budi Santoso", "groups": []string{"spkt-kanit"}}
+		mockRepo := new(mocks.UserRepository)
+		mockAudit := new(mocks.AuditLogService)
+		mockRepo.On("FindByNRP", "88010001").Return(nil, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", mock.MatchedBy(func(u *models.User) bool {
+			return u.NRP == "88010001" && u.Peran == models.RoleKanit && u.AuthSource == models.AuthSourceOIDC
+		})).Return(nil)
+		mockAudit.On("LogActivity", mock.Anything, mock.Anything, mock.Anything).Return()
+		svc := newTestOIDCService(t, idp, mockRepo, mockAudit)
+
+		authURL, state, err := svc.BeginLogin(ctx, "http://localhost:8080/api/auth/sso/callback")
+		require.NoError(t, err)
+		idpState, code := idp.authorize(t, authURL)
+		assert.Equal(t, state, idpState)
+
+		result, err := svc.CompleteLogin(ctx, state, code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Token)
+		assert.NotEmpty(t, result.IDToken)
+
+		parsed, err := jwt.Parse(result.Token, func(*jwt.Token) (interface{}, error) { return JWTSecretKey, nil })
+		require.NoError(t, err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, "sesi-1", claims["sid"])
+		assert.Equal(t, "user-1", claims["sub"])
+		mockRepo.AssertExpectations(t)
+
+		// State hanya bisa dipakai sekali
+		_, err = svc.CompleteLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrSSOInvalidState)
+	})
+
+	t.Run("Klaim Peran Dari Userinfo", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.claims = jwt.MapClaims{"sub": "user-1", "nrp": "88010002"}
+		mockRepo := new(mocks.UserRepository)
+		mockAudit := new(mocks.AuditLogService)
+		existing := &models.User{ID: 4, NRP: "88010002", NamaLengkap: "Lama", Peran: models.RoleOperator, AuthSource: models.AuthSourceOIDC}
+		mockRepo.On("FindByNRP", "88010002").Return(existing, nil)
+		mockAudit.On("LogActivity", uint(4), models.AuditSSOLogin, mock.Anything).Return()
+		svc := newTestOIDCService(t, idp, mockRepo, mockAudit)
+
+		authURL, state, err := svc.BeginLogin(ctx, "http://localhost/cb")
+		require.NoError(t, err)
+		_, code := idp.authorize(t, authURL)
+
+		result, err := svc.CompleteLogin(ctx, state, code)
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), result.User.ID)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Gagal - Verifier PKCE Tidak Cocok", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.claims = jwt.MapClaims{"sub": "user-1", "nrp": "88010001", "groups": []string{"spkt-kanit"}}
+		svc := newTestOIDCService(t, idp, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+		authURL, state, err := svc.BeginLogin(ctx, "http://localhost/cb")
+		require.NoError(t, err)
+		_, code := idp.authorize(t, authURL)
+		idp.mu.Lock()
+		entry := idp.codes[code]
+		entry.challenge = "challenge-lain"
+		idp.codes[code] = entry
+		idp.mu.Unlock()
+
+		_, err = svc.CompleteLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrSSOLoginFailed)
+	})
+
+	t.Run("Gagal - Nonce Tidak Cocok", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.claims = jwt.MapClaims{"sub": "user-1", "nrp": "88010001", "groups": []string{"spkt-kanit"}, "nonce": "nonce-palsu"}
+		svc := newTestOIDCService(t, idp, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+		authURL, state, err := svc.BeginLogin(ctx, "http://localhost/cb")
+		require.NoError(t, err)
+		_, code := idp.authorize(t, authURL)
+
+		_, err = svc.CompleteLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrSSOLoginFailed)
+	})
+
+	t.Run("Gagal - Peran Tidak Dipetakan", func(t *testing.T) {
+		idp := newMockIdP(t)
+		idp.claims = jwt.MapClaims{"sub": "user-1", "nrp": "88010001", "groups": []string{"humas"}}
+		svc := newTestOIDCService(t, idp, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+		authURL, state, err := svc.BeginLogin(ctx, "http://localhost/cb")
+		require.NoError(t, err)
+		_, code := idp.authorize(t, authURL)
+
+		_, err = svc.CompleteLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrNoGroupMapping)
+	})
+}
+
+func TestOIDCService_Logout(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t)
+	svc := newTestOIDCService(t, idp, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+	t.Run("URL Logout IdP", func(t *testing.T) {
+		logoutURL, err := svc.LogoutURL(ctx, "id-token", "http://localhost/app/login")
+		require.NoError(t, err)
+		parsed, _ := url.Parse(logoutURL)
+		assert.Equal(t, "/logout", parsed.Path)
+		assert.Equal(t, "id-token", parsed.Query().Get("id_token_hint"))
+		assert.Equal(t, "http://localhost/app/login", parsed.Query().Get("post_logout_redirect_uri"))
+	})
+
+	t.Run("Back-channel Logout Mencabut Sesi", func(t *testing.T) {
+		revocations := svc.(*oidcService).revocations
+		issuedAt := time.Now().Add(-time.Minute)
+		keys := SessionKeys("sesi-bc", "user-bc")
+		revoked, err := revocations.IsRevoked(issuedAt, keys...)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		logoutToken := idp.sign(t, jwt.MapClaims{
+			"sub":    "user-bc",
+			"sid":    "sesi-bc",
+			"jti":    "logout-1",
+			"events": map[string]interface{}{backchannelLogoutEvent: map[string]interface{}{}},
+		})
+		require.NoError(t, svc.HandleBackchannelLogout(ctx, logoutToken))
+		revoked, err = revocations.IsRevoked(issuedAt, keys...)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = revocations.IsRevoked(time.Now().Add(time.Minute), keys...)
+		require.NoError(t, err)
+		assert.False(t, revoked, "login baru setelah logout tetap berlaku")
+	})
+
+	t.Run("Back-channel Logout Tanpa Event Ditolak", func(t *testing.T) {
+		logoutToken := idp.sign(t, jwt.MapClaims{"sub": "user-x", "sid": "sesi-x"})
+		assert.ErrorIs(t, svc.HandleBackchannelLogout(ctx, logoutToken), ErrSSOInvalidLogoutToken)
+	})
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Map dedup dibersihkan dari entri kedaluwarsa bila melewati ukuran ini
+	readAuditDedupMax = 10000
+	// Batas entri di laporan akses per penduduk (sisanya lewat filter log audit)
+	residentAccessReportLimit = 1000
+)
+
+// readAuditGate menerapkan opsi sampling audit baca. Sampling dan dedup hanya
+// berlaku untuk aksi "lihat"; cetak, unduh PDF dan ekspor selalu dicatat.
+type readAuditGate struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	now  func() time.Time
+	roll func() int // 0-99, diganti di test
+}
+
+func newReadAuditGate() *readAuditGate {
+	return &readAuditGate{
+		seen: make(map[string]time.Time),
+		now:  time.Now,
+		roll: func() int { return rand.Intn(100) },
+	}
+}
+
+func (g *readAuditGate) allow(cfg *dto.AppConfig, action string, actorID, residentID uint) bool {
+	if cfg == nil || !cfg.AuditReadEnabled {
+		return false
+	}
+	if action != models.AuditViewResident {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	window := time.Duration(cfg.AuditReadDedupMinutes) * time.Minute
+	key := fmt.Sprintf("%d:%d:%s", actorID, residentID, action)
+	if window > 0 {
+		if last, ok := g.seen[key]; ok && now.Sub(last) < window {
+			return false
+		}
+	}
+	if cfg.AuditReadSamplePercent > 0 && cfg.AuditReadSamplePercent < 100 && g.roll() >= cfg.AuditReadSamplePercent {
+		return false
+	}
+	if window > 0 {
+		if len(g.seen) >= readAuditDedupMax {
+			for k, last := range g.seen {
+				if now.Sub(last) >= window {
+					delete(g.seen, k)
+				}
+			}
+		}
+		g.seen[key] = now
+	}
+	return true
+}
+
+func documentResidentID(doc *models.LostDocument) uint {
+	if doc.ResidentID != 0 {
+		return doc.ResidentID
+	}
+	return doc.Resident.ID
+}
+
+// logResidentRead mencatat bahwa actor membuka data pribadi pemohon sebuah dokumen.
+func (s *lostDocumentService) logResidentRead(ctx context.Context, actorID uint, action string, doc *models.LostDocument) {
+	residentID := documentResidentID(doc)
+	if residentID == 0 {
+		return
+	}
+	cfg, _ := s.configService.GetConfig()
+	if !s.readGate.allow(cfg, action, actorID, residentID) {
+		return
+	}
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     action,
+		Detail:     fmt.Sprintf("Surat %s (dokumen #%d)", doc.NomorSurat, doc.ID),
+		EntityType: models.AuditEntityResident,
+		EntityID:   strconv.FormatUint(uint64(residentID), 10),
+	})
+}
+
+// logResidentExport mencatat satu entri per penduduk yang ikut terekspor,
+// supaya laporan per penduduk juga menampilkan ekspor massal.
+func (s *lostDocumentService) logResidentExport(ctx context.Context, actorID uint, docs []models.LostDocument, filename string) {
+	cfg, _ := s.configService.GetConfig()
+	if !s.readGate.allow(cfg, models.AuditExportResidents, actorID, 0) {
+		return
+	}
+	exported := make(map[uint]bool)
+	for i := range docs {
+		residentID := documentResidentID(&docs[i])
+		if residentID == 0 || exported[residentID] {
+			continue
+		}
+		exported[residentID] = true
+		s.auditService.LogEvent(ctx, dto.AuditEvent{
+			UserID:     actorID,
+			Action:     models.AuditExportResidents,
+			Detail:     fmt.Sprintf("Ikut terekspor di %s (%d baris)", filename, len(docs)),
+			EntityType: models.AuditEntityResident,
+			EntityID:   strconv.FormatUint(uint64(residentID), 10),
+		})
+	}
+}
+
+// ResidentAccessReport menyusun laporan akses dari log audit aktif lalu
+// melanjutkan ke arsip retensi, sehingga akses lama tetap terlihat. Arsip yang
+// tidak bisa dibaca dicantumkan dan laporan ditandai terpotong.
+func (s *lostDocumentService) ResidentAccessReport(nik string) (*dto.ResidentAccessReport, error) {
+	resident, err := s.residentRepo.FindByNIK(nil, strings.TrimSpace(nik))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	entityID := strconv.FormatUint(uint64(resident.ID), 10)
+	logs, err := s.auditService.FindEntityHistory(models.AuditEntityResident, entityID, residentAccessReportLimit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.ResidentAccessReport{
+		ResidentID:  resident.ID,
+		NIK:         resident.NIK,
+		NamaLengkap: resident.NamaLengkap,
+		Accessors:   []dto.ResidentAccessor{},
+		Entries:     []dto.ResidentAccessEntry{},
+		GeneratedAt: time.Now(),
+	}
+
+	// entri terurut terbaru dulu: log aktif, lalu arsip (selalu lebih lama)
+	for _, entry := range logs {
+		userName, userNRP := "SISTEM", "N/A"
+		if entry.User.ID != 0 {
+			userName, userNRP = entry.User.NamaLengkap, entry.User.NRP
+		}
+		report.Entries = append(report.Entries, dto.ResidentAccessEntry{
+			LogID:     entry.ID,
+			Timestamp: entry.Timestamp,
+			UserID:    entry.UserID,
+			UserName:  userName,
+			UserNRP:   userNRP,
+			Aksi:      entry.Aksi,
+			Detail:    entry.Detail,
+			IPAddress: entry.IPAddress,
+			RequestID: entry.RequestID,
+		})
+	}
+
+	if remaining := residentAccessReportLimit + 1 - len(report.Entries); remaining > 0 {
+		archived, unreadable, err := s.auditService.FindArchivedEntityHistory(models.AuditEntityResident, entityID, remaining)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range archived {
+			userName, userNRP := entry.UserName, entry.UserNRP
+			if entry.UserID == 0 || userName == "" {
+				userName, userNRP = "SISTEM", "N/A"
+			}
+			report.Entries = append(report.Entries, dto.ResidentAccessEntry{
+				LogID:     entry.ID,
+				Timestamp: entry.Timestamp,
+				UserID:    entry.UserID,
+				UserName:  userName,
+				UserNRP:   userNRP,
+				Aksi:      entry.Aksi,
+				Detail:    entry.Detail,
+				IPAddress: entry.IPAddress,
+				RequestID: entry.RequestID,
+				Archived:  true,
+			})
+			report.ArchivedEntries++
+		}
+		for _, archive := range unreadable {
+			report.ArchivesUnavailable = append(report.ArchivesUnavailable, archive.Period)
+			report.Truncated = true
+		}
+	}
+
+	if len(report.Entries) > residentAccessReportLimit {
+		if report.Entries[residentAccessReportLimit].Archived {
+			report.ArchivedEntries--
+		}
+		report.Entries = report.Entries[:residentAccessReportLimit]
+		report.Truncated = true
+	}
+	report.TotalAccess = len(report.Entries)
+
+	accessors := make(map[uint]*dto.ResidentAccessor)
+	for _, entry := range report.Entries {
+		accessor, ok := accessors[entry.UserID]
+		if !ok {
+			accessor = &dto.ResidentAccessor{
+				UserID:      entry.UserID,
+				NamaLengkap: entry.UserName,
+				NRP:         entry.UserNRP,
+				Actions:     make(map[string]int),
+				LastAccess:  entry.Timestamp,
+			}
+			accessors[entry.UserID] = accessor
+		}
+		accessor.Count++
+		accessor.Actions[entry.Aksi]++
+		accessor.FirstAccess = entry.Timestamp
+	}
+
+	for _, accessor := range accessors {
+		report.Accessors = append(report.Accessors, *accessor)
+	}
+	sort.Slice(report.Accessors, func(i, j int) bool {
+		return report.Accessors[i].LastAccess.After(report.Accessors[j].LastAccess)
+	})
+	return report, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestReadAuditGate_Allow(t *testing.T) {
+	now := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	newGate := func(roll int) *readAuditGate {
+		gate := newReadAuditGate()
+		gate.now = func() time.Time { return now }
+		gate.roll = func() int { return roll }
+		return gate
+	}
+
+	t.Run("Nonaktif", func(t *testing.T) {
+		gate := newGate(0)
+		assert.False(t, gate.allow(&dto.AppConfig{}, models.AuditViewResident, 1, 7))
+		assert.False(t, gate.allow(nil, models.AuditPrintDocument, 1, 7))
+	})
+
+	t.Run("Sampling Hanya Untuk Lihat", func(t *testing.T) {
+		cfg := &dto.AppConfig{AuditReadEnabled: true, AuditReadSamplePercent: 30}
+		gate := newGate(50)
+		assert.False(t, gate.allow(cfg, models.AuditViewResident, 1, 7))
+		assert.True(t, gate.allow(cfg, models.AuditPrintDocument, 1, 7))
+		assert.True(t, gate.allow(cfg, models.AuditExportResidents, 1, 0))
+
+		cfg.AuditReadSamplePercent = 60
+		assert.True(t, gate.allow(cfg, models.AuditViewResident, 1, 7))
+	})
+
+	t.Run("Dedup Per Pengguna Dan Penduduk", func(t *testing.T) {
+		cfg := &dto.AppConfig{AuditReadEnabled: true, AuditReadSamplePercent: 100, AuditReadDedupMinutes: 10}
+		gate := newGate(0)
+		assert.True(t, gate.allow(cfg, models.AuditViewResident, 1, 7))
+		assert.False(t, gate.allow(cfg, models.AuditViewResident, 1, 7))
+		assert.True(t, gate.allow(cfg, models.AuditViewResident, 2, 7), "pengguna lain tetap dicatat")
+		assert.True(t, gate.allow(cfg, models.AuditViewResident, 1, 8), "penduduk lain tetap dicatat")
+
+		now = now.Add(11 * time.Minute)
+		assert.True(t, gate.allow(cfg, models.AuditViewResident, 1, 7))
+	})
+}
+
+func TestLostDocumentService_ReadAccessLogged(t *testing.T) {
+	admin := &models.User{ID: 1, Peran: models.RoleSuperAdmin}
+	doc := &models.LostDocument{ID: 101, NomorSurat: "SKH/1/I/2025", ResidentID: 7, OperatorID: admin.ID}
+
+	mockDocRepo := new(mocks.LostDocumentRepository)
+	mockUserRepo := new(mocks.UserRepository)
+	mockAuditService := new(mocks.AuditLogService)
+	mockConfigService := new(mocks.ConfigService)
+
+	mockDocRepo.On("FindByID", uint(101)).Return(doc, nil)
+	mockUserRepo.On("FindByID", admin.ID).Return(admin, nil)
+	mockConfigService.On("GetConfig").Return(&dto.AppConfig{AuditReadEnabled: true, AuditReadSamplePercent: 100}, nil)
+	for _, action := range []string{models.AuditViewResident, models.AuditPrintDocument} {
+		action := action
+		mockAuditService.On("LogEvent", mock.Anything, mock.MatchedBy(func(e dto.AuditEvent) bool {
+			return e.Action == action && e.UserID == admin.ID && e.EntityType == models.AuditEntityResident && e.EntityID == "7"
+		})).Return().Once()
+	}
+
+	service := NewLostDocumentService(nil, mockDocRepo, new(mocks.ResidentRepository), mockUserRepo, mockAuditService, mockConfigService, new(mocks.RoleService), new(mocks.ConfigRepository), "")
+
+	_, err := service.FindByID(context.Background(), 101, admin.ID)
+	require.NoError(t, err)
+	_, err = service.FindForPrint(context.Background(), 101, admin.ID)
+	require.NoError(t, err)
+
+	mockAuditService.AssertExpectations(t)
+}
+
+func TestLostDocumentService_ResidentAccessReport(t *testing.T) {
+	resident := &models.Resident{ID: 7, NIK: "3201010101010001", NamaLengkap: "BUDI SANTOSO"}
+	base := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	logs := []models.AuditLog{
+		{ID: 3, UserID: 2, User: models.User{ID: 2, NamaLengkap: "Operator B", NRP: "222"}, Aksi: models.AuditPrintDocument, Timestamp: base.Add(2 * time.Hour)},
+		{ID: 2, UserID: 1, User: models.User{ID: 1, NamaLengkap: "Operator A", NRP: "111"}, Aksi: models.AuditViewResident, Timestamp: base.Add(time.Hour)},
+		{ID: 1, UserID: 1, User: models.User{ID: 1, NamaLengkap: "Operator A", NRP: "111"}, Aksi: models.AuditViewResident, Timestamp: base, IPAddress: "10.0.0.5"},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockResRepo := new(mocks.ResidentRepository)
+		mockAuditService := new(mocks.AuditLogService)
+		mockResRepo.On("FindByNIK", (*gorm.DB)(nil), resident.NIK).Return(resident, nil).Once()
+		mockAuditService.On("FindEntityHistory", models.AuditEntityResident, "7", residentAccessReportLimit+1).Return(logs, nil).Once()
+		mockAuditService.On("FindArchivedEntityHistory", models.AuditEntityResident, "7", residentAccessReportLimit+1-len(logs)).Return(nil, nil, nil).Once()
+
+		service := NewLostDocumentService(nil, new(mocks.LostDocumentRepository), mockResRepo, new(mocks.UserRepository), mockAuditService, new(mocks.ConfigService), new(mocks.RoleService), new(mocks.ConfigRepository), "")
+		report, err := service.ResidentAccessReport(" " + resident.NIK + " ")
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, report.TotalAccess)
+		assert.False(t, report.Truncated)
+		require.Len(t, report.Accessors, 2)
+		assert.Equal(t, "Operator B", report.Accessors[0].NamaLengkap)
+		assert.Equal(t, 2, report.Accessors[1].Count)
+		assert.Equal(t, 2, report.Accessors[1].Actions[models.AuditViewResident])
+		assert.Equal(t, base, report.Accessors[1].FirstAccess)
+		assert.Equal(t, base.Add(time.Hour), report.Accessors[1].LastAccess)
+		assert.Equal(t, "10.0.0.5", report.Entries[2].IPAddress)
+	})
+
+	t.Run("Termasuk Arsip Retensi", func(t *testing.T) {
+		mockResRepo := new(mocks.ResidentRepository)
+		mockAuditService := new(mocks.AuditLogService)
+		archived := []dto.AuditArchiveEntry{
+			{ID: 9, UserID: 3, UserName: "Operator C", UserNRP: "333", Aksi: models.AuditViewResident, Timestamp: base.AddDate(-1, 0, 0)},
+		}
+		mockResRepo.On("FindByNIK", (*gorm.DB)(nil), resident.NIK).Return(resident, nil).Once()
+		mockAuditService.On("FindEntityHistory", models.AuditEntityResident, "7", residentAccessReportLimit+1).Return(logs, nil).Once()
+		mockAuditService.On("FindArchivedEntityHistory", models.AuditEntityResident, "7", residentAccessReportLimit+1-len(logs)).
+			Return(archived, []models.AuditArchive{{Period: "2024-06"}}, nil).Once()
+
+		service := NewLostDocumentService(nil, new(mocks.LostDocumentRepository), mockResRepo, new(mocks.UserRepository), mockAuditService, new(mocks.ConfigService), new(mocks.RoleService), new(mocks.ConfigRepository), "")
+		report, err := service.ResidentAccessReport(resident.NIK)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, report.TotalAccess)
+		assert.Equal(t, 1, report.ArchivedEntries)
+		assert.True(t, report.Entries[3].Archived)
+		assert.Equal(t, "Operator C", report.Entries[3].UserName)
+		require.Len(t, report.Accessors, 3)
+		assert.Equal(t, "Operator C", report.Accessors[2].NamaLengkap)
+		// Arsip yang gagal dibaca membuat laporan tidak lengkap
+		assert.True(t, report.Truncated)
+		assert.Equal(t, []string{"2024-06"}, report.ArchivesUnavailable)
+	})
+
+	t.Run("NIK Tidak Dikenal", func(t *testing.T) {
+		mockResRepo := new(mocks.ResidentRepository)
+		mockResRepo.On("FindByNIK", (*gorm.DB)(nil), "999").Return((*models.Resident)(nil), gorm.ErrRecordNotFound).Once()
+
+		service := NewLostDocumentService(nil, new(mocks.LostDocumentRepository), mockResRepo, new(mocks.UserRepository), new(mocks.AuditLogService), new(mocks.ConfigService), new(mocks.RoleService), new(mocks.ConfigRepository), "")
+		_, err := service.ResidentAccessReport("999")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"sync"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,49}$`)
+
+type RoleService interface {
+	FindAll() ([]models.Role, error)
+	FindByName(nama string) (*models.Role, error)
+	Save(role *models.Role, actorID uint) error
+	Delete(nama string, actorID uint) error
+	HasPermission(user *models.User, permission string) bool
+	GetPermissions(user *models.User) []string
+	AvailablePermissions() []models.PermissionInfo
+}
+
+type roleService struct {
+	roleRepo     repositories.RoleRepository
+	userRepo     repositories.UserRepository
+	auditService AuditLogService
+
+	mu    sync.RWMutex
+	cache map[string]*models.Role
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, userRepo repositories.UserRepository, auditService AuditLogService) RoleService {
+	return &roleService{
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+	}
+}
+
+func (s *roleService) FindAll() ([]models.Role, error) {
+	return s.roleRepo.FindAll()
+}
+
+func (s *roleService) FindByName(nama string) (*models.Role, error) {
+	return s.roleRepo.FindByName(strings.ToUpper(strings.TrimSpace(nama)))
+}
+
+func (s *roleService) AvailablePermissions() []models.PermissionInfo {
+	return models.AllPermissions
+}
+
+func (s *roleService) Save(role *models.Role, actorID uint) error {
+	if role == nil {
+		return errors.New("peran tidak valid")
+	}
+	role.Nama = strings.ToUpper(strings.TrimSpace(role.Nama))
+	if !roleNamePattern.MatchString(role.Nama) {
+		return errors.New("nama peran hanya boleh huruf kapital, angka dan garis bawah")
+	}
+	if role.Nama == models.RoleSuperAdmin {
+		return ErrRoleProtected
+	}
+
+	seen := make(map[string]bool)
+	perms := models.JSONStringArray{}
+	for _, p := range role.Permissions {
+		if !models.IsKnownPermission(p) {
+			return fmt.Errorf("izin tidak dikenal: %s", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+	role.Permissions = perms
+
+	if existing, err := s.roleRepo.FindByName(role.Nama); err == nil {
+		role.IsSystem = existing.IsSystem
+		role.CreatedAt = existing.CreatedAt
+	}
+
+	if err := s.roleRepo.Save(role); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.auditService.LogActivity(actorID, models.AuditRoleUpdated, fmt.Sprintf("Menyimpan peran %s dengan izin: %s", role.Nama, strings.Join(role.Permissions, ", ")))
+	return nil
+}
+
+func (s *roleService) Delete(nama string, actorID uint) error {
+	role, err := s.FindByName(nama)
+	if err != nil {
+		return ErrNotFound
+	}
+	if role.IsSystem {
+		return ErrRoleProtected
+	}
+
+	count, err := s.userRepo.CountByRole(role.Nama)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.roleRepo.Delete(role.Nama); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.auditService.LogActivity(actorID, models.AuditRoleDeleted, fmt.Sprintf("Menghapus peran %s", role.Nama))
+	return nil
+}
+
+// HasPermission: SUPER_ADMIN selalu lolos, peran lain dicek dari tabel roles (di-cache).
+func (s *roleService) HasPermission(user *models.User, permission string) bool {
+	if user == nil {
+		return false
+	}
+	if user.Peran == models.RoleSuperAdmin {
+		return true
+	}
+	role := s.lookup(user.Peran)
+	if role == nil {
+		return false
+	}
+	return role.HasPermission(permission)
+}
+
+func (s *roleService) GetPermissions(user *models.User) []string {
+	perms := []string{}
+	if user == nil {
+		return perms
+	}
+	for _, p := range models.AllPermissions {
+		if s.HasPermission(user, p.Key) {
+			perms = append(perms, p.Key)
+		}
+	}
+	return perms
+}
+
+func (s *roleService) lookup(nama string) *models.Role {
+	s.mu.RLock()
+	if s.cache != nil {
+		role := s.cache[nama]
+		s.mu.RUnlock()
+		return role
+	}
+	s.mu.RUnlock()
+
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		log.Printf("WARN: gagal memuat daftar peran: %v", err)
+		return nil
+	}
+
+	cache := make(map[string]*models.Role, len(roles))
+	for i := range roles {
+		cache[roles[i].Nama] = &roles[i]
+	}
+
+	s.mu.Lock()
+	s.cache = cache
+	s.mu.Unlock()
+
+	return cache[nama]
+}
+
+func (s *roleService) invalidate() {
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
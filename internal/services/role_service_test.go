@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoleService_HasPermission(t *testing.T) {
+	mockRoleRepo := new(mocks.RoleRepository)
+	mockRoleRepo.On("FindAll").Return([]models.Role{
+		{Nama: models.RoleOperator, Permissions: models.JSONStringArray{models.PermDocumentCreate}},
+		{Nama: models.RolePimpinan, Permissions: models.JSONStringArray{models.PermDocumentReadAll, models.PermReportView}},
+	}, nil).Once()
+
+	service := NewRoleService(mockRoleRepo, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+	superAdmin := &models.User{Peran: models.RoleSuperAdmin}
+	operator := &models.User{Peran: models.RoleOperator}
+	pimpinan := &models.User{Peran: models.RolePimpinan}
+	unknown := &models.User{Peran: "TIDAK_ADA"}
+
+	assert.True(t, service.HasPermission(superAdmin, models.PermDatabaseManage))
+	assert.True(t, service.HasPermission(operator, models.PermDocumentCreate))
+	assert.False(t, service.HasPermission(operator, models.PermDocumentReadAll))
+	assert.True(t, service.HasPermission(pimpinan, models.PermReportView))
+	assert.False(t, service.HasPermission(pimpinan, models.PermDocumentCreate))
+	assert.False(t, service.HasPermission(unknown, models.PermDocumentCreate))
+	assert.False(t, service.HasPermission(nil, models.PermDocumentCreate))
+
+	assert.Equal(t, []string{models.PermDocumentReadAll, models.PermReportView}, service.GetPermissions(pimpinan))
+
+	// Daftar peran hanya dimuat sekali lalu di-cache.
+	mockRoleRepo.AssertNumberOfCalls(t, "FindAll", 1)
+}
+
+func TestRoleService_Save(t *testing.T) {
+	t.Run("Success - Normalisasi nama dan izin", func(t *testing.T) {
+		mockRoleRepo := new(mocks.RoleRepository)
+		mockAuditService := new(mocks.AuditLogService)
+		service := NewRoleService(mockRoleRepo, new(mocks.UserRepository), mockAuditService)
+
+		mockRoleRepo.On("FindByName", "KANIT").Return(&models.Role{Nama: "KANIT", IsSystem: false}, nil).Once()
+		mockRoleRepo.On("Save", mock.MatchedBy(func(r *models.Role) bool {
+			return r.Nama == "KANIT" && len(r.Permissions) == 1
+		})).Return(nil).Once()
+		mockAuditService.On("LogActivity", uint(1), models.AuditRoleUpdated, mock.Anything).Return().Once()
+
+		role := &models.Role{Nama: " kanit ", Permissions: models.JSONStringArray{models.PermReportView, models.PermReportView}}
+		err := service.Save(role, 1)
+
+		assert.NoError(t, err)
+		mockRoleRepo.AssertExpectations(t)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("Failure - SUPER_ADMIN dilindungi", func(t *testing.T) {
+		service := NewRoleService(new(mocks.RoleRepository), new(mocks.UserRepository), new(mocks.AuditLogService))
+		err := service.Save(&models.Role{Nama: models.RoleSuperAdmin}, 1)
+		assert.ErrorIs(t, err, ErrRoleProtected)
+	})
+
+	t.Run("Failure - Izin tidak dikenal", func(t *testing.T) {
+		service := NewRoleService(new(mocks.RoleRepository), new(mocks.UserRepository), new(mocks.AuditLogService))
+		err := service.Save(&models.Role{Nama: "KANIT", Permissions: models.JSONStringArray{"hapus:semua"}}, 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestRoleService_Delete(t *testing.T) {
+	t.Run("Failure - Peran sistem", func(t *testing.T) {
+		mockRoleRepo := new(mocks.RoleRepository)
+		mockRoleRepo.On("FindByName", models.RoleOperator).Return(&models.Role{Nama: models.RoleOperator, IsSystem: true}, nil).Once()
+		service := NewRoleService(mockRoleRepo, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+		assert.ErrorIs(t, service.Delete(models.RoleOperator, 1), ErrRoleProtected)
+	})
+
+	t.Run("Failure - Peran masih dipakai", func(t *testing.T) {
+		mockRoleRepo := new(mocks.RoleRepository)
+		mockUserRepo := new(mocks.UserRepository)
+		mockRoleRepo.On("FindByName", models.RoleKanit).Return(&models.Role{Nama: models.RoleKanit}, nil).Once()
+		mockUserRepo.On("CountByRole", models.RoleKanit).Return(int64(2), nil).Once()
+		service := NewRoleService(mockRoleRepo, mockUserRepo, new(mocks.AuditLogService))
+
+		assert.ErrorIs(t, service.Delete(models.RoleKanit, 1), ErrRoleInUse)
+	})
+
+	t.Run("Failure - Peran tidak ditemukan", func(t *testing.T) {
+		mockRoleRepo := new(mocks.RoleRepository)
+		mockRoleRepo.On("FindByName", "HANTU").Return(nil, errors.New("record not found")).Once()
+		service := NewRoleService(mockRoleRepo, new(mocks.UserRepository), new(mocks.AuditLogService))
+
+		assert.ErrorIs(t, service.Delete("HANTU", 1), ErrNotFound)
+	})
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlSchema skema hasil "menjalankan" file migrasi secara tekstual: tabel ->
+// kolom dan index -> tabel. Dipakai untuk memeriksa set migrasi MySQL dan
+// Postgres tanpa server database; perilakunya meniru server (CREATE tabel
+// yang sudah ada atau DROP kolom yang tidak ada = error).
+type sqlSchema struct {
+	tables  map[string]map[string]bool
+	indexes map[string]string
+}
+
+const sqlIdent = "[`\"]?(\\w+)[`\"]?"
+
+var (
+	sqlCreateTable = regexp.MustCompile(`(?is)^CREATE TABLE (IF NOT EXISTS )?` + sqlIdent + `\s*\((.*)\)[^)]*;?$`)
+	sqlAddColumn   = regexp.MustCompile(`(?is)^ALTER TABLE ` + sqlIdent + ` ADD COLUMN (IF NOT EXISTS )?` + sqlIdent)
+	sqlDropColumn  = regexp.MustCompile(`(?is)^ALTER TABLE ` + sqlIdent + ` DROP COLUMN (IF EXISTS )?` + sqlIdent)
+	sqlAlterIndex  = regexp.MustCompile(`(?is)^ALTER TABLE ` + sqlIdent + ` DROP INDEX ` + sqlIdent)
+	sqlCreateIndex = regexp.MustCompile(`(?is)^CREATE (UNIQUE )?INDEX (IF NOT EXISTS )?` + sqlIdent + ` ON ` + sqlIdent + `\s*\(`)
+	sqlDropIndex   = regexp.MustCompile(`(?is)^DROP INDEX (IF EXISTS )?` + sqlIdent + `( ON ` + sqlIdent + `)?`)
+	sqlDropTable   = regexp.MustCompile(`(?is)^DROP TABLE (IF EXISTS )?` + sqlIdent)
+	sqlWriteData   = regexp.MustCompile(`(?is)^(INSERT INTO|DELETE FROM|UPDATE) ` + sqlIdent)
+	sqlInlineIndex = regexp.MustCompile(`(?is)^(UNIQUE )?(INDEX|KEY) ` + sqlIdent)
+)
+
+func (s *sqlSchema) apply(statement string) error {
+	if m := sqlCreateTable.FindStringSubmatch(statement); m != nil {
+		if s.tables[m[2]] != nil {
+			if m[1] != "" {
+				return nil
+			}
+			return fmt.Errorf("tabel %s sudah ada", m[2])
+		}
+		columns := map[string]bool{}
+		for _, part := range splitTopLevel(m[3]) {
+			part = strings.TrimSpace(part)
+			if idx := sqlInlineIndex.FindStringSubmatch(part); idx != nil {
+				s.indexes[idx[3]] = m[2]
+				continue
+			}
+			if strings.HasPrefix(part, "`") || strings.HasPrefix(part, `"`) {
+				columns[strings.Trim(strings.Fields(part)[0], "`\"")] = true
+			}
+		}
+		s.tables[m[2]] = columns
+		return nil
+	}
+	if m := sqlAddColumn.FindStringSubmatch(statement); m != nil {
+		columns := s.tables[m[1]]
+		if columns == nil {
+			return fmt.Errorf("tabel %s tidak ada", m[1])
+		}
+		if columns[m[3]] && m[2] == "" {
+			return fmt.Errorf("kolom %s.%s sudah ada", m[1], m[3])
+		}
+		columns[m[3]] = true
+		return nil
+	}
+	if m := sqlDropColumn.FindStringSubmatch(statement); m != nil {
+		columns := s.tables[m[1]]
+		if columns == nil || (!columns[m[3]] && m[2] == "") {
+			return fmt.Errorf("kolom %s.%s tidak ada", m[1], m[3])
+		}
+		delete(columns, m[3])
+		return nil
+	}
+	if m := sqlAlterIndex.FindStringSubmatch(statement); m != nil {
+		return s.dropIndex(m[2], m[1], false)
+	}
+	if m := sqlCreateIndex.FindStringSubmatch(statement); m != nil {
+		if s.tables[m[4]] == nil {
+			return fmt.Errorf("tabel %s tidak ada", m[4])
+		}
+		if _, exists := s.indexes[m[3]]; exists && m[2] == "" {
+			return fmt.Errorf("index %s sudah ada", m[3])
+		}
+		s.indexes[m[3]] = m[4]
+		return nil
+	}
+	if m := sqlDropIndex.FindStringSubmatch(statement); m != nil {
+		return s.dropIndex(m[2], m[4], m[1] != "")
+	}
+	if m := sqlDropTable.FindStringSubmatch(statement); m != nil {
+		if s.tables[m[2]] == nil {
+			if m[1] != "" {
+				return nil
+			}
+			return fmt.Errorf("tabel %s tidak ada", m[2])
+		}
+		delete(s.tables, m[2])
+		for name, table := range s.indexes {
+			if table == m[2] {
+				delete(s.indexes, name)
+			}
+		}
+		return nil
+	}
+	if m := sqlWriteData.FindStringSubmatch(statement); m != nil {
+		if s.tables[m[2]] == nil {
+			return fmt.Errorf("tabel %s tidak ada", m[2])
+		}
+		return nil
+	}
+	return fmt.Errorf("statement tidak dikenali pemeriksa: %.60s", statement)
+}
+
+func (s *sqlSchema) dropIndex(name, table string, ifExists bool) error {
+	owner, exists := s.indexes[name]
+	if !exists {
+		if ifExists {
+			return nil
+		}
+		return fmt.Errorf("index %s tidak ada", name)
+	}
+	if table != "" && owner != table {
+		return fmt.Errorf("index %s milik tabel %s, bukan %s", name, owner, table)
+	}
+	delete(s.indexes, name)
+	return nil
+}
+
+// splitTopLevel memecah isi CREATE TABLE per koma di luar kurung dan string.
+func splitTopLevel(body string) []string {
+	var parts []string
+	depth, start, inString := 0, 0, false
+	for i, r := range body {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, body[start:])
+}
+
+// stripSQLStrings membuang literal string agar pemeriksaan sintaks hanya
+// melihat kode SQL; error bila ada string yang tidak ditutup.
+func stripSQLStrings(statement string) (string, error) {
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		if c == '\'' {
+			if inString && i+1 < len(statement) && statement[i+1] == '\'' {
+				i++
+				continue
+			}
+			inString = !inString
+			out.WriteByte('\'')
+			continue
+		}
+		if !inString {
+			out.WriteByte(c)
+		}
+	}
+	if inString {
+		return "", fmt.Errorf("string tidak ditutup")
+	}
+	return out.String(), nil
+}
+
+// checkDialectSyntax pemeriksaan sintaks ringan: kurung seimbang dan tidak
+// ada konstruksi milik dialek lain.
+func checkDialectSyntax(dialect, statement string) error {
+	code, err := stripSQLStrings(statement)
+	if err != nil {
+		return err
+	}
+	if strings.Count(code, "(") != strings.Count(code, ")") {
+		return fmt.Errorf("kurung tidak seimbang")
+	}
+	upper := strings.ToUpper(code)
+	forbidden := map[string][]string{
+		"mysql":    {`"`, "SERIAL", "TIMESTAMPTZ", "ON CONFLICT", "ADD COLUMN IF NOT EXISTS", "INDEX IF NOT EXISTS", "DROP INDEX IF EXISTS", "AUTOINCREMENT"},
+		"postgres": {"`", "AUTO_INCREMENT", "AUTOINCREMENT", "DATETIME", "ENGINE=", "ON DUPLICATE KEY"},
+	}[dialect]
+	for _, token := range forbidden {
+		if strings.Contains(upper, token) {
+			return fmt.Errorf("memakai %q yang tidak didukung %s", token, dialect)
+		}
+	}
+	if dialect == "postgres" && regexp.MustCompile(`(?i)ALTER TABLE \S+ DROP INDEX`).MatchString(code) {
+		return fmt.Errorf("postgres tidak punya ALTER TABLE ... DROP INDEX")
+	}
+	return nil
+}
+
+func TestSchemaMigrationService_MySQLAndPostgresSets(t *testing.T) {
+	naming := openEmptySQLiteDB(t)
+	for _, dialect := range []string{"mysql", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			files, err := NewSchemaMigrationService(nil, dialect).(*schemaMigrationService).loadMigrations()
+			require.NoError(t, err)
+			schema := &sqlSchema{tables: map[string]map[string]bool{}, indexes: map[string]string{}}
+
+			for _, file := range files {
+				for _, statement := range splitSQLStatements(file.Up) {
+					require.NoError(t, checkDialectSyntax(dialect, statement), "%06d up: %s", file.Version, statement)
+					require.NoError(t, schema.apply(statement), "%06d_%s up", file.Version, file.Name)
+				}
+			}
+
+			// Skema akhir harus memuat semua tabel, kolom dan index model
+			for _, model := range schemaModels() {
+				modelSchema, err := parseModelSchema(naming, model)
+				require.NoError(t, err)
+				columns := schema.tables[modelSchema.Table]
+				require.NotNil(t, columns, "tabel %s", modelSchema.Table)
+				for _, field := range modelSchema.Fields {
+					if field.DBName != "" {
+						assert.True(t, columns[field.DBName], "%s.%s", modelSchema.Table, field.DBName)
+					}
+				}
+				for _, index := range modelSchema.ParseIndexes() {
+					assert.Equal(t, modelSchema.Table, schema.indexes[index.Name], "index %s", index.Name)
+				}
+			}
+
+			// Rollback semua versi harus mengosongkan skema lagi; ini juga
+			// menjaga 000001/000002 yang dinomori ulang tetap berpasangan
+			for i := len(files) - 1; i >= 0; i-- {
+				for _, statement := range splitSQLStatements(files[i].Down) {
+					require.NoError(t, checkDialectSyntax(dialect, statement), "%06d down: %s", files[i].Version, statement)
+					require.NoError(t, schema.apply(statement), "%06d_%s down", files[i].Version, files[i].Name)
+				}
+				if files[i].Version == 2 {
+					assert.Nil(t, schema.tables["item_templates"], "000002 down menghapus item_templates")
+					assert.NotNil(t, schema.tables["users"], "000002 down tidak menyentuh tabel 000001")
+				}
+			}
+			tables := make([]string, 0, len(schema.tables))
+			for table := range schema.tables {
+				tables = append(tables, table)
+			}
+			sort.Strings(tables)
+			assert.Empty(t, tables)
+			assert.Empty(t, schema.indexes)
+		})
+	}
+}
+
+func TestSchemaMigrationService_ParserCatchesBrokenSets(t *testing.T) {
+	schema := &sqlSchema{tables: map[string]map[string]bool{}, indexes: map[string]string{}}
+	require.NoError(t, schema.apply("CREATE TABLE `a` (\n`id` integer,\nINDEX `idx_a_id` (`id`)\n) ENGINE=InnoDB;"))
+	assert.Error(t, schema.apply(`CREATE TABLE "a" ("id" INTEGER);`), "tabel ganda")
+	assert.Error(t, schema.apply("ALTER TABLE `a` ADD COLUMN `id` integer;"), "kolom ganda")
+	assert.Error(t, schema.apply(`CREATE INDEX "idx_b" ON "b"("id");`), "tabel tidak ada")
+	assert.Error(t, schema.apply("DROP INDEX `idx_a_id` ON `b`;"), "index milik tabel lain")
+	assert.Error(t, schema.apply("DROP TABLE `b`;"))
+	assert.NoError(t, schema.apply(`DROP TABLE IF EXISTS "b";`))
+
+	assert.Error(t, checkDialectSyntax("mysql", `CREATE TABLE "a" ("id" SERIAL);`))
+	assert.Error(t, checkDialectSyntax("postgres", "CREATE TABLE `a` (`id` integer AUTO_INCREMENT);"))
+	assert.Error(t, checkDialectSyntax("postgres", `ALTER TABLE "a" DROP INDEX "idx";`))
+	assert.Error(t, checkDialectSyntax("postgres", `INSERT INTO "a" VALUES ('x);`))
+	assert.Error(t, checkDialectSyntax("mysql", "CREATE TABLE `a` (`id` integer;"))
+	assert.NoError(t, checkDialectSyntax("mysql", "INSERT INTO `a` VALUES ('{\"k\":\"(\"}');"))
+}
+
+// TestSchemaMigrationService_ServerIntegration menjalankan migrasi ke server
+// sungguhan, mis. MySQL / Postgres lokal via docker. Dilewati bila
+// SIMDOKPOL_TEST_MYSQL_DSN / SIMDOKPOL_TEST_POSTGRES_DSN kosong. Database
+// tujuan harus kosong.
+func TestSchemaMigrationService_ServerIntegration(t *testing.T) {
+	targets := []struct {
+		dialect string
+		env     string
+		open    func(dsn string) gorm.Dialector
+	}{
+		{"mysql", "SIMDOKPOL_TEST_MYSQL_DSN", mysql.Open},
+		{"postgres", "SIMDOKPOL_TEST_POSTGRES_DSN", postgres.Open},
+	}
+	for _, target := range targets {
+		t.Run(target.dialect, func(t *testing.T) {
+			dsn := os.Getenv(target.env)
+			if dsn == "" {
+				t.Skipf("%s tidak diatur", target.env)
+			}
+			db, err := gorm.Open(target.open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			service := NewSchemaMigrationService(db, target.dialect)
+
+			applied, err := service.Up(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, models.SchemaVersion, applied)
+			for _, model := range schemaModels() {
+				modelSchema, err := parseModelSchema(db, model)
+				require.NoError(t, err)
+				for _, field := range modelSchema.Fields {
+					if field.DBName != "" {
+						assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", modelSchema.Table, field.DBName)
+					}
+				}
+			}
+
+			rolledBack, err := service.Down(context.Background(), models.SchemaVersion)
+			require.NoError(t, err)
+			assert.Equal(t, models.SchemaVersion, rolledBack)
+			assert.False(t, db.Migrator().HasTable(&models.User{}))
+		})
+	}
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/migrations"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchemaMigrationService menjalankan file migrasi SQL bernomor dari folder
+// migrations/ (di-embed ke binary) dan mencatat versi yang sudah dijalankan
+// di tabel schema_migrations.
+type SchemaMigrationService interface {
+	Status() (*dto.SchemaStatus, error)
+	// Up menjalankan semua migrasi yang belum tercatat dan mengembalikan
+	// jumlahnya. Dipanggil setiap aplikasi start.
+	Up(ctx context.Context) (int, error)
+	// Down membatalkan steps migrasi terakhir memakai file .down.sql.
+	Down(ctx context.Context, steps int) (int, error)
+	// Force menandai database berada di versi tertentu tanpa menjalankan SQL
+	// apa pun; dipakai setelah memperbaiki migrasi yang gagal (dirty).
+	Force(ctx context.Context, version int) error
+}
+
+type schemaMigrationService struct {
+	db      *gorm.DB
+	dialect string
+	source  fs.FS // isi folder migrations/<dialek>
+}
+
+type schemaMigrationFile struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+const (
+	schemaAdvisoryLockID = 7361367 // kunci pg_advisory_lock
+	schemaMySQLLockName  = "simdokpol_schema_migration"
+)
+
+var (
+	schemaLockTimeout   = 3 * time.Minute
+	schemaLockPoll      = 500 * time.Millisecond
+	schemaSQLiteLockAge = 2 * time.Minute // kunci SQLite lebih tua dari ini dianggap basi
+
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+func NewSchemaMigrationService(db *gorm.DB, dialect string) SchemaMigrationService {
+	if dialect != "mysql" && dialect != "postgres" {
+		dialect = "sqlite"
+	}
+	source, err := fs.Sub(migrations.Files, dialect)
+	if err != nil {
+		log.Printf("ERROR: folder migrasi %s tidak ditemukan: %v", dialect, err)
+	}
+	return &schemaMigrationService{db: db, dialect: dialect, source: source}
+}
+
+// loadMigrations membaca pasangan file up/down dan mengurutkannya per versi.
+func (s *schemaMigrationService) loadMigrations() ([]schemaMigrationFile, error) {
+	if s.source == nil {
+		return nil, fmt.Errorf("%w: dialek %s", ErrMigrationNotFound, s.dialect)
+	}
+	entries, err := fs.ReadDir(s.source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca folder migrasi: %w", err)
+	}
+	byVersion := make(map[int]*schemaMigrationFile)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(s.source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca %s: %w", entry.Name(), err)
+		}
+		file := byVersion[version]
+		if file == nil {
+			file = &schemaMigrationFile{Version: version, Name: match[2]}
+			byVersion[version] = file
+		}
+		if match[3] == "up" {
+			file.Up = string(content)
+		} else {
+			file.Down = string(content)
+		}
+	}
+
+	files := make([]schemaMigrationFile, 0, len(byVersion))
+	for _, file := range byVersion {
+		if file.Up == "" {
+			return nil, fmt.Errorf("%w: %06d_%s.up.sql", ErrMigrationNotFound, file.Version, file.Name)
+		}
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// splitSQLStatements memecah isi file per pernyataan. Pemisahnya titik koma
+// di akhir baris; baris komentar (termasuk penanda "-- +migrate") dibuang.
+func splitSQLStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if statement := strings.TrimSpace(current.String()); statement != ";" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+func (s *schemaMigrationService) appliedMigrations() ([]models.SchemaMigration, error) {
+	var applied []models.SchemaMigration
+	if err := s.db.Order("version ASC").Find(&applied).Error; err != nil {
+		return nil, fmt.Errorf("gagal membaca tabel schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func (s *schemaMigrationService) Status() (*dto.SchemaStatus, error) {
+	files, err := s.loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	status := &dto.SchemaStatus{Dialect: s.dialect, Migrations: []dto.SchemaMigrationInfo{}}
+	if len(files) > 0 {
+		status.LatestVersion = files[len(files)-1].Version
+	}
+
+	appliedByVersion := make(map[int]models.SchemaMigration)
+	if s.db.Migrator().HasTable(&models.SchemaMigration{}) {
+		applied, err := s.appliedMigrations()
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range applied {
+			appliedByVersion[row.Version] = row
+		}
+	}
+
+	for _, file := range files {
+		info := dto.SchemaMigrationInfo{Version: file.Version, Name: file.Name}
+		if row, ok := appliedByVersion[file.Version]; ok {
+			appliedAt := row.AppliedAt
+			info.Applied, info.Dirty, info.AppliedAt = true, row.Dirty, &appliedAt
+			delete(appliedByVersion, file.Version)
+		} else {
+			status.Pending++
+		}
+		status.Migrations = append(status.Migrations, info)
+	}
+	// Versi yang tercatat tapi file-nya tidak ada: dibuat aplikasi yang lebih baru
+	for _, row := range appliedByVersion {
+		appliedAt := row.AppliedAt
+		status.Migrations = append(status.Migrations, dto.SchemaMigrationInfo{
+			Version: row.Version, Name: row.Name, Applied: true, Dirty: row.Dirty, AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(status.Migrations, func(i, j int) bool { return status.Migrations[i].Version < status.Migrations[j].Version })
+
+	for _, info := range status.Migrations {
+		if info.Applied && info.Version > status.CurrentVersion {
+			status.CurrentVersion = info.Version
+		}
+		status.Dirty = status.Dirty || info.Dirty
+	}
+	return status, nil
+}
+
+func (s *schemaMigrationService) Up(ctx context.Context) (int, error) {
+	files, err := s.loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	// Database lama dibuat AutoMigrate tanpa catatan migrasi
+	legacy := s.db.Migrator().HasTable(&models.User{}) && !s.db.Migrator().HasTable(&models.SchemaMigration{})
+
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := s.checkApplied(files)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 && legacy {
+		return 0, s.baseline(ctx, files)
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, row := range applied {
+		done[row.Version] = true
+	}
+	count := 0
+	for _, file := range files {
+		if done[file.Version] {
+			continue
+		}
+		if err := s.apply(ctx, file, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *schemaMigrationService) Down(ctx context.Context, steps int) (int, error) {
+	if steps < 1 {
+		return 0, fmt.Errorf("jumlah langkah rollback minimal 1")
+	}
+	files, err := s.loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := s.checkApplied(files)
+	if err != nil {
+		return 0, err
+	}
+	byVersion := make(map[int]schemaMigrationFile, len(files))
+	for _, file := range files {
+		byVersion[file.Version] = file
+	}
+
+	count := 0
+	for i := len(applied) - 1; i >= 0 && count < steps; i-- {
+		file := byVersion[applied[i].Version]
+		if file.Down == "" {
+			return count, fmt.Errorf("%w: %06d_%s.down.sql", ErrMigrationNotFound, file.Version, file.Name)
+		}
+		if err := s.apply(ctx, file, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *schemaMigrationService) Force(ctx context.Context, version int) error {
+	files, err := s.loadMigrations()
+	if err != nil {
+		return err
+	}
+	known := version == 0
+	for _, file := range files {
+		known = known || file.Version == version
+	}
+	if !known {
+		return fmt.Errorf("%w: versi %d", ErrMigrationNotFound, version)
+	}
+
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("version > ?", version).Delete(&models.SchemaMigration{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SchemaMigration{}).Where("dirty = ?", true).Update("dirty", false).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, file := range files {
+			if file.Version > version {
+				break
+			}
+			row := models.SchemaMigration{Version: file.Version, Name: file.Name, AppliedAt: now}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("WARN: versi skema database ditandai %d secara manual (force)", version)
+		return nil
+	})
+}
+
+// checkApplied membaca versi yang tercatat dan menolak database yang dirty
+// atau sudah dimigrasi oleh aplikasi yang lebih baru.
+func (s *schemaMigrationService) checkApplied(files []schemaMigrationFile) ([]models.SchemaMigration, error) {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(files) > 0 {
+		latest = files[len(files)-1].Version
+	}
+	for _, row := range applied {
+		if row.Dirty {
+			return nil, fmt.Errorf("%w: versi %d (%s)", ErrSchemaDirty, row.Version, row.Name)
+		}
+		if row.Version > latest {
+			return nil, fmt.Errorf("%w: database versi %d, aplikasi ini hanya mengenal sampai versi %d", ErrSchemaTooNew, row.Version, latest)
+		}
+	}
+	return applied, nil
+}
+
+// schemaModels semua tabel yang dibuat file migrasi (selain tabel pencatat
+// migrasi itu sendiri).
+func schemaModels() []interface{} {
+	return append(portableBackupModels(), &models.BackupRun{}, &models.ServerInstance{}, &models.SessionRevocation{})
+}
+
+// baseline dipakai sekali untuk database yang dibuat sebelum migrasi SQL
+// dijalankan (hanya AutoMigrate): struktur dilengkapi lewat AutoMigrate lalu
+// semua versi dicatat sebagai sudah berjalan.
+func (s *schemaMigrationService) baseline(ctx context.Context, files []schemaMigrationFile) error {
+	if err := s.db.WithContext(ctx).AutoMigrate(schemaModels()...); err != nil {
+		return fmt.Errorf("gagal melengkapi skema database lama: %w", err)
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, file := range files {
+			if err := tx.Create(&models.SchemaMigration{Version: file.Version, Name: file.Name, AppliedAt: now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("gagal mencatat baseline migrasi: %w", err)
+	}
+	if len(files) > 0 {
+		log.Printf("INFO: database lama ditandai di versi skema %d", files[len(files)-1].Version)
+	}
+	return nil
+}
+
+// apply menjalankan satu file migrasi. SQLite dan PostgreSQL menjalankan
+// DDL beserta catatan versinya dalam satu transaksi. DDL MySQL langsung
+// ter-commit, jadi versinya ditandai dirty dulu dan baru dibersihkan setelah
+// semua pernyataan berhasil.
+func (s *schemaMigrationService) apply(ctx context.Context, file schemaMigrationFile, up bool) error {
+	content, direction := file.Up, "up"
+	if !up {
+		content, direction = file.Down, "down"
+	}
+	statements := splitSQLStatements(content)
+	wrap := func(err error) error {
+		return fmt.Errorf("migrasi %06d_%s (%s) gagal: %w", file.Version, file.Name, direction, err)
+	}
+	record := models.SchemaMigration{Version: file.Version, Name: file.Name, AppliedAt: time.Now()}
+
+	if s.dialect != "mysql" {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			if up {
+				return tx.Create(&record).Error
+			}
+			return tx.Delete(&models.SchemaMigration{}, file.Version).Error
+		})
+		if err != nil {
+			return wrap(err)
+		}
+		log.Printf("INFO: migrasi %06d_%s (%s) selesai", file.Version, file.Name, direction)
+		return nil
+	}
+
+	db := s.db.WithContext(ctx)
+	var err error
+	if up {
+		record.Dirty = true
+		err = db.Create(&record).Error
+	} else {
+		err = db.Model(&models.SchemaMigration{}).Where("version = ?", file.Version).Update("dirty", true).Error
+	}
+	if err != nil {
+		return wrap(err)
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return wrap(err)
+		}
+	}
+	if up {
+		err = db.Model(&models.SchemaMigration{}).Where("version = ?", file.Version).Update("dirty", false).Error
+	} else {
+		err = db.Delete(&models.SchemaMigration{}, file.Version).Error
+	}
+	if err != nil {
+		return wrap(err)
+	}
+	log.Printf("INFO: migrasi %06d_%s (%s) selesai", file.Version, file.Name, direction)
+	return nil
+}
+
+// lock memastikan hanya satu proses (server atau CLI migrate) yang
+// menjalankan migrasi. Kunci MySQL/PostgreSQL melekat di satu koneksi
+// khusus sehingga otomatis lepas bila proses mati.
+func (s *schemaMigrationService) lock(ctx context.Context) (func(), error) {
+	if err := s.db.WithContext(ctx).AutoMigrate(&models.SchemaMigration{}, &models.SchemaMigrationLock{}); err != nil {
+		return nil, fmt.Errorf("gagal menyiapkan tabel schema_migrations: %w", err)
+	}
+	if s.dialect == "sqlite" {
+		return s.lockSQLite(ctx)
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tryLock, unlock := "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+	var key interface{} = schemaAdvisoryLockID
+	if s.dialect == "mysql" {
+		tryLock, unlock, key = "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", schemaMySQLLockName
+	}
+
+	deadline := time.Now().Add(schemaLockTimeout)
+	for {
+		var acquired sql.NullBool
+		if err := conn.QueryRowContext(ctx, tryLock, key).Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("gagal mengambil kunci migrasi: %w", err)
+		}
+		if acquired.Valid && acquired.Bool {
+			return func() {
+				if _, err := conn.ExecContext(context.Background(), unlock, key); err != nil {
+					log.Printf("WARN: gagal melepas kunci migrasi: %v", err)
+				}
+				conn.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			conn.Close()
+			return nil, ErrSchemaLocked
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(schemaLockPoll):
+		}
+	}
+}
+
+func (s *schemaMigrationService) lockSQLite(ctx context.Context) (func(), error) {
+	ownerBytes := make([]byte, 16)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(ownerBytes)
+	db := s.db.WithContext(ctx)
+
+	deadline := time.Now().Add(schemaLockTimeout)
+	for {
+		now := time.Now()
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SchemaMigrationLock{ID: 1, Owner: owner, LockedAt: now})
+		if result.Error != nil {
+			return nil, fmt.Errorf("gagal mengambil kunci migrasi: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// Ambil alih kunci sisa proses yang mati di tengah migrasi
+			result = db.Model(&models.SchemaMigrationLock{}).
+				Where("id = ? AND locked_at < ?", 1, now.Add(-schemaSQLiteLockAge)).
+				Updates(map[string]interface{}{"owner": owner, "locked_at": now})
+			if result.Error != nil {
+				return nil, fmt.Errorf("gagal mengambil kunci migrasi: %w", result.Error)
+			}
+		}
+		if result.RowsAffected == 1 {
+			return func() {
+				err := s.db.Where("id = ? AND owner = ?", 1, owner).Delete(&models.SchemaMigrationLock{}).Error
+				if err != nil {
+					log.Printf("WARN: gagal melepas kunci migrasi: %v", err)
+				}
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrSchemaLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(schemaLockPoll):
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"simdokpol/internal/models"
+	"simdokpol/migrations"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openEmptySQLiteDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "skema.db")+"?_foreign_keys=on"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return db
+}
+
+func TestSchemaMigrationService_LatestVersionMatchesModels(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			service := NewSchemaMigrationService(nil, dialect).(*schemaMigrationService)
+			files, err := service.loadMigrations()
+			require.NoError(t, err)
+			require.Len(t, files, models.SchemaVersion, "nomor migrasi semua dialek harus sejajar")
+			for i, file := range files {
+				assert.Equal(t, i+1, file.Version)
+				assert.NotEmpty(t, file.Down, "%06d_%s tidak punya file down", file.Version, file.Name)
+			}
+		})
+	}
+}
+
+func TestSchemaMigrationService_UpMatchesModelsAndRollsBack(t *testing.T) {
+	db := openEmptySQLiteDB(t)
+	service := NewSchemaMigrationService(db, "sqlite")
+
+	applied, err := service.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, applied)
+
+	// Skema dari file SQL harus memuat semua kolom dan index model
+	for _, model := range schemaModels() {
+		modelSchema, err := parseModelSchema(db, model)
+		require.NoError(t, err)
+		require.True(t, db.Migrator().HasTable(model), modelSchema.Table)
+		for _, field := range modelSchema.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", modelSchema.Table, field.DBName)
+			}
+		}
+		for _, index := range modelSchema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "%s: %s", modelSchema.Table, index.Name)
+		}
+	}
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, status.CurrentVersion)
+	assert.Zero(t, status.Pending)
+
+	applied, err = service.Up(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, applied, "migrasi yang sudah tercatat tidak dijalankan ulang")
+
+	rolledBack, err := service.Down(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rolledBack)
+	assert.False(t, db.Migrator().HasTable(&models.SessionRevocation{}))
+	assert.True(t, db.Migrator().HasTable(&models.ServerInstance{}))
+
+	rolledBack, err = service.Down(context.Background(), models.SchemaVersion)
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion-1, rolledBack)
+	assert.False(t, db.Migrator().HasTable(&models.User{}))
+	status, err = service.Status()
+	require.NoError(t, err)
+	assert.Zero(t, status.CurrentVersion)
+
+	applied, err = service.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, applied)
+}
+
+func TestSchemaMigrationService_BaselinesLegacyDatabase(t *testing.T) {
+	db := openBackupTestDB(t, "lama.db")
+	seedBackupData(t, db)
+	service := NewSchemaMigrationService(db, "sqlite")
+
+	applied, err := service.Up(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, status.CurrentVersion)
+	assert.Zero(t, status.Pending)
+	assertBackupDataRestored(t, db)
+}
+
+func TestSchemaMigrationService_RefusesNewerOrDirtySchema(t *testing.T) {
+	db := openEmptySQLiteDB(t)
+	service := NewSchemaMigrationService(db, "sqlite")
+	_, err := service.Up(context.Background())
+	require.NoError(t, err)
+
+	newer := models.SchemaMigration{Version: models.SchemaVersion + 1, Name: "dari_versi_baru", AppliedAt: time.Now()}
+	require.NoError(t, db.Create(&newer).Error)
+	_, err = service.Up(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion+1, status.CurrentVersion)
+	require.NoError(t, db.Delete(&newer).Error)
+
+	require.NoError(t, db.Model(&models.SchemaMigration{}).Where("version = ?", models.SchemaVersion).Update("dirty", true).Error)
+	_, err = service.Up(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaDirty)
+	_, err = service.Down(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrSchemaDirty)
+
+	require.NoError(t, service.Force(context.Background(), models.SchemaVersion))
+	_, err = service.Up(context.Background())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, service.Force(context.Background(), models.SchemaVersion+5), ErrMigrationNotFound)
+}
+
+func TestSchemaMigrationService_FailedMigrationRollsBack(t *testing.T) {
+	db := openEmptySQLiteDB(t)
+	service := &schemaMigrationService{db: db, dialect: "sqlite", source: fstest.MapFS{
+		"000001_tabel_a.up.sql":   {Data: []byte("-- tabel pertama\nCREATE TABLE a (id integer);\nINSERT INTO a (id) VALUES (1);\n")},
+		"000001_tabel_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+		"000002_rusak.up.sql":     {Data: []byte("CREATE TABLE b (id integer);\nINI BUKAN SQL;\n")},
+		"000002_rusak.down.sql":   {Data: []byte("DROP TABLE b;\n")},
+	}}
+
+	applied, err := service.Up(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "000002_rusak")
+	assert.Equal(t, 1, applied)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.False(t, db.Migrator().HasTable("b"), "DDL migrasi yang gagal ikut di-rollback")
+
+	status, err := service.Status()
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.CurrentVersion)
+	assert.Equal(t, 1, status.Pending)
+	assert.False(t, status.Dirty)
+}
+
+func TestSchemaMigrationService_Lock(t *testing.T) {
+	previousTimeout, previousPoll := schemaLockTimeout, schemaLockPoll
+	schemaLockTimeout, schemaLockPoll = 50*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { schemaLockTimeout, schemaLockPoll = previousTimeout, previousPoll })
+
+	db := openEmptySQLiteDB(t)
+	service := NewSchemaMigrationService(db, "sqlite")
+	require.NoError(t, db.AutoMigrate(&models.SchemaMigrationLock{}))
+	held := models.SchemaMigrationLock{ID: 1, Owner: "proses-lain", LockedAt: time.Now()}
+	require.NoError(t, db.Create(&held).Error)
+
+	_, err := service.Up(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaLocked)
+	assert.False(t, db.Migrator().HasTable(&models.User{}))
+
+	// Kunci sisa proses yang mati diambil alih
+	require.NoError(t, db.Model(&held).Update("locked_at", time.Now().Add(-time.Hour)).Error)
+	applied, err := service.Up(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersion, applied)
+	var locks int64
+	require.NoError(t, db.Model(&models.SchemaMigrationLock{}).Count(&locks).Error)
+	assert.Zero(t, locks, "kunci dilepas setelah selesai")
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	content, err := fs.ReadFile(migrations.Files, "mysql/000002_add_item_templates.up.sql")
+	require.NoError(t, err)
+	statements := splitSQLStatements(string(content))
+	require.Len(t, statements, 2)
+	assert.Contains(t, statements[0], "CREATE TABLE `item_templates`")
+	assert.Contains(t, statements[1], "('LAINNYA', '[]', 99);")
+
+	assert.Equal(t, []string{"SELECT 1;", "SELECT 'a;b'"}, splitSQLStatements("-- +migrate Up\r\nSELECT 1;\r\n\r\nSELECT 'a;b'"))
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchTables dibaca pencarian global serta daftar surat dan penduduk.
+var searchTables = []string{"lost_documents", "lost_items", "residents"}
+
+// ReindexSearch membangun ulang indeks tabel pencarian dan memperbarui
+// statistik planner. Pencarian memakai LIKE di atas indeks biasa (belum ada
+// indeks full-text), jadi ini yang dipulihkan setelah impor/restore besar
+// atau saat pencarian terasa lambat.
+func ReindexSearch(ctx context.Context, db *gorm.DB, dialect string) ([]string, error) {
+	for _, table := range searchTables {
+		var statements []string
+		switch dialect {
+		case "postgres":
+			statements = []string{"REINDEX TABLE " + table, "ANALYZE " + table}
+		case "mysql":
+			// InnoDB tidak punya REINDEX; OPTIMIZE membangun ulang tabel beserta indeksnya
+			statements = []string{"OPTIMIZE TABLE " + table, "ANALYZE TABLE " + table}
+		default:
+			statements = []string{"REINDEX " + table, "ANALYZE " + table}
+		}
+		for _, statement := range statements {
+			if err := db.WithContext(ctx).Exec(statement).Error; err != nil {
+				return nil, fmt.Errorf("%s: %w", statement, err)
+			}
+		}
+	}
+	return searchTables, nil
+}
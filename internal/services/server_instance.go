@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	serverHeartbeatInterval = 30 * time.Second
+	// serverHeartbeatStale detak lebih tua dari ini dianggap sisa server yang mati
+	serverHeartbeatStale = 3 * serverHeartbeatInterval
+)
+
+// StartServerHeartbeat mencatat server ini di tabel server_instances dan
+// memperbarui detaknya berkala, supaya perintah admin di proses lain tahu
+// database sedang dipakai. Fungsi yang dikembalikan menghapus catatan itu.
+func StartServerHeartbeat(db *gorm.DB) (stop func()) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Printf("WARN: detak server tidak dicatat: %v", err)
+		return func() {}
+	}
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	instance := models.ServerInstance{
+		ID:        hex.EncodeToString(idBytes),
+		Hostname:  hostname,
+		PID:       os.Getpid(),
+		StartedAt: now,
+	}
+
+	failing := false
+	beat := func() {
+		instance.HeartbeatAt = time.Now()
+		// Save menyisipkan ulang bila barisnya hilang (mis. database baru dipulihkan)
+		if err := db.Save(&instance).Error; err != nil {
+			if !failing {
+				log.Printf("WARN: gagal mencatat detak server: %v", err)
+			}
+			failing = true
+			return
+		}
+		failing = false
+	}
+	beat()
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(serverHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				leave, _ := Maintenance.Hold(context.Background())
+				beat()
+				leave()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopCh)
+			<-done
+			if err := db.Delete(&models.ServerInstance{}, "id = ?", instance.ID).Error; err != nil {
+				log.Printf("WARN: gagal menghapus catatan detak server: %v", err)
+			}
+		})
+	}
+}
+
+// ActiveServerInstances server yang detaknya masih segar di database ini.
+func ActiveServerInstances(ctx context.Context, db *gorm.DB) ([]models.ServerInstance, error) {
+	var instances []models.ServerInstance
+	err := db.WithContext(ctx).
+		Where("heartbeat_at >= ?", time.Now().Add(-serverHeartbeatStale)).
+		Order("started_at").
+		Find(&instances).Error
+	return instances, err
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerHeartbeat(t *testing.T) {
+	db := openBackupTestDB(t, "detak.db")
+	require.NoError(t, db.AutoMigrate(&models.ServerInstance{}))
+
+	stop := StartServerHeartbeat(db)
+	instances, err := ActiveServerInstances(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, os.Getpid(), instances[0].PID)
+
+	stop()
+	stop()
+	instances, err = ActiveServerInstances(context.Background(), db)
+	require.NoError(t, err)
+	assert.Empty(t, instances, "catatan dihapus saat server berhenti")
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"simdokpol/internal/repositories"
+	"time"
+)
+
+// SessionRevocationService mencatat sesi SSO yang sudah di-logout oleh IdP
+// (back-channel logout) di database, sehingga pencabutan tetap berlaku
+// setelah server restart dan di semua server yang memakai database yang sama.
+type SessionRevocationService interface {
+	// Revoke menandai sesi dengan kunci tersebut sebagai tidak berlaku: semua
+	// JWT yang terbit sebelum saat ini ditolak.
+	Revoke(keys ...string) error
+	// IsRevoked mengecek apakah JWT yang terbit pada issuedAt sudah dicabut.
+	IsRevoked(issuedAt time.Time, keys ...string) (bool, error)
+}
+
+type sessionRevocationService struct {
+	repo          repositories.SessionRevocationRepository
+	configService ConfigService
+}
+
+func NewSessionRevocationService(repo repositories.SessionRevocationRepository, configService ConfigService) SessionRevocationService {
+	return &sessionRevocationService{repo: repo, configService: configService}
+}
+
+func (s *sessionRevocationService) Revoke(keys ...string) error {
+	keys = nonEmptyKeys(keys)
+	if len(keys) == 0 {
+		return nil
+	}
+	now := time.Now()
+	if err := s.repo.DeleteExpired(now); err != nil {
+		return err
+	}
+	// JWT yang terbit sebelum pencabutan paling lama berlaku selama
+	// session_timeout; setelah itu catatannya tidak diperlukan lagi
+	config, _ := s.configService.GetConfig()
+	return s.repo.Revoke(keys, now, now.Add(sessionLifetime(config)))
+}
+
+func (s *sessionRevocationService) IsRevoked(issuedAt time.Time, keys ...string) (bool, error) {
+	keys = nonEmptyKeys(keys)
+	if len(keys) == 0 {
+		return false, nil
+	}
+	return s.repo.IsRevoked(keys, issuedAt)
+}
+
+func nonEmptyKeys(keys []string) []string {
+	var result []string
+	for _, key := range keys {
+		if key != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// SessionKeys menyusun kunci pencabutan dari klaim sid dan sub SSO.
+func SessionKeys(sid, sub string) []string {
+	var keys []string
+	if sid != "" {
+		keys = append(keys, "sid:"+sid)
+	}
+	if sub != "" {
+		keys = append(keys, "sub:"+sub)
+	}
+	return keys
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRevocationService(t *testing.T) {
+	db := openEmptySQLiteDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SessionRevocation{}))
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig").Return(&dto.AppConfig{SessionTimeout: 30}, nil)
+	newService := func() SessionRevocationService {
+		return NewSessionRevocationService(repositories.NewSessionRevocationRepository(db), mockConfig)
+	}
+
+	issuedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, newService().Revoke(SessionKeys("sesi-1", "")...))
+
+	t.Run("Pencabutan berlaku untuk server lain dan setelah restart", func(t *testing.T) {
+		revoked, err := newService().IsRevoked(issuedAt, SessionKeys("sesi-1", "user-1")...)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = newService().IsRevoked(issuedAt, SessionKeys("sesi-2", "user-1")...)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("Masa simpan mengikuti session_timeout", func(t *testing.T) {
+		var revocation models.SessionRevocation
+		require.NoError(t, db.First(&revocation, "session_key = ?", "sid:sesi-1").Error)
+		assert.WithinDuration(t, revocation.RevokedAt.Add(30*time.Minute), revocation.ExpiresAt, time.Second)
+	})
+
+	t.Run("Catatan kedaluwarsa dibersihkan saat pencabutan berikutnya", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, db.Create(&models.SessionRevocation{SessionKey: "sid:lama", RevokedAt: past.Add(-time.Hour), ExpiresAt: past}).Error)
+
+		require.NoError(t, newService().Revoke(SessionKeys("", "user-2")...))
+
+		var keys []string
+		require.NoError(t, db.Model(&models.SessionRevocation{}).Order("session_key").Pluck("session_key", &keys).Error)
+		assert.Equal(t, []string{"sid:sesi-1", "sub:user-2"}, keys)
+	})
+}
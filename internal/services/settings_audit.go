@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"simdokpol/internal/dto"
+	"sort"
+)
+
+const redactedSetting = "********"
+
+// secretSettingKeys tidak pernah ditulis apa adanya ke log audit.
+var secretSettingKeys = map[string]bool{
+	"db_pass":            true,
+	"ldap_bind_password": true,
+	"oidc_client_secret": true,
+
+	"backup_encryption_passphrase": true,
+	"backup_offsite_sftp_password": true,
+	"backup_offsite_s3_secret_key": true,
+}
+
+// IsSecretSetting: nilai kosong untuk key ini berarti "tidak diubah".
+func IsSecretSetting(key string) bool {
+	return secretSettingKeys[key]
+}
+
+// SettingsSnapshot mengambil nilai lama untuk key yang akan diubah (isi
+// Before di log audit). Nilai LDAP/OIDC/penerusan audit/jadwal backup ada di
+// objek bersarang (ldap_url -> ldap.url).
+func SettingsSnapshot(config *dto.AppConfig, changed map[string]string) map[string]interface{} {
+	var flat map[string]interface{}
+	raw, _ := json.Marshal(config)
+	_ = json.Unmarshal(raw, &flat)
+	for _, section := range []string{"ldap", "oidc", "audit_syslog", "audit_file", "backup_schedule", "backup_encryption", "backup_offsite"} {
+		if nested, ok := flat[section].(map[string]interface{}); ok {
+			for key, value := range nested {
+				flat[section+"_"+key] = value
+			}
+		}
+	}
+
+	snapshot := make(map[string]interface{}, len(changed))
+	for key := range changed {
+		if secretSettingKeys[key] {
+			snapshot[key] = redactedSetting
+			continue
+		}
+		if value, ok := flat[key]; ok {
+			snapshot[key] = value
+		}
+	}
+	return snapshot
+}
+
+// RedactSettings menyalin nilai baru untuk After di log audit dengan rahasia
+// (password, secret) disamarkan.
+func RedactSettings(settings map[string]string) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		redacted[key] = value
+		if secretSettingKeys[key] {
+			redacted[key] = redactedSetting
+		}
+	}
+	return redacted
+}
+
+func SortedSettingKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
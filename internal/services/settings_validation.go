@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+)
+
+// groupMappingSettings key pemetaan grup LDAP / peran SSO beserta labelnya.
+var groupMappingSettings = []struct{ key, label string }{
+	{"ldap_group_mappings", "grup LDAP"},
+	{"oidc_role_mappings", "peran SSO"},
+}
+
+// IsDatabaseSetting menandai pengaturan yang menyangkut koneksi database dan
+// tempat/enkripsi/pengiriman backup. Mengubahnya butuh izin database:manage,
+// bukan cukup settings:manage.
+func IsDatabaseSetting(key string) bool {
+	return strings.HasPrefix(key, "db_") || key == "backup_path" ||
+		strings.HasPrefix(key, "backup_encryption_") || strings.HasPrefix(key, "backup_offsite_")
+}
+
+// IsAuthSetting menandai pengaturan sumber login (provider, LDAP, SSO).
+// Pengubahnya bisa mengarahkan login ke direktori/IdP miliknya sendiri lalu
+// masuk sebagai Super Admin, jadi hanya Super Admin yang boleh mengubahnya.
+// Pemetaan grup tidak termasuk; aturannya ada di validateGroupMappings.
+func IsAuthSetting(key string) bool {
+	for _, setting := range groupMappingSettings {
+		if setting.key == key {
+			return false
+		}
+	}
+	return key == "auth_provider" || strings.HasPrefix(key, "ldap_") || strings.HasPrefix(key, "oidc_")
+}
+
+func invalidSetting(message string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSetting, message)
+}
+
+// ValidateSettings memeriksa pengaturan sebelum disimpan, baik dari halaman
+// Pengaturan maupun `admin set-config`. Hanya key yang dikirim yang dicek;
+// error selain ErrInvalidSetting, ErrInvalidGroupMapping dan
+// ErrPrivilegedGroupMapping berarti pengaturan lama gagal dibaca.
+func (s *configService) ValidateSettings(settings map[string]string, allowPrivileged bool) error {
+	for _, key := range []string{"backup_path", "db_dsn", "audit_file_path", "backup_encryption_key_file", "backup_offsite_folder_path", "backup_offsite_sftp_key_file"} {
+		if path, exists := settings[key]; exists && strings.Contains(path, "..") {
+			return invalidSetting(fmt.Sprintf("path %s tidak boleh memuat \"..\"", key))
+		}
+	}
+
+	if !allowPrivileged {
+		var authKeys []string
+		for key, value := range settings {
+			// Rahasia kosong berarti "tidak diubah"
+			if IsAuthSetting(key) && !(value == "" && IsSecretSetting(key)) {
+				authKeys = append(authKeys, key)
+			}
+		}
+		if len(authKeys) > 0 {
+			sort.Strings(authKeys)
+			return fmt.Errorf("%w (%s)", ErrPrivilegedGroupMapping, strings.Join(authKeys, ", "))
+		}
+	}
+
+	if path := settings["audit_file_path"]; path != "" {
+		if _, err := ResolveAuditFilePath(path); err != nil {
+			return invalidSetting(err.Error())
+		}
+	}
+
+	if provider, exists := settings["auth_provider"]; exists && provider != models.AuthSourceLocal && provider != models.AuthSourceLDAP {
+		return invalidSetting("provider autentikasi tidak dikenal")
+	}
+	if err := s.validateGroupMappings(settings, allowPrivileged); err != nil {
+		return err
+	}
+	if issuer, exists := settings["oidc_issuer"]; exists && issuer != "" {
+		parsed, err := url.Parse(issuer)
+		isLocal := err == nil && (parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1")
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && !(parsed.Scheme == "http" && isLocal)) {
+			return invalidSetting("issuer SSO harus berupa URL https")
+		}
+	}
+
+	if network, exists := settings["audit_syslog_network"]; exists && network != "" && network != "udp" && network != "tcp" && network != "tls" {
+		return invalidSetting("protokol syslog harus udp, tcp atau tls")
+	}
+	if address, exists := settings["audit_syslog_address"]; exists && address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return invalidSetting("alamat syslog harus berformat host:port")
+		}
+	}
+
+	if frequency, exists := settings["backup_schedule_frequency"]; exists && frequency != "" && frequency != "daily" && frequency != "weekly" {
+		return invalidSetting("frekuensi backup harus harian atau mingguan")
+	}
+	if clock, exists := settings["backup_schedule_time"]; exists && clock != "" {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return invalidSetting("jam backup harus berformat HH:MM")
+		}
+	}
+	if weekday, exists := settings["backup_schedule_weekday"]; exists && weekday != "" {
+		if day, err := strconv.Atoi(weekday); err != nil || day < 0 || day > 6 {
+			return invalidSetting("hari backup tidak valid")
+		}
+	}
+	if method, exists := settings["backup_schedule_method"]; exists && method != "" && method != BackupMethodSQLite &&
+		method != BackupMethodPgDump && method != BackupMethodMySQLDump && method != BackupMethodPortable {
+		return invalidSetting("metode backup terjadwal tidak dikenal")
+	}
+	for _, key := range []string{"backup_schedule_keep_daily", "backup_schedule_keep_weekly", "backup_schedule_keep_monthly"} {
+		if value, exists := settings[key]; exists && value != "" {
+			if count, err := strconv.Atoi(value); err != nil || count < 0 {
+				return invalidSetting("jumlah backup yang disimpan harus angka 0 atau lebih")
+			}
+		}
+	}
+
+	if mode, exists := settings["backup_encryption_mode"]; exists && mode != "" && mode != BackupEncryptionNone &&
+		mode != BackupEncryptionPassphrase && mode != BackupEncryptionKeyFile {
+		return invalidSetting("mode enkripsi backup tidak dikenal")
+	}
+	if passphrase := settings["backup_encryption_passphrase"]; passphrase != "" && len([]rune(passphrase)) < 12 {
+		return invalidSetting("passphrase backup minimal 12 karakter")
+	}
+	switch settings["backup_encryption_mode"] {
+	case BackupEncryptionPassphrase:
+		// Passphrase boleh dikosongkan bila sebelumnya sudah tersimpan
+		if settings["backup_encryption_passphrase"] == "" {
+			current, err := s.GetConfig()
+			if err != nil {
+				return err
+			}
+			if !current.BackupEncryption.HasPassphrase {
+				return invalidSetting("isi passphrase untuk mengaktifkan enkripsi backup")
+			}
+		}
+	case BackupEncryptionKeyFile:
+		if info, err := os.Stat(settings["backup_encryption_key_file"]); err != nil || info.IsDir() || info.Size() < 32 {
+			return invalidSetting("file kunci backup tidak ditemukan atau kurang dari 32 byte")
+		}
+	}
+
+	if offsiteType, exists := settings["backup_offsite_type"]; exists && offsiteType != "" && offsiteType != BackupOffsiteNone &&
+		offsiteType != BackupOffsiteSFTP && offsiteType != BackupOffsiteS3 && offsiteType != BackupOffsiteFolder {
+		return invalidSetting("tujuan backup off-site tidak dikenal")
+	}
+	if port, exists := settings["backup_offsite_sftp_port"]; exists && port != "" {
+		if value, err := strconv.Atoi(port); err != nil || value <= 0 || value > 65535 {
+			return invalidSetting("port SFTP tidak valid")
+		}
+	}
+	if retries, exists := settings["backup_offsite_retries"]; exists && retries != "" {
+		if value, err := strconv.Atoi(retries); err != nil || value < 0 || value > 10 {
+			return invalidSetting("percobaan ulang kirim backup harus 0 sampai 10")
+		}
+	}
+	if hostKey := strings.TrimSpace(settings["backup_offsite_sftp_host_key"]); hostKey != "" && !strings.HasPrefix(hostKey, "SHA256:") {
+		return invalidSetting("sidik jari host key SFTP harus berformat SHA256:...")
+	}
+	if settings["backup_offsite_type"] == BackupOffsiteFolder {
+		if path := settings["backup_offsite_folder_path"]; path == "" || !filepath.IsAbs(path) {
+			return invalidSetting("folder tujuan backup harus berupa path lengkap")
+		}
+	}
+	return nil
+}
+
+// validateGroupMappings memeriksa format pemetaan grup LDAP dan peran SSO.
+// Tanpa allowPrivileged (pengubah bukan Super Admin), pemetaan ke peran
+// SUPER_ADMIN tidak boleh ditambah, diubah atau dihapus: lewat pemetaan,
+// ADMIN_TU bisa memberi dirinya Super Admin lewat grup direktori. Pemetaan
+// yang dikirim ulang tanpa perubahan tetap diterima.
+func (s *configService) validateGroupMappings(settings map[string]string, allowPrivileged bool) error {
+	var current *dto.AppConfig
+	for _, setting := range groupMappingSettings {
+		raw, exists := settings[setting.key]
+		if !exists {
+			continue
+		}
+		var mappings []dto.GroupMapping
+		if strings.TrimSpace(raw) != "" {
+			if err := json.Unmarshal([]byte(raw), &mappings); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidGroupMapping, setting.label)
+			}
+		}
+		if allowPrivileged {
+			continue
+		}
+
+		if current == nil {
+			var err error
+			if current, err = s.GetConfig(); err != nil {
+				return err
+			}
+		}
+		saved := current.LDAP.GroupMappings
+		if setting.key == "oidc_role_mappings" {
+			saved = current.OIDC.RoleMappings
+		}
+		if !slices.Equal(privilegedMappings(mappings), privilegedMappings(saved)) {
+			return fmt.Errorf("%w (%s)", ErrPrivilegedGroupMapping, setting.label)
+		}
+	}
+	return nil
+}
+
+// privilegedMappings daftar grup yang dipetakan ke SUPER_ADMIN, terurut.
+func privilegedMappings(mappings []dto.GroupMapping) []string {
+	var groups []string
+	for _, mapping := range mappings {
+		if strings.EqualFold(strings.TrimSpace(mapping.Peran), models.RoleSuperAdmin) {
+			groups = append(groups, strings.ToLower(strings.TrimSpace(mapping.Group)))
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
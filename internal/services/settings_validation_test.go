@@ -0,0 +1,124 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigService_ValidateSettings_GroupMappings(t *testing.T) {
+	repo := new(mocks.ConfigRepository)
+	repo.On("GetAll").Return(map[string]string{
+		"ldap_group_mappings": `[{"group":"CN=Pimpinan,DC=polri","peran":"SUPER_ADMIN"},{"group":"Piket","peran":"OPERATOR"}]`,
+	}, nil)
+	service := NewConfigService(repo, nil)
+
+	testCases := []struct {
+		name            string
+		settings        map[string]string
+		allowPrivileged bool
+		expected        error
+	}{
+		{"JSON Rusak", map[string]string{"oidc_role_mappings": "{"}, true, ErrInvalidGroupMapping},
+		{"Kosong Boleh", map[string]string{"oidc_role_mappings": ""}, false, nil},
+		{"Peran Biasa Boleh", map[string]string{"oidc_role_mappings": `[{"group":"kanit","peran":"KANIT"}]`}, false, nil},
+		{"Tambah Super Admin Ditolak", map[string]string{"oidc_role_mappings": `[{"group":"admin","peran":"SUPER_ADMIN"}]`}, false, ErrPrivilegedGroupMapping},
+		{"Huruf Kecil Tetap Ditolak", map[string]string{"oidc_role_mappings": `[{"group":"admin","peran":"super_admin"}]`}, false, ErrPrivilegedGroupMapping},
+		{"Super Admin Boleh", map[string]string{"oidc_role_mappings": `[{"group":"admin","peran":"SUPER_ADMIN"}]`}, true, nil},
+		{"Dikirim Ulang Tanpa Perubahan", map[string]string{
+			"ldap_group_mappings": `[{"group":"Piket","peran":"KANIT"},{"group":"CN=Pimpinan,DC=polri","peran":"SUPER_ADMIN"}]`,
+		}, false, nil},
+		{"Hapus Pemetaan Super Admin Ditolak", map[string]string{"ldap_group_mappings": `[{"group":"Piket","peran":"OPERATOR"}]`}, false, ErrPrivilegedGroupMapping},
+		{"Server LDAP Ditolak", map[string]string{"ldap_url": "ldaps://ldap.contoh.id"}, false, ErrPrivilegedGroupMapping},
+		{"Issuer SSO Ditolak", map[string]string{"oidc_issuer": "https://idp.contoh.id"}, false, ErrPrivilegedGroupMapping},
+		{"Provider Login Ditolak", map[string]string{"auth_provider": "ldap"}, false, ErrPrivilegedGroupMapping},
+		{"Rahasia Kosong Boleh", map[string]string{"ldap_bind_password": "", "oidc_client_secret": ""}, false, nil},
+		{"Server LDAP Oleh Super Admin Boleh", map[string]string{"ldap_url": "ldaps://ldap.contoh.id"}, true, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := service.ValidateSettings(tc.settings, tc.allowPrivileged)
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestConfigService_ValidateSettings(t *testing.T) {
+	repo := new(mocks.ConfigRepository)
+	repo.On("GetAll").Return(map[string]string{}, nil)
+	service := NewConfigService(repo, nil)
+
+	for name, settings := range map[string]map[string]string{
+		"Path Backup":    {"backup_path": "../../etc"},
+		"Provider":       {"auth_provider": "kerberos"},
+		"Issuer HTTP":    {"oidc_issuer": "http://sso.polri.go.id"},
+		"Syslog":         {"audit_syslog_address": "syslog.polri"},
+		"Frekuensi":      {"backup_schedule_frequency": "hourly"},
+		"Jam":            {"backup_schedule_time": "25:00"},
+		"Hari":           {"backup_schedule_weekday": "7"},
+		"Retensi":        {"backup_schedule_keep_daily": "-1"},
+		"Metode":         {"backup_schedule_method": "rsync"},
+		"Off-site":       {"backup_offsite_type": "ftp"},
+		"Port SFTP":      {"backup_offsite_sftp_port": "70000"},
+		"Folder Relatif": {"backup_offsite_type": "folder", "backup_offsite_folder_path": "backup"},
+
+		"Mode Enkripsi":        {"backup_encryption_mode": "rot13"},
+		"Passphrase Pendek":    {"backup_encryption_mode": "passphrase", "backup_encryption_passphrase": "pendek"},
+		"Passphrase Belum Ada": {"backup_encryption_mode": "passphrase"},
+		"File Kunci Hilang":    {"backup_encryption_mode": "keyfile", "backup_encryption_key_file": "/tidak/ada/backup.key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, service.ValidateSettings(settings, true), ErrInvalidSetting)
+		})
+	}
+
+	assert.NoError(t, service.ValidateSettings(map[string]string{
+		"nama_kantor":                "POLSEK UJI",
+		"backup_schedule_frequency":  "weekly",
+		"backup_schedule_time":       "23:30",
+		"backup_offsite_type":        "folder",
+		"backup_offsite_folder_path": t.TempDir(),
+	}, false))
+}
+
+func TestConfigService_ValidateSettings_AuditFilePath(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, utils.SetAppDataDir(dataDir))
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(dataDir, "tautan")))
+	service := NewConfigService(new(mocks.ConfigRepository), nil)
+
+	testCases := []struct {
+		name  string
+		path  string
+		valid bool
+	}{
+		{"Di Folder Data", filepath.Join(dataDir, "audit-logs", "audit.jsonl"), true},
+		{"Relatif Ke Folder Data", "audit-logs/audit.jsonl", true},
+		{"Path Absolut Lain", filepath.Join(outside, "audit.jsonl"), false},
+		{"File Sistem", "/etc/passwd", false},
+		{"Folder Data Itu Sendiri", dataDir, false},
+		{"Lewat Symlink Ke Luar", filepath.Join(dataDir, "tautan", "audit.jsonl"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := service.ValidateSettings(map[string]string{"audit_file_path": tc.path}, true)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidSetting)
+			}
+		})
+	}
+}
@@ -1,28 +1,30 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"simdokpol/internal/config"
 	"simdokpol/internal/dto" // <-- Pastikan import ini
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
+	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	Create(user *models.User, actorID uint) error
+	Create(ctx context.Context, user *models.User, actorID uint) error
 	// Update: Ganti FindAll jadi GetUsersPaged
 	GetUsersPaged(req dto.DataTableRequest, statusFilter string) (*dto.DataTableResponse, error)
 	FindByID(id uint) (*models.User, error)
 	FindOperators() ([]models.User, error)
-	Update(user *models.User, newPassword string, actorID uint) error
-	Deactivate(id uint, actorID uint) error
-	Activate(id uint, actorID uint) error
-	ChangePassword(userID uint, oldPassword, newPassword string) error
-	UpdateProfile(userID uint, dataToUpdate *models.User) (*models.User, error)
+	Update(ctx context.Context, user *models.User, newPassword string, actorID uint) error
+	Deactivate(ctx context.Context, id uint, actorID uint) error
+	Activate(ctx context.Context, id uint, actorID uint) error
+	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
+	UpdateProfile(ctx context.Context, userID uint, dataToUpdate *models.User) (*models.User, error)
 }
 
 type userService struct {
@@ -58,11 +60,26 @@ func (s *userService) GetUsersPaged(req dto.DataTableRequest, statusFilter strin
 // ... (SISA FUNGSI LAINNYA: Create, Update, FindByID dll TETAP SAMA SEPERTI SEBELUMNYA) ...
 // ... Copy Paste saja fungsi lama di bawah sini agar tidak hilang ...
 
-func (s *userService) UpdateProfile(userID uint, dataToUpdate *models.User) (*models.User, error) {
+// logUserEvent mencatat perubahan pengguna beserta snapshot sebelum/sesudah.
+// KataSandi tidak ikut tersimpan karena bertag json:"-".
+func (s *userService) logUserEvent(ctx context.Context, actorID uint, action, detail string, userID uint, before, after interface{}) {
+	s.auditService.LogEvent(ctx, dto.AuditEvent{
+		UserID:     actorID,
+		Action:     action,
+		Detail:     detail,
+		EntityType: models.AuditEntityUser,
+		EntityID:   strconv.FormatUint(uint64(userID), 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, userID uint, dataToUpdate *models.User) (*models.User, error) {
 	currentUser, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, errors.New("pengguna tidak ditemukan")
 	}
+	before := *currentUser
 	currentUser.NamaLengkap = dataToUpdate.NamaLengkap
 	currentUser.NRP = dataToUpdate.NRP
 	currentUser.Pangkat = dataToUpdate.Pangkat
@@ -70,11 +87,11 @@ func (s *userService) UpdateProfile(userID uint, dataToUpdate *models.User) (*mo
 	if err := s.userRepo.Update(currentUser); err != nil {
 		return nil, err
 	}
-	s.auditService.LogActivity(userID, models.AuditUpdateUser, fmt.Sprintf("Pengguna '%s' memperbarui profil.", currentUser.NamaLengkap))
+	s.logUserEvent(ctx, userID, models.AuditUpdateUser, fmt.Sprintf("Pengguna '%s' memperbarui profil.", currentUser.NamaLengkap), userID, before, currentUser)
 	return currentUser, nil
 }
 
-func (s *userService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+func (s *userService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil { return errors.New("pengguna tidak ditemukan") }
 
@@ -86,11 +103,11 @@ func (s *userService) ChangePassword(userID uint, oldPassword, newPassword strin
 	user.KataSandi = string(hashedPassword)
 
 	if err := s.userRepo.Update(user); err != nil { return err }
-	s.auditService.LogActivity(userID, models.AuditUpdateUser, "Pengguna mengubah kata sandi.")
+	s.logUserEvent(ctx, userID, models.AuditUpdateUser, "Pengguna mengubah kata sandi.", userID, nil, nil)
 	return nil
 }
 
-func (s *userService) Create(user *models.User, actorID uint) error {
+func (s *userService) Create(ctx context.Context, user *models.User, actorID uint) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.KataSandi), s.cfg.BcryptCost)
 	if err != nil { return err }
 	user.KataSandi = string(hashedPassword)
@@ -99,11 +116,11 @@ func (s *userService) Create(user *models.User, actorID uint) error {
 	action := models.AuditCreateUser
 	if actorID == 0 { actorID = user.ID; action = models.AuditSystemSetup }
 	
-	s.auditService.LogActivity(actorID, action, fmt.Sprintf("Pengguna baru '%s' dibuat.", user.NamaLengkap))
+	s.logUserEvent(ctx, actorID, action, fmt.Sprintf("Pengguna baru '%s' dibuat.", user.NamaLengkap), user.ID, nil, user)
 	return nil
 }
 
-func (s *userService) Update(user *models.User, newPassword string, actorID uint) error {
+func (s *userService) Update(ctx context.Context, user *models.User, newPassword string, actorID uint) error {
 	oldUser, err := s.userRepo.FindByID(user.ID)
 	if err != nil { return errors.New("pengguna tidak ditemukan") }
 
@@ -114,25 +131,28 @@ func (s *userService) Update(user *models.User, newPassword string, actorID uint
 	} else {
 		user.KataSandi = oldUser.KataSandi
 	}
+	if user.AuthSource == "" {
+		user.AuthSource = oldUser.AuthSource
+	}
 
 	if err := s.userRepo.Update(user); err != nil { return err }
-	s.auditService.LogActivity(actorID, models.AuditUpdateUser, fmt.Sprintf("Data pengguna '%s' diperbarui.", user.NamaLengkap))
+	s.logUserEvent(ctx, actorID, models.AuditUpdateUser, fmt.Sprintf("Data pengguna '%s' diperbarui.", user.NamaLengkap), user.ID, oldUser, user)
 	return nil
 }
 
-func (s *userService) Deactivate(id uint, actorID uint) error {
+func (s *userService) Deactivate(ctx context.Context, id uint, actorID uint) error {
 	user, err := s.userRepo.FindByID(id)
 	if err != nil { return err }
 	if err := s.userRepo.Delete(id); err != nil { return err }
-	s.auditService.LogActivity(actorID, models.AuditDeactivateUser, fmt.Sprintf("Pengguna '%s' dinonaktifkan.", user.NamaLengkap))
+	s.logUserEvent(ctx, actorID, models.AuditDeactivateUser, fmt.Sprintf("Pengguna '%s' dinonaktifkan.", user.NamaLengkap), id, nil, nil)
 	return nil
 }
 
-func (s *userService) Activate(id uint, actorID uint) error {
+func (s *userService) Activate(ctx context.Context, id uint, actorID uint) error {
 	user, err := s.userRepo.FindByID(id)
 	if err != nil { return err }
 	if err := s.userRepo.Restore(id); err != nil { return err }
-	s.auditService.LogActivity(actorID, models.AuditActivateUser, fmt.Sprintf("Pengguna '%s' diaktifkan kembali.", user.NamaLengkap))
+	s.logUserEvent(ctx, actorID, models.AuditActivateUser, fmt.Sprintf("Pengguna '%s' diaktifkan kembali.", user.NamaLengkap), id, nil, nil)
 	return nil
 }
 
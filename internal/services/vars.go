@@ -7,4 +7,22 @@ var (
 	
 	// Ini yang dipakai aplikasi (akan diisi dari string di atas saat runtime)
 	JWTSecretKey       []byte
+
+	// AuditSigningKey menandatangani checkpoint log audit (dari AUDIT_SIGNING_KEY di .env)
+	AuditSigningKey []byte
+
+	// AuditJournalPath menampung log audit saat database tidak bisa ditulis.
+	// Kosong berarti tanpa journal (entri yang gagal ditulis hilang).
+	AuditJournalPath string
+
+	// AuditDeadLetterPath menampung entri yang ditolak database secara permanen
+	// (mis. melanggar constraint) agar tidak menyumbat journal. Kosong berarti
+	// entri seperti itu dibuang dan dihitung dropped.
+	AuditDeadLetterPath string
+
+	// AuditArchiveDir menyimpan file arsip bulanan hasil retensi log audit
+	AuditArchiveDir string
+
+	// AppVersion diisi main saat start; dicatat di header backup terenkripsi
+	AppVersion = "dev"
 )
\ No newline at end of file
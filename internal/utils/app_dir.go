@@ -40,4 +40,18 @@ func GetAppDataDir() string {
 	appDataDir = appSpecificDir
 	log.Printf("INFO: Menggunakan direktori data aplikasi: %s", appDataDir)
 	return appDataDir
-}
\ No newline at end of file
+}
+// SetAppDataDir memakai folder lain sebagai direktori data aplikasi (mis.
+// flag --data-dir saat server headless). Harus dipanggil sebelum .env dimuat.
+func SetAppDataDir(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return err
+	}
+	appDataDir = absDir
+	log.Printf("INFO: Menggunakan direktori data aplikasi: %s", appDataDir)
+	return nil
+}
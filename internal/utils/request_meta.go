@@ -0,0 +1,27 @@
+package utils
+
+import "context"
+
+// RequestMeta adalah info request HTTP yang ikut dicatat di log audit.
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type requestMetaKey struct{}
+
+// WithRequestMeta menitipkan info request ke context agar service bisa mencatatnya.
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext mengembalikan info request, kosong bila bukan dari HTTP
+// (mis. proses latar belakang atau CLI).
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	if ctx == nil {
+		return RequestMeta{}
+	}
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
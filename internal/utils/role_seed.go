@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"slices"
+	"strconv"
+
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultRoles adalah peran bawaan beserta izinnya.
+// SUPER_ADMIN selalu dianggap memiliki semua izin oleh RoleService.
+var DefaultRoles = []models.Role{
+	{
+		Nama:      models.RoleSuperAdmin,
+		Deskripsi: "Akses penuh ke seluruh fitur",
+		IsSystem:  true,
+	},
+	{
+		Nama:        models.RoleOperator,
+		Deskripsi:   "Petugas SPKT, membuat dan mengelola surat miliknya sendiri",
+		Permissions: models.JSONStringArray{models.PermDocumentCreate},
+		IsSystem:    true,
+	},
+	{
+		Nama:        models.RolePimpinan,
+		Deskripsi:   "Pimpinan, hanya melihat surat dan laporan",
+		Permissions: models.JSONStringArray{models.PermDocumentReadAll, models.PermReportView},
+	},
+	{
+		Nama:        models.RoleKanit,
+		Deskripsi:   "Kepala unit jaga, mengelola surat seluruh anggota regunya",
+		Permissions: models.JSONStringArray{models.PermDocumentCreate, models.PermDocumentRegu, models.PermReportView},
+	},
+	{
+		Nama:      models.RoleAdminTU,
+		Deskripsi: "Admin tata usaha, mengelola pengguna dan pengaturan tanpa akses database",
+		Permissions: models.JSONStringArray{
+			models.PermUserManage, models.PermSettingsManage, models.PermTemplateManage,
+			models.PermAuditView, models.PermLicenseManage,
+		},
+	},
+}
+
+// defaultPermissionGrants izin bawaan yang ditambahkan setelah perannya
+// mungkin sudah tersimpan di database (FirstOrCreate tidak menyentuh peran
+// yang sudah ada). Setiap versi hanya diterapkan sekali, dicatat di
+// configurations, jadi izin yang kemudian dicabut admin tidak kembali.
+// Tambahkan versi baru di akhir setiap kali izin peran bawaan bertambah.
+var defaultPermissionGrants = []struct {
+	Version     int
+	Role        string
+	Permissions []string
+}{
+	{Version: 1, Role: models.RoleKanit, Permissions: []string{models.PermDocumentRegu}},
+}
+
+// rolePermissionGrantsKey versi defaultPermissionGrants terakhir yang sudah diterapkan
+const rolePermissionGrantsKey = "role_permission_grants_version"
+
+// EnsureDefaultRoles membuat peran bawaan yang belum ada tanpa menimpa hasil
+// edit admin, lalu menambahkan izin bawaan baru ke peran yang sudah ada.
+func EnsureDefaultRoles(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+
+	for _, role := range DefaultRoles {
+		r := role
+		if err := db.Where("nama = ?", r.Nama).FirstOrCreate(&r).Error; err != nil {
+			return err
+		}
+	}
+	return applyDefaultPermissionGrants(db)
+}
+
+func applyDefaultPermissionGrants(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var setting models.Configuration
+		err := tx.Where(clause.Eq{Column: clause.Column{Name: "key"}, Value: rolePermissionGrantsKey}).Limit(1).Find(&setting).Error
+		if err != nil {
+			return err
+		}
+		applied, _ := strconv.Atoi(setting.Value)
+
+		latest := applied
+		for _, grant := range defaultPermissionGrants {
+			if grant.Version <= applied {
+				continue
+			}
+			latest = max(latest, grant.Version)
+
+			var role models.Role
+			result := tx.Where("nama = ?", grant.Role).Limit(1).Find(&role)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			permissions := role.Permissions
+			for _, permission := range grant.Permissions {
+				if !slices.Contains(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+			if len(permissions) == len(role.Permissions) {
+				continue
+			}
+			if err := tx.Model(&role).Update("permissions", permissions).Error; err != nil {
+				return err
+			}
+		}
+		if latest == applied {
+			return nil
+		}
+		return tx.Save(&models.Configuration{Key: rolePermissionGrantsKey, Value: strconv.Itoa(latest)}).Error
+	})
+}
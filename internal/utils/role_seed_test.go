@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestEnsureDefaultRoles_GrantsNewPermissionsOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "peran.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Role{}, &models.Configuration{}))
+
+	// Peran KANIT dari versi sebelum document:regu ada
+	require.NoError(t, db.Create(&models.Role{
+		Nama:        models.RoleKanit,
+		Permissions: models.JSONStringArray{models.PermDocumentCreate, models.PermReportView},
+	}).Error)
+
+	require.NoError(t, EnsureDefaultRoles(db))
+	var kanit models.Role
+	require.NoError(t, db.First(&kanit, "nama = ?", models.RoleKanit).Error)
+	assert.ElementsMatch(t, []string{models.PermDocumentCreate, models.PermReportView, models.PermDocumentRegu}, kanit.Permissions)
+
+	// Izin yang dicabut admin tidak dikembalikan saat start berikutnya
+	require.NoError(t, db.Model(&kanit).Update("permissions", models.JSONStringArray{models.PermDocumentCreate}).Error)
+	require.NoError(t, EnsureDefaultRoles(db))
+	require.NoError(t, db.First(&kanit, "nama = ?", models.RoleKanit).Error)
+	assert.Equal(t, models.JSONStringArray{models.PermDocumentCreate}, kanit.Permissions)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Role{}).Count(&count).Error)
+	assert.EqualValues(t, len(DefaultRoles), count)
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"errors"
+
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EnsureSystemUser mengembalikan akun aktor sistem, dibuat bila belum ada.
+// Log audit butuh user_id yang valid (foreign key ke users), jadi job latar
+// belakang (backup terjadwal, retensi) dan perintah CLI admin dicatat atas
+// nama akun ini. Kata sandinya bukan hash bcrypt dan
+// AuthSource-nya "system" sehingga tidak pernah bisa dipakai login.
+func EnsureSystemUser(db *gorm.DB) (*models.User, error) {
+	var user models.User
+	err := db.Unscoped().Where("nrp = ?", models.SystemUserNRP).First(&user).Error
+	if err == nil {
+		if user.AuthSource != models.AuthSourceSystem {
+			return nil, errors.New("NRP " + models.SystemUserNRP + " sudah dipakai pengguna biasa")
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = models.User{
+		NamaLengkap: "SISTEM",
+		NRP:         models.SystemUserNRP,
+		KataSandi:   "!",
+		Peran:       models.RoleOperator,
+		AuthSource:  models.AuthSourceSystem,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
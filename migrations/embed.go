@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// Files berisi file migrasi SQL bernomor per dialek (sqlite/, mysql/,
+// postgres/) yang dijalankan oleh SchemaMigrationService saat aplikasi start.
+//
+//go:embed sqlite/*.sql mysql/*.sql postgres/*.sql
+var Files embed.FS
@@ -0,0 +1,10 @@
+-- +migrate Up
+
+CREATE TABLE `roles` (
+    `nama` varchar(50) PRIMARY KEY,
+    `deskripsi` varchar(255),
+    `permissions` text,
+    `is_system` boolean NOT NULL DEFAULT false,
+    `created_at` datetime(3),
+    `updated_at` datetime(3)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,18 @@
+-- +migrate Up
+
+CREATE TABLE `api_tokens` (
+    `id` integer AUTO_INCREMENT PRIMARY KEY,
+    `user_id` integer NOT NULL,
+    `nama` varchar(100) NOT NULL,
+    `prefix` varchar(16) NOT NULL,
+    `token_hash` varchar(64) NOT NULL,
+    `scopes` text,
+    `expires_at` datetime(3),
+    `last_used_at` datetime(3),
+    `last_used_ip` varchar(64),
+    `revoked_at` datetime(3),
+    `created_at` datetime(3),
+    UNIQUE INDEX `idx_api_tokens_token_hash` (`token_hash`),
+    INDEX `idx_api_tokens_user_id` (`user_id`),
+    CONSTRAINT `fk_api_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+-- +migrate Down
+
+ALTER TABLE `users` DROP COLUMN `auth_source`;
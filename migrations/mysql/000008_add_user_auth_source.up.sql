@@ -0,0 +1,3 @@
+-- +migrate Up
+
+ALTER TABLE `users` ADD COLUMN `auth_source` varchar(20) NOT NULL DEFAULT 'local';
@@ -0,0 +1,6 @@
+-- +migrate Down
+
+DROP TABLE IF EXISTS `audit_checkpoints`;
+ALTER TABLE `audit_logs` DROP INDEX `idx_audit_logs_hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `prev_hash`;
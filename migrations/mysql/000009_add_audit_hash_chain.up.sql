@@ -0,0 +1,14 @@
+-- +migrate Up
+
+ALTER TABLE `audit_logs` ADD COLUMN `prev_hash` varchar(64);
+ALTER TABLE `audit_logs` ADD COLUMN `hash` varchar(64);
+CREATE INDEX `idx_audit_logs_hash` ON `audit_logs`(`hash`);
+
+CREATE TABLE `audit_checkpoints` (
+    `id` integer AUTO_INCREMENT PRIMARY KEY,
+    `last_log_id` integer NOT NULL,
+    `last_hash` varchar(64) NOT NULL,
+    `signature` varchar(64) NOT NULL,
+    `created_at` datetime(3) NOT NULL,
+    INDEX `idx_audit_checkpoints_last_log_id` (`last_log_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
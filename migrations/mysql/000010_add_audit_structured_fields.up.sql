@@ -0,0 +1,11 @@
+-- +migrate Up
+
+ALTER TABLE `audit_logs` ADD COLUMN `entity_type` varchar(50);
+ALTER TABLE `audit_logs` ADD COLUMN `entity_id` varchar(64);
+ALTER TABLE `audit_logs` ADD COLUMN `ip_address` varchar(64);
+ALTER TABLE `audit_logs` ADD COLUMN `user_agent` varchar(255);
+ALTER TABLE `audit_logs` ADD COLUMN `request_id` varchar(64);
+ALTER TABLE `audit_logs` ADD COLUMN `before` text;
+ALTER TABLE `audit_logs` ADD COLUMN `after` text;
+CREATE INDEX `idx_audit_logs_entity` ON `audit_logs`(`entity_type`, `entity_id`);
+CREATE INDEX `idx_audit_logs_request_id` ON `audit_logs`(`request_id`);
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE `audit_archives` (
+    `id` integer AUTO_INCREMENT PRIMARY KEY,
+    `period` varchar(7) NOT NULL,
+    `file_name` varchar(255) NOT NULL,
+    `first_log_id` integer NOT NULL,
+    `last_log_id` integer NOT NULL,
+    `entry_count` integer NOT NULL,
+    `prev_hash` varchar(64),
+    `last_hash` varchar(64),
+    `file_sha256` varchar(64) NOT NULL,
+    `signature` varchar(64) NOT NULL,
+    `created_at` datetime(3) NOT NULL,
+    INDEX `idx_audit_archives_period` (`period`),
+    UNIQUE INDEX `idx_audit_archives_last_log_id` (`last_log_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,21 @@
+-- +migrate Up
+
+CREATE TABLE `backup_runs` (
+    `id` integer AUTO_INCREMENT PRIMARY KEY,
+    `kind` varchar(20) NOT NULL,
+    `method` varchar(20) NOT NULL,
+    `status` varchar(20) NOT NULL,
+    `file_name` varchar(255),
+    `file_path` varchar(1024),
+    `size_bytes` bigint,
+    `duration_ms` bigint,
+    `sha256` varchar(64),
+    `error` text,
+    `actor_id` integer,
+    `started_at` datetime(3) NOT NULL,
+    `finished_at` datetime(3) NOT NULL,
+    `pruned_at` datetime(3),
+    INDEX `idx_backup_runs_kind` (`kind`),
+    INDEX `idx_backup_runs_status` (`status`),
+    INDEX `idx_backup_runs_started_at` (`started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_status` varchar(20);
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_target` varchar(255);
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_attempts` integer;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_error` text;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_at` datetime(3);
+CREATE INDEX `idx_backup_runs_offsite_status` ON `backup_runs`(`offsite_status`);
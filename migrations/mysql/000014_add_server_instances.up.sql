@@ -0,0 +1,10 @@
+-- +migrate Up
+
+CREATE TABLE `server_instances` (
+    `id` varchar(32) PRIMARY KEY,
+    `hostname` varchar(255),
+    `pid` bigint,
+    `started_at` datetime(3) NOT NULL,
+    `heartbeat_at` datetime(3) NOT NULL,
+    INDEX `idx_server_instances_heartbeat_at` (`heartbeat_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
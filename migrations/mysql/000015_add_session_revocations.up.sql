@@ -0,0 +1,8 @@
+-- +migrate Up
+
+CREATE TABLE `session_revocations` (
+    `session_key` varchar(191) PRIMARY KEY,
+    `revoked_at` datetime(3) NOT NULL,
+    `expires_at` datetime(3) NOT NULL,
+    INDEX `idx_session_revocations_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -1,6 +1,5 @@
 -- Perintah untuk menghapus semua tabel (Migrasi TURUN / Rollback - PostgreSQL)
 
-DROP TABLE IF EXISTS "item_templates";
 DROP TABLE IF EXISTS "configurations";
 DROP TABLE IF EXISTS "audit_logs";
 DROP TABLE IF EXISTS "lost_items";
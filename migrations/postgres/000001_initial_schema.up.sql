@@ -69,27 +69,3 @@ CREATE TABLE "configurations" (
     "value" TEXT,
     "updated_at" TIMESTAMPTZ
 );
-
-CREATE TABLE "item_templates" (
-    "id" SERIAL PRIMARY KEY,
-    "nama_barang" VARCHAR(100) NOT NULL UNIQUE,
-    "fields_config" JSONB, 
-    "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
-    "urutan" INTEGER DEFAULT 0,
-    "created_at" TIMESTAMPTZ,
-    "updated_at" TIMESTAMPTZ,
-    "deleted_at" TIMESTAMPTZ
-);
-CREATE INDEX "idx_item_templates_deleted_at" ON "item_templates"("deleted_at");
-
--- SEEDING DATA
-INSERT INTO "item_templates" ("nama_barang", "fields_config", "urutan")
-VALUES
-    ('KTP', '[{"label":"NIK","type":"text","data_label":"NIK","regex":"^[0-9]{16}$","placeholder":"16 Digit NIK","required_length":16,"min_length":16,"max_length":16,"is_numeric":true}]', 1),
-    ('SIM', '[{"label":"Golongan SIM","type":"select","data_label":"Gol","options":["A","B I","B II","C","D"]},{"label":"Nomor SIM","type":"text","data_label":"No. SIM","regex":"^[0-9]{12,14}$","is_numeric":true}]', 2),
-    ('STNK', '[{"label":"Nomor Polisi","type":"text","data_label":"No. Pol"},{"label":"Nomor Rangka","type":"text","data_label":"No. Rangka"},{"label":"Nomor Mesin","type":"text","data_label":"No. Mesin"}]', 3),
-    ('BPKB', '[{"label":"Nomor BPKB","type":"text","data_label":"No. BPKB"},{"label":"Atas Nama","type":"text","data_label":"a.n."}]', 4),
-    ('IJAZAH', '[{"label":"Tingkat","type":"select","data_label":"Tingkat","options":["SD","SMP","SMA","D3","S1"]},{"label":"Nomor Ijazah","type":"text","data_label":"No. Ijazah"}]', 5),
-    ('ATM', '[{"label":"Nama Bank","type":"select","data_label":"Bank","options":["BRI","BCA","Mandiri"]},{"label":"Nomor Rekening","type":"text","data_label":"No. Rek"}]', 6),
-    ('LAINNYA', '[]', 99)
-ON CONFLICT ("nama_barang") DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Migrasi PostgreSQL: Rollback tabel item_templates
+DROP TABLE IF EXISTS "item_templates";
@@ -0,0 +1,8 @@
+CREATE TABLE "roles" (
+    "nama" VARCHAR(50) PRIMARY KEY,
+    "deskripsi" VARCHAR(255),
+    "permissions" TEXT,
+    "is_system" BOOLEAN NOT NULL DEFAULT false,
+    "created_at" TIMESTAMPTZ,
+    "updated_at" TIMESTAMPTZ
+);
@@ -0,0 +1,15 @@
+CREATE TABLE "api_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id"),
+    "nama" VARCHAR(100) NOT NULL,
+    "prefix" VARCHAR(16) NOT NULL,
+    "token_hash" VARCHAR(64) NOT NULL,
+    "scopes" TEXT,
+    "expires_at" TIMESTAMPTZ,
+    "last_used_at" TIMESTAMPTZ,
+    "last_used_ip" VARCHAR(64),
+    "revoked_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_tokens_token_hash" ON "api_tokens"("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_user_id" ON "api_tokens"("user_id");
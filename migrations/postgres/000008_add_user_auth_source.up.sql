@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "auth_source" VARCHAR(20) NOT NULL DEFAULT 'local';
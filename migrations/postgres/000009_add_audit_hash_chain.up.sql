@@ -0,0 +1,12 @@
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "prev_hash" VARCHAR(64);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "hash" VARCHAR(64);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_hash" ON "audit_logs"("hash");
+
+CREATE TABLE "audit_checkpoints" (
+    "id" SERIAL PRIMARY KEY,
+    "last_log_id" INTEGER NOT NULL,
+    "last_hash" VARCHAR(64) NOT NULL,
+    "signature" VARCHAR(64) NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_checkpoints_last_log_id" ON "audit_checkpoints"("last_log_id");
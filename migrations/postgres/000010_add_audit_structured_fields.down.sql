@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "idx_audit_logs_request_id";
+DROP INDEX IF EXISTS "idx_audit_logs_entity";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "after";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "before";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "request_id";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "user_agent";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "ip_address";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "entity_id";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "entity_type";
@@ -0,0 +1,9 @@
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "entity_type" VARCHAR(50);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "entity_id" VARCHAR(64);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "ip_address" VARCHAR(64);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "user_agent" VARCHAR(255);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "request_id" VARCHAR(64);
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "before" TEXT;
+ALTER TABLE "audit_logs" ADD COLUMN IF NOT EXISTS "after" TEXT;
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_entity" ON "audit_logs"("entity_type", "entity_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_request_id" ON "audit_logs"("request_id");
@@ -0,0 +1,15 @@
+CREATE TABLE "audit_archives" (
+    "id" SERIAL PRIMARY KEY,
+    "period" VARCHAR(7) NOT NULL,
+    "file_name" VARCHAR(255) NOT NULL,
+    "first_log_id" INTEGER NOT NULL,
+    "last_log_id" INTEGER NOT NULL,
+    "entry_count" INTEGER NOT NULL,
+    "prev_hash" VARCHAR(64),
+    "last_hash" VARCHAR(64),
+    "file_sha256" VARCHAR(64) NOT NULL,
+    "signature" VARCHAR(64) NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_archives_period" ON "audit_archives"("period");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_audit_archives_last_log_id" ON "audit_archives"("last_log_id");
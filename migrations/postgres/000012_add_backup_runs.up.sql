@@ -0,0 +1,19 @@
+CREATE TABLE "backup_runs" (
+    "id" SERIAL PRIMARY KEY,
+    "kind" VARCHAR(20) NOT NULL,
+    "method" VARCHAR(20) NOT NULL,
+    "status" VARCHAR(20) NOT NULL,
+    "file_name" VARCHAR(255),
+    "file_path" VARCHAR(1024),
+    "size_bytes" BIGINT,
+    "duration_ms" BIGINT,
+    "sha256" VARCHAR(64),
+    "error" TEXT,
+    "actor_id" INTEGER,
+    "started_at" TIMESTAMPTZ NOT NULL,
+    "finished_at" TIMESTAMPTZ NOT NULL,
+    "pruned_at" TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS "idx_backup_runs_kind" ON "backup_runs"("kind");
+CREATE INDEX IF NOT EXISTS "idx_backup_runs_status" ON "backup_runs"("status");
+CREATE INDEX IF NOT EXISTS "idx_backup_runs_started_at" ON "backup_runs"("started_at");
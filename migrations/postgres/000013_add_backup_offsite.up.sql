@@ -0,0 +1,6 @@
+ALTER TABLE "backup_runs" ADD COLUMN IF NOT EXISTS "offsite_status" VARCHAR(20);
+ALTER TABLE "backup_runs" ADD COLUMN IF NOT EXISTS "offsite_target" VARCHAR(255);
+ALTER TABLE "backup_runs" ADD COLUMN IF NOT EXISTS "offsite_attempts" INTEGER;
+ALTER TABLE "backup_runs" ADD COLUMN IF NOT EXISTS "offsite_error" TEXT;
+ALTER TABLE "backup_runs" ADD COLUMN IF NOT EXISTS "offsite_at" TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS "idx_backup_runs_offsite_status" ON "backup_runs"("offsite_status");
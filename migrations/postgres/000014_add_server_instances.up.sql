@@ -0,0 +1,8 @@
+CREATE TABLE "server_instances" (
+    "id" VARCHAR(32) PRIMARY KEY,
+    "hostname" VARCHAR(255),
+    "pid" BIGINT,
+    "started_at" TIMESTAMPTZ NOT NULL,
+    "heartbeat_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_server_instances_heartbeat_at" ON "server_instances"("heartbeat_at");
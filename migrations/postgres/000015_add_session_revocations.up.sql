@@ -0,0 +1,6 @@
+CREATE TABLE "session_revocations" (
+    "session_key" VARCHAR(191) PRIMARY KEY,
+    "revoked_at" TIMESTAMPTZ NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_session_revocations_expires_at" ON "session_revocations"("expires_at");
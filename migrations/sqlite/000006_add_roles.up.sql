@@ -0,0 +1,8 @@
+CREATE TABLE `roles` (
+    `nama` text PRIMARY KEY,
+    `deskripsi` text,
+    `permissions` text,
+    `is_system` boolean NOT NULL DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime
+);
@@ -0,0 +1,16 @@
+CREATE TABLE `api_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `nama` text NOT NULL,
+    `prefix` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `scopes` text,
+    `expires_at` datetime,
+    `last_used_at` datetime,
+    `last_used_ip` text,
+    `revoked_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_api_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE UNIQUE INDEX `idx_api_tokens_token_hash` ON `api_tokens`(`token_hash`);
+CREATE INDEX `idx_api_tokens_user_id` ON `api_tokens`(`user_id`);
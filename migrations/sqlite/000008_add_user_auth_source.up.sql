@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `auth_source` text NOT NULL DEFAULT 'local';
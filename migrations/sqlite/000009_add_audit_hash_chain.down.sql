@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `audit_checkpoints`;
+DROP INDEX IF EXISTS `idx_audit_logs_hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `prev_hash`;
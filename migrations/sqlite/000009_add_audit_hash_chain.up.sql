@@ -0,0 +1,11 @@
+ALTER TABLE `audit_logs` ADD COLUMN `prev_hash` text;
+ALTER TABLE `audit_logs` ADD COLUMN `hash` text;
+CREATE INDEX `idx_audit_logs_hash` ON `audit_logs`(`hash`);
+CREATE TABLE `audit_checkpoints` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `last_log_id` integer NOT NULL,
+    `last_hash` text NOT NULL,
+    `signature` text NOT NULL,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX `idx_audit_checkpoints_last_log_id` ON `audit_checkpoints`(`last_log_id`);
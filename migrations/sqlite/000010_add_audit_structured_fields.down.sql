@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS `idx_audit_logs_request_id`;
+DROP INDEX IF EXISTS `idx_audit_logs_entity`;
+ALTER TABLE `audit_logs` DROP COLUMN `after`;
+ALTER TABLE `audit_logs` DROP COLUMN `before`;
+ALTER TABLE `audit_logs` DROP COLUMN `request_id`;
+ALTER TABLE `audit_logs` DROP COLUMN `user_agent`;
+ALTER TABLE `audit_logs` DROP COLUMN `ip_address`;
+ALTER TABLE `audit_logs` DROP COLUMN `entity_id`;
+ALTER TABLE `audit_logs` DROP COLUMN `entity_type`;
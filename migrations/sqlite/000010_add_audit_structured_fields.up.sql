@@ -0,0 +1,9 @@
+ALTER TABLE `audit_logs` ADD COLUMN `entity_type` text;
+ALTER TABLE `audit_logs` ADD COLUMN `entity_id` text;
+ALTER TABLE `audit_logs` ADD COLUMN `ip_address` text;
+ALTER TABLE `audit_logs` ADD COLUMN `user_agent` text;
+ALTER TABLE `audit_logs` ADD COLUMN `request_id` text;
+ALTER TABLE `audit_logs` ADD COLUMN `before` text;
+ALTER TABLE `audit_logs` ADD COLUMN `after` text;
+CREATE INDEX `idx_audit_logs_entity` ON `audit_logs`(`entity_type`, `entity_id`);
+CREATE INDEX `idx_audit_logs_request_id` ON `audit_logs`(`request_id`);
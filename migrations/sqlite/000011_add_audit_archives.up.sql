@@ -0,0 +1,15 @@
+CREATE TABLE `audit_archives` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `period` text NOT NULL,
+    `file_name` text NOT NULL,
+    `first_log_id` integer NOT NULL,
+    `last_log_id` integer NOT NULL,
+    `entry_count` integer NOT NULL,
+    `prev_hash` text,
+    `last_hash` text,
+    `file_sha256` text NOT NULL,
+    `signature` text NOT NULL,
+    `created_at` datetime NOT NULL
+);
+CREATE INDEX `idx_audit_archives_period` ON `audit_archives`(`period`);
+CREATE UNIQUE INDEX `idx_audit_archives_last_log_id` ON `audit_archives`(`last_log_id`);
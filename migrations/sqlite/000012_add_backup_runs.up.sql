@@ -0,0 +1,19 @@
+CREATE TABLE `backup_runs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `kind` text NOT NULL,
+    `method` text NOT NULL,
+    `status` text NOT NULL,
+    `file_name` text,
+    `file_path` text,
+    `size_bytes` integer,
+    `duration_ms` integer,
+    `sha256` text,
+    `error` text,
+    `actor_id` integer,
+    `started_at` datetime NOT NULL,
+    `finished_at` datetime NOT NULL,
+    `pruned_at` datetime
+);
+CREATE INDEX `idx_backup_runs_kind` ON `backup_runs`(`kind`);
+CREATE INDEX `idx_backup_runs_status` ON `backup_runs`(`status`);
+CREATE INDEX `idx_backup_runs_started_at` ON `backup_runs`(`started_at`);
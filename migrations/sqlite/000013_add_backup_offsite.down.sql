@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS `idx_backup_runs_offsite_status`;
+ALTER TABLE `backup_runs` DROP COLUMN `offsite_at`;
+ALTER TABLE `backup_runs` DROP COLUMN `offsite_error`;
+ALTER TABLE `backup_runs` DROP COLUMN `offsite_attempts`;
+ALTER TABLE `backup_runs` DROP COLUMN `offsite_target`;
+ALTER TABLE `backup_runs` DROP COLUMN `offsite_status`;
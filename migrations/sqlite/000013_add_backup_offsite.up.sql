@@ -0,0 +1,6 @@
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_status` text;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_target` text;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_attempts` integer;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_error` text;
+ALTER TABLE `backup_runs` ADD COLUMN `offsite_at` datetime;
+CREATE INDEX `idx_backup_runs_offsite_status` ON `backup_runs`(`offsite_status`);
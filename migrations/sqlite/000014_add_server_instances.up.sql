@@ -0,0 +1,8 @@
+CREATE TABLE `server_instances` (
+    `id` text PRIMARY KEY,
+    `hostname` text,
+    `pid` integer,
+    `started_at` datetime NOT NULL,
+    `heartbeat_at` datetime NOT NULL
+);
+CREATE INDEX `idx_server_instances_heartbeat_at` ON `server_instances`(`heartbeat_at`);
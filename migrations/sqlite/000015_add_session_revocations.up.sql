@@ -0,0 +1,6 @@
+CREATE TABLE `session_revocations` (
+    `session_key` text PRIMARY KEY,
+    `revoked_at` datetime NOT NULL,
+    `expires_at` datetime NOT NULL
+);
+CREATE INDEX `idx_session_revocations_expires_at` ON `session_revocations`(`expires_at`);
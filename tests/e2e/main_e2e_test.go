@@ -27,7 +27,26 @@ type LoginReq struct {
 	Password string `json:"password"`
 }
 
+// csrfTransport menambahkan pasangan cookie + header CSRF (double-submit)
+// ke setiap request yang mengubah data, seperti yang dilakukan frontend.
+type csrfTransport struct {
+	base http.RoundTripper
+}
+
+const e2eCSRFToken = "e2e-csrf-token"
+
+func (t csrfTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		req = req.Clone(req.Context())
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: e2eCSRFToken})
+		req.Header.Set("X-CSRF-Token", e2eCSRFToken)
+	}
+	return t.base.RoundTrip(req)
+}
+
 func TestEndToEndFlow(t *testing.T) {
+	http.DefaultTransport = csrfTransport{base: http.DefaultTransport}
+
 	fmt.Println("⏳ [1/10] Menunggu server up...")
 	waitForServer(t)
 
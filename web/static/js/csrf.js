@@ -0,0 +1,44 @@
+/**
+ * Menyisipkan header X-CSRF-Token (dari cookie csrf_token) ke semua
+ * request $.ajax dan fetch() yang mengubah data di halaman lama.
+ */
+(function () {
+    function csrfToken() {
+        var match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]+)/);
+        return match ? decodeURIComponent(match[1]) : "";
+    }
+
+    function isUnsafe(method) {
+        return !/^(GET|HEAD|OPTIONS|TRACE)$/i.test(method || "GET");
+    }
+
+    function isSameOrigin(url) {
+        var target = new URL(url, window.location.href);
+        return target.origin === window.location.origin;
+    }
+
+    if (window.jQuery) {
+        window.jQuery.ajaxSetup({
+            beforeSend: function (xhr, settings) {
+                if (isUnsafe(settings.type) && isSameOrigin(settings.url)) {
+                    xhr.setRequestHeader("X-CSRF-Token", csrfToken());
+                }
+            }
+        });
+    }
+
+    if (window.fetch) {
+        var originalFetch = window.fetch;
+        window.fetch = function (input, init) {
+            init = init || {};
+            var method = init.method || (input && input.method) || "GET";
+            var url = typeof input === "string" ? input : input.url;
+            if (isUnsafe(method) && isSameOrigin(url)) {
+                var headers = new Headers(init.headers || (input && input.headers) || {});
+                headers.set("X-CSRF-Token", csrfToken());
+                init.headers = headers;
+            }
+            return originalFetch.call(this, input, init);
+        };
+    }
+})();